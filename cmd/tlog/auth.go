@@ -0,0 +1,383 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+	"github.com/zalando/go-keyring"
+)
+
+// moveToKeyring stores password in keyring and removes it from config.
+// Headless machines often have no keyring, config keeps the password there.
+func moveToKeyring(cfg Config) Config {
+	if err := keyring.Set(config.KeyringService, config.KeyringUser(cfg), cfg.JiraPassword); err != nil {
+		pterm.Warning.WithWriter(os.Stderr).Printfln("No keyring available (%s), password will be stored in config file", err)
+		return cfg
+	}
+	cfg.JiraPassword = ""
+	cfg.PasswordSource = config.PasswordSourceKeyring
+	return cfg
+}
+
+func runAuth(args []string) error {
+	switch safeGet(args, 0) {
+	case "set":
+		return runAuthSet()
+	case "migrate":
+		return runAuthMigrate()
+	case "login":
+		return runAuthLogin()
+	case "logout":
+		return runAuthLogout(args[1:])
+	case "test":
+		return runAuthTest()
+	default:
+		return errors.New(authUsage)
+	}
+}
+
+const authUsage = `Usage: tlog auth set
+       tlog auth migrate
+       tlog auth login
+       tlog auth logout [--all-contexts]
+       tlog auth test`
+
+// runAuthLogout removes credentials of the selected context, or all contexts, from every store.
+func runAuthLogout(args []string) error {
+	flags := flag.NewFlagSet("auth logout", flag.ContinueOnError)
+	all := flags.Bool("all-contexts", false, "log out of every context")
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+
+	var contexts []Config
+	if *all {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		raw, err := config.Decode(path)
+		if err != nil {
+			return err
+		}
+		contexts = append(contexts, raw)
+		for _, name := range config.ProfileNames(raw) {
+			conf, err := config.SelectContext(raw, name)
+			if err != nil {
+				return err
+			}
+			contexts = append(contexts, conf)
+		}
+	} else {
+		conf, err := LoadConfigQuiet()
+		if err != nil {
+			return fmt.Errorf("cannot load config: %s", err)
+		}
+		contexts = append(contexts, conf)
+	}
+
+	var removed []string
+	oauthRemoved := false
+	for _, conf := range contexts {
+		name := conf.Context()
+		if name == "" {
+			name = config.DefaultContext
+		}
+		what, err := logout(conf)
+		if err != nil {
+			return fmt.Errorf("log out of context %q: %w", name, err)
+		}
+		for _, w := range what {
+			removed = append(removed, fmt.Sprintf("%s (%s)", w, name))
+			oauthRemoved = oauthRemoved || w == oauthTokensRemoved
+		}
+	}
+
+	fileRemoved, err := removeConfigPasswords(*all)
+	if err != nil {
+		return err
+	}
+	removed = append(removed, fileRemoved...)
+
+	if len(removed) == 0 {
+		pterm.Info.Println("No stored credentials found")
+		return nil
+	}
+	pterm.Success.Println("Removed:\n" + strings.Join(removed, "\n"))
+	if oauthRemoved {
+		// Atlassian has no token revocation endpoint, access is revoked in account settings
+		pterm.Info.Println("To revoke OAuth access completely, remove tlog at https://id.atlassian.com/manage-profile/apps")
+	}
+	return nil
+}
+
+const oauthTokensRemoved = "OAuth tokens"
+
+// logout removes credentials of a context kept outside of config file.
+func logout(conf Config) ([]string, error) {
+	var removed []string
+	err := keyring.Delete(config.KeyringService, config.KeyringUser(conf))
+	if err == nil {
+		removed = append(removed, "password in keyring")
+	}
+
+	deleted, err := backend.DeleteOAuthToken(conf)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		removed = append(removed, oauthTokensRemoved)
+	}
+
+	deleted, err = backend.DeleteSession(conf)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		removed = append(removed, "cached session")
+	}
+	return removed, nil
+}
+
+// removeConfigPasswords blanks passwords in config file, of selected context or of all contexts.
+func removeConfigPasswords(all bool) ([]string, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+
+	var removed []string
+	if !all {
+		err = updateContextConfig(func(p *config.Profile) error {
+			if p.JiraPassword != "" {
+				removed = append(removed, "password in "+path)
+				p.JiraPassword = ""
+			}
+			return nil
+		})
+		return removed, err
+	}
+
+	err = updateConfigFile(func(cfg *Config) error {
+		if cfg.JiraPassword != "" {
+			removed = append(removed, fmt.Sprintf("password in %s (%s)", path, config.DefaultContext))
+			cfg.JiraPassword = ""
+		}
+		for _, name := range config.ProfileNames(*cfg) {
+			if p := cfg.Profiles[name]; p.JiraPassword != "" {
+				removed = append(removed, fmt.Sprintf("password in %s (%s)", path, name))
+				p.JiraPassword = ""
+				cfg.Profiles[name] = p
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// runAuthSet asks for password of the selected context and stores it in keyring.
+func runAuthSet() error {
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	if conf.JiraURL == "" || conf.JiraLogin == "" {
+		return errors.New("JiraURL and JiraLogin must be configured first")
+	}
+
+	prompt := promptui.Prompt{
+		Label:       pterm.LightBlue(fmt.Sprintf("Password for %s", config.KeyringUser(conf))),
+		HideEntered: true,
+		Mask:        '*',
+	}
+	password, err := prompt.Run()
+	if err != nil {
+		return errSilent
+	}
+
+	if err := keyring.Set(config.KeyringService, config.KeyringUser(conf), password); err != nil {
+		return fmt.Errorf("cannot store password in keyring: %w", err)
+	}
+	err = updateContextConfig(func(p *config.Profile) error {
+		p.JiraPassword = ""
+		p.PasswordSource = config.PasswordSourceKeyring
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pterm.Success.Printfln("Password for %s is stored in keyring", config.KeyringUser(conf))
+	return nil
+}
+
+// runAuthMigrate moves plaintext passwords of all contexts from config file to keyring.
+func runAuthMigrate() error {
+	var migrated int
+	err := updateConfigFile(func(cfg *Config) error {
+		var err error
+		migrated, err = migratePasswords(cfg)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if migrated == 0 {
+		pterm.Info.Println("Config contains no passwords, nothing to migrate")
+		return nil
+	}
+
+	pterm.Success.Printfln("Moved %d password(s) to keyring", migrated)
+	return nil
+}
+
+// migratePasswords stores passwords found in config in keyring and removes them from config.
+func migratePasswords(cfg *Config) (int, error) {
+	original := cfg.Clone()
+	migrated := 0
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name, p := range cfg.Profiles {
+		if p.JiraPassword != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		effective, err := config.SelectContext(original, name)
+		if err != nil {
+			return 0, err
+		}
+		p := cfg.Profiles[name]
+		if err := keyring.Set(config.KeyringService, config.KeyringUser(effective), p.JiraPassword); err != nil {
+			return 0, fmt.Errorf("cannot store password of context %q in keyring: %w", name, err)
+		}
+		p.JiraPassword = ""
+		p.PasswordSource = config.PasswordSourceKeyring
+		cfg.Profiles[name] = p
+		migrated++
+	}
+
+	if cfg.JiraPassword != "" {
+		if err := keyring.Set(config.KeyringService, config.KeyringUser(original), cfg.JiraPassword); err != nil {
+			return 0, fmt.Errorf("cannot store password in keyring: %w", err)
+		}
+		// profiles without password of their own inherit keyring source, and
+		// look the password up under their own URL or login
+		for _, name := range config.ProfileNames(original) {
+			p := original.Profiles[name]
+			if p.JiraPassword != "" || p.PasswordSource != "" || p.PasswordCommand != "" {
+				continue
+			}
+			effective, err := config.SelectContext(original, name)
+			if err != nil {
+				return 0, err
+			}
+			if config.KeyringUser(effective) == config.KeyringUser(original) {
+				continue
+			}
+			if err := keyring.Set(config.KeyringService, config.KeyringUser(effective), cfg.JiraPassword); err != nil {
+				return 0, fmt.Errorf("cannot store password of context %q in keyring: %w", name, err)
+			}
+		}
+		cfg.JiraPassword = ""
+		cfg.PasswordSource = config.PasswordSourceKeyring
+		migrated++
+	}
+	return migrated, nil
+}
+
+// Exit codes of failures scripts may want to tell apart, `tlog auth test` exits with them too.
+const (
+	exitNetwork     = 2
+	exitCredentials = 3
+)
+
+// runAuthTest checks that JIRA accepts configured credentials and tells what went wrong if not.
+func runAuthTest() error {
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	client, err := backend.NewJiraClient(conf)
+	if err != nil {
+		return err
+	}
+
+	user, resp, err := client.User.GetSelf()
+	if err != nil {
+		var httpResp *http.Response
+		if resp != nil {
+			httpResp = resp.Response
+		}
+		reason, code := diagnoseAuthFailure(httpResp, err)
+		pterm.Error.Println(reason)
+		return &exitError{code: code, err: shownError{errors.New(reason)}}
+	}
+
+	account := user.Name
+	if account == "" {
+		account = user.AccountID
+	}
+	pterm.Success.Printfln("Authenticated as %s (%s)", user.DisplayName, account)
+
+	var info struct {
+		ServerTitle    string `json:"serverTitle"`
+		Version        string `json:"version"`
+		DeploymentType string `json:"deploymentType"`
+	}
+	req, err := client.NewRequest(http.MethodGet, "rest/api/2/serverInfo", nil)
+	if err == nil {
+		_, err = client.Do(req, &info)
+	}
+	if err == nil {
+		pterm.Info.Printfln("Server: %s, %s %s", info.ServerTitle, info.DeploymentType, info.Version)
+	}
+	return nil
+}
+
+// diagnoseAuthFailure explains why request to JIRA failed and picks exit code for it.
+func diagnoseAuthFailure(resp *http.Response, err error) (string, int) {
+	if resp != nil {
+		if reason := resp.Header.Get("X-Authentication-Denied-Reason"); strings.Contains(reason, "CAPTCHA") {
+			return "JIRA requires CAPTCHA after failed logins, log in through browser once to clear it", exitCredentials
+		}
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			return "JIRA rejected credentials (401 Unauthorized), check login and password", exitCredentials
+		case http.StatusForbidden:
+			return "JIRA denied access (403 Forbidden), account might be locked or lack permissions", exitCredentials
+		}
+	}
+
+	var dnsErr *net.DNSError
+	var unknownCA x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCert x509.CertificateInvalidError
+	switch {
+	case errors.Is(err, backend.ErrCloudPassword):
+		return err.Error(), exitCredentials
+	case errors.As(err, &dnsErr):
+		return fmt.Sprintf("cannot resolve %s, check JiraURL", dnsErr.Name), exitNetwork
+	case errors.As(err, &unknownCA), errors.As(err, &hostnameErr), errors.As(err, &invalidCert):
+		return fmt.Sprintf("TLS certificate of JIRA is not trusted: %s", err), exitNetwork
+	case resp == nil:
+		return fmt.Sprintf("cannot reach JIRA: %s", err), exitNetwork
+	default:
+		return fmt.Sprintf("unexpected JIRA response (%s): %s", resp.Status, err), 1
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func Test_migratePasswords(t *testing.T) {
+	keyring.MockInit()
+	cfg := Config{
+		JiraURL:      "https://jira.example.com",
+		JiraLogin:    "user",
+		JiraPassword: "secret",
+		Profiles: map[string]config.Profile{
+			"client":  {JiraURL: "https://client.atlassian.net", JiraPassword: "token"},
+			"other":   {JiraURL: "https://other.atlassian.net", PasswordSource: config.PasswordSourceKeyring},
+			"staging": {JiraURL: "https://staging.example.com"},
+		},
+	}
+
+	migrated, err := migratePasswords(&cfg)
+	require.NoError(t, err)
+	require.Equal(t, 2, migrated)
+	require.Empty(t, cfg.JiraPassword)
+	require.Equal(t, config.PasswordSourceKeyring, cfg.PasswordSource)
+	require.Equal(t, config.Profile{JiraURL: "https://client.atlassian.net", PasswordSource: config.PasswordSourceKeyring}, cfg.Profiles["client"])
+
+	password, err := keyring.Get(config.KeyringService, "user@https://jira.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "secret", password)
+	password, err = keyring.Get(config.KeyringService, "user@https://client.atlassian.net")
+	require.NoError(t, err)
+	require.Equal(t, "token", password)
+	password, err = keyring.Get(config.KeyringService, "user@https://staging.example.com")
+	require.NoError(t, err, "inherited password is stored for the profile too")
+	require.Equal(t, "secret", password)
+	_, err = keyring.Get(config.KeyringService, "user@https://other.atlassian.net")
+	require.ErrorIs(t, err, keyring.ErrNotFound, "profile with its own source is left alone")
+
+	staging, err := config.SelectContext(cfg, "staging")
+	require.NoError(t, err)
+	password, err = staging.Password()
+	require.NoError(t, err)
+	require.Equal(t, "secret", password)
+
+	migrated, err = migratePasswords(&cfg)
+	require.NoError(t, err)
+	require.Zero(t, migrated)
+}
+
+func Test_diagnoseAuthFailure(t *testing.T) {
+	captcha := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	captcha.Header.Set("X-Authentication-Denied-Reason", "CAPTCHA_CHALLENGE; login-url=https://jira.example.com/login.jsp")
+
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		err      error
+		wantCode int
+		want     string
+	}{
+		{name: "captcha", resp: captcha, err: errors.New("403"), wantCode: exitCredentials, want: "CAPTCHA"},
+		{name: "unauthorized", resp: &http.Response{StatusCode: http.StatusUnauthorized}, err: errors.New("401"), wantCode: exitCredentials, want: "401"},
+		{name: "forbidden", resp: &http.Response{StatusCode: http.StatusForbidden}, err: errors.New("403"), wantCode: exitCredentials, want: "403"},
+		{name: "cloud password", err: fmt.Errorf("wrapped: %w", backend.ErrCloudPassword), wantCode: exitCredentials, want: "cloud-token"},
+		{name: "dns", err: &url.Error{Op: "Get", URL: "https://jira.invalid", Err: &net.DNSError{Name: "jira.invalid"}}, wantCode: exitNetwork, want: "cannot resolve jira.invalid"},
+		{name: "tls", err: &url.Error{Op: "Get", URL: "https://jira.example.com", Err: x509.UnknownAuthorityError{}}, wantCode: exitNetwork, want: "TLS certificate"},
+		{name: "connection refused", err: errors.New("connection refused"), wantCode: exitNetwork, want: "cannot reach JIRA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, code := diagnoseAuthFailure(tt.resp, tt.err)
+			require.Equal(t, tt.wantCode, code)
+			require.Contains(t, reason, tt.want)
+		})
+	}
+}
+
+func Test_logout(t *testing.T) {
+	keyring.MockInit()
+	isolateUserDirs(t)
+	conf := Config{JiraURL: "https://jira.example.com", JiraLogin: "user"}
+
+	require.NoError(t, keyring.Set(config.KeyringService, config.KeyringUser(conf), "secret"))
+	require.NoError(t, backend.SaveSession(conf, &backend.SessionCookie{Name: "JSESSIONID", Value: "s1"}))
+
+	removed, err := logout(conf)
+	require.NoError(t, err)
+	require.Equal(t, []string{"password in keyring", "cached session"}, removed)
+
+	removed, err = logout(conf)
+	require.NoError(t, err)
+	require.Empty(t, removed)
+}
+
+func Test_removeConfigPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	globalOpts.ConfigPath = path
+	t.Cleanup(func() { globalOpts.ConfigPath = "" })
+
+	const text = `JiraURL = "https://jira.example.com"
+JiraLogin = "user"
+JiraPassword = "secret" # top-level
+
+[profiles.client]
+JiraURL = "https://client.atlassian.net"
+JiraPassword = "token"
+`
+	require.NoError(t, os.WriteFile(path, []byte(text), 0600))
+
+	removed, err := removeConfigPasswords(true)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"password in " + path + " (default)",
+		"password in " + path + " (client)",
+	}, removed)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `JiraURL = "https://jira.example.com"
+JiraLogin = "user"
+
+[profiles.client]
+JiraURL = "https://client.atlassian.net"
+`, string(data))
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/pterm/pterm"
+)
+
+// monthBalance is what one month adds to balance.
+type monthBalance struct {
+	Month    string  `json:"month"` // "2006-01"
+	Logged   float64 `json:"logged_hours"`
+	Target   float64 `json:"target_hours"`
+	Adjusted float64 `json:"adjusted_hours"`
+	Delta    float64 `json:"delta_hours"`
+	// balance at the end of the month
+	Balance float64 `json:"balance_hours"`
+}
+
+// runBalance prints flex time balance: time logged minus time expected
+// since --since or EmploymentStart, up to today.
+func runBalance(args []string) error {
+	flags := flag.NewFlagSet("balance", flag.ContinueOnError)
+	since := flags.String("since", "", "count from this day, e.g. 2025-01-01, EmploymentStart or start of the year if not set")
+	output := flags.String("output", "text", "output format: text or json")
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records")
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+	if flags.NArg() > 0 {
+		return errors.New("Usage: tlog balance [--since <day>] [--output json]")
+	}
+	switch *output {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q, text or json expected", *output)
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+	today, _ := convertToDay("", conf.Location())
+	from, err := balanceStart(conf, *since, today)
+	if err != nil {
+		return err
+	}
+	to := today.AddDate(0, 0, 1)
+	worklogs, err := reportWorklogs(conf, from, to)
+	if err != nil {
+		return err
+	}
+	months, err := balanceByMonth(conf, worklogs, from, to)
+	if err != nil {
+		return err
+	}
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			From    string         `json:"from"`
+			Months  []monthBalance `json:"months"`
+			Balance float64        `json:"balance_hours"`
+		}{from.Format("2006-01-02"), months, months[len(months)-1].Balance})
+	}
+
+	data := pterm.TableData{{"Month", "Logged", "Target", "Adjusted", "Month +/-", "Balance"}}
+	for _, m := range months {
+		data = append(data, []string{
+			m.Month, formatHours(m.Logged), formatHours(m.Target), formatSignedHours(m.Adjusted), formatSignedHours(m.Delta), formatSignedHours(m.Balance),
+		})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+		return err
+	}
+	balance := months[len(months)-1].Balance
+	switch {
+	case balance > 0:
+		pterm.Printfln("Since %s you are %s in credit", from.Format("2006-01-02"), pterm.Green(formatHours(balance)))
+	case balance < 0:
+		pterm.Printfln("Since %s you are %s in debt", from.Format("2006-01-02"), pterm.Yellow(formatHours(-balance)))
+	default:
+		pterm.Printfln("Since %s you are even", from.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// balanceStart returns the first day of balance: --since, start of the year
+// without it, but never before EmploymentStart.
+func balanceStart(conf Config, since string, today time.Time) (time.Time, error) {
+	start, err := conf.Balance.EmploymentDate(conf.Location())
+	if err != nil {
+		return time.Time{}, err
+	}
+	var from time.Time
+	switch {
+	case since != "":
+		if from, err = convertToDay(since, conf.Location()); err != nil {
+			return time.Time{}, fmt.Errorf("--since: %w", err)
+		}
+	case !start.IsZero():
+		from = start
+	default:
+		from = time.Date(today.Year(), 1, 1, 0, 0, 0, 0, today.Location())
+	}
+	if from.Before(start) {
+		from = start
+	}
+	if from.After(today) {
+		return time.Time{}, fmt.Errorf("%s is in the future", from.Format("2006-01-02"))
+	}
+	return from, nil
+}
+
+// balanceByMonth returns contributions of months to balance in [from, to),
+// WorkdayHours are expected on every working day. Adjustments before from
+// open the balance, they are counted in the first month; those from to on
+// are left out.
+func balanceByMonth(conf Config, worklogs []Worklog, from, to time.Time) ([]monthBalance, error) {
+	loc := conf.Location()
+	var months []monthBalance
+	target := map[string]time.Duration{}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01")
+		if len(months) == 0 || months[len(months)-1].Month != key {
+			months = append(months, monthBalance{Month: key})
+		}
+		if conf.Calendar.IsWorkday(day) {
+			target[key] += conf.Workday()
+		}
+	}
+	logged := map[string]time.Duration{}
+	for _, wl := range worklogs {
+		logged[wl.Started.In(loc).Format("2006-01")] += wl.Spent
+	}
+	adjusted := map[string]float64{}
+	for i, a := range conf.Balance.Adjustments {
+		day, err := conf.Balance.AdjustmentDate(i, loc)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case day.Before(from):
+			adjusted[months[0].Month] += a.Hours
+		case day.Before(to):
+			adjusted[day.Format("2006-01")] += a.Hours
+		}
+	}
+
+	var balance float64
+	for i := range months {
+		m := &months[i]
+		m.Logged = roundTo(logged[m.Month].Hours(), 2)
+		m.Target = roundTo(target[m.Month].Hours(), 2)
+		m.Adjusted = adjusted[m.Month]
+		delta := logged[m.Month].Hours() - target[m.Month].Hours() + m.Adjusted
+		balance += delta
+		m.Delta = roundTo(delta, 2)
+		m.Balance = roundTo(balance, 2)
+	}
+	return months, nil
+}
+
+// formatSignedHours renders hours with explicit sign, e.g. +1h30m or -2h.
+func formatSignedHours(hours float64) string {
+	if hours < 0 {
+		return "-" + formatHours(-hours)
+	}
+	return "+" + formatHours(hours)
+}
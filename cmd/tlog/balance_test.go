@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_balanceByMonth(t *testing.T) {
+	conf := Config{Timezone: "UTC", Calendar: config.Calendar{Holidays: []string{"2024-03-01"}}, Balance: config.Balance{
+		Adjustments: []config.Adjustment{
+			{Date: "2024-02-28", Hours: 10, Note: "carried over"},
+			{Date: "2024-03-04", Hours: -2.5, Note: "paid overtime"},
+			{Date: "2023-12-31", Hours: 4, Note: "before the range opens the balance"},
+			{Date: "2024-03-06", Hours: 100, Note: "after the range"},
+		},
+	}}
+	// from monday, 4 working days in february, holiday and 2 working days in march
+	from := time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)
+	worklogs := []Worklog{
+		{Issue: "APP-1", Started: from.Add(9 * time.Hour), Spent: 10 * time.Hour},
+		{Issue: "APP-1", Started: from.AddDate(0, 0, 1).Add(9 * time.Hour), Spent: 8 * time.Hour},
+		{Issue: "APP-1", Started: from.AddDate(0, 0, 5).Add(9 * time.Hour), Spent: 2 * time.Hour}, // saturday
+		{Issue: "APP-1", Started: from.AddDate(0, 0, 7).Add(9 * time.Hour), Spent: 8 * time.Hour},
+	}
+	months, err := balanceByMonth(conf, worklogs, from, to)
+	require.NoError(t, err)
+	require.Equal(t, []monthBalance{
+		{Month: "2024-02", Logged: 18, Target: 32, Adjusted: 14, Delta: 0, Balance: 0},
+		{Month: "2024-03", Logged: 10, Target: 16, Adjusted: -2.5, Delta: -8.5, Balance: -8.5},
+	}, months)
+
+	conf.Balance.Adjustments = []config.Adjustment{{Date: "28.02"}}
+	_, err = balanceByMonth(conf, worklogs, from, to)
+	var config config.Error
+	require.ErrorAs(t, err, &config)
+	require.Equal(t, "Balance.Adjustments[0].Date", config.Field)
+}
+
+func Test_balanceStart(t *testing.T) {
+	today := time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)
+	conf := Config{Timezone: "UTC"}
+	from, err := balanceStart(conf, "", today)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), from)
+
+	conf.Balance.EmploymentStart = "2024-02-12"
+	from, err = balanceStart(conf, "", today)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 2, 12, 0, 0, 0, 0, time.UTC), from)
+
+	from, err = balanceStart(conf, "2024.01.01", today)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 2, 12, 0, 0, 0, 0, time.UTC), from, "nothing is expected before employment")
+
+	conf.Balance.EmploymentStart = "soon"
+	_, err = balanceStart(conf, "", today)
+	require.ErrorAs(t, err, &config.Error{})
+}
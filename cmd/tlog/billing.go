@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// issueBilling is time logged to one issue.
+type issueBilling struct {
+	Issue    string        `json:"issue"`
+	Billable bool          `json:"billable"`
+	Spent    time.Duration `json:"-"`
+	Hours    float64       `json:"hours"`
+	Amount   float64       `json:"amount,omitempty"`
+}
+
+// billingReport splits time logged over a period into billable and not.
+type billingReport struct {
+	Issues      []issueBilling `json:"issues"`
+	Billable    float64        `json:"billable_hours"`
+	NonBillable float64        `json:"non_billable_hours"`
+	// billable hours multiplied by rates, zero without any
+	Amount float64 `json:"amount,omitempty"`
+}
+
+// runReportBillable prints time logged over range, the current month by
+// default, split into billable and non-billable.
+func runReportBillable(args []string) error {
+	flags := flag.NewFlagSet("report billable", flag.ContinueOnError)
+	rate := flags.Float64("rate", 0, "amount per billable hour, wins over Rate of projects")
+	tag := addTagFlag(flags)
+	xlsx := addXLSXFlag(flags)
+	output := flags.String("output", "text", "output format: text or json")
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog report billable [day|from..to] [--rate <amount>] [--tag <tag>] [--output json]")
+	}
+	switch *output {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q, text or json expected", *output)
+	}
+	if *rate < 0 {
+		return fmt.Errorf("--rate: %v is negative", *rate)
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+	from, to, err := reportRange(conf, safeGet(positional, 0))
+	if err != nil {
+		return err
+	}
+	worklogs, err := reportWorklogs(conf, from, to)
+	if err != nil {
+		return err
+	}
+	worklogs = withTag(worklogs, *tag)
+	if *xlsx != "" {
+		return saveReportXLSX(*xlsx, conf, worklogs)
+	}
+	report := billingOf(conf, worklogs, *rate)
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			From     string `json:"from"`
+			To       string `json:"to"`
+			Currency string `json:"currency,omitempty"`
+			billingReport
+		}{from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"), conf.Billing.Currency, report})
+	}
+
+	pterm.Printfln("%s – %s", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+	if len(report.Issues) == 0 {
+		pterm.Println("Nothing is logged")
+		return nil
+	}
+	data := pterm.TableData{{"Issue", "Billable", "Time", "Amount"}}
+	for _, ib := range report.Issues {
+		billable, amount := "no", ""
+		if ib.Billable {
+			billable = "yes"
+		}
+		if ib.Amount > 0 {
+			amount = formatAmount(ib.Amount, conf.Billing.Currency)
+		}
+		data = append(data, []string{ib.Issue, billable, parse.FormatDuration(ib.Spent), amount})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+		return err
+	}
+	total := report.Billable + report.NonBillable
+	pterm.Printfln("Billable: %s (%s), non-billable: %s", pterm.Green(formatHours(report.Billable)), formatPercent(roundTo(100*report.Billable/total, 1)), formatHours(report.NonBillable))
+	if report.Amount > 0 {
+		pterm.Printfln("Amount: %s", pterm.Bold.Sprint(formatAmount(report.Amount, conf.Billing.Currency)))
+	}
+	return nil
+}
+
+// billingOf sums worklogs up by issue, billable ones first, the most time
+// first within them. Billable hours are multiplied by rate or, without it,
+// by Rate of their project.
+func billingOf(conf Config, worklogs []Worklog, rate float64) billingReport {
+	byIssue := map[string]time.Duration{}
+	for _, wl := range worklogs {
+		byIssue[wl.Issue] += wl.Spent
+	}
+
+	report := billingReport{Issues: []issueBilling{}}
+	var billable, nonBillable time.Duration
+	for issue, spent := range byIssue {
+		ib := issueBilling{Issue: issue, Billable: conf.Billable(issue), Spent: spent, Hours: roundTo(spent.Hours(), 2)}
+		if ib.Billable {
+			billable += spent
+			r := rate
+			if r == 0 {
+				r = conf.Billing.Projects[parse.Project(issue)].Rate
+			}
+			ib.Amount = roundTo(spent.Hours()*r, 2)
+			report.Amount += ib.Amount
+		} else {
+			nonBillable += spent
+		}
+		report.Issues = append(report.Issues, ib)
+	}
+	sort.Slice(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.Billable != b.Billable {
+			return a.Billable
+		}
+		if a.Spent != b.Spent {
+			return a.Spent > b.Spent
+		}
+		return a.Issue < b.Issue
+	})
+	report.Billable = roundTo(billable.Hours(), 2)
+	report.NonBillable = roundTo(nonBillable.Hours(), 2)
+	report.Amount = roundTo(report.Amount, 2)
+	return report
+}
+
+// formatAmount renders amount with two decimals, e.g. 1234.50 EUR.
+func formatAmount(amount float64, currency string) string {
+	s := strconv.FormatFloat(amount, 'f', 2, 64)
+	if currency != "" {
+		s += " " + currency
+	}
+	return s
+}
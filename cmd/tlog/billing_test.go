@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_billingOf(t *testing.T) {
+	conf := Config{
+		TaskAliasDetails: map[string]config.AliasDetail{
+			"standup":  {Issue: "APP-9", Billable: toPtr(false)},
+			"hotfix":   {Issue: "OPS-3", Billable: toPtr(true)},
+			"internal": {Issue: "OPS-4"},
+		},
+		Billing: config.Billing{Projects: map[string]config.BillingProject{
+			"APP": {Billable: true, Rate: 100},
+			"OPS": {Rate: 80},
+		}},
+	}
+	worklogs := []Worklog{
+		{Issue: "APP-1", Spent: 2 * time.Hour},
+		{Issue: "APP-1", Spent: 30 * time.Minute},
+		{Issue: "APP-9", Spent: 15 * time.Minute},
+		{Issue: "OPS-3", Spent: time.Hour},
+		{Issue: "OPS-4", Spent: time.Hour},
+		{Issue: "HR-1", Spent: 4 * time.Hour},
+	}
+
+	report := billingOf(conf, worklogs, 0)
+	require.Equal(t, []issueBilling{
+		{Issue: "APP-1", Billable: true, Spent: 150 * time.Minute, Hours: 2.5, Amount: 250},
+		{Issue: "OPS-3", Billable: true, Spent: time.Hour, Hours: 1, Amount: 80},
+		{Issue: "HR-1", Spent: 4 * time.Hour, Hours: 4},
+		{Issue: "OPS-4", Spent: time.Hour, Hours: 1},
+		{Issue: "APP-9", Spent: 15 * time.Minute, Hours: 0.25},
+	}, report.Issues, "aliases win over project defaults")
+	require.Equal(t, 3.5, report.Billable)
+	require.Equal(t, 5.25, report.NonBillable)
+	require.Equal(t, 330.0, report.Amount)
+
+	report = billingOf(conf, worklogs, 50)
+	require.Equal(t, 175.0, report.Amount, "--rate wins over rates of projects")
+}
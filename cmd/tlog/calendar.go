@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+)
+
+// calendarProblems reports invalid and duplicate holidays and unknown region.
+func calendarProblems(cfg Config) []ConfigProblem {
+	var problems []ConfigProblem
+	seen := map[config.Holiday]string{}
+	for _, s := range cfg.Calendar.Holidays {
+		h, err := config.ParseHoliday(s)
+		if err != nil {
+			problems = append(problems, ConfigProblem{Key: "Calendar.Holidays", Message: err.Error()})
+			continue
+		}
+		prev, ok := seen[h]
+		if !ok {
+			prev, ok = seen[config.Holiday{Month: h.Month, Day: h.Day}]
+		}
+		if !ok && h.Year == 0 {
+			for other, s := range seen {
+				if other.Month == h.Month && other.Day == h.Day {
+					prev, ok = s, true
+				}
+			}
+		}
+		if ok {
+			problems = append(problems, ConfigProblem{
+				Key:        "Calendar.Holidays",
+				Message:    fmt.Sprintf("%q is the same day as %q", s, prev),
+				Suggestion: "remove one of them",
+			})
+			continue
+		}
+		seen[h] = s
+	}
+
+	if cfg.Calendar.Region != "" {
+		if _, ok := config.RegionHolidays[strings.ToUpper(cfg.Calendar.Region)]; !ok {
+			regions := make([]string, 0, len(config.RegionHolidays))
+			for r := range config.RegionHolidays {
+				regions = append(regions, r)
+			}
+			sort.Strings(regions)
+			problems = append(problems, ConfigProblem{
+				Key:        "Calendar.Region",
+				Message:    fmt.Sprintf("unknown region %q", cfg.Calendar.Region),
+				Suggestion: "use one of " + strings.Join(regions, ", ") + " or list holidays in Calendar.Holidays",
+			})
+		}
+	}
+
+	for _, off := range []timeOff{vacationOff, sickOff} {
+		if alias := off.alias(cfg); alias != "" {
+			if _, ok := cfg.Aliases()[alias]; !ok && !parse.IsIssue(alias) {
+				problems = append(problems, ConfigProblem{
+					Key:        "Calendar." + off.key,
+					Message:    fmt.Sprintf("%q is neither alias nor issue key", alias),
+					Suggestion: "add it to TaskAliases",
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// weekTarget returns WeeklyTarget of the week containing day, reduced by a workday
+// for every holiday falling on a weekday.
+func weekTarget(conf Config, day time.Time) time.Duration {
+	target := conf.WeeklyTarget()
+	monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	for i := 0; i < 5; i++ {
+		if conf.Calendar.IsHoliday(monday.AddDate(0, 0, i)) {
+			target -= conf.Workday()
+		}
+	}
+	if target < 0 {
+		return 0
+	}
+	return target
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_calendarProblems(t *testing.T) {
+	cfg := Config{
+		TaskAliases: map[string]string{"vacation": "HR-1"},
+		Calendar: config.Calendar{
+			Holidays:      []string{"2022-12-25", "2022.12.25", "12.32", "12.26", "2023-12-26"},
+			Region:        "XX",
+			VacationAlias: "vacation",
+		},
+	}
+	var got []string
+	for _, p := range calendarProblems(cfg) {
+		got = append(got, p.String())
+	}
+	require.Equal(t, []string{
+		`Calendar.Holidays: "2022.12.25" is the same day as "2022-12-25", remove one of them`,
+		`Calendar.Holidays: "12.32" is not a date, use yyyy-mm-dd, yyyy.mm.dd or mm.dd for every year`,
+		`Calendar.Holidays: "2023-12-26" is the same day as "12.26", remove one of them`,
+		`Calendar.Region: unknown region "XX", use one of DE, FR, GB, RU, US or list holidays in Calendar.Holidays`,
+	}, got)
+
+	cfg.Calendar = config.Calendar{VacationAlias: "holidays"}
+	require.Len(t, calendarProblems(cfg), 1)
+	cfg.Calendar = config.Calendar{VacationAlias: "HR-2"}
+	require.Empty(t, calendarProblems(cfg))
+	cfg.Calendar = config.Calendar{SickAlias: "ill"}
+	require.Equal(t, "Calendar.SickAlias", calendarProblems(cfg)[0].Key)
+}
+
+func Test_weekTarget(t *testing.T) {
+	wednesday := time.Date(2022, time.December, 28, 0, 0, 0, 0, time.UTC)
+	conf := Config{Calendar: config.Calendar{Holidays: []string{"2022-12-26", "2022-12-31"}}}
+	require.Equal(t, 32*time.Hour, weekTarget(conf, wednesday)) // saturday does not count
+	require.Equal(t, 40*time.Hour, weekTarget(conf, wednesday.AddDate(0, 0, 7)))
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+	"golang.org/x/term"
+)
+
+// chartWidth is how many characters bar of workday takes.
+const chartWidth = 40
+
+// chartBlocks are eighths of a character of unicode bars, the last one full.
+var chartBlocks = []rune("▏▎▍▌▋▊▉█")
+
+// runChart prints bar of time logged on every day of range, the current
+// month by default, with totals of weeks. Days after today are not shown.
+func runChart(args []string) error {
+	flags := flag.NewFlagSet("chart", flag.ContinueOnError)
+	tag := addTagFlag(flags)
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog chart [day|from..to] [--tag <tag>]")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+	from, to, err := reportRange(conf, safeGet(positional, 0))
+	if err != nil {
+		return err
+	}
+	today, _ := convertToDay("", conf.Location())
+	if tomorrow := today.AddDate(0, 0, 1); to.After(tomorrow) {
+		to = tomorrow
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("%s is in the future", from.Format("2006-01-02"))
+	}
+	worklogs, err := reportWorklogs(conf, from, to)
+	if err != nil {
+		return err
+	}
+
+	// colors and block characters need terminal, plain ones survive pipes
+	plain := pterm.RawOutput || os.Getenv("NO_COLOR") != "" || !term.IsTerminal(int(os.Stdout.Fd()))
+	for _, line := range chartLines(conf, withTag(worklogs, *tag), from, to, plain) {
+		pterm.Println(line)
+	}
+	return nil
+}
+
+// chartLines renders bars of days in [from, to), workday being chartWidth
+// long, and total of every week after its last day. Plain lines use "#" and
+// mark days instead of coloring them.
+func chartLines(conf Config, worklogs []Worklog, from, to time.Time, plain bool) []string {
+	loc := conf.Location()
+	perDay := map[string]time.Duration{}
+	for _, wl := range worklogs {
+		perDay[wl.Started.In(loc).Format("2006-01-02")] += wl.Spent
+	}
+	workday := conf.Workday()
+
+	var lines []string
+	var week, weekTarget time.Duration
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		spent := perDay[day.Format("2006-01-02")]
+		workingDay := conf.Calendar.IsWorkday(day)
+		week += spent
+		if workingDay {
+			weekTarget += workday
+		}
+
+		bar := chartBar(spent, workday, plain)
+		label := day.Format("Mon 01.02")
+		line := fmt.Sprintf("%s %s %s", label, bar, parse.FormatDuration(spent))
+		switch {
+		case !workingDay:
+			if plain {
+				line += " (day off)"
+			} else {
+				line = pterm.Gray(line)
+			}
+		case spent < workday:
+			if plain {
+				line += " (short)"
+			} else {
+				line = fmt.Sprintf("%s %s %s", label, pterm.Yellow(bar), parse.FormatDuration(spent))
+			}
+		case spent > workday:
+			if plain {
+				line += " (over)"
+			} else {
+				line = fmt.Sprintf("%s %s %s", label, pterm.Red(bar), parse.FormatDuration(spent))
+			}
+		}
+		lines = append(lines, line)
+
+		if next := day.AddDate(0, 0, 1); next.Weekday() == time.Monday || !next.Before(to) {
+			total := fmt.Sprintf("    week: %s of %s", parse.FormatDuration(week), parse.FormatDuration(weekTarget))
+			if !plain {
+				total = pterm.Bold.Sprint(total)
+			}
+			lines = append(lines, total)
+			week, weekTarget = 0, 0
+		}
+	}
+	return lines
+}
+
+// chartBar returns bar of spent, workday being chartWidth characters long.
+// Bars grow past it, at most twice as long.
+func chartBar(spent, workday time.Duration, plain bool) string {
+	if workday <= 0 {
+		return ""
+	}
+	eighths := int(int64(spent) * chartWidth * 8 / int64(workday))
+	if eighths > 2*chartWidth*8 {
+		eighths = 2 * chartWidth * 8
+	}
+	if plain {
+		return strings.Repeat("#", (eighths+4)/8)
+	}
+	bar := strings.Repeat(string(chartBlocks[7]), eighths/8)
+	if rest := eighths % 8; rest > 0 {
+		bar += string(chartBlocks[rest-1])
+	}
+	return bar
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_chartLines(t *testing.T) {
+	conf := Config{Timezone: "UTC", WorkdayHours: 8}
+	// friday to tuesday
+	from := time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 27, 0, 0, 0, 0, time.UTC)
+	worklogs := []Worklog{
+		{Issue: "APP-1", Started: from.Add(9 * time.Hour), Spent: 4 * time.Hour},
+		{Issue: "APP-1", Started: from.AddDate(0, 0, 1).Add(9 * time.Hour), Spent: time.Hour},
+		{Issue: "APP-1", Started: from.AddDate(0, 0, 3).Add(9 * time.Hour), Spent: 8 * time.Hour},
+		{Issue: "APP-1", Started: from.AddDate(0, 0, 4).Add(9 * time.Hour), Spent: 10 * time.Hour},
+	}
+
+	require.Equal(t, []string{
+		"Fri 03.22 " + strings.Repeat("#", 20) + " 4h (short)",
+		"Sat 03.23 " + strings.Repeat("#", 5) + " 1h (day off)",
+		"Sun 03.24  0m (day off)",
+		"    week: 5h of 8h",
+		"Mon 03.25 " + strings.Repeat("#", 40) + " 8h",
+		"Tue 03.26 " + strings.Repeat("#", 50) + " 10h (over)",
+		"    week: 18h of 16h",
+	}, chartLines(conf, worklogs, from, to, true))
+}
+
+func Test_chartBar(t *testing.T) {
+	require.Equal(t, strings.Repeat("█", 20)+"▌", chartBar(4*time.Hour+6*time.Minute, 8*time.Hour, false))
+	require.Equal(t, strings.Repeat("█", 80), chartBar(24*time.Hour, 8*time.Hour, false), "at most twice as long as workday")
+	require.Equal(t, "", chartBar(0, 8*time.Hour, false))
+}
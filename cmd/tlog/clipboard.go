@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"golang.org/x/term"
+)
+
+// errNoClipboard tells that neither clipboard tool nor terminal to copy
+// through was found.
+var errNoClipboard = errors.New("no clipboard found, install wl-clipboard, xclip or xsel")
+
+// takeCopyFlag removes --copy from args, reporting whether it was there.
+func takeCopyFlag(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	copied := false
+	for _, arg := range args {
+		if arg == "--copy" {
+			copied = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, copied
+}
+
+// withCopy runs command, with --copy among args putting what it prints on
+// clipboard instead of stdout. Output is printed anyway if command fails or
+// there is no clipboard.
+func withCopy(args []string, command func(args []string) error) error {
+	args, copied := takeCopyFlag(args)
+	if !copied {
+		return command(args)
+	}
+	output, err := captureStdout(func() error { return command(args) })
+	if err != nil {
+		os.Stdout.Write(output)
+		return err
+	}
+	text := pterm.RemoveColorFromString(string(output))
+	how, err := writeClipboard(text)
+	if err != nil {
+		os.Stdout.Write(output)
+		pterm.Warning.WithWriter(os.Stderr).Printfln("Output is not copied: %s", err)
+		return nil
+	}
+	lines := strings.Count(text, "\n")
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		lines++
+	}
+	pterm.Success.WithWriter(os.Stderr).Printfln("Copied %d lines (%d bytes) to %s", lines, len(text), how)
+	return nil
+}
+
+// captureStdout returns what run printed to stdout, directly or with pterm.
+func captureStdout(run func() error) ([]byte, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	pterm.SetDefaultOutput(w)
+	read := make(chan []byte)
+	go func() {
+		var b bytes.Buffer
+		_, _ = io.Copy(&b, r)
+		read <- b.Bytes()
+	}()
+
+	err = run()
+	os.Stdout = stdout
+	pterm.SetDefaultOutput(stdout)
+	w.Close()
+	output := <-read
+	r.Close()
+	return output, err
+}
+
+// writeClipboard puts text on system clipboard, telling where it went,
+// variable for tests. Over SSH without clipboard tools text is sent to the
+// local terminal by OSC 52 escape sequence.
+var writeClipboard = func(text string) (string, error) {
+	for _, command := range clipboardCommands() {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("%s: %s", command[0], strings.TrimSpace(string(out)+" "+err.Error()))
+		}
+		return "clipboard with " + command[0], nil
+	}
+	if err := nativeClipboard(text); err == nil {
+		return "clipboard", nil
+	} else if !errors.Is(err, errNoClipboard) {
+		return "", err
+	}
+	if os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == "" {
+		return "", errNoClipboard
+	}
+	for _, f := range []*os.File{os.Stderr, os.Stdout} {
+		if term.IsTerminal(int(f.Fd())) {
+			_, err := io.WriteString(f, osc52(text, os.Getenv("TMUX") != ""))
+			return "clipboard of the terminal over SSH", err
+		}
+	}
+	return "", errNoClipboard
+}
+
+// clipboardCommands lists tools copying stdin to clipboard of the platform,
+// the preferred first.
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return nil
+	}
+	var commands [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		commands = append(commands, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		commands = append(commands, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+	return commands
+}
+
+// osc52 returns escape sequence asking terminal to put text on clipboard,
+// passed through tmux to the terminal it runs in.
+func osc52(text string, tmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if tmux {
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
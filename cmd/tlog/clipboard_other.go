@@ -0,0 +1,8 @@
+//go:build !windows
+
+package main
+
+// nativeClipboard is not there, other platforms have clipboard tools.
+func nativeClipboard(text string) error {
+	return errNoClipboard
+}
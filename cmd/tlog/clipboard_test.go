@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/pterm/pterm"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_withCopy(t *testing.T) {
+	var copied string
+	clipboard := writeClipboard
+	t.Cleanup(func() { writeClipboard = clipboard })
+	writeClipboard = func(text string) (string, error) {
+		copied = text
+		return "clipboard", nil
+	}
+	command := func(args []string) error {
+		require.Equal(t, []string{"sprint", "--markdown"}, args)
+		fmt.Println("| Issue | Time |")
+		pterm.Println(pterm.Red("| APP-1 | 2h |"))
+		return nil
+	}
+
+	output, err := captureStdout(func() error { return withCopy([]string{"sprint", "--copy", "--markdown"}, command) })
+	require.NoError(t, err)
+	require.Empty(t, output, "copied output is not printed")
+	require.Equal(t, "| Issue | Time |\n| APP-1 | 2h |\n", copied)
+
+	writeClipboard = func(text string) (string, error) { return "", errNoClipboard }
+	output, err = captureStdout(func() error { return withCopy([]string{"sprint", "--markdown", "--copy"}, command) })
+	require.NoError(t, err)
+	require.Contains(t, string(output), "| Issue | Time |\n", "printed without clipboard")
+
+	failed := errors.New("no sprint")
+	output, err = captureStdout(func() error {
+		return withCopy([]string{"--copy"}, func([]string) error {
+			fmt.Print("partial")
+			return failed
+		})
+	})
+	require.ErrorIs(t, err, failed)
+	require.Equal(t, "partial", string(output))
+}
+
+func Test_osc52(t *testing.T) {
+	require.Equal(t, "\x1b]52;c;aGk=\a", osc52("hi", false))
+	require.Equal(t, "\x1bPtmux;\x1b\x1b]52;c;aGk=\a\x1b\\", osc52("hi", true))
+}
+
+func Test_captureStdout(t *testing.T) {
+	output, err := captureStdout(func() error {
+		_, err := io.WriteString(os.Stdout, "plain\n")
+		pterm.Print("styled\n")
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, "plain\nstyled\n", string(output))
+}
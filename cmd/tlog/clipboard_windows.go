@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	user32               = windows.NewLazySystemDLL("user32.dll")
+	kernel32             = windows.NewLazySystemDLL("kernel32.dll")
+	procOpenClipboard    = user32.NewProc("OpenClipboard")
+	procCloseClipboard   = user32.NewProc("CloseClipboard")
+	procEmptyClipboard   = user32.NewProc("EmptyClipboard")
+	procSetClipboardData = user32.NewProc("SetClipboardData")
+	procGlobalAlloc      = kernel32.NewProc("GlobalAlloc")
+	procGlobalFree       = kernel32.NewProc("GlobalFree")
+	procGlobalLock       = kernel32.NewProc("GlobalLock")
+	procGlobalUnlock     = kernel32.NewProc("GlobalUnlock")
+	procMoveMemory       = kernel32.NewProc("RtlMoveMemory")
+)
+
+const (
+	cfUnicodeText = 13
+	gmemMoveable  = 0x0002
+)
+
+// nativeClipboard puts text on clipboard with Windows API, as UTF-16.
+func nativeClipboard(text string) error {
+	data, err := windows.UTF16FromString(text)
+	if err != nil {
+		return err
+	}
+	// another program may hold clipboard for a moment
+	opened := false
+	for i := 0; i < 10 && !opened; i++ {
+		if r, _, _ := procOpenClipboard.Call(0); r != 0 {
+			opened = true
+		} else {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	if !opened {
+		return fmt.Errorf("clipboard is used by another program")
+	}
+	defer procCloseClipboard.Call()
+
+	if r, _, err := procEmptyClipboard.Call(); r == 0 {
+		return fmt.Errorf("empty clipboard: %w", err)
+	}
+	size := uintptr(len(data)) * unsafe.Sizeof(data[0])
+	h, _, err := procGlobalAlloc.Call(gmemMoveable, size)
+	if h == 0 {
+		return fmt.Errorf("allocate clipboard data: %w", err)
+	}
+	p, _, err := procGlobalLock.Call(h)
+	if p == 0 {
+		procGlobalFree.Call(h)
+		return fmt.Errorf("lock clipboard data: %w", err)
+	}
+	procMoveMemory.Call(p, uintptr(unsafe.Pointer(&data[0])), size)
+	procGlobalUnlock.Call(h)
+	if r, _, err := procSetClipboardData.Call(cfUnicodeText, h); r == 0 {
+		procGlobalFree.Call(h)
+		return fmt.Errorf("set clipboard data: %w", err)
+	}
+	// clipboard owns the memory now
+	return nil
+}
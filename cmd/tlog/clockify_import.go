@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+)
+
+const defaultClockifyURL = "https://api.clockify.me/api/v1"
+
+// clockifyClient reads time entries of the current Clockify user.
+type clockifyClient struct {
+	conf   Config
+	client *http.Client
+	base   string
+}
+
+type clockifyTimeEntry struct {
+	ID           string `json:"id"`
+	Description  string `json:"description"`
+	TimeInterval struct {
+		Start time.Time  `json:"start"`
+		End   *time.Time `json:"end"`
+	} `json:"timeInterval"`
+	Project *struct {
+		Name string `json:"name"`
+	} `json:"project"`
+	Task *struct {
+		Name string `json:"name"`
+	} `json:"task"`
+}
+
+func newClockifyClient(conf Config) (*clockifyClient, error) {
+	if conf.ClockifyAPIKey == "" {
+		return nil, errors.New("ClockifyAPIKey is not set in config, generate it in Clockify > Preferences > Advanced")
+	}
+	transport, err := backend.BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := backend.NewRetryTransport(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+	base := conf.ClockifyURL
+	if base == "" {
+		base = defaultClockifyURL
+	}
+	return &clockifyClient{conf: conf, client: &http.Client{Transport: retry}, base: strings.TrimSuffix(base, "/")}, nil
+}
+
+// get sends GET request to Clockify API and decodes response into out.
+func (c *clockifyClient) get(path string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Api-Key", c.conf.ClockifyAPIKey)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach Clockify: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+		if failure.Message != "" {
+			return fmt.Errorf("Clockify: %s, %s", resp.Status, failure.Message)
+		}
+		return fmt.Errorf("Clockify: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// timeEntries returns entries of the current user started in [from, to), in
+// ClockifyWorkspace or the active workspace of the user.
+func (c *clockifyClient) timeEntries(from, to time.Time) ([]clockifyTimeEntry, error) {
+	var user struct {
+		ID              string `json:"id"`
+		ActiveWorkspace string `json:"activeWorkspace"`
+	}
+	if err := c.get("/user", &user); err != nil {
+		return nil, fmt.Errorf("get current user: %w", err)
+	}
+	workspace := c.conf.ClockifyWorkspace
+	if workspace == "" {
+		workspace = user.ActiveWorkspace
+	}
+
+	const pageSize = 200
+	var entries []clockifyTimeEntry
+	for page := 1; ; page++ {
+		query := url.Values{
+			"start":     {from.UTC().Format(time.RFC3339)},
+			"end":       {to.UTC().Format(time.RFC3339)},
+			"hydrated":  {"true"},
+			"page":      {strconv.Itoa(page)},
+			"page-size": {strconv.Itoa(pageSize)},
+		}
+		var batch []clockifyTimeEntry
+		path := fmt.Sprintf("/workspaces/%s/user/%s/time-entries?%s", url.PathEscape(workspace), url.PathEscape(user.ID), query.Encode())
+		if err := c.get(path, &batch); err != nil {
+			return nil, fmt.Errorf("list time entries: %w", err)
+		}
+		for _, e := range batch {
+			if !e.TimeInterval.Start.Before(from) && e.TimeInterval.Start.Before(to) {
+				entries = append(entries, e)
+			}
+		}
+		if len(batch) < pageSize {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// clockifyImportEntries maps Clockify entries to worklogs: issue key in
+// description, then ClockifyMapping of description, "Project/Task" or "Project".
+func clockifyImportEntries(conf Config, entries []clockifyTimeEntry) []importEntry {
+	imported := make([]importEntry, 0, len(entries))
+	for _, e := range entries {
+		var project, task string
+		if e.Project != nil {
+			project = e.Project.Name
+		}
+		label := project
+		if e.Task != nil && project != "" {
+			task = project + "/" + e.Task.Name
+			label = task
+		}
+
+		ie := importEntry{Source: label, Worklog: Worklog{
+			Started: e.TimeInterval.Start.In(conf.Location()),
+			Comment: e.Description,
+		}, ImportIDs: []string{"clockify:" + e.ID}}
+		if e.TimeInterval.End == nil {
+			ie.Problem = "timer is still running"
+			imported = append(imported, ie)
+			continue
+		}
+		ie.Worklog.Spent = e.TimeInterval.End.Sub(e.TimeInterval.Start).Round(time.Minute)
+
+		issue, err := mapImported(conf, conf.ClockifyMapping, e.Description, e.Description, task, project)
+		switch {
+		case err != nil:
+			ie.Problem = err.Error()
+		case ie.Worklog.Spent == 0:
+			ie.Problem = "shorter than a minute"
+		}
+		ie.Worklog.Issue = issue
+		imported = append(imported, ie)
+	}
+	return imported
+}
+
+func runImportClockify(args []string) error {
+	flags := flag.NewFlagSet("import clockify", flag.ContinueOnError)
+	fromInput := flags.String("from", "", "first day to import, today if not set")
+	toInput := flags.String("to", "", "last day to import, same as --from if not set")
+	opts := addImportFlags(flags)
+	opts.hint = "put issue key into description or map it in ClockifyMapping"
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	from, to, err := importRange(conf, *fromInput, *toInput)
+	if err != nil {
+		return err
+	}
+	client, err := newClockifyClient(conf)
+	if err != nil {
+		return err
+	}
+	entries, err := client.timeEntries(from, to)
+	if err != nil {
+		return err
+	}
+
+	selected, err := reviewImport(conf, clockifyImportEntries(conf, entries), *opts)
+	if err != nil {
+		return err
+	}
+	return submitImport(conf, selected, *opts)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_clockifyImport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "key", r.Header.Get("X-Api-Key"))
+		switch r.URL.Path {
+		case "/user":
+			w.Write([]byte(`{"id": "u1", "activeWorkspace": "ws1"}`))
+		case "/workspaces/ws1/user/u1/time-entries":
+			require.Equal(t, "true", r.URL.Query().Get("hydrated"))
+			require.Equal(t, "2024-03-04T00:00:00Z", r.URL.Query().Get("start"))
+			w.Write([]byte(`[
+				{"id": "1", "description": "PRJ-7 code review", "timeInterval": {"start": "2024-03-04T09:00:00Z", "end": "2024-03-04T10:30:00Z"}},
+				{"id": "2", "description": "sync", "project": {"name": "Internal"}, "task": {"name": "Meetings"},
+					"timeInterval": {"start": "2024-03-04T11:00:00Z", "end": "2024-03-04T11:30:00Z"}},
+				{"id": "3", "description": "planning", "project": {"name": "internal"},
+					"timeInterval": {"start": "2024-03-04T12:00:00Z", "end": "2024-03-04T12:15:00Z"}},
+				{"id": "4", "description": "lunch", "timeInterval": {"start": "2024-03-04T13:00:00Z", "end": "2024-03-04T14:00:00Z"}},
+				{"id": "5", "description": "PRJ-8", "timeInterval": {"start": "2024-03-04T15:00:00Z", "end": null}}
+			]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := Config{
+		ClockifyURL: srv.URL, ClockifyAPIKey: "key", Timezone: "UTC", DefaultProject: "PRJ",
+		TaskAliases:     map[string]string{"meet": "MEET-1"},
+		ClockifyMapping: map[string]string{"Internal/Meetings": "meet", "Internal": "42"},
+	}
+	client, err := newClockifyClient(conf)
+	require.NoError(t, err)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	entries, err := client.timeEntries(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+
+	imported := clockifyImportEntries(conf, entries)
+	require.Len(t, imported, 5)
+	require.Equal(t, Worklog{Issue: "PRJ-7", Started: day.Add(9 * time.Hour), Spent: 90 * time.Minute, Comment: "PRJ-7 code review"}, imported[0].Worklog)
+	require.Equal(t, "MEET-1", imported[1].Worklog.Issue)
+	require.Equal(t, "Internal/Meetings", imported[1].Source)
+	require.Equal(t, "PRJ-42", imported[2].Worklog.Issue, "project is matched regardless of case")
+	require.Empty(t, imported[2].Problem)
+	require.Empty(t, imported[3].Worklog.Issue)
+	require.Empty(t, imported[3].Problem, "not mapped entry can be assigned in review")
+	require.Equal(t, "timer is still running", imported[4].Problem)
+}
+
+func Test_importRange(t *testing.T) {
+	conf := Config{Timezone: "UTC"}
+	from, to, err := importRange(conf, "2024.03.04", "2024.03.08")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), from)
+	require.Equal(t, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), to)
+
+	_, _, err = importRange(conf, "2024.03.04", "2024.03.01")
+	require.ErrorContains(t, err, "before --from")
+}
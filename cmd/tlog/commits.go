@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// timeDirectiveRe matches time directive of commit message line, e.g.
+// "PRJ-12 #time 2h30m fixed the thing".
+var timeDirectiveRe = regexp.MustCompile(`\b([A-Z][A-Z0-9_]*-[0-9]+)\s+#time\s+(\S+)\s*(.*)$`)
+
+// timeDirective is time logged to issue in commit message.
+type timeDirective struct {
+	Commit  gitCommit
+	Worklog Worklog
+	// import id the worklog is recorded with in the ledger
+	ImportID string
+	// why directive cannot be logged
+	Problem string
+}
+
+// commitDirectives finds "#time" directives in commit messages. Worklog
+// starts on author date of the commit, comment is the rest of directive line,
+// or the subject. Commits may have several directives, one per line.
+func commitDirectives(conf Config, commits []gitCommit) []timeDirective {
+	var directives []timeDirective
+	for _, c := range commits {
+		n := 0
+		for _, line := range strings.Split(c.Subject+"\n"+c.Body, "\n") {
+			if !strings.Contains(line, "#time") {
+				continue
+			}
+			n++
+			d := timeDirective{Commit: c, ImportID: "git:" + c.SHA}
+			if n > 1 {
+				d.ImportID = fmt.Sprintf("git:%s/%d", c.SHA, n)
+			}
+			m := timeDirectiveRe.FindStringSubmatch(strings.TrimSpace(line))
+			if m == nil {
+				d.Problem = fmt.Sprintf("malformed directive %q, ISSUE-1 #time 1h30m [comment] expected", strings.TrimSpace(line))
+				directives = append(directives, d)
+				continue
+			}
+			spent, err := convertToTimeLog(m[2], conf.Workday())
+			switch {
+			case err != nil:
+				d.Problem = fmt.Sprintf("malformed directive %q: %q is not a duration", strings.TrimSpace(line), m[2])
+			case spent <= 0:
+				d.Problem = fmt.Sprintf("malformed directive %q: no time", strings.TrimSpace(line))
+			}
+			d.Worklog = Worklog{Issue: m[1], Started: c.Author.In(conf.Location()), Spent: spent, Comment: m[3]}
+			if d.Worklog.Comment == "" && d.Problem == "" {
+				d.Worklog.Comment = trimIssuePrefix(c.Subject, m[1])
+			}
+			directives = append(directives, d)
+		}
+	}
+	return directives
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+// runCommits logs time of "#time" directives in commits of the user in the
+// current repository, today's ones unless range is given. Commits are logged
+// once, their SHA is kept in the ledger.
+func runCommits(args []string) error {
+	flags := flag.NewFlagSet("commits", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "list directives without logging them")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return errSilent
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog commits [range] [--dry-run], e.g. main..HEAD, today's commits if not set")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	logArgs := []string{"--reverse"}
+	if len(positional) == 1 {
+		logArgs = append(logArgs, positional[0])
+	} else {
+		today, _ := convertToDay("", conf.Location())
+		logArgs = append(logArgs, "--since="+today.Format(time.RFC3339))
+	}
+	if email := gitUserEmail("."); email != "" {
+		logArgs = append(logArgs, "--author="+email)
+	}
+	commits, err := gitLog(".", logArgs...)
+	if err != nil {
+		return err
+	}
+	imported, err := importedIDs(conf)
+	if err != nil {
+		return err
+	}
+	directives := commitDirectives(conf, commits)
+	if len(directives) == 0 {
+		pterm.Info.Printfln("No #time directives in %d commits", len(commits))
+		return nil
+	}
+
+	results := pterm.TableData{{"Commit", "Day", "Issue", "Time", "Comment", "Result"}}
+	failed := 0
+	var pending []importEntry
+	var pendingRows []int
+	for _, d := range directives {
+		wl := d.Worklog
+		var result string
+		switch {
+		case imported[d.ImportID]:
+			result = "logged before"
+		case d.Problem != "":
+			result = pterm.Red(d.Problem)
+			failed++
+		case *dryRun:
+			result = "to log"
+		default:
+			pending = append(pending, importEntry{Worklog: wl, ImportIDs: []string{d.ImportID}})
+			pendingRows = append(pendingRows, len(results))
+		}
+		day := d.Commit.Author.In(conf.Location()).Format("2006-01-02 15:04")
+		results = append(results, []string{shortSHA(d.Commit.SHA), day, wl.Issue, parse.FormatDuration(wl.Spent), wl.Comment, result})
+	}
+	logged, err := logImported(conf, pending)
+	if err != nil {
+		return err
+	}
+	for i, item := range logged.Items {
+		row := results[pendingRows[i]]
+		switch {
+		case errors.Is(item.Err, backend.ErrInterrupted):
+			row[len(row)-1] = pterm.Yellow("interrupted")
+			failed++
+			continue
+		case item.Err != nil:
+			if !errors.Is(item.Err, errSilent) {
+				pterm.Error.Printfln("%s: %s", item.Input.Worklog.Issue, item.Err)
+			}
+			row[len(row)-1] = pterm.Red("failed")
+			failed++
+			continue
+		}
+		finishWorklog(conf, item.Output)
+		row[len(row)-1] = pterm.Green("logged")
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(results).Render(); err != nil {
+		return err
+	}
+	switch {
+	case logged.Interrupted():
+		return backend.ErrInterrupted
+	case failed > 0:
+		return errSilent
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_commitDirectives(t *testing.T) {
+	dir, run := gitRepo(t)
+	commitAt(t, run, "2024-03-04T09:00:00Z", "PRJ-1 #time 2h30m fixed the thing")
+	commitAt(t, run, "2024-03-04T11:00:00Z", "PRJ-2: add tests\n\nPRJ-3 #time 1d\nPRJ-4 #time 15m docs")
+	commitAt(t, run, "2024-03-04T12:00:00Z", "PRJ-5 #time soon")
+	commitAt(t, run, "2024-03-04T13:00:00Z", "#time 1h")
+	commitAt(t, run, "2024-03-04T14:00:00Z", "no directive")
+
+	commits, err := gitLog(dir, "--reverse")
+	require.NoError(t, err)
+	require.Len(t, commits, 5)
+	directives := commitDirectives(Config{Timezone: "UTC", WorkdayHours: 6}, commits)
+	require.Len(t, directives, 5)
+
+	require.Equal(t, Worklog{Issue: "PRJ-1", Started: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), Spent: 150 * time.Minute, Comment: "fixed the thing"}, directives[0].Worklog)
+	require.Equal(t, "git:"+commits[0].SHA, directives[0].ImportID)
+	require.Equal(t, Worklog{Issue: "PRJ-3", Started: time.Date(2024, 3, 4, 11, 0, 0, 0, time.UTC), Spent: 6 * time.Hour, Comment: "PRJ-2: add tests"}, directives[1].Worklog, "subject is the comment if directive has none")
+	require.Equal(t, "docs", directives[2].Worklog.Comment)
+	require.Equal(t, "git:"+commits[1].SHA+"/2", directives[2].ImportID)
+	require.Contains(t, directives[3].Problem, `"soon" is not a duration`)
+	require.Contains(t, directives[4].Problem, "malformed directive")
+	for _, d := range directives[:3] {
+		require.Empty(t, d.Problem)
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+)
+
+// configPath resolves location of the config file. Config used to live at
+// ~/.time_logger_conf.toml, such config is moved to the config dir on first use.
+func configPath() (string, error) {
+	if globalOpts.ConfigPath != "" {
+		return globalOpts.ConfigPath, nil
+	}
+
+	dirname, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot obtain config dir: %s", err)
+	}
+	path := filepath.Join(dirname, "tlog", "config.toml")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path, nil
+	}
+	legacy := filepath.Join(home, ".time_logger_conf.toml")
+	if _, err := os.Stat(legacy); err != nil {
+		return path, nil
+	}
+
+	if err := migrateConfig(legacy, path); err != nil {
+		fmt.Fprintf(os.Stderr, "Using config at %s, cannot move it to %s: %s\n", legacy, path, err)
+		return legacy, nil
+	}
+	fmt.Fprintf(os.Stderr, "Config moved from %s to %s\n", legacy, path)
+	return path, nil
+}
+
+func migrateConfig(from, to string) error {
+	data, err := os.ReadFile(from)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0700); err != nil {
+		return err
+	}
+	if err := state.WriteFileAtomic(to, data, 0600); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
+func LoadConfig() (Config, error) {
+	homeConfig, err := configPath()
+	if err != nil {
+		return Config{}, err
+	}
+
+	if _, err := os.Stat(homeConfig); err != nil {
+		// everything might come from environment, e.g. in CI
+		if cfg, err := resolveConfig(Config{}); err == nil && cfg.HasCredentials() {
+			return cfg, nil
+		}
+		if !stdinIsTerminal() {
+			// nobody is there to answer the wizard
+			return Config{}, fmt.Errorf(
+				"config %s does not exist, create it with `tlog setup --url <url> --login <login> --password-env <variable>` "+
+					"or set TLOG_JIRA_URL, TLOG_JIRA_LOGIN and TLOG_JIRA_PASSWORD", homeConfig,
+			)
+		}
+
+		if globalOpts.ConfigPath != "" {
+			create, _ := pterm.DefaultInteractiveConfirm.Show(fmt.Sprintf("Config %s does not exist. Create it?", homeConfig))
+			if !create {
+				return Config{}, fmt.Errorf("config %s does not exist", homeConfig)
+			}
+		}
+		cfg := setupConfig(true)
+		if cfg.JiraPassword != "" {
+			cfg = moveToKeyring(cfg)
+		}
+		err := config.Write(cfg, homeConfig)
+		if err != nil {
+			return Config{}, fmt.Errorf("create config: %w", err)
+		}
+		pterm.Println(pterm.Green(pterm.Sprintf("Config saved at: %s\n", homeConfig)))
+	}
+
+	checkConfigPerms(homeConfig)
+	return loadConfigFile(homeConfig)
+}
+
+// LoadConfigQuiet loads config without ever running the setup wizard.
+// Missing config results in zero Config, which is fine for local-only commands.
+func LoadConfigQuiet() (Config, error) {
+	homeConfig, err := configPath()
+	if err != nil {
+		return Config{}, err
+	}
+
+	if _, err := os.Stat(homeConfig); err != nil {
+		return resolveConfig(Config{})
+	}
+
+	checkConfigPerms(homeConfig)
+	return loadConfigFile(homeConfig)
+}
+
+// checkConfigPerms warns when config, which contains password, is readable by others.
+func checkConfigPerms(path string) {
+	if runtime.GOOS == "windows" {
+		// Windows uses ACLs, mode bits tell nothing there
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm()&0077 == 0 {
+		return
+	}
+
+	warn := pterm.Warning.WithWriter(os.Stderr)
+	if globalOpts.FixPerms {
+		if err := os.Chmod(path, 0600); err != nil {
+			warn.Printfln("Cannot fix permissions of %s: %s", path, err)
+		}
+		return
+	}
+	warn.Printfln(
+		"Config %s is accessible by other users (%s) and contains your password!\nRun `chmod 600 %s` or pass --fix-perms.",
+		path, info.Mode().Perm(), path,
+	)
+}
+
+// loadConfigFile loads effective config from file: with selected profile and environment applied.
+func loadConfigFile(path string) (Config, error) {
+	cfg, err := config.Decode(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return resolveConfig(cfg)
+}
+
+// resolveConfig builds effective config from decoded one. Layers, from lowest priority:
+// shared aliases, top-level keys, selected profile, repository config (.tlog.toml) and environment.
+func resolveConfig(cfg Config) (Config, error) {
+	local, err := loadLocalConfig()
+	if err != nil {
+		return Config{}, err
+	}
+
+	context := globalOpts.Context
+	if context == "" {
+		context = local.CurrentContext
+	}
+	cfg, err = config.SelectContext(cfg, context)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg, err = config.ApplyEnv(config.Merge(cfg, local))
+	if err != nil {
+		return Config{}, err
+	}
+	if err := config.Check(cfg); err != nil {
+		return Config{}, err
+	}
+	if cfg.Backend == config.Mock {
+		// commands that never reach backend, e.g. status of the local timer, warn too
+		if path, err := state.ContextPath(cfg.Context(), backend.MockFile); err == nil {
+			backend.NoticeMock(path)
+		}
+	}
+	return withRemoteAliases(cfg), nil
+}
+
+func setupConfig(verify bool) Config {
+	cfg := Config{}
+	area, _ := pterm.DefaultArea.Start()
+	area.Update(
+		pterm.DefaultSection.Sprint("Hello there 👋"),
+		pterm.LightBlue("Let's perform some basic setup."),
+	)
+	time.Sleep(2 * time.Second)
+	area.Clear()
+	area.Stop()
+
+	step := stepDeployment
+	var loginLabel, passwordLabel, urlLabel string
+	// YouTrack needs only token and URL, they are kept in their own keys
+	address, secret := &cfg.JiraURL, &cfg.JiraPassword
+	for {
+		if step <= stepDeployment {
+			deployments := []string{"Server / Data Center", "Server / Data Center with personal access token", "Cloud (*.atlassian.net)", "YouTrack"}
+			deployment := promptui.Select{
+				Label:        pterm.LightBlue("Which JIRA do you use?"),
+				Items:        deployments,
+				HideSelected: true,
+			}
+			idx, _, err := deployment.Run()
+			if err != nil {
+				os.Exit(0)
+			}
+			cfg.AuthType, cfg.JiraLogin, cfg.Backend = "", "", ""
+			address, secret = &cfg.JiraURL, &cfg.JiraPassword
+			loginLabel, passwordLabel, urlLabel = "Enter you JIRA username", "Now enter your password 🤫", "Almost done! Now enter JIRA url"
+			switch idx {
+			case 1:
+				cfg.AuthType = config.AuthPAT
+				pterm.Info.Println("Create token in JIRA: your profile > Personal Access Tokens")
+				loginLabel, passwordLabel = "", "Enter personal access token 🤫"
+			case 2:
+				cfg.AuthType = config.AuthCloudToken
+				pterm.Info.Println("JIRA Cloud needs API token instead of password, create one at " + config.CloudTokenURL)
+				loginLabel, passwordLabel = "Enter your Atlassian account email", "Now enter API token 🤫"
+			case 3:
+				cfg.Backend = config.YouTrack
+				address, secret = &cfg.YouTrackURL, &cfg.YouTrackToken
+				pterm.Info.Println("Create permanent token in YouTrack: your profile > Account security")
+				loginLabel, passwordLabel, urlLabel = "", "Enter permanent token 🤫", "Almost done! Now enter YouTrack url"
+			}
+		}
+
+		if step <= stepCredentials {
+			if loginLabel != "" {
+				prompt := promptui.Prompt{
+					Label:       pterm.LightBlue(loginLabel),
+					HideEntered: true,
+					Validate:    validateRequired,
+					Default:     cfg.JiraLogin,
+					AllowEdit:   true,
+				}
+				result, err := prompt.Run()
+				if err != nil {
+					os.Exit(0)
+				}
+				cfg.JiraLogin = result
+			}
+
+			prompt := promptui.Prompt{
+				Label:       pterm.LightBlue(passwordLabel),
+				HideEntered: true,
+				Mask:        '*',
+				Validate:    validateRequired,
+			}
+			result, err := prompt.Run()
+			if err != nil {
+				os.Exit(0)
+			}
+			*secret = result
+		}
+
+		if step <= stepURL {
+			prompt := promptui.Prompt{
+				Label:       pterm.LightBlue(urlLabel),
+				HideEntered: true,
+				Validate:    validateURL,
+				Default:     *address,
+				AllowEdit:   true,
+			}
+			result, err := prompt.Run()
+			if err != nil {
+				os.Exit(0)
+			}
+			*address = result
+		}
+
+		confirmed, _ := pterm.DefaultInteractiveConfirm.Show(pterm.Sprint(
+			pterm.LightBlue("Got it👌"),
+			pterm.LightBlue("\nYour login is: "), pterm.Yellow(cfg.JiraLogin),
+			pterm.LightBlue("\nPassword is: "), pterm.Yellow(strings.Repeat("*", len(*secret))),
+			pterm.LightBlue("\nURL is: "), pterm.Yellow(*address),
+			pterm.LightBlue("\nCorrect?"),
+		))
+		clearLinesUp(5)
+		if !confirmed {
+			step = stepDeployment
+			continue
+		}
+		if !verify {
+			break
+		}
+
+		spinner := startSpinner("Checking credentials...")
+		name, retry, err := checkCredentials(cfg)
+		if err == nil {
+			spinner.Success("Logged in as " + name)
+			break
+		}
+		spinner.Fail(err.Error())
+		step = retry
+	}
+
+	return cfg
+}
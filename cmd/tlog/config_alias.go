@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// aliasesFileHeader starts exported aliases, the file has the same format as AliasesURL document.
+const aliasesFileHeader = "# tlog aliases, add them to your config with `tlog config alias import <file>`\n\n"
+
+// runAliasExport writes aliases of the selected context to a standalone file,
+// so they can be shared without the rest of config.
+func runAliasExport(args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: tlog config alias export <file>")
+	}
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+
+	doc := struct {
+		TaskAliases      map[string]string             `toml:"TaskAliases,omitempty"`
+		TaskAliasDetails map[string]config.AliasDetail `toml:"TaskAliasDetails,omitempty"`
+	}{map[string]string{}, map[string]config.AliasDetail{}}
+	shared := 0
+	for name, issue := range conf.TaskAliases {
+		if _, ok := conf.TaskAliasDetails[name]; ok {
+			continue
+		}
+		if conf.Origin("TaskAliases."+name) == remoteAliasesOrigin {
+			shared++
+			continue
+		}
+		doc.TaskAliases[name] = issue
+	}
+	for name, d := range conf.TaskAliasDetails {
+		if conf.Origin("TaskAliasDetails."+name) == remoteAliasesOrigin {
+			shared++
+			continue
+		}
+		doc.TaskAliasDetails[name] = d
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(aliasesFileHeader)
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return fmt.Errorf("encode aliases: %w", err)
+	}
+	if err := state.WriteFileAtomic(args[0], buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	pterm.Success.Printfln("Exported %d aliases to %s", len(doc.TaskAliases)+len(doc.TaskAliasDetails), args[0])
+	if shared > 0 {
+		pterm.Info.Printfln("%d shared aliases from AliasesURL are not exported", shared)
+	}
+	return nil
+}
+
+func runAliasImport(args []string) error {
+	flags := flag.NewFlagSet("config alias import", flag.ContinueOnError)
+	replace := flags.Bool("replace", false, "remove aliases missing in the file")
+	merge := flags.Bool("merge", false, "keep aliases missing in the file (default)")
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+	// flags may follow the file name too
+	file := flags.Arg(0)
+	if flags.NArg() > 0 {
+		if err := flags.Parse(flags.Args()[1:]); err != nil {
+			return errSilent
+		}
+	}
+	if file == "" || flags.NArg() > 0 {
+		return errors.New("Usage: tlog config alias import <file> [--replace|--merge]")
+	}
+	if *replace && *merge {
+		return errors.New("--replace and --merge cannot be used together")
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("cannot read aliases: %w", err)
+	}
+	doc, err := parseAliasesDocument(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	var result aliasImport
+	err = updateContextConfig(func(p *config.Profile) error {
+		result = importAliases(p, doc, *replace)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, problem := range result.Problems {
+		pterm.Warning.Println(problem.String())
+	}
+	summary := fmt.Sprintf("Aliases imported: %d added, %d updated, %d skipped", result.Added, result.Updated, result.Skipped)
+	if *replace {
+		summary += fmt.Sprintf(", %d removed", result.Removed)
+	}
+	pterm.Success.Println(summary)
+	return nil
+}
+
+// aliasImport counts what importAliases did.
+type aliasImport struct {
+	Added, Updated, Skipped, Removed int
+	// entries skipped because they are invalid
+	Problems []ConfigProblem
+}
+
+// importAliases adds aliases of doc to profile. Entries with invalid issue keys and
+// entries that are already set are skipped. With replace, other aliases are removed.
+func importAliases(p *config.Profile, doc remoteAliases, replace bool) aliasImport {
+	old := Config{TaskAliases: config.CloneMap(p.TaskAliases), TaskAliasDetails: config.CloneDetails(p.TaskAliasDetails)}
+	if replace || p.TaskAliases == nil {
+		p.TaskAliases = map[string]string{}
+	}
+	if replace || p.TaskAliasDetails == nil {
+		p.TaskAliasDetails = map[string]config.AliasDetail{}
+	}
+
+	var result aliasImport
+	count := func(name string, unchanged bool) {
+		_, existed := old.Aliases()[name]
+		switch {
+		case !existed:
+			result.Added++
+		case unchanged:
+			result.Skipped++
+		default:
+			result.Updated++
+		}
+	}
+	invalid := func(key, issue string) bool {
+		if parse.IsIssue(issue) {
+			return false
+		}
+		result.Skipped++
+		result.Problems = append(result.Problems, ConfigProblem{
+			Key: key, Message: fmt.Sprintf("%q does not look like issue key", issue), Suggestion: "skipped",
+		})
+		return true
+	}
+
+	for _, name := range sortedKeys(doc.TaskAliases) {
+		issue := doc.TaskAliases[name]
+		if invalid("TaskAliases."+name, issue) {
+			continue
+		}
+		_, wasDetailed := old.TaskAliasDetails[name]
+		count(name, !wasDetailed && old.TaskAliases[name] == issue)
+		p.TaskAliases[name] = issue
+		delete(p.TaskAliasDetails, name)
+	}
+	for _, name := range sortedKeys(doc.TaskAliasDetails) {
+		d := doc.TaskAliasDetails[name]
+		if invalid("TaskAliasDetails."+name+".Issue", d.Issue) {
+			continue
+		}
+		prev, wasDetailed := old.TaskAliasDetails[name]
+		count(name, wasDetailed && reflect.DeepEqual(prev, d))
+		p.TaskAliasDetails[name] = d
+		delete(p.TaskAliases, name)
+	}
+
+	if replace {
+		imported := Config{TaskAliases: p.TaskAliases, TaskAliasDetails: p.TaskAliasDetails}.Aliases()
+		for name := range old.Aliases() {
+			if _, ok := imported[name]; !ok {
+				result.Removed++
+			}
+		}
+	}
+	return result
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
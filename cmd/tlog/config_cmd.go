@@ -0,0 +1,639 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// configDefaults are effective values of settings that are not set in config.
+var configDefaults = map[string]interface{}{
+	"WorkdayHours":       float64(config.DefaultWorkdayHours),
+	"WeeklyTargetHours":  float64(config.DefaultWeeklyHours),
+	"RoundTo":            "1m",
+	"TimerRounding":      config.RoundNearest,
+	"StaleTimerHours":    float64(config.DefaultStaleTimerHours),
+	"RequestTimeout":     config.DefaultRequestTimeout.String(),
+	"Retries":            config.DefaultRetries,
+	"RetryBackoff":       config.DefaultRetryBackoff.String(),
+	"BulkParallelism":    config.DefaultParallelism,
+	"RateLimit":          float64(config.DefaultRateLimit),
+	"EstimateIssueLimit": config.DefaultEstimateIssueLimit,
+	"IssueCacheTTL":      defaultIssueCacheTTL.String(),
+	"TempoURL":           backend.DefaultTempoURL,
+	"GitLabURL":          backend.DefaultGitLabURL,
+	"ClockifyURL":        defaultClockifyURL,
+	"HarvestURL":         defaultHarvestURL,
+	"GoogleCalendarID":   defaultGoogleCalendarID,
+	"WakatimeURL":        defaultWakatimeURL,
+	"WakatimeMinimum":    defaultWakatimeMinimum.String(),
+}
+
+const secretMask = "********"
+
+func runConfig(args []string) error {
+	switch safeGet(args, 0) {
+	case "show":
+		return runConfigShow(args[1:])
+	case "alias":
+		return runConfigAlias(args[1:])
+	case "edit":
+		return runConfigEdit()
+	case "set-project":
+		return runSetProject(args[1:])
+	case "set-workday":
+		return runSetWorkday(args[1:])
+	case "validate":
+		return runConfigValidate(args[1:])
+	default:
+		return errors.New(configUsage)
+	}
+}
+
+const configUsage = `Usage: tlog config show [--reveal] [--output json]
+       tlog config edit
+       tlog config validate [--remote]
+       tlog config set-project <project>
+       tlog config set-workday <hours> [weekly hours]
+       tlog config alias list [--no-fetch]
+       tlog config alias set <name> <issue>
+       tlog config alias rm <name>
+       tlog config alias rename <old> <new>
+       tlog config alias export <file>
+       tlog config alias import <file> [--replace|--merge]`
+
+func runConfigAlias(args []string) error {
+	switch safeGet(args, 0) {
+	case "list":
+		return runAliasList(args[1:])
+	case "set":
+		if len(args) != 3 {
+			return errors.New("Usage: tlog config alias set <name> <issue>")
+		}
+		err := updateContextConfig(func(p *config.Profile) error { return setAlias(p, args[1], args[2]) })
+		if err != nil {
+			return err
+		}
+		pterm.Success.Printfln("Alias %q set to %s", args[1], args[2])
+	case "rm":
+		if len(args) != 2 {
+			return errors.New("Usage: tlog config alias rm <name>")
+		}
+		conf, err := LoadConfig()
+		if err != nil {
+			return fmt.Errorf("cannot load config: %s", err)
+		}
+		if refs := aliasReferences(conf, args[1]); len(refs) > 0 {
+			return fmt.Errorf("alias %q is used by %s, change them first", args[1], strings.Join(refs, ", "))
+		}
+		err = updateContextConfig(func(p *config.Profile) error { return removeAlias(p, args[1]) })
+		if err != nil {
+			return err
+		}
+		pterm.Success.Printfln("Alias %q removed", args[1])
+	case "export":
+		return runAliasExport(args[1:])
+	case "import":
+		return runAliasImport(args[1:])
+	case "rename":
+		if len(args) != 3 {
+			return errors.New("Usage: tlog config alias rename <old> <new>")
+		}
+		err := updateContextConfig(func(p *config.Profile) error { return renameAlias(p, args[1], args[2]) })
+		if err != nil {
+			return err
+		}
+		pterm.Success.Printfln("Alias %q renamed to %q", args[1], args[2])
+	default:
+		return errors.New(configUsage)
+	}
+	return nil
+}
+
+func runSetProject(args []string) error {
+	if len(args) != 1 {
+		return errors.New("Usage: tlog config set-project <project>")
+	}
+	project := args[0]
+	// GitLab project paths are case sensitive
+	if !strings.Contains(project, "/") {
+		project = strings.ToUpper(project)
+	}
+	err := updateContextConfig(func(p *config.Profile) error {
+		p.DefaultProject = project
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	pterm.Success.Printfln("Default project set to %s", project)
+	return nil
+}
+
+func runSetWorkday(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("Usage: tlog config set-workday <hours> [weekly hours]")
+	}
+	hours := make([]float64, len(args))
+	for i, arg := range args {
+		h, err := strconv.ParseFloat(arg, 64)
+		if err != nil || h <= 0 {
+			return fmt.Errorf("invalid hours %q: positive number expected", arg)
+		}
+		hours[i] = h
+	}
+
+	err := updateConfigFile(func(cfg *Config) error {
+		cfg.WorkdayHours = hours[0]
+		if len(hours) == 2 {
+			cfg.WeeklyTargetHours = hours[1]
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	pterm.Success.Printfln("Workday set to %vh", hours[0])
+	if len(hours) == 2 {
+		pterm.Success.Printfln("Weekly target set to %vh", hours[1])
+	}
+	return nil
+}
+
+func runAliasList(args []string) error {
+	flags := flag.NewFlagSet("config alias list", flag.ContinueOnError)
+	noFetch := flags.Bool("no-fetch", false, "do not fetch missing issue summaries from JIRA")
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	aliases := conf.Aliases()
+	if len(aliases) == 0 {
+		pterm.Println("No aliases configured")
+		return nil
+	}
+
+	names := make([]string, 0, len(aliases))
+	issues := make([]string, 0, len(aliases))
+	for name, issue := range aliases {
+		names = append(names, name)
+		// issue of jql: alias is only known once it is used
+		if !strings.HasPrefix(issue, jqlAlias) {
+			issues = append(issues, issue)
+		}
+	}
+	sort.Strings(names)
+
+	var summaries map[string]string
+	if *noFetch {
+		summaries = cachedSummaries(conf)
+	} else {
+		summaries = issueSummaries(conf, issues)
+	}
+
+	data := pterm.TableData{{"Alias", "Issue", "Summary", "Comment"}}
+	for _, name := range names {
+		issue := aliases[name]
+		data = append(data, []string{name, issue, summaries[issue], conf.AliasComment(name)})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+func setAlias(p *config.Profile, name, issue string) error {
+	if !parse.IsIssue(issue) && !strings.HasPrefix(issue, jqlAlias) {
+		return fmt.Errorf("%q does not look like issue key, e.g. PROJ-123 or group/project#123, or jql: query", issue)
+	}
+	if p.TaskAliases == nil {
+		p.TaskAliases = map[string]string{}
+	}
+	p.TaskAliases[name] = issue
+	return nil
+}
+
+// hasAlias reports whether name is defined either in TaskAliases or in TaskAliasDetails.
+func hasAlias(p *config.Profile, name string) bool {
+	_, simple := p.TaskAliases[name]
+	_, detailed := p.TaskAliasDetails[name]
+	return simple || detailed
+}
+
+func removeAlias(p *config.Profile, name string) error {
+	if !hasAlias(p, name) {
+		return fmt.Errorf("alias %q does not exist", name)
+	}
+	delete(p.TaskAliases, name)
+	delete(p.TaskAliasDetails, name)
+	return nil
+}
+
+// aliasReferences returns keys of config settings that log to alias, which
+// removing it would break.
+func aliasReferences(cfg Config, name string) []string {
+	var refs []string
+	add := func(key, value string) {
+		if value == name {
+			refs = append(refs, key)
+		}
+	}
+	add("Calendar.VacationAlias", cfg.Calendar.VacationAlias)
+	add("Calendar.SickAlias", cfg.Calendar.SickAlias)
+	add("Meetings.Alias", cfg.Meetings.Alias)
+	for i, r := range cfg.Meetings.Rules {
+		add(fmt.Sprintf("Meetings.Rules[%d].Alias", i), r.Alias)
+	}
+	for i, r := range cfg.Recurring {
+		add(fmt.Sprintf("Recurring[%d].Task", i), r.Task)
+	}
+	mappings := []struct {
+		key     string
+		mapping map[string]string
+	}{{"ClockifyMapping", cfg.ClockifyMapping}, {"HarvestMapping", cfg.HarvestMapping}, {"WakatimeMapping", cfg.WakatimeMapping}}
+	for _, m := range mappings {
+		for _, k := range sortedKeys(m.mapping) {
+			add(fmt.Sprintf("%s.%q", m.key, k), m.mapping[k])
+		}
+	}
+	return refs
+}
+
+func renameAlias(p *config.Profile, oldName, newName string) error {
+	if !hasAlias(p, oldName) {
+		return fmt.Errorf("alias %q does not exist", oldName)
+	}
+	if hasAlias(p, newName) {
+		return fmt.Errorf("alias %q already exists", newName)
+	}
+	if issue, ok := p.TaskAliases[oldName]; ok {
+		delete(p.TaskAliases, oldName)
+		p.TaskAliases[newName] = issue
+	}
+	if detail, ok := p.TaskAliasDetails[oldName]; ok {
+		delete(p.TaskAliasDetails, oldName)
+		p.TaskAliasDetails[newName] = detail
+	}
+	return nil
+}
+
+// updateContextConfig is like updateConfigFile, but lets fn edit settings of the selected context:
+// either top-level ones or the ones of a profile.
+func updateContextConfig(fn func(p *config.Profile) error) error {
+	return updateConfigFile(func(cfg *Config) error {
+		name := globalOpts.Context
+		if name == "" {
+			name = cfg.CurrentContext
+		}
+
+		if name == "" || name == config.DefaultContext {
+			p := config.Profile{
+				JiraURL:          cfg.JiraURL,
+				JiraLogin:        cfg.JiraLogin,
+				JiraPassword:     cfg.JiraPassword,
+				PasswordSource:   cfg.PasswordSource,
+				PasswordCommand:  cfg.PasswordCommand,
+				AuthType:         cfg.AuthType,
+				DefaultProject:   cfg.DefaultProject,
+				TaskAliases:      cfg.TaskAliases,
+				TaskAliasDetails: cfg.TaskAliasDetails,
+			}
+			if err := fn(&p); err != nil {
+				return err
+			}
+			cfg.JiraURL, cfg.JiraLogin, cfg.JiraPassword, cfg.PasswordSource = p.JiraURL, p.JiraLogin, p.JiraPassword, p.PasswordSource
+			cfg.PasswordCommand, cfg.AuthType = p.PasswordCommand, p.AuthType
+			cfg.DefaultProject, cfg.TaskAliases, cfg.TaskAliasDetails = p.DefaultProject, p.TaskAliases, p.TaskAliasDetails
+			return nil
+		}
+
+		p, ok := cfg.Profiles[name]
+		if !ok {
+			return fmt.Errorf("context %q is not defined in config", name)
+		}
+		if err := fn(&p); err != nil {
+			return err
+		}
+		cfg.Profiles[name] = p
+		return nil
+	})
+}
+
+// updateConfigFile applies fn to the config file and saves the result.
+// Config is not written if fn fails.
+func updateConfigFile(fn func(cfg *Config) error) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file %s does not exist, run tlog to create it", path)
+	}
+
+	cfg, err := config.Decode(path)
+	if err != nil {
+		return err
+	}
+	updated := cfg.Clone()
+	if err := fn(&updated); err != nil {
+		return err
+	}
+
+	text, err := rewriteConfig(string(original), cfg, updated)
+	if err != nil {
+		return err
+	}
+	return state.WriteFileAtomic(path, []byte(text), 0600)
+}
+
+// rewriteConfig changes only lines of config text affected by the update.
+// If that is not possible, whole config is re-encoded after user's confirmation.
+func rewriteConfig(text string, old, new Config) (string, error) {
+	edited, err := applyConfigEdits(text, diffConfig(old, new))
+	if err == nil && decodesTo(edited, new) {
+		return edited, nil
+	}
+
+	confirmed, _ := pterm.DefaultInteractiveConfirm.Show(
+		"Config cannot be edited in place. Rewrite it? Comments and unknown keys will be lost.",
+	)
+	if !confirmed {
+		return "", errors.New("config is not changed")
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(new); err != nil {
+		return "", fmt.Errorf("encode config: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func decodesTo(text string, want Config) bool {
+	var got Config
+	if _, err := toml.Decode(text, &got); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(got.Normalized(), want.Normalized())
+}
+
+// Setting is a single effective config value.
+type Setting struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Origin string      `json:"origin"`
+	Secret bool        `json:"-"`
+}
+
+func runConfigShow(args []string) error {
+	flags := flag.NewFlagSet("config show", flag.ContinueOnError)
+	reveal := flags.Bool("reveal", false, "show secrets in plain text")
+	output := flags.String("output", "text", "output format: text or json")
+	byOrigin := flags.Bool("origin", false, "group settings by file or variable they came from")
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	settings := configSettings(conf)
+	if *byOrigin {
+		sort.SliceStable(settings, func(i, j int) bool { return settings[i].Origin < settings[j].Origin })
+	}
+
+	switch *output {
+	case "json":
+		// secrets are never part of machine readable output
+		visible := make([]Setting, 0, len(settings))
+		for _, s := range settings {
+			if !s.Secret {
+				visible = append(visible, s)
+			}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(visible)
+	case "text":
+	default:
+		return fmt.Errorf("unknown output format %q, text or json expected", *output)
+	}
+
+	if *reveal {
+		*reveal, _ = pterm.DefaultInteractiveConfirm.Show("Secrets will be printed in plain text. Continue?")
+	}
+
+	if conf.Context() != "" {
+		pterm.Printfln("Context: %s", pterm.Cyan(conf.Context()))
+	}
+	data := pterm.TableData{{"Key", "Value", "Origin"}}
+	if *byOrigin {
+		data = pterm.TableData{{"Origin", "Key", "Value"}}
+	}
+	for i, s := range settings {
+		value := fmt.Sprint(s.Value)
+		if s.Secret && value != "" && !*reveal {
+			value = secretMask
+		}
+		if !*byOrigin {
+			data = append(data, []string{s.Key, value, s.Origin})
+			continue
+		}
+		origin := s.Origin
+		if i > 0 && settings[i-1].Origin == origin {
+			origin = ""
+		}
+		data = append(data, []string{origin, s.Key, value})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+// configSettings flattens config into list of settings, maps are expanded into "Map.key" entries.
+func configSettings(conf Config) []Setting {
+	var settings []Setting
+	v := reflect.ValueOf(conf)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if key == "" || !field.IsExported() {
+			continue
+		}
+		secret := field.Tag.Get("secret") == "true"
+		value := v.Field(i)
+
+		if value.Kind() == reflect.Map {
+			if key == "profiles" {
+				// profiles are already applied to effective values
+				continue
+			}
+			keys := make([]string, 0, value.Len())
+			for _, k := range value.MapKeys() {
+				keys = append(keys, k.String())
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fullKey := key + "." + k
+				if entry := value.MapIndex(reflect.ValueOf(k)); entry.Kind() == reflect.Struct {
+					// e.g. TaskAliasDetails, every entry is a table
+					for j := 0; j < entry.NumField(); j++ {
+						settings = append(settings, Setting{
+							Key:    fullKey + "." + strings.Split(entry.Type().Field(j).Tag.Get("toml"), ",")[0],
+							Value:  entry.Field(j).Interface(),
+							Origin: conf.Origin(fullKey),
+							Secret: secret,
+						})
+					}
+					continue
+				}
+				settings = append(settings, Setting{
+					Key:    fullKey,
+					Value:  value.MapIndex(reflect.ValueOf(k)).Interface(),
+					Origin: conf.Origin(fullKey),
+					Secret: secret,
+				})
+			}
+			continue
+		}
+
+		if value.Kind() == reflect.Struct {
+			for j := 0; j < value.NumField(); j++ {
+				fullKey := key + "." + strings.Split(value.Type().Field(j).Tag.Get("toml"), ",")[0]
+				settings = append(settings, Setting{
+					Key:    fullKey,
+					Value:  value.Field(j).Interface(),
+					Origin: conf.Origin(fullKey),
+					Secret: secret,
+				})
+			}
+			continue
+		}
+
+		s := Setting{Key: key, Value: value.Interface(), Origin: conf.Origin(key), Secret: secret}
+		if def, ok := configDefaults[key]; ok && value.IsZero() && conf.Origin(key) == "default" {
+			s.Value = def
+		}
+		settings = append(settings, s)
+	}
+	return settings
+}
+
+// runConfigEdit opens config in $EDITOR and validates the result,
+// so broken config never makes it to the next run.
+func runConfigEdit() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file %s does not exist, run tlog to create it", path)
+	}
+
+	backup := path + ".bak"
+	if err := os.WriteFile(backup, original, 0600); err != nil {
+		return fmt.Errorf("backup config: %w", err)
+	}
+
+	for {
+		if err := openEditor(path); err != nil {
+			return err
+		}
+
+		var problems []string
+		if cfg, err := loadConfigFile(path); err != nil {
+			problems = append(problems, err.Error())
+		} else {
+			for _, p := range validateConfig(cfg) {
+				problems = append(problems, p.String())
+			}
+		}
+		if len(problems) == 0 {
+			os.Remove(backup)
+			pterm.Success.Printfln("Config saved at: %s", path)
+			return nil
+		}
+
+		pterm.Error.Println("Config is invalid:\n" + strings.Join(problems, "\n"))
+		const editAgain = "Edit again"
+		choice, _ := pterm.DefaultInteractiveSelect.WithOptions([]string{editAgain, "Discard changes"}).Show()
+		if choice != editAgain {
+			if err := state.WriteFileAtomic(path, original, 0600); err != nil {
+				return fmt.Errorf("restore config from %s: %w", backup, err)
+			}
+			os.Remove(backup)
+			pterm.Info.Println("Changes discarded")
+			return nil
+		}
+	}
+}
+
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	parts := editorCommand(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor %q: %w", editor, err)
+	}
+	return nil
+}
+
+// editorCommand splits EDITOR into program and its arguments, e.g. "code --wait".
+// Windows paths contain spaces, so path of existing program is never split
+// and quoted parts are kept whole, e.g. "\"C:\Program Files\Vim\gvim.exe\" -f".
+func editorCommand(editor string) []string {
+	if _, err := os.Stat(editor); err == nil {
+		return []string{editor}
+	}
+	var parts []string
+	var part strings.Builder
+	quoted, started := false, false
+	for _, r := range editor {
+		switch {
+		case r == '"':
+			quoted, started = !quoted, true
+		case (r == ' ' || r == '\t') && !quoted:
+			if started {
+				parts = append(parts, part.String())
+				part.Reset()
+				started = false
+			}
+		default:
+			part.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		parts = append(parts, part.String())
+	}
+	return parts
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configEdit is a change of a single key in config file.
+// Edits are applied to config text line by line, so comments,
+// key order and keys unknown to tlog are preserved.
+type configEdit struct {
+	Table  string      // "" for top-level keys
+	Key    string      // key to change, "" removes the whole table
+	NewKey string      // if set, key is renamed
+	Value  interface{} // new value, nil removes the key
+}
+
+var (
+	tableHeaderRe = regexp.MustCompile(`^\s*\[\s*([^\[\]]+?)\s*\]\s*(#.*)?$`)
+	keyLineRe     = regexp.MustCompile(`^(\s*)("(?:[^"\\]|\\.)*"|'[^']*'|[A-Za-z0-9_-]+)\s*=\s*`)
+	bareKeyRe     = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+var errUnsupportedEdit = errors.New("config cannot be edited in place")
+
+// diffConfig returns edits that turn old config into new one.
+func diffConfig(old, new Config) []configEdit {
+	return diffFields("", reflect.ValueOf(old), reflect.ValueOf(new))
+}
+
+// diffFields compares fields of two structs of the same type, located in given table.
+func diffFields(table string, ov, nv reflect.Value) []configEdit {
+	var edits []configEdit
+	t := ov.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if key == "" || !field.IsExported() {
+			continue
+		}
+		fullKey := key
+		if table != "" {
+			fullKey = table + "." + key
+		}
+
+		if field.Type.Kind() == reflect.Map {
+			if field.Type.Elem().Kind() == reflect.Struct {
+				edits = append(edits, diffSubtables(fullKey, ov.Field(i), nv.Field(i))...)
+			} else {
+				edits = append(edits, diffTable(fullKey, ov.Field(i).Interface(), nv.Field(i).Interface())...)
+			}
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			edits = append(edits, diffFields(fullKey, ov.Field(i), nv.Field(i))...)
+			continue
+		}
+
+		oldValue, newValue := ov.Field(i), nv.Field(i)
+		if reflect.DeepEqual(oldValue.Interface(), newValue.Interface()) {
+			continue
+		}
+		edit := configEdit{Table: table, Key: key, Value: newValue.Interface()}
+		if newValue.IsZero() && strings.Contains(field.Tag.Get("toml"), ",omit") {
+			edit.Value = nil
+		}
+		edits = append(edits, edit)
+	}
+	return edits
+}
+
+// diffSubtables compares maps of structs, e.g. profiles.
+func diffSubtables(table string, ov, nv reflect.Value) []configEdit {
+	names := make([]string, 0, nv.Len())
+	for _, k := range nv.MapKeys() {
+		names = append(names, k.String())
+	}
+	sort.Strings(names)
+
+	var edits []configEdit
+	for _, name := range names {
+		k := reflect.ValueOf(name)
+		newValue := nv.MapIndex(k)
+		oldValue := reflect.Zero(newValue.Type())
+		if ov.Len() > 0 && ov.MapIndex(k).IsValid() {
+			oldValue = ov.MapIndex(k)
+		}
+		edits = append(edits, diffFields(table+"."+name, oldValue, newValue)...)
+	}
+
+	var removed []string
+	for _, k := range ov.MapKeys() {
+		if !nv.MapIndex(k).IsValid() {
+			removed = append(removed, k.String())
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		edits = append(edits, configEdit{Table: table + "." + name})
+	}
+	return edits
+}
+
+// diffTable compares map[string]string tables.
+// Key removed and key added with the same value are treated as rename.
+func diffTable(table string, old, new interface{}) []configEdit {
+	oldMap, _ := old.(map[string]string)
+	newMap, _ := new.(map[string]string)
+
+	var removed, added []string
+	var edits []configEdit
+	for k, v := range newMap {
+		oldV, ok := oldMap[k]
+		switch {
+		case !ok:
+			added = append(added, k)
+		case oldV != v:
+			edits = append(edits, configEdit{Table: table, Key: k, Value: v})
+		}
+	}
+	for k := range oldMap {
+		if _, ok := newMap[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	for _, k := range removed {
+		renamed := false
+		for i, a := range added {
+			if newMap[a] == oldMap[k] {
+				edits = append(edits, configEdit{Table: table, Key: k, NewKey: a, Value: newMap[a]})
+				added = append(added[:i], added[i+1:]...)
+				renamed = true
+				break
+			}
+		}
+		if !renamed {
+			edits = append(edits, configEdit{Table: table, Key: k})
+		}
+	}
+	for _, k := range added {
+		edits = append(edits, configEdit{Table: table, Key: k, Value: newMap[k]})
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].Key < edits[j].Key })
+	return edits
+}
+
+// applyConfigEdits applies edits to config text.
+func applyConfigEdits(text string, edits []configEdit) (string, error) {
+	lines := strings.Split(text, "\n")
+	for _, e := range edits {
+		var err error
+		lines, err = applyConfigEdit(lines, e)
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func applyConfigEdit(lines []string, e configEdit) ([]string, error) {
+	if e.Key == "" {
+		return removeTable(lines, e.Table), nil
+	}
+	idx := findKeyLine(lines, e.Table, e.Key)
+
+	switch {
+	case idx == -1 && e.Value == nil:
+		return lines, nil
+	case idx == -1:
+		value, err := tomlValue(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		key := e.Key
+		if e.NewKey != "" {
+			key = e.NewKey
+		}
+		return insertKey(lines, e.Table, tomlKey(key)+" = "+value), nil
+	case e.Value == nil:
+		return append(lines[:idx], lines[idx+1:]...), nil
+	}
+
+	m := keyLineRe.FindStringSubmatch(lines[idx])
+	indent, keyText := m[1], m[2]
+	if e.NewKey != "" {
+		keyText = tomlKey(e.NewKey)
+	}
+
+	rest := lines[idx][len(m[0]):]
+	valueEnd, err := valueLength(rest)
+	if err != nil {
+		return nil, err
+	}
+	value, err := tomlValue(e.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	lines[idx] = indent + keyText + " = " + value + rest[valueEnd:]
+	return lines, nil
+}
+
+// findKeyLine returns index of line that defines key in table, or -1.
+func findKeyLine(lines []string, table, key string) int {
+	current := ""
+	for i, line := range lines {
+		if m := tableHeaderRe.FindStringSubmatch(line); m != nil {
+			current = m[1]
+			continue
+		}
+		if current != table {
+			continue
+		}
+		if m := keyLineRe.FindStringSubmatch(line); m != nil && unquoteKey(m[2]) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeTable removes table and its subtables: headers and everything up to the next header.
+// Keys of the table defined with dotted keys elsewhere are left for decoding verification to catch.
+func removeTable(lines []string, table string) []string {
+	kept := make([]string, 0, len(lines))
+	removing := false
+	for _, line := range lines {
+		if m := tableHeaderRe.FindStringSubmatch(line); m != nil {
+			removing = m[1] == table || strings.HasPrefix(m[1], table+".")
+		}
+		if !removing {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
+
+// insertKey inserts line after the last key of the table, creating table if needed.
+func insertKey(lines []string, table, line string) []string {
+	current := ""
+	tableFound := table == ""
+	insertAt := -1
+	indent := ""
+	for i, l := range lines {
+		if m := tableHeaderRe.FindStringSubmatch(l); m != nil {
+			current = m[1]
+			if current == table {
+				tableFound = true
+				insertAt = i + 1
+			}
+			continue
+		}
+		if current != table {
+			continue
+		}
+		if m := keyLineRe.FindStringSubmatch(l); m != nil {
+			insertAt = i + 1
+			indent = m[1]
+		}
+	}
+
+	if !tableFound {
+		for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			lines = lines[:len(lines)-1]
+		}
+		return append(lines, "", "["+tomlTable(table)+"]", line, "")
+	}
+	if insertAt == -1 {
+		insertAt = 0
+	}
+
+	lines = append(lines, "")
+	copy(lines[insertAt+1:], lines[insertAt:])
+	lines[insertAt] = indent + line
+	return lines
+}
+
+// valueLength returns length of the single-line value at the beginning of s.
+func valueLength(s string) (int, error) {
+	switch {
+	case strings.HasPrefix(s, `"""`), strings.HasPrefix(s, "'''"), strings.HasPrefix(s, "["), strings.HasPrefix(s, "{"):
+		return 0, errUnsupportedEdit
+	case strings.HasPrefix(s, `"`):
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, nil
+			}
+		}
+		return 0, errUnsupportedEdit
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end == -1 {
+			return 0, errUnsupportedEdit
+		}
+		return end + 2, nil
+	default:
+		if end := strings.Index(s, "#"); end != -1 {
+			return len(strings.TrimRight(s[:end], " \t")), nil
+		}
+		return len(strings.TrimRight(s, " \t\r")), nil
+	}
+}
+
+// tomlValue renders value as TOML, reusing encoder to get escaping right.
+func tomlValue(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]interface{}{"v": v}); err != nil {
+		return "", fmt.Errorf("encode config value: %w", err)
+	}
+	return strings.TrimPrefix(strings.TrimSpace(buf.String()), "v = "), nil
+}
+
+func tomlKey(key string) string {
+	if bareKeyRe.MatchString(key) {
+		return key
+	}
+	quoted, _ := tomlValue(key)
+	return quoted
+}
+
+func tomlTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = tomlKey(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+func unquoteKey(key string) string {
+	switch {
+	case strings.HasPrefix(key, `"`):
+		if k, err := strconv.Unquote(key); err == nil {
+			return k
+		}
+		return strings.Trim(key, `"`)
+	case strings.HasPrefix(key, "'"):
+		return strings.Trim(key, "'")
+	default:
+		return key
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+const commentedConfig = `# tlog config, edited by hand
+JiraURL = "https://company.jira.ru" # work instance
+JiraLogin = "user.name"
+JiraPassword = 'pa"ss#word'
+DefaultProject = "SCENTRE" # if you only specify JIRA issue number, this project will be used
+SomethingCustom = [1, 2, 3] # unknown to tlog
+
+# aliases I use every day
+[ TaskAliases ]
+meeting = "INT-18" # aliases "meeting" to INT-18
+review = "INT-24"
+"daily standup" = "INT-1"
+
+# table tlog knows nothing about
+[Custom]
+key = "value"
+`
+
+func Test_rewriteConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(cfg *Config) error
+		// replaced maps old line to new one, "" means line is removed
+		replaced map[string]string
+		// added lines are inserted right after given line
+		added map[string]string
+	}{
+		{
+			name:     "set-project",
+			update:   func(cfg *Config) error { cfg.DefaultProject = "INT"; return nil },
+			replaced: map[string]string{`DefaultProject = "SCENTRE" # if you only specify JIRA issue number, this project will be used`: `DefaultProject = "INT" # if you only specify JIRA issue number, this project will be used`},
+		},
+		{
+			name:     "alias set existing",
+			update:   func(cfg *Config) error { return setAlias(aliasesOf(cfg), "meeting", "INT-19") },
+			replaced: map[string]string{`meeting = "INT-18" # aliases "meeting" to INT-18`: `meeting = "INT-19" # aliases "meeting" to INT-18`},
+		},
+		{
+			name:   "alias set new",
+			update: func(cfg *Config) error { return setAlias(aliasesOf(cfg), "on call", "OPS-7") },
+			added:  map[string]string{`"daily standup" = "INT-1"`: `"on call" = "OPS-7"`},
+		},
+		{
+			name:     "alias rm",
+			update:   func(cfg *Config) error { return removeAlias(aliasesOf(cfg), "review") },
+			replaced: map[string]string{`review = "INT-24"`: ""},
+		},
+		{
+			name:     "alias rename",
+			update:   func(cfg *Config) error { return renameAlias(aliasesOf(cfg), "meeting", "sync") },
+			replaced: map[string]string{`meeting = "INT-18" # aliases "meeting" to INT-18`: `sync = "INT-18" # aliases "meeting" to INT-18`},
+		},
+		{
+			name:     "password with quotes and hash",
+			update:   func(cfg *Config) error { cfg.JiraPassword = `new"pass\#`; return nil },
+			replaced: map[string]string{`JiraPassword = 'pa"ss#word'`: `JiraPassword = "new\"pass\\#"`},
+		},
+		{
+			name:   "new top-level key",
+			update: func(cfg *Config) error { cfg.WorkdayHours = 7.5; return nil },
+			added:  map[string]string{`SomethingCustom = [1, 2, 3] # unknown to tlog`: `WorkdayHours = 7.5`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var old Config
+			_, err := toml.Decode(commentedConfig, &old)
+			require.NoError(t, err)
+			updated := old.Clone()
+			require.NoError(t, tt.update(&updated))
+
+			got, err := rewriteConfig(commentedConfig, old, updated)
+			require.NoError(t, err)
+
+			var want []string
+			for _, line := range strings.Split(commentedConfig, "\n") {
+				if repl, ok := tt.replaced[line]; ok {
+					if repl != "" {
+						want = append(want, repl)
+					}
+				} else {
+					want = append(want, line)
+				}
+				if add, ok := tt.added[line]; ok {
+					want = append(want, add)
+				}
+			}
+			require.Equal(t, strings.Join(want, "\n"), got)
+			require.True(t, decodesTo(got, updated))
+		})
+	}
+}
+
+func Test_applyConfigEdits_newTable(t *testing.T) {
+	got, err := applyConfigEdits("JiraLogin = \"user\"\n", []configEdit{{Table: "TaskAliases", Key: "review", Value: "INT-24"}})
+	require.NoError(t, err)
+	require.Equal(t, "JiraLogin = \"user\"\n\n[TaskAliases]\nreview = \"INT-24\"\n", got)
+}
+
+func Test_rewriteConfig_removeSubtable(t *testing.T) {
+	text := `JiraURL = "https://company.jira.ru"
+
+[TaskAliasDetails.standup] # every morning
+Issue = "MEET-1"
+Comment = "daily standup"
+
+[TaskAliasDetails.demo]
+Issue = "MEET-2"
+
+[profiles.client]
+JiraURL = "https://client.atlassian.net"
+
+[profiles.client.TaskAliases]
+sync = "CL-1"
+`
+	var old Config
+	_, err := toml.Decode(text, &old)
+	require.NoError(t, err)
+
+	updated := old.Clone()
+	delete(updated.TaskAliasDetails, "standup")
+	delete(updated.Profiles, "client")
+
+	got, err := rewriteConfig(text, old, updated)
+	require.NoError(t, err)
+	require.Equal(t, `JiraURL = "https://company.jira.ru"
+
+[TaskAliasDetails.demo]
+Issue = "MEET-2"
+`, got)
+}
+
+func Test_applyConfigEdits_unsupported(t *testing.T) {
+	_, err := applyConfigEdits("JiraLogin = \"\"\"\nuser\"\"\"\n", []configEdit{{Key: "JiraLogin", Value: "other"}})
+	require.ErrorIs(t, err, errUnsupportedEdit)
+}
+
+// aliasesOf returns profile view sharing aliases with cfg.
+func aliasesOf(cfg *Config) *config.Profile {
+	return &config.Profile{TaskAliases: cfg.TaskAliases, TaskAliasDetails: cfg.TaskAliasDetails}
+}
+
+func Test_rewriteConfig_aliasDetails(t *testing.T) {
+	text := `JiraURL = "https://company.jira.ru"
+
+[TaskAliasDetails.standup] # every morning
+Issue = "MEET-1"
+Comment = "daily standup"
+
+[TaskAliasDetails.demo]
+Issue = "MEET-2"
+`
+	var old Config
+	_, err := toml.Decode(text, &old)
+	require.NoError(t, err)
+
+	updated := old.Clone()
+	require.NoError(t, removeAlias(aliasesOf(&updated), "demo"))
+	require.NoError(t, renameAlias(aliasesOf(&updated), "standup", "daily"))
+
+	got, err := rewriteConfig(text, old, updated)
+	require.NoError(t, err)
+	require.Equal(t, `JiraURL = "https://company.jira.ru"
+
+[TaskAliasDetails.daily]
+Issue = "MEET-1"
+Comment = "daily standup"
+`, got)
+}
+
+func Test_rewriteConfig_profiles(t *testing.T) {
+	text := `JiraURL = "https://company.jira.ru"
+CurrentContext = "work" # switched by tlog context use
+
+[profiles.client] # freelance gig
+JiraURL = "https://client.atlassian.net"
+
+[profiles.client.TaskAliases]
+sync = "CL-1"
+`
+	var old Config
+	_, err := toml.Decode(text, &old)
+	require.NoError(t, err)
+
+	updated := old.Clone()
+	updated.CurrentContext = "client"
+	client := updated.Profiles["client"]
+	require.NoError(t, setAlias(&client, "review", "CL-2"))
+	client.DefaultProject = "CL"
+	updated.Profiles["client"] = client
+
+	got, err := rewriteConfig(text, old, updated)
+	require.NoError(t, err)
+	require.Equal(t, `JiraURL = "https://company.jira.ru"
+CurrentContext = "client" # switched by tlog context use
+
+[profiles.client] # freelance gig
+JiraURL = "https://client.atlassian.net"
+DefaultProject = "CL"
+
+[profiles.client.TaskAliases]
+sync = "CL-1"
+review = "CL-2"
+`, got)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_configSettings(t *testing.T) {
+	cfg := Config{
+		JiraURL:      "https://jira.example.com",
+		JiraPassword: "hunter2",
+		TaskAliases:  map[string]string{"review": "INT-24", "meeting": "INT-18"},
+		WorkdayHours: 7.5,
+	}
+	cfg.SetOrigin("JiraURL", "/file.toml")
+	cfg.SetOrigin("TaskAliases.review", "/file.toml")
+
+	byKey := map[string]Setting{}
+	for _, s := range configSettings(cfg) {
+		byKey[s.Key] = s
+	}
+
+	require.Equal(t, Setting{Key: "JiraURL", Value: "https://jira.example.com", Origin: "/file.toml"}, byKey["JiraURL"])
+	require.Equal(t, Setting{Key: "JiraPassword", Value: "hunter2", Origin: "default", Secret: true}, byKey["JiraPassword"])
+	require.Equal(t, Setting{Key: "TaskAliases.review", Value: "INT-24", Origin: "/file.toml"}, byKey["TaskAliases.review"])
+	require.Equal(t, Setting{Key: "TaskAliases.meeting", Value: "INT-18", Origin: "default"}, byKey["TaskAliases.meeting"])
+	require.Equal(t, 7.5, byKey["WorkdayHours"].Value)
+	require.Equal(t, float64(config.DefaultStaleTimerHours), byKey["StaleTimerHours"].Value)
+	require.NotContains(t, byKey, "TaskAliases")
+}
+
+func Test_setAlias(t *testing.T) {
+	var cfg config.Profile
+
+	require.Error(t, setAlias(&cfg, "bug", "bug"))
+	require.NoError(t, setAlias(&cfg, "standup", "jql:project = OPS AND summary ~ standup"))
+	require.Equal(t, map[string]string{"standup": "jql:project = OPS AND summary ~ standup"}, cfg.TaskAliases)
+}
+
+func Test_renameAlias(t *testing.T) {
+	cfg := config.Profile{TaskAliases: map[string]string{"review": "INT-24", "meeting": "INT-18"}}
+
+	require.Error(t, renameAlias(&cfg, "missing", "new"))
+	require.Error(t, renameAlias(&cfg, "review", "meeting"))
+
+	require.NoError(t, renameAlias(&cfg, "review", "cr"))
+	require.Equal(t, map[string]string{"cr": "INT-24", "meeting": "INT-18"}, cfg.TaskAliases)
+
+	cfg.TaskAliasDetails = map[string]config.AliasDetail{"standup": {Issue: "MEET-1", Comment: "daily standup"}}
+	require.Error(t, renameAlias(&cfg, "cr", "standup"))
+	require.Error(t, renameAlias(&cfg, "standup", "meeting"))
+
+	require.NoError(t, renameAlias(&cfg, "standup", "daily"))
+	require.Equal(t, map[string]config.AliasDetail{"daily": {Issue: "MEET-1", Comment: "daily standup"}}, cfg.TaskAliasDetails)
+	require.Equal(t, map[string]string{"cr": "INT-24", "meeting": "INT-18"}, cfg.TaskAliases)
+}
+
+func Test_aliasReferences(t *testing.T) {
+	cfg := Config{
+		Calendar:        config.Calendar{VacationAlias: "vacation"},
+		Meetings:        config.Meetings{Alias: "meeting", Rules: []config.MeetingRule{{Match: "standup", Alias: "standup"}, {Match: "sync", Alias: "meeting"}}},
+		Recurring:       []config.RecurringEntry{{Task: "standup"}},
+		ClockifyMapping: map[string]string{"Calls": "meeting", "Dev": "INT-1"},
+	}
+	require.Equal(t, []string{"Meetings.Alias", "Meetings.Rules[1].Alias", `ClockifyMapping."Calls"`}, aliasReferences(cfg, "meeting"))
+	require.Equal(t, []string{"Meetings.Rules[0].Alias", "Recurring[0].Task"}, aliasReferences(cfg, "standup"))
+	require.Empty(t, aliasReferences(cfg, "review"))
+}
+
+func Test_removeAlias(t *testing.T) {
+	cfg := config.Profile{TaskAliases: map[string]string{"review": "INT-24"}}
+
+	require.Error(t, removeAlias(&cfg, "missing"))
+	require.NoError(t, removeAlias(&cfg, "review"))
+	require.Empty(t, cfg.TaskAliases)
+
+	cfg.TaskAliasDetails = map[string]config.AliasDetail{"standup": {Issue: "MEET-1"}}
+	require.NoError(t, removeAlias(&cfg, "standup"))
+	require.Empty(t, cfg.TaskAliasDetails)
+	require.Error(t, removeAlias(&cfg, "standup"))
+}
+
+func Test_validateConfig(t *testing.T) {
+	valid := Config{
+		JiraURL:      "https://jira.example.com",
+		JiraLogin:    "user",
+		JiraPassword: "secret",
+		TaskAliases:  map[string]string{"review": "INT-24"},
+	}
+	require.Empty(t, validateConfig(valid))
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want []ConfigProblem
+	}{
+		{
+			name: "missing values and bad aliases",
+			cfg: Config{
+				JiraURL:     "jira.example.com",
+				TaskAliases: map[string]string{"review": "INT-24", "meeting": "int-18", "bug": "bug"},
+			},
+			want: []ConfigProblem{
+				{Key: "JiraURL", Message: `"jira.example.com" is not a valid URL`, Suggestion: "use absolute URL with scheme, e.g. https://company.atlassian.net"},
+				{Key: "JiraLogin", Message: "value is required", Suggestion: "set it to your JIRA username or email"},
+				{Key: "JiraPassword", Message: "value is required", Suggestion: "set it with `tlog auth set`, in config or TLOG_JIRA_PASSWORD"},
+				{Key: "TaskAliases.bug", Message: `"bug" does not look like issue key`, Suggestion: "use issue key, e.g. PROJ-123, or jql: query finding it"},
+				{Key: "TaskAliases.meeting", Message: `"int-18" does not look like issue key`, Suggestion: `did you mean "INT-18"?`},
+			},
+		},
+		{
+			name: "jql aliases",
+			cfg: Config{
+				JiraURL: "https://jira.example.com", JiraLogin: "user", JiraPassword: "secret",
+				TaskAliases: map[string]string{"standup": "jql:project = OPS AND summary ~ standup", "todo": "jql: "},
+			},
+			want: []ConfigProblem{
+				{Key: "TaskAliases.todo", Message: "jql: query is empty", Suggestion: "write query finding the issue, e.g. jql:project = OPS AND summary ~ standup"},
+			},
+		},
+		{
+			name: "trailing whitespace in URL",
+			cfg:  Config{JiraURL: "https://jira.example.com ", JiraLogin: "user", JiraPassword: "secret"},
+			want: []ConfigProblem{
+				{Key: "JiraURL", Message: `"https://jira.example.com " has leading or trailing whitespace`, Suggestion: "remove it"},
+			},
+		},
+		{
+			name: "password for JIRA Cloud",
+			cfg:  Config{JiraURL: "https://company.atlassian.net", JiraLogin: "user", JiraPassword: "secret"},
+			want: []ConfigProblem{
+				{Key: "AuthType", Message: "JIRA Cloud does not accept passwords", Suggestion: `set it to "cloud-token" and use API token from ` + config.CloudTokenURL},
+			},
+		},
+		{
+			name: "cloud token with username",
+			cfg:  Config{JiraURL: "https://company.atlassian.net", JiraLogin: "user", JiraPassword: "token", AuthType: config.AuthCloudToken},
+			want: []ConfigProblem{
+				{Key: "JiraLogin", Message: `"user" is not an email`, Suggestion: "JIRA Cloud expects your Atlassian account email"},
+			},
+		},
+		{
+			name: "numbers out of range",
+			cfg: Config{
+				JiraURL: "https://jira.example.com", JiraLogin: "user", JiraPassword: "secret",
+				WorkdayHours: 25, StaleTimerHours: -1, TimerRounding: "sideways",
+			},
+			want: []ConfigProblem{
+				{Key: "WorkdayHours", Message: "25 is out of range", Suggestion: "use number of hours between 0 and 24"},
+				{Key: "StaleTimerHours", Message: "-1 is out of range", Suggestion: "use positive number of hours or remove it to use default"},
+				{Key: "TimerRounding", Message: `invalid TimerRounding "sideways" in config: up, down or nearest expected`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, validateConfig(tt.cfg))
+		})
+	}
+}
+
+func Test_configPath(t *testing.T) {
+	configDir := isolateUserDirs(t)
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	want := filepath.Join(configDir, "tlog", "config.toml")
+	legacy := filepath.Join(home, ".time_logger_conf.toml")
+
+	t.Run("fresh install", func(t *testing.T) {
+		path, err := configPath()
+		require.NoError(t, err)
+		require.Equal(t, want, path)
+		require.NoFileExists(t, want)
+	})
+
+	t.Run("legacy config is migrated", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(legacy, []byte(`JiraLogin = "user"`), 0644))
+
+		path, err := configPath()
+		require.NoError(t, err)
+		require.Equal(t, want, path)
+		require.NoFileExists(t, legacy)
+
+		data, err := os.ReadFile(want)
+		require.NoError(t, err)
+		require.Equal(t, `JiraLogin = "user"`, string(data))
+	})
+
+	t.Run("new config wins", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(legacy, []byte(`JiraLogin = "old"`), 0644))
+
+		path, err := configPath()
+		require.NoError(t, err)
+		require.Equal(t, want, path)
+		require.FileExists(t, legacy)
+	})
+}
+
+func TestLoadConfig_envOnly(t *testing.T) {
+	isolateUserDirs(t)
+	t.Setenv("TLOG_JIRA_URL", "https://env.example.com")
+	t.Setenv("TLOG_JIRA_LOGIN", "user")
+	t.Setenv("TLOG_JIRA_PASSWORD", "secret")
+
+	// must not run setup wizard
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	require.Equal(t, "https://env.example.com", cfg.JiraURL)
+	require.Equal(t, "user", cfg.JiraLogin)
+	require.Equal(t, "secret", cfg.JiraPassword)
+}
+
+func Test_checkConfigPerms(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not used on windows")
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`JiraLogin = "user"`), 0644))
+
+	checkConfigPerms(path)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0644), info.Mode().Perm(), "only warns by default")
+
+	globalOpts.FixPerms = true
+	t.Cleanup(func() { globalOpts.FixPerms = false })
+	checkConfigPerms(path)
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func Test_editorCommand(t *testing.T) {
+	require.Equal(t, []string{"vi"}, editorCommand("vi"))
+	require.Equal(t, []string{"code", "--wait"}, editorCommand("code  --wait"))
+	require.Equal(t, []string{`C:\Program Files\Vim\gvim.exe`, "-f"}, editorCommand(`"C:\Program Files\Vim\gvim.exe" -f`))
+
+	dir := filepath.Join(t.TempDir(), "My Editors")
+	require.NoError(t, os.Mkdir(dir, 0700))
+	editor := filepath.Join(dir, "edit")
+	require.NoError(t, os.WriteFile(editor, nil, 0700))
+	require.Equal(t, []string{editor}, editorCommand(editor), "path of existing program is not split")
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// customFieldRe matches ids of JIRA custom fields.
+var customFieldRe = regexp.MustCompile(`^customfield_[0-9]+$`)
+
+// ConfigProblem describes invalid config value.
+type ConfigProblem struct {
+	Key        string
+	Message    string
+	Suggestion string
+}
+
+func (p ConfigProblem) String() string {
+	if p.Suggestion == "" {
+		return fmt.Sprintf("%s: %s", p.Key, p.Message)
+	}
+	return fmt.Sprintf("%s: %s, %s", p.Key, p.Message, p.Suggestion)
+}
+
+// validateConfig performs semantic checks of effective config.
+func validateConfig(cfg Config) []ConfigProblem {
+	var problems []ConfigProblem
+	add := func(key, message, suggestion string) {
+		problems = append(problems, ConfigProblem{Key: key, Message: message, Suggestion: suggestion})
+	}
+
+	switch cfg.Backend {
+	case "", config.Jira:
+		problems = append(problems, jiraProblems(cfg)...)
+	case config.Tempo:
+		problems = append(problems, jiraProblems(cfg)...)
+		if cfg.TempoToken == "" {
+			add("TempoToken", "value is required", "create token in Tempo > Settings > API integration")
+		}
+	case config.GitLab:
+		if cfg.GitLabToken == "" {
+			add("GitLabToken", "value is required", "create personal access token with api scope in GitLab > Preferences > Access tokens")
+		}
+		if u, err := url.ParseRequestURI(cfg.GitLabURL); cfg.GitLabURL != "" && (err != nil || u.Host == "") {
+			add("GitLabURL", fmt.Sprintf("%q is not a valid URL", cfg.GitLabURL), "use absolute URL with scheme, e.g. https://gitlab.company.com")
+		}
+	case config.YouTrack:
+		if u, err := url.ParseRequestURI(cfg.YouTrackURL); err != nil || u.Host == "" {
+			add("YouTrackURL", fmt.Sprintf("%q is not a valid URL", cfg.YouTrackURL), "set it to address of your YouTrack, e.g. https://company.youtrack.cloud")
+		}
+		if cfg.YouTrackToken == "" {
+			add("YouTrackToken", "value is required", "create permanent token in YouTrack > Profile > Account security")
+		}
+	case config.Redmine:
+		if u, err := url.ParseRequestURI(cfg.RedmineURL); err != nil || u.Host == "" {
+			add("RedmineURL", fmt.Sprintf("%q is not a valid URL", cfg.RedmineURL), "set it to address of your Redmine, e.g. https://redmine.company.com")
+		}
+		if cfg.RedmineAPIKey == "" {
+			add("RedmineAPIKey", "value is required", "find it in Redmine > My account > API access key")
+		}
+	case config.AzureDevOps:
+		if u, err := url.ParseRequestURI(cfg.AzureDevOpsURL); err != nil || u.Host == "" {
+			add("AzureDevOpsURL", fmt.Sprintf("%q is not a valid URL", cfg.AzureDevOpsURL), "set it to address of your organization, e.g. https://dev.azure.com/company")
+		}
+		if cfg.AzureDevOpsToken == "" {
+			add("AzureDevOpsToken", "value is required", "create personal access token with Work Items (Read & write) scope in User settings > Personal access tokens")
+		}
+	case config.Mock:
+	default:
+		add("Backend", fmt.Sprintf("unknown backend %q", cfg.Backend), "use jira, tempo, gitlab, youtrack, redmine, azuredevops or mock")
+	}
+
+	checkAliasIssue := func(key, issue string) {
+		if parse.IsIssue(issue) {
+			return
+		}
+		if strings.HasPrefix(issue, jqlAlias) {
+			if strings.TrimSpace(strings.TrimPrefix(issue, jqlAlias)) == "" {
+				add(key, "jql: query is empty", "write query finding the issue, e.g. jql:project = OPS AND summary ~ standup")
+			}
+			return
+		}
+		suggestion := "use issue key, e.g. PROJ-123, or jql: query finding it"
+		if upper := strings.ToUpper(strings.TrimSpace(issue)); parse.IsIssueKey(upper) {
+			suggestion = fmt.Sprintf("did you mean %q?", upper)
+		}
+		add(key, fmt.Sprintf("%q does not look like issue key", issue), suggestion)
+	}
+	aliases := make([]string, 0, len(cfg.TaskAliases))
+	for alias := range cfg.TaskAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		checkAliasIssue("TaskAliases."+alias, cfg.TaskAliases[alias])
+	}
+	details := make([]string, 0, len(cfg.TaskAliasDetails))
+	for alias := range cfg.TaskAliasDetails {
+		details = append(details, alias)
+	}
+	sort.Strings(details)
+	for _, alias := range details {
+		checkAliasIssue("TaskAliasDetails."+alias+".Issue", cfg.TaskAliasDetails[alias].Issue)
+	}
+	// import mappings point at aliases or issues
+	known := cfg.Aliases()
+	mappings := map[string]map[string]string{"ClockifyMapping": cfg.ClockifyMapping, "HarvestMapping": cfg.HarvestMapping, "WakatimeMapping": cfg.WakatimeMapping}
+	for _, name := range sortedKeys(mappings) {
+		mapping := mappings[name]
+		for _, key := range sortedKeys(mapping) {
+			if target := mapping[key]; known[target] == "" {
+				checkAliasIssue(name+"."+key, target)
+			}
+		}
+	}
+
+	for i, rule := range cfg.Meetings.Rules {
+		key := fmt.Sprintf("Meetings.Rules[%d]", i)
+		if _, err := regexp.Compile(rule.Match); err != nil {
+			add(key+".Match", fmt.Sprintf("%q is not a regular expression", rule.Match), err.Error())
+		}
+		if known[rule.Alias] == "" {
+			checkAliasIssue(key+".Alias", rule.Alias)
+		}
+	}
+	if alias := cfg.Meetings.Alias; alias != "" && known[alias] == "" {
+		checkAliasIssue("Meetings.Alias", alias)
+	}
+
+	problems = append(problems, calendarProblems(cfg)...)
+	problems = append(problems, recurringProblems(cfg)...)
+	if _, err := cfg.Balance.EmploymentDate(cfg.Location()); err != nil {
+		add("Balance.EmploymentStart", err.Error(), "")
+	}
+	for i := range cfg.Balance.Adjustments {
+		if _, err := cfg.Balance.AdjustmentDate(i, cfg.Location()); err != nil {
+			add(configField(err, "Balance.Adjustments"), err.Error(), "")
+		}
+	}
+	for _, key := range sortedKeys(cfg.Billing.Projects) {
+		if rate := cfg.Billing.Projects[key].Rate; rate < 0 {
+			add("Billing.Projects."+key+".Rate", fmt.Sprintf("%v is negative", rate), "use amount per billable hour")
+		}
+	}
+	if _, err := cfg.Export.Preset(""); err != nil {
+		add(configField(err, "Export.Default"), err.Error(), "")
+	}
+	for _, name := range sortedKeys(cfg.Export.Presets) {
+		if name == cfg.Export.Default {
+			continue
+		}
+		if _, err := cfg.Export.Preset(name); err != nil {
+			add(configField(err, "Export.Presets."+name), err.Error(), "")
+		}
+	}
+
+	if cfg.CACertFile != "" {
+		if _, err := os.Stat(cfg.CACertFile); err != nil {
+			add("CACertFile", fmt.Sprintf("cannot read %s", cfg.CACertFile), "check the path, it must point to PEM bundle")
+		}
+	}
+	if cfg.WorkdayHours < 0 || cfg.WorkdayHours > 24 {
+		add("WorkdayHours", fmt.Sprintf("%v is out of range", cfg.WorkdayHours), "use number of hours between 0 and 24")
+	}
+	if cfg.WeeklyTargetHours < 0 || cfg.WeeklyTargetHours > 168 {
+		add("WeeklyTargetHours", fmt.Sprintf("%v is out of range", cfg.WeeklyTargetHours), "use number of hours between 0 and 168")
+	}
+	if cfg.StaleTimerHours < 0 {
+		add("StaleTimerHours", fmt.Sprintf("%v is out of range", cfg.StaleTimerHours), "use positive number of hours or remove it to use default")
+	}
+	if _, _, _, err := cfg.RetryPolicy(); err != nil {
+		add(configField(err, "RequestTimeout"), err.Error(), "")
+	}
+	if cfg.BulkParallelism < 0 {
+		add("BulkParallelism", fmt.Sprintf("%d is out of range", cfg.BulkParallelism), "use positive number or remove it to use default")
+	}
+	if cfg.SprintBoard < 0 {
+		add("SprintBoard", fmt.Sprintf("%d is not a board id", cfg.SprintBoard), "use id of the board, e.g. 42 of .../RapidBoard.jspa?rapidView=42")
+	}
+	if cfg.EpicLinkField != "" && !customFieldRe.MatchString(cfg.EpicLinkField) {
+		add("EpicLinkField", fmt.Sprintf("%q is not id of custom field", cfg.EpicLinkField), "use id like customfield_10014, or remove it to find the field by name")
+	}
+	if cfg.EstimateIssueLimit < 0 {
+		add("EstimateIssueLimit", fmt.Sprintf("%d is out of range", cfg.EstimateIssueLimit), "use positive number of issues or remove it to use default")
+	}
+	if cfg.RateLimit < 0 {
+		add("RateLimit", fmt.Sprintf("%v is out of range", cfg.RateLimit), "use positive number of requests per second or remove it to use default")
+	}
+	if _, err := issueCacheTTL(cfg); err != nil {
+		add("IssueCacheTTL", err.Error(), "")
+	}
+	if _, err := wakatimeMinimum(cfg); err != nil {
+		add("WakatimeMinimum", err.Error(), "")
+	}
+	if _, err := cfg.Hooks.RunTimeout(); err != nil {
+		add("Hooks.Timeout", err.Error(), "")
+	}
+	if _, err := parseNotifyTemplate(cfg); err != nil {
+		add("NotifyTemplate", err.Error(), "see https://pkg.go.dev/text/template")
+	}
+	if u, err := url.ParseRequestURI(cfg.NotifyWebhook); cfg.NotifyWebhook != "" && (err != nil || u.Host == "") {
+		add("NotifyWebhook", "value is not a valid URL", "use absolute URL with scheme, e.g. https://hooks.slack.com/services/...")
+	}
+	if _, _, err := cfg.Rounding(); err != nil {
+		add(configField(err, "RoundTo"), err.Error(), "")
+	}
+
+	return problems
+}
+
+// jiraProblems checks JIRA address and credentials, which other backends do not need.
+func jiraProblems(cfg Config) []ConfigProblem {
+	var problems []ConfigProblem
+	add := func(key, message, suggestion string) {
+		problems = append(problems, ConfigProblem{Key: key, Message: message, Suggestion: suggestion})
+	}
+
+	u, err := url.ParseRequestURI(cfg.JiraURL)
+	switch {
+	case cfg.JiraURL == "":
+		add("JiraURL", "value is required", "set it to address of your JIRA, e.g. https://company.atlassian.net")
+	case strings.TrimSpace(cfg.JiraURL) != cfg.JiraURL:
+		add("JiraURL", fmt.Sprintf("%q has leading or trailing whitespace", cfg.JiraURL), "remove it")
+	case err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https"):
+		add("JiraURL", fmt.Sprintf("%q is not a valid URL", cfg.JiraURL), "use absolute URL with scheme, e.g. https://company.atlassian.net")
+	}
+	if cfg.JiraLogin == "" && cfg.AuthType != config.AuthPAT && cfg.AuthType != config.AuthOAuth {
+		add("JiraLogin", "value is required", "set it to your JIRA username or email")
+	}
+	switch cfg.AuthType {
+	case "", config.AuthBasic:
+		if u != nil && strings.HasSuffix(u.Hostname(), ".atlassian.net") {
+			add("AuthType", "JIRA Cloud does not accept passwords", `set it to "cloud-token" and use API token from `+config.CloudTokenURL)
+		}
+	case config.AuthPAT, config.AuthSession:
+	case config.AuthOAuth:
+		if cfg.OAuthClientID == "" || cfg.OAuthClientSecret == "" {
+			add("AuthType", "oauth needs OAuth app", "set OAuthClientID and OAuthClientSecret of app from https://developer.atlassian.com/console/myapps/")
+		}
+	case config.AuthCloudToken:
+		if cfg.JiraLogin != "" && !strings.Contains(cfg.JiraLogin, "@") {
+			add("JiraLogin", fmt.Sprintf("%q is not an email", cfg.JiraLogin), "JIRA Cloud expects your Atlassian account email")
+		}
+	default:
+		add("AuthType", fmt.Sprintf("unknown type %q", cfg.AuthType), "use basic, cloud-token, pat, oauth or session")
+	}
+	switch cfg.PasswordSource {
+	case config.PasswordSourceKeyring:
+	case "":
+		if cfg.JiraPassword == "" && cfg.PasswordCommand == "" && cfg.AuthType != config.AuthOAuth {
+			add("JiraPassword", "value is required", "set it with `tlog auth set`, in config or TLOG_JIRA_PASSWORD")
+		}
+	default:
+		add("PasswordSource", fmt.Sprintf("unknown source %q", cfg.PasswordSource), `use "keyring" or remove it to use JiraPassword`)
+	}
+	return problems
+}
+
+// runConfigValidate checks effective config and exits with error if it has problems.
+func runConfigValidate(args []string) error {
+	flags := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	remote := flags.Bool("remote", false, "also check credentials against JIRA")
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		pterm.Error.Println(err)
+		return errSilent
+	}
+
+	problems := validateConfig(conf)
+	for _, p := range problems {
+		pterm.Error.Println(p.String())
+	}
+	if len(problems) > 0 {
+		return errSilent
+	}
+
+	// mock backend has no credentials to check
+	if *remote && conf.Backend != config.Mock {
+		client, err := backend.NewJiraClient(conf)
+		if err != nil {
+			return err
+		}
+		user, _, err := client.User.GetSelf()
+		if err != nil {
+			pterm.Error.Printfln("cannot verify credentials: %s", err)
+			return errSilent
+		}
+		if user == nil {
+			return errors.New("JIRA returned empty user")
+		}
+		pterm.Info.Printfln("Authenticated as %s", user.DisplayName)
+		// jql: aliases have to find the issue, which only JIRA can tell
+		failed := false
+		aliases := conf.Aliases()
+		for _, alias := range sortedKeys(aliases) {
+			if !strings.HasPrefix(aliases[alias], jqlAlias) {
+				continue
+			}
+			if _, err := resolveTask(conf, alias); err != nil {
+				pterm.Error.Println(err)
+				failed = true
+			}
+		}
+		if failed {
+			return errSilent
+		}
+	}
+
+	pterm.Success.Println("Config is valid")
+	return nil
+}
+
+// configField returns key of config the error is about, fallback if it does not tell.
+func configField(err error, fallback string) string {
+	var config config.Error
+	if errors.As(err, &config) {
+		return config.Field
+	}
+	return fallback
+}
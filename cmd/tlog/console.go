@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+
+	"atomicgo.dev/cursor"
+	"github.com/pterm/pterm"
+	"golang.org/x/term"
+)
+
+// setupConsole makes output readable where it goes: colors and spinner
+// animation need terminal that understands ANSI escape sequences, e.g. old
+// cmd.exe does not, nor does output redirected to a file on Windows.
+func setupConsole() {
+	if !enableVirtualTerminal() {
+		pterm.DisableStyling()
+	}
+}
+
+// clearLinesUp removes n lines printed above the cursor, e.g. answered
+// prompt. Plain output is left as it is.
+func clearLinesUp(n int) {
+	if pterm.RawOutput || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return
+	}
+	cursor.ClearLinesUp(n)
+}
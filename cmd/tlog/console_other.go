@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// enableVirtualTerminal reports whether stdout understands ANSI escape
+// sequences, terminals other than Windows console always do.
+func enableVirtualTerminal() bool {
+	return true
+}
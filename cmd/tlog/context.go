@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/pterm/pterm"
+)
+
+func runContext(args []string) error {
+	switch safeGet(args, 0) {
+	case "list":
+		return runContextList()
+	case "use":
+		if len(args) != 2 {
+			return errors.New("Usage: tlog context use <name>")
+		}
+		return runContextUse(args[1])
+	default:
+		return errors.New("Usage: tlog context list | use <name>")
+	}
+}
+
+func runContextList() error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Decode(path)
+	if err != nil {
+		return err
+	}
+
+	current := globalOpts.Context
+	if current == "" {
+		current = cfg.CurrentContext
+	}
+	if current == "" {
+		current = config.DefaultContext
+	}
+
+	names := config.ProfileNames(cfg)
+
+	data := pterm.TableData{{"", "Context", "JIRA", "Project"}}
+	row := func(name string, p Config) []string {
+		marker := ""
+		if name == current {
+			marker = "*"
+		}
+		return []string{marker, name, p.JiraURL, p.DefaultProject}
+	}
+	data = append(data, row(config.DefaultContext, cfg))
+	for _, name := range names {
+		p, _ := config.SelectContext(cfg, name)
+		data = append(data, row(name, p))
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+func runContextUse(name string) error {
+	err := updateConfigFile(func(cfg *Config) error {
+		if name == config.DefaultContext {
+			cfg.CurrentContext = ""
+			return nil
+		}
+		if _, ok := cfg.Profiles[name]; !ok {
+			return fmt.Errorf("context %q is not defined in config", name)
+		}
+		cfg.CurrentContext = name
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pterm.Success.Printfln("Switched to context %q", name)
+	return nil
+}
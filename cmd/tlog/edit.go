@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/pterm/pterm"
+)
+
+// runEdit changes worklog picked like for `tlog split`: its day, keeping
+// the clock time, its time or its comment.
+func runEdit(args []string) error {
+	flags := flag.NewFlagSet("edit", flag.ContinueOnError)
+	dayInput := flags.String("day", "", "move worklog to this day, keeping its start time")
+	spent := flags.String("time", "", "change time of worklog, e.g. 1h30m")
+	comment := flags.String("comment", "", "change comment of worklog")
+	days := flags.String("range", "", "day or from..to to find worklog in, the current month if not set")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if len(positional) != 2 || !set["day"] && !set["time"] && !set["comment"] {
+		return errors.New("Usage: tlog edit <task> <#index|id> [--day <day>] [--time <duration>] [--comment <comment>] [--range <day|from..to>]")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	issue, err := resolveTask(conf, positional[0])
+	if err != nil {
+		return err
+	}
+	from, to, err := reportRange(conf, *days)
+	if err != nil {
+		return err
+	}
+	b, err := backend.New(conf)
+	if err != nil {
+		return err
+	}
+	worklogs, err := listWorklogs(b, from, to)
+	if err != nil {
+		return err
+	}
+	wl, err := pickWorklog(worklogsOf(worklogs, issue), positional[1])
+	if err != nil {
+		return fmt.Errorf("%w, see `tlog ls %s`", err, positional[0])
+	}
+
+	changed := wl
+	if set["day"] {
+		day, err := convertToDay(*dayInput, conf.Location())
+		if err != nil {
+			return err
+		}
+		changed.Started = redate(conf, wl.Started, day)
+	}
+	if set["time"] {
+		if changed.Spent, err = convertToTimeLog(*spent, conf.Workday()); err != nil {
+			return err
+		}
+	}
+	if set["comment"] {
+		changed.Comment = *comment
+	}
+	if changed.Started.Equal(wl.Started) && changed.Spent == wl.Spent && changed.Comment == wl.Comment {
+		pterm.Info.Println("Nothing changed")
+		return nil
+	}
+
+	if _, err := editWorklog(conf, b, wl, changed); err != nil {
+		return err
+	}
+	if !changed.Started.Equal(wl.Started) {
+		pterm.Info.Printfln("%s moved: %s → %s", wl.Issue,
+			wl.Started.In(conf.Location()).Format("Mon 2006-01-02"), changed.Started.In(conf.Location()).Format("Mon 2006-01-02"))
+	}
+	return nil
+}
+
+// editWorklog changes wl to changed in place, or logs it anew if backend
+// cannot move worklog to another day, be it always or for this one.
+func editWorklog(conf Config, b Backend, wl, changed Worklog) (Worklog, error) {
+	moved := !changed.Started.Equal(wl.Started)
+	if _, ok := b.(backend.Relogger); ok && moved {
+		return relogWorklog(conf, b, wl, changed)
+	}
+	updated, err := updateWorklog(conf, b, changed)
+	var refused backend.RedateError
+	if moved && errors.As(err, &refused) {
+		pterm.Info.Println("Backend cannot move the worklog, logging it anew on the day")
+		return relogWorklog(conf, b, wl, changed)
+	}
+	return updated, err
+}
+
+// redate returns started moved to day, at the same wall clock time in the
+// configured timezone, even across DST change.
+func redate(conf Config, started, day time.Time) time.Time {
+	loc := conf.Location()
+	s := started.In(loc)
+	d := day.In(loc)
+	return time.Date(d.Year(), d.Month(), d.Day(), s.Hour(), s.Minute(), s.Second(), s.Nanosecond(), loc)
+}
+
+// relogWorklog replaces wl by changed logged anew, for backends that cannot
+// change worklogs in place. The new one goes first and is deleted again if
+// wl cannot be, so time is never lost nor counted twice, in backend or in
+// the ledger.
+func relogWorklog(conf Config, b Backend, wl, changed Worklog) (Worklog, error) {
+	changed.ID, changed.URL = "", ""
+	if err := prepareWorklog(conf, b, &changed); err != nil {
+		return Worklog{}, err
+	}
+	created, err := createWorklog(conf, b, changed, nil)
+	if err != nil {
+		return Worklog{}, fmt.Errorf("worklog is not moved: %w", err)
+	}
+	pterm.Success.Println(createdMessage(created))
+	if err := deleteWorklog(conf, b, wl); err != nil {
+		if derr := discardWorklog(conf, b, created); derr != nil {
+			return Worklog{}, fmt.Errorf("original worklog is not deleted and the new one %s of %s is left, delete it: %w", created.ID, created.Issue, derr)
+		}
+		pterm.Info.Println("The new worklog is deleted again, nothing is changed")
+		return Worklog{}, err
+	}
+	return created, nil
+}
+
+// discardWorklog deletes worklog created by a change that failed halfway,
+// along with its ledger entry.
+func discardWorklog(conf Config, b Backend, created Worklog) error {
+	if err := b.DeleteWorklog(created.Issue, created.ID); err != nil {
+		return err
+	}
+	return backend.RemoveLedgerWorklog(conf, created.Issue, created.ID)
+}
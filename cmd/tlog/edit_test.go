@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_redate(t *testing.T) {
+	conf := Config{Timezone: "Europe/Berlin"}
+	loc := conf.Location()
+	// Saturday before DST change, in UTC as backends return it
+	started := time.Date(2024, 3, 30, 9, 15, 0, 0, loc).UTC()
+	friday, err := convertToDay("2024-03-29", loc)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 3, 29, 9, 15, 0, 0, loc), redate(conf, started, friday))
+
+	monday, err := convertToDay("2024-04-01", loc)
+	require.NoError(t, err)
+	moved := redate(conf, started, monday)
+	require.Equal(t, "2024-04-01 09:15 +0200", moved.Format("2006-01-02 15:04 -0700"))
+
+	// late evening stays on its day, though it is the next one in UTC
+	late := time.Date(2024, 3, 30, 23, 30, 0, 0, loc)
+	require.Equal(t, "2024-03-29 23:30", redate(conf, late, friday).Format("2006-01-02 15:04"))
+}
+
+func Test_relogWorklog(t *testing.T) {
+	isolateUserDirs(t)
+	b := &backend.MockBackend{Author: "me"}
+	conf := Config{Timezone: "UTC"}
+	saturday := time.Date(2024, 3, 9, 9, 0, 0, 0, time.UTC)
+	wl, err := createWorklog(conf, b, Worklog{Issue: "APP-1", Started: saturday, Spent: 2 * time.Hour, Comment: "release"}, nil)
+	require.NoError(t, err)
+
+	changed := wl
+	changed.Started = saturday.AddDate(0, 0, -1)
+	created, err := relogWorklog(conf, b, wl, changed)
+	require.NoError(t, err)
+	require.NotEqual(t, wl.ID, created.ID)
+
+	worklogs, err := b.ListWorklogs(saturday.AddDate(0, 0, -1), saturday.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, worklogs, 1)
+	require.Equal(t, created.ID, worklogs[0].ID)
+	require.Equal(t, changed.Started, worklogs[0].Started)
+	require.Equal(t, 2*time.Hour, worklogs[0].Spent)
+	require.Equal(t, "release", worklogs[0].Comment)
+	entries, err := backend.ReadLedger(conf)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "moved worklog is counted once")
+	require.Equal(t, created.ID, entries[0].WorklogID)
+	require.True(t, changed.Started.Equal(entries[0].Started))
+
+	// the original cannot be deleted, the new one goes away again
+	fixed := changed
+	fixed.Started = saturday
+	_, err = relogWorklog(conf, failingDelete{b, created.ID}, created, fixed)
+	require.Error(t, err)
+	entries, err = backend.ReadLedger(conf)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, created.ID, entries[0].WorklogID)
+}
+
+// closedPeriodBackend refuses to change any worklog, as if its day was in a
+// closed period.
+type closedPeriodBackend struct {
+	*backend.MockBackend
+}
+
+func (b closedPeriodBackend) UpdateWorklog(wl Worklog) (Worklog, error) {
+	return Worklog{}, backend.RedateError{Err: errors.New("Tempo: startDate is in a closed period")}
+}
+
+func Test_editWorklog_relogsIfRefused(t *testing.T) {
+	isolateUserDirs(t)
+	b := closedPeriodBackend{MockBackend: &backend.MockBackend{Author: "me"}}
+	conf := Config{Timezone: "UTC"}
+	started := time.Date(2024, 3, 8, 9, 0, 0, 0, time.UTC)
+	wl, err := b.AddWorklog(Worklog{Issue: "APP-1", Started: started, Spent: time.Hour})
+	require.NoError(t, err)
+
+	changed := wl
+	changed.Started = started.AddDate(0, 0, 3)
+	created, err := editWorklog(conf, b, wl, changed)
+	require.NoError(t, err)
+	require.NotEqual(t, wl.ID, created.ID)
+	worklogs, err := b.ListWorklogs(started, started.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.Len(t, worklogs, 1)
+	require.Equal(t, changed.Started, worklogs[0].Started)
+
+	changed = worklogs[0]
+	changed.Spent = 2 * time.Hour
+	_, err = editWorklog(conf, b, worklogs[0], changed)
+	require.ErrorAs(t, err, &backend.RedateError{}, "worklog staying on its day is not logged anew")
+}
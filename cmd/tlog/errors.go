@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+)
+
+// Failures commands tell apart are typed, so that exit code, JSON output
+// and tests pick them with errors.As rather than by message. They wrap what
+// caused them.
+
+// Kinds of parseError.
+const (
+	parseTime = parse.KindTime
+	parseDay  = parse.KindDay
+)
+
+// parseError is returned for command line argument that is not what it
+// should be, e.g. time to log.
+type parseError = parse.Error
+
+// errorKind names kind of failure for machine readable output, empty if it is none of the known ones.
+func errorKind(err error) string {
+	var notFound backend.IssueNotFoundError
+	var auth backend.AuthError
+	var config config.Error
+	var invalid parseError
+	var exitErr *exitError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, backend.ErrInterrupted):
+		return "interrupted"
+	case errors.As(err, &notFound):
+		return "issue_not_found"
+	case errors.As(err, &auth):
+		return "auth"
+	case errors.As(err, &config):
+		return "config"
+	case errors.As(err, &invalid):
+		return "parse"
+	case backend.IsDialError(err):
+		return "network"
+	case errors.As(err, &exitErr):
+		// failure reported by command itself, e.g. by `tlog auth test`
+		switch exitErr.code {
+		case exitCredentials:
+			return "auth"
+		case exitNetwork:
+			return "network"
+		}
+	}
+	return ""
+}
+
+// exitCode picks exit code of failed command.
+func exitCode(err error) int {
+	var exitErr *exitError
+	var auth backend.AuthError
+	switch {
+	case errors.As(err, &exitErr):
+		return exitErr.code
+	case errors.Is(err, backend.ErrInterrupted):
+		return exitInterrupted
+	case errors.As(err, &auth):
+		return exitCredentials
+	case backend.IsDialError(err):
+		return exitNetwork
+	}
+	return 1
+}
+
+// outputFormat returns output format asked for by --output among command
+// arguments, text if there is none.
+func outputFormat(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		// flag package takes -output as well
+		name := "-" + strings.TrimLeft(arg, "-")
+		if name == "-output" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value := strings.TrimPrefix(name, "-output="); value != name {
+			return value
+		}
+	}
+	return "text"
+}
+
+// jsonError is failed command as told to scripts with --output json.
+type jsonError struct {
+	// see errorKind, "error" if it is none of the known ones
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details"`
+}
+
+// writeJSONError writes err as {"error": {...}} object, which scripts read
+// from stderr while output stays on stdout.
+func writeJSONError(w io.Writer, err error) error {
+	out := jsonError{Code: errorKind(err), Message: err.Error(), Details: map[string]interface{}{"exit_code": exitCode(err)}}
+	if out.Code == "" {
+		out.Code = "error"
+	}
+	if out.Message == errSilent.Error() {
+		// whatever failed is in the output already
+		out.Message = "command failed, see its output"
+	}
+	var notFound backend.IssueNotFoundError
+	var config config.Error
+	var invalid parseError
+	var truncated backend.TruncatedError
+	switch {
+	case errors.As(err, &notFound):
+		out.Details["issue"] = notFound.Issue
+	case errors.As(err, &config):
+		out.Details["field"] = config.Field
+	case errors.As(err, &invalid):
+		out.Details["input"] = invalid.Input
+		out.Details["kind"] = invalid.Kind
+	case errors.As(err, &truncated):
+		out.Details["limit"] = truncated.Limit
+	}
+	return json.NewEncoder(w).Encode(struct {
+		Error jsonError `json:"error"`
+	}{out})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_errorKind(t *testing.T) {
+	_, dayErr := convertToDay("someday", time.UTC)
+	_, timeErr := convertToTimeLog("ahaha", 8*time.Hour)
+	_, taskErr := convertToTask("42", "", nil)
+	_, _, roundErr := Config{TimerRounding: "sideways"}.Rounding()
+	dialErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	tests := []struct {
+		name string
+		err  error
+		kind string
+		code int
+	}{
+		{name: "interrupted", err: fmt.Errorf("log: %w", backend.ErrInterrupted), kind: "interrupted", code: exitInterrupted},
+		{name: "not found", err: backend.IssueNotFoundError{Issue: "PRJ-1"}, kind: "issue_not_found", code: 1},
+		{name: "auth", err: backend.StatusError(401, errors.New("GitLab: 401 Unauthorized")), kind: "auth", code: exitCredentials},
+		{name: "day", err: dayErr, kind: "parse", code: 1},
+		{name: "time", err: timeErr, kind: "parse", code: 1},
+		{name: "default project", err: taskErr, kind: "config", code: 1},
+		{name: "rounding", err: roundErr, kind: "config", code: 1},
+		{name: "network", err: dialErr, kind: "network", code: exitNetwork},
+		{name: "other", err: errors.New("JIRA: 500 Internal Server Error"), code: 1},
+		{name: "exit", err: &exitError{code: 5}, code: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.kind, errorKind(tt.err))
+			require.Equal(t, tt.code, exitCode(tt.err))
+		})
+	}
+}
+
+func Test_typedErrors(t *testing.T) {
+	_, err := convertToDay("someday", time.UTC)
+	var invalid parseError
+	require.ErrorAs(t, err, &invalid)
+	require.Equal(t, parseError{Input: "someday", Kind: parseDay}, invalid)
+	require.EqualError(t, err, `invalid day "someday", [yy.]mm.dd, day of the week, or day of the month expected`)
+
+	_, err = convertToTask("42", "", nil)
+	var config config.Error
+	require.ErrorAs(t, err, &config)
+	require.Equal(t, "DefaultProject", config.Field)
+
+	_, _, _, err = Config{Retries: -1}.RetryPolicy()
+	require.ErrorAs(t, err, &config)
+	require.Equal(t, "Retries", config.Field)
+
+	notFound := errors.New("GitLab: 404 Not Found")
+	require.Equal(t, notFound, backend.StatusError(404, notFound))
+	require.ErrorAs(t, backend.StatusError(400, errors.New("Tempo: startDate is in a closed period")), &backend.RedateError{})
+	require.False(t, errors.As(backend.StatusError(400, errors.New("Tempo: description is too long")), &backend.RedateError{}))
+}
+
+func Test_writeJSONError(t *testing.T) {
+	_, timeErr := convertToTimeLog("ahaha", 8*time.Hour)
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "not found",
+			err:  fmt.Errorf("log: %w", backend.IssueNotFoundError{Issue: "PRJ-1"}),
+			want: `{"error": {"code": "issue_not_found", "message": "log: issue PRJ-1 is not found, or you are not permitted to see it", "details": {"exit_code": 1, "issue": "PRJ-1"}}}`,
+		},
+		{
+			name: "parse",
+			err:  timeErr,
+			want: `{"error": {"code": "parse", "message": "invalid time \"ahaha\", duration like 1h30m or 0.5d expected", "details": {"exit_code": 1, "input": "ahaha", "kind": "time"}}}`,
+		},
+		{
+			name: "config",
+			err:  config.Error{Field: "RoundTo", Err: errors.New("bad RoundTo")},
+			want: `{"error": {"code": "config", "message": "bad RoundTo", "details": {"exit_code": 1, "field": "RoundTo"}}}`,
+		},
+		{
+			name: "reported",
+			err:  &exitError{code: exitCredentials, err: shownError{errors.New("JIRA rejected credentials")}},
+			want: `{"error": {"code": "auth", "message": "JIRA rejected credentials", "details": {"exit_code": 3}}}`,
+		},
+		{
+			name: "silent",
+			err:  errSilent,
+			want: `{"error": {"code": "error", "message": "command failed, see its output", "details": {"exit_code": 1}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, writeJSONError(&buf, tt.err))
+			require.True(t, json.Valid(buf.Bytes()))
+			require.JSONEq(t, tt.want, buf.String())
+		})
+	}
+}
+
+func Test_outputFormat(t *testing.T) {
+	require.Equal(t, "text", outputFormat(nil))
+	require.Equal(t, "json", outputFormat([]string{"config", "show", "--output", "json"}))
+	require.Equal(t, "json", outputFormat([]string{"-output=json"}))
+	require.Equal(t, "text", outputFormat([]string{"1h", "PRJ-1", "today", "output"}))
+	require.Equal(t, "text", outputFormat([]string{"--", "--output", "json"}))
+}
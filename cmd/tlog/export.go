@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// runExport writes worklogs of range, the current month by default, as CSV
+// timesheet laid out as preset tells.
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	presetName := flags.String("preset", "", "layout of timesheet from [Export.Presets], Export.Default if not set")
+	file := flags.String("file", "", "write timesheet to this file instead of stdout")
+	tag := addTagFlag(flags)
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog export [day|from..to] [--preset <name>] [--file <path>]")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+	preset, err := conf.Export.Preset(*presetName)
+	if err != nil {
+		return err
+	}
+	from, to, err := reportRange(conf, safeGet(positional, 0))
+	if err != nil {
+		return err
+	}
+	worklogs, err := reportWorklogs(conf, from, to)
+	if err != nil {
+		return err
+	}
+	worklogs = withTag(worklogs, *tag)
+	var summaries map[string]string
+	if containsString(preset.Columns, "summary") {
+		keys := map[string]time.Duration{}
+		for _, wl := range worklogs {
+			keys[wl.Issue] += wl.Spent
+		}
+		summaries = issueSummaries(conf, sortedKeys(keys))
+	}
+
+	var b bytes.Buffer
+	if err := writeTimesheet(&b, conf, preset, worklogs, summaries); err != nil {
+		return err
+	}
+	if *file == "" {
+		_, err := os.Stdout.Write(b.Bytes())
+		return err
+	}
+	if err := os.WriteFile(*file, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("cannot write timesheet: %w", err)
+	}
+	pterm.Success.Printfln("%d worklogs are written to %s", len(worklogs), *file)
+	return nil
+}
+
+// writeTimesheet writes a row of every worklog, the earliest first.
+func writeTimesheet(b *bytes.Buffer, conf Config, p config.ExportPreset, worklogs []Worklog, summaries map[string]string) error {
+	loc := conf.Location()
+	sorted := append([]Worklog(nil), worklogs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Started.Before(sorted[j].Started) })
+
+	w := csv.NewWriter(b)
+	w.Comma, _ = utf8.DecodeRuneInString(p.Delimiter)
+	w.UseCRLF = p.CRLF
+	if !p.NoHeader {
+		if err := w.Write(p.Headers); err != nil {
+			return err
+		}
+	}
+	for _, wl := range sorted {
+		started := wl.Started.In(loc)
+		record := make([]string, 0, len(p.Columns))
+		for _, column := range p.Columns {
+			var value string
+			switch column {
+			case "day":
+				value = started.Format(p.DateFormat)
+			case "start":
+				value = started.Format("15:04")
+			case "issue":
+				value = wl.Issue
+			case "project":
+				value = parse.Project(wl.Issue)
+			case "summary":
+				value = summaries[wl.Issue]
+			case "hours":
+				value = strings.Replace(strconv.FormatFloat(wl.Spent.Hours(), 'f', 2, 64), ".", p.Decimal, 1)
+			case "minutes":
+				value = strconv.Itoa(int(wl.Spent.Round(time.Minute).Minutes()))
+			case "duration":
+				value = parse.FormatDuration(wl.Spent)
+			case "comment":
+				value = wl.Comment
+			case "author":
+				value = wl.Author
+			}
+			record = append(record, value)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport_preset(t *testing.T) {
+	p, err := config.Export{}.Preset("")
+	require.NoError(t, err)
+	require.Equal(t, config.ExportPreset{
+		Columns:    []string{"day", "issue", "hours", "comment"},
+		Headers:    []string{"day", "issue", "hours", "comment"},
+		Delimiter:  ",",
+		Decimal:    ".",
+		DateFormat: "2006-01-02",
+	}, p, "built-in layout without presets")
+
+	tests := []struct {
+		name   string
+		preset config.ExportPreset
+		field  string
+	}{
+		{"no columns", config.ExportPreset{}, "Export.Presets.erp.Columns"},
+		{"unknown column", config.ExportPreset{Columns: []string{"day", "rate"}}, "Export.Presets.erp.Columns"},
+		{"headers of other columns", config.ExportPreset{Columns: []string{"day", "hours"}, Headers: []string{"Datum"}}, "Export.Presets.erp.Headers"},
+		{"long delimiter", config.ExportPreset{Columns: []string{"day"}, Delimiter: ";;"}, "Export.Presets.erp.Delimiter"},
+		{"decimal is delimiter", config.ExportPreset{Columns: []string{"hours"}, Decimal: ","}, "Export.Presets.erp.Decimal"},
+		{"unknown decimal", config.ExportPreset{Columns: []string{"hours"}, Decimal: "'"}, "Export.Presets.erp.Decimal"},
+		{"date without day", config.ExportPreset{Columns: []string{"day"}, DateFormat: "January 2006"}, "Export.Presets.erp.DateFormat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.Export{Presets: map[string]config.ExportPreset{"erp": tt.preset}}.Preset("erp")
+			require.Equal(t, tt.field, configField(err, ""))
+		})
+	}
+
+	_, err = config.Export{Default: "payroll"}.Preset("")
+	require.Equal(t, "Export.Default", configField(err, ""))
+	_, err = config.Export{}.Preset("payroll")
+	require.EqualError(t, err, `unknown export preset "payroll", add [Export.Presets.payroll] to config`)
+}
+
+func Test_validateConfig_export(t *testing.T) {
+	cfg := Config{
+		JiraURL: "https://jira.example.com", JiraLogin: "user", JiraPassword: "secret",
+		Export: config.Export{Default: "erp", Presets: map[string]config.ExportPreset{
+			"erp":  {Columns: []string{"day", "hours"}},
+			"bill": {Columns: []string{"hours"}, Delimiter: ",", Decimal: ","},
+		}},
+	}
+	require.Equal(t, []ConfigProblem{
+		{Key: "Export.Presets.bill.Decimal", Message: "Export.Presets.bill.Decimal is the same as Delimiter, hours would be split in two"},
+	}, validateConfig(cfg))
+}
+
+func Test_writeTimesheet(t *testing.T) {
+	conf := Config{Timezone: "UTC"}
+	preset, err := config.Export{Presets: map[string]config.ExportPreset{"erp": {
+		Columns:    []string{"day", "project", "summary", "hours", "comment"},
+		Headers:    []string{"Datum", "Projekt", "Aufgabe", "Stunden", "Taetigkeit"},
+		Delimiter:  ";",
+		Decimal:    ",",
+		DateFormat: "02.01.2006",
+		CRLF:       true,
+	}}}.Preset("erp")
+	require.NoError(t, err)
+	worklogs := []Worklog{
+		{Issue: "APP-2", Started: time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC), Spent: 90 * time.Minute, Comment: "review; fixes"},
+		{Issue: "OPS-1", Started: time.Date(2024, 3, 4, 14, 0, 0, 0, time.UTC), Spent: 20 * time.Minute},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, writeTimesheet(&b, conf, preset, worklogs, map[string]string{"APP-2": "Login page"}))
+	require.Equal(t, "Datum;Projekt;Aufgabe;Stunden;Taetigkeit\r\n"+
+		"04.03.2024;OPS;;0,33;\r\n"+
+		"05.03.2024;APP;Login page;1,50;\"review; fixes\"\r\n", b.String())
+
+	preset, err = config.Export{Presets: map[string]config.ExportPreset{"raw": {
+		Columns:  []string{"start", "issue", "minutes", "duration"},
+		NoHeader: true,
+	}}}.Preset("raw")
+	require.NoError(t, err)
+	b.Reset()
+	require.NoError(t, writeTimesheet(&b, conf, preset, worklogs, nil))
+	require.Equal(t, "14:00,OPS-1,20,20m\n09:00,APP-2,90,1h30m\n", b.String())
+}
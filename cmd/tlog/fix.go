@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"atomicgo.dev/keyboard"
+	"atomicgo.dev/keyboard/keys"
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+)
+
+// fixStep is what +/- change time by in `tlog fix` unless RoundTo is set.
+const fixStep = 15 * time.Minute
+
+// fixAction is what key pressed in `tlog fix` asks for.
+type fixAction int
+
+const (
+	fixNone fixAction = iota
+	fixEdit
+	fixApply
+	fixQuit
+)
+
+// fixEntry is worklog being fixed, with its time and comment as changed so far.
+type fixEntry struct {
+	Original Worklog
+	Spent    time.Duration
+	Comment  string
+	Deleted  bool
+}
+
+func (e fixEntry) changed() bool {
+	return e.Deleted || e.Spent != e.Original.Spent || e.Comment != e.Original.Comment
+}
+
+// fixList is the screen of `tlog fix`: worklogs of the day, the picked one
+// and what they add up to against Target.
+type fixList struct {
+	Entries []fixEntry
+	Cursor  int
+	Step    time.Duration
+	Target  time.Duration
+}
+
+func newFixList(worklogs []Worklog, step, target time.Duration) *fixList {
+	l := &fixList{Step: step, Target: target}
+	for _, wl := range worklogs {
+		l.Entries = append(l.Entries, fixEntry{Original: wl, Spent: wl.Spent, Comment: wl.Comment})
+	}
+	return l
+}
+
+// runFix lists worklogs of the day, today by default, in one screen to
+// change their time with +/-, edit comments and delete them, with the total
+// updated as they change. Nothing is sent until the changes are confirmed.
+func runFix(args []string) error {
+	flags := flag.NewFlagSet("fix", flag.ContinueOnError)
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog fix [day]")
+	}
+	if !stdinIsTerminal() {
+		return errors.New("tlog fix needs terminal, use `tlog edit` and `tlog rm` in scripts")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	day, err := convertToDay(safeGet(positional, 0), conf.Location())
+	if err != nil {
+		return err
+	}
+	step := fixStep
+	if conf.RoundTo != "" {
+		if step, _, err = conf.Rounding(); err != nil {
+			return err
+		}
+	}
+	target := conf.Workday()
+	if !conf.Calendar.IsWorkday(day) {
+		target = 0
+	}
+	b, err := backend.New(conf)
+	if err != nil {
+		return err
+	}
+	worklogs, err := listWorklogs(b, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	if len(worklogs) == 0 {
+		pterm.Info.Printfln("No worklogs on %s", day.Format("Mon 01.02"))
+		return nil
+	}
+
+	list := newFixList(sortedByStart(worklogs), step, target)
+	for {
+		action, err := list.run(conf)
+		if err != nil {
+			return err
+		}
+		if action == fixQuit {
+			return errors.New("nothing is changed")
+		}
+		if action == fixApply {
+			break
+		}
+		entry := &list.Entries[list.Cursor]
+		prompt := promptui.Prompt{Label: pterm.LightBlue("Comment of " + entry.Original.Issue), Default: entry.Comment, AllowEdit: true}
+		if comment, err := prompt.Run(); err == nil {
+			entry.Comment = comment
+		}
+	}
+
+	updated, deleted := list.changes()
+	if len(updated) == 0 && len(deleted) == 0 {
+		pterm.Info.Println("Nothing changed")
+		return nil
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(list.rows(conf, false)).Render(); err != nil {
+		return err
+	}
+	confirmed, _ := pterm.DefaultInteractiveConfirm.WithDefaultValue(true).Show(pterm.Sprintf("Update %d and delete %d worklogs?", len(updated), len(deleted)))
+	if !confirmed {
+		return errors.New("nothing is changed")
+	}
+
+	var failed []failedWorklog
+	for _, wl := range updated {
+		if _, err := updateWorklog(conf, b, wl); err != nil {
+			var shown shownError
+			if errors.As(err, &shown) {
+				err = shown.err
+			}
+			failed = append(failed, failedWorklog{Worklog: wl, Err: err})
+		}
+	}
+	failed = append(failed, deleteWorklogs(conf, b, deleted)...)
+	if len(failed) > 0 {
+		pterm.Warning.Printfln("%d of %d changes are not applied:", len(failed), len(updated)+len(deleted))
+		if err := pterm.DefaultTable.WithHasHeader().WithData(rmRows(conf, failed)).Render(); err != nil {
+			return err
+		}
+		return errSilent
+	}
+	return nil
+}
+
+// run shows the list until a key asks for something else than a change
+// of the list itself.
+func (l *fixList) run(conf Config) (fixAction, error) {
+	area, err := pterm.DefaultArea.WithRemoveWhenDone().Start(l.render(conf))
+	if err != nil {
+		return fixNone, err
+	}
+	action := fixNone
+	err = keyboard.Listen(func(key keys.Key) (bool, error) {
+		action = l.press(key)
+		area.Update(l.render(conf))
+		return action != fixNone, nil
+	})
+	if serr := area.Stop(); err == nil {
+		err = serr
+	}
+	return action, err
+}
+
+// press changes the list as key asks, or tells what else to do.
+func (l *fixList) press(key keys.Key) fixAction {
+	entry := &l.Entries[l.Cursor]
+	switch key.Code {
+	case keys.Up:
+		l.Cursor = (l.Cursor + len(l.Entries) - 1) % len(l.Entries)
+	case keys.Down, keys.Tab:
+		l.Cursor = (l.Cursor + 1) % len(l.Entries)
+	case keys.Delete:
+		entry.Deleted = !entry.Deleted
+	case keys.Enter:
+		return fixApply
+	case keys.Escape, keys.CtrlC:
+		return fixQuit
+	case keys.RuneKey:
+		switch key.String() {
+		case "k":
+			return l.press(keys.Key{Code: keys.Up})
+		case "j":
+			return l.press(keys.Key{Code: keys.Down})
+		case "+", "=":
+			entry.Spent = entry.Spent.Truncate(l.Step) + l.Step
+		case "-", "_":
+			if less := (entry.Spent - 1).Truncate(l.Step); less > 0 {
+				entry.Spent = less
+			}
+		case "d":
+			entry.Deleted = !entry.Deleted
+		case "e":
+			if !entry.Deleted {
+				return fixEdit
+			}
+		case "q":
+			return fixQuit
+		}
+	}
+	return fixNone
+}
+
+// total returns time of the entries that are not deleted.
+func (l fixList) total() time.Duration {
+	var total time.Duration
+	for _, e := range l.Entries {
+		if !e.Deleted {
+			total += e.Spent
+		}
+	}
+	return total
+}
+
+// changes returns worklogs to update and to delete to apply the list.
+func (l fixList) changes() ([]Worklog, []Worklog) {
+	var updated, deleted []Worklog
+	for _, e := range l.Entries {
+		switch {
+		case e.Deleted:
+			deleted = append(deleted, e.Original)
+		case e.changed():
+			wl := e.Original
+			wl.Spent, wl.Comment = e.Spent, e.Comment
+			updated = append(updated, wl)
+		}
+	}
+	return updated, deleted
+}
+
+// rows renders entries, with the picked one marked if cursor is set.
+func (l fixList) rows(conf Config, cursor bool) [][]string {
+	rows := [][]string{{"", "Start", "Issue", "Time", "Comment"}}
+	for i, e := range l.Entries {
+		mark := " "
+		if cursor && i == l.Cursor {
+			mark = "›"
+		}
+		spent := formatSpent(e.Spent)
+		switch {
+		case e.Deleted:
+			spent = "deleted"
+		case e.Spent != e.Original.Spent:
+			spent += " (was " + formatSpent(e.Original.Spent) + ")"
+		}
+		comment := e.Comment
+		if comment != e.Original.Comment {
+			comment += " (edited)"
+		}
+		started := e.Original.Started.In(conf.Location())
+		rows = append(rows, []string{mark, started.Format("15:04"), e.Original.Issue, spent, comment})
+	}
+	return rows
+}
+
+// render returns the screen: entries, total against target and keys.
+func (l fixList) render(conf Config) string {
+	table, err := pterm.DefaultTable.WithHasHeader().WithData(l.rows(conf, true)).Srender()
+	if err != nil {
+		table = err.Error()
+	}
+	total := l.total()
+	status := "Total " + parse.FormatDuration(total)
+	switch {
+	case l.Target == 0:
+		status += ", day off"
+	case total < l.Target:
+		status += fmt.Sprintf(" of %s, %s left", parse.FormatDuration(l.Target), parse.FormatDuration(l.Target-total))
+	case total > l.Target:
+		status += fmt.Sprintf(" of %s, %s over", parse.FormatDuration(l.Target), parse.FormatDuration(total-l.Target))
+	default:
+		status += " of " + parse.FormatDuration(l.Target)
+	}
+	help := fmt.Sprintf("↑/↓ pick, +/- %s, e comment, d delete, Enter apply, Esc quit", parse.FormatDuration(l.Step))
+	return strings.Join([]string{table, pterm.Bold.Sprint(status), pterm.Gray(help)}, "\n")
+}
+
+// sortedByStart returns worklogs sorted by start, the earliest first.
+func sortedByStart(worklogs []Worklog) []Worklog {
+	sorted := append([]Worklog(nil), worklogs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Started.Before(sorted[j].Started) })
+	return sorted
+}
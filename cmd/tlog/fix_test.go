@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"atomicgo.dev/keyboard/keys"
+	"github.com/pterm/pterm"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fixList_press(t *testing.T) {
+	nine := time.Date(2024, time.December, 13, 9, 0, 0, 0, time.UTC)
+	list := newFixList([]Worklog{
+		{ID: "1", Issue: "ABC-1", Started: nine, Spent: 67 * time.Minute, Comment: "login"},
+		{ID: "2", Issue: "ABC-2", Started: nine.Add(2 * time.Hour), Spent: 10 * time.Minute},
+		{ID: "3", Issue: "ABC-3", Started: nine.Add(3 * time.Hour), Spent: time.Hour},
+	}, 15*time.Minute, 8*time.Hour)
+	press := func(input ...interface{}) fixAction {
+		action := fixNone
+		for _, in := range input {
+			key, ok := in.(keys.Key)
+			if !ok {
+				key = keys.Key{Code: keys.RuneKey, Runes: []rune{in.(rune)}}
+			}
+			action = list.press(key)
+		}
+		return action
+	}
+
+	press('+')
+	require.Equal(t, 75*time.Minute, list.Entries[0].Spent, "snaps to the next step")
+	press('-', '-')
+	require.Equal(t, 45*time.Minute, list.Entries[0].Spent)
+
+	press(keys.Key{Code: keys.Down}, '-')
+	require.Equal(t, 10*time.Minute, list.Entries[1].Spent, "less than a step is left as it is")
+	press('j', 'd')
+	require.True(t, list.Entries[2].Deleted)
+	press('j')
+	require.Equal(t, 0, list.Cursor, "wraps around")
+	press(keys.Key{Code: keys.Up})
+	require.Equal(t, fixNone, press('e'), "deleted entry cannot be edited")
+	require.Equal(t, 55*time.Minute, list.total())
+
+	require.Equal(t, fixEdit, press('k', 'k', 'e'))
+	require.Equal(t, fixApply, press(keys.Key{Code: keys.Enter}))
+	require.Equal(t, fixQuit, press('q'))
+	require.Equal(t, fixQuit, press(keys.Key{Code: keys.Escape}))
+
+	list.Entries[1].Comment = "review"
+	updated, deleted := list.changes()
+	require.Equal(t, []Worklog{
+		{ID: "1", Issue: "ABC-1", Started: nine, Spent: 45 * time.Minute, Comment: "login"},
+		{ID: "2", Issue: "ABC-2", Started: nine.Add(2 * time.Hour), Spent: 10 * time.Minute, Comment: "review"},
+	}, updated)
+	require.Equal(t, []Worklog{{ID: "3", Issue: "ABC-3", Started: nine.Add(3 * time.Hour), Spent: time.Hour}}, deleted)
+}
+
+func Test_fixList_render(t *testing.T) {
+	conf := Config{Timezone: "UTC"}
+	nine := time.Date(2024, time.December, 13, 9, 0, 0, 0, time.UTC)
+	list := newFixList([]Worklog{
+		{Issue: "ABC-1", Started: nine, Spent: 6 * time.Hour, Comment: "login"},
+		{Issue: "ABC-2", Started: nine.Add(6 * time.Hour), Spent: time.Hour},
+	}, 15*time.Minute, 8*time.Hour)
+	list.Entries[0].Spent = 7 * time.Hour
+	list.Entries[1].Deleted = true
+
+	require.Equal(t, [][]string{
+		{"", "Start", "Issue", "Time", "Comment"},
+		{"›", "09:00", "ABC-1", "7h (was 6h)", "login"},
+		{" ", "15:00", "ABC-2", "deleted", ""},
+	}, list.rows(conf, true))
+	screen := pterm.RemoveColorFromString(list.render(conf))
+	require.Contains(t, screen, "Total 7h of 8h, 1h left")
+	require.Contains(t, screen, "+/- 15m")
+
+	list.Entries[1].Deleted = false
+	require.Contains(t, pterm.RemoveColorFromString(list.render(conf)), "Total 8h of 8h\n")
+	list.Target = 0
+	require.Contains(t, pterm.RemoveColorFromString(list.render(conf)), "Total 8h, day off")
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/pterm/pterm"
+)
+
+const (
+	defaultGoogleCalendarID = "primary"
+	googleTokenFile         = "google_token.json"
+	// loopback redirect, Google accepts it for desktop clients without registration
+	googleRedirectURL = "http://127.0.0.1:8977/callback"
+	googleScope       = "https://www.googleapis.com/auth/calendar.readonly"
+)
+
+// Google endpoints, variables so tests can point them to a fake server.
+var (
+	googleAuthorizeURL = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL     = "https://oauth2.googleapis.com/token"
+	googleCalendarURL  = "https://www.googleapis.com/calendar/v3"
+)
+
+// browserAvailable tells whether user can authorize tlog in browser right now,
+// consent page cannot be completed from cron or over plain SSH.
+var browserAvailable = func() bool {
+	if !stdinIsTerminal() {
+		return false
+	}
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return true
+	}
+	return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+type gcalTime struct {
+	// set for all-day events only
+	Date     string    `json:"date"`
+	DateTime time.Time `json:"dateTime"`
+}
+
+type gcalEvent struct {
+	ID        string   `json:"id"`
+	ICalUID   string   `json:"iCalUID"`
+	Status    string   `json:"status"`
+	Summary   string   `json:"summary"`
+	EventType string   `json:"eventType"`
+	Start     gcalTime `json:"start"`
+	End       gcalTime `json:"end"`
+	Attendees []struct {
+		Self           bool   `json:"self"`
+		ResponseStatus string `json:"responseStatus"`
+	} `json:"attendees"`
+}
+
+// loadGoogleToken reads cached Google token, nil if there is none.
+func loadGoogleToken(conf Config) (*backend.OAuthToken, error) {
+	path, err := state.ContextPath(conf.Context(), googleTokenFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read Google token: %w", err)
+	}
+	var t backend.OAuthToken
+	if err := json.Unmarshal(data, &t); err != nil {
+		// authorizing again replaces it
+		return nil, nil
+	}
+	return &t, nil
+}
+
+func saveGoogleToken(conf Config, t *backend.OAuthToken) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	path, err := state.ContextPath(conf.Context(), googleTokenFile)
+	if err != nil {
+		return err
+	}
+	return state.WriteFileAtomic(path, data, 0600)
+}
+
+// requestGoogleToken performs token request of given grant type, Google
+// expects form encoded body.
+func requestGoogleToken(conf Config, params url.Values) (*backend.OAuthToken, error) {
+	form := url.Values{
+		"client_id":     {conf.GoogleClientID},
+		"client_secret": {conf.GoogleClientSecret},
+	}
+	for k := range params {
+		form.Set(k, params.Get(k))
+	}
+	transport, err := backend.BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	// limits the wait, so unreachable Google does not hang the import
+	retry, err := backend.NewRetryTransport(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := (&http.Client{Transport: retry}).PostForm(googleTokenURL, form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode token response (%s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return nil, fmt.Errorf("token request failed (%s): %s %s", resp.Status, result.Error, result.ErrorDescription)
+	}
+	return &backend.OAuthToken{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(result.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// googleAccessToken returns cached access token, refreshing it if it is
+// expired. If there is no usable token, user is sent to consent page, unless
+// no browser is available, then it fails instead of waiting for a callback
+// that never comes.
+func googleAccessToken(conf Config) (string, error) {
+	if conf.GoogleClientID == "" || conf.GoogleClientSecret == "" {
+		return "", errors.New("GoogleClientID and GoogleClientSecret are not set in config, " +
+			"create OAuth client of Desktop app type at https://console.cloud.google.com/apis/credentials")
+	}
+	token, err := loadGoogleToken(conf)
+	if err != nil {
+		return "", err
+	}
+	reason := "tlog is not authorized to read Google Calendar"
+	if token != nil {
+		if !token.Expired(time.Now()) {
+			return token.AccessToken, nil
+		}
+		reason = "Google token is expired"
+		if token.RefreshToken != "" {
+			refreshed, err := requestGoogleToken(conf, url.Values{
+				"grant_type":    {"refresh_token"},
+				"refresh_token": {token.RefreshToken},
+			})
+			if err == nil {
+				if refreshed.RefreshToken == "" {
+					refreshed.RefreshToken = token.RefreshToken
+				}
+				return refreshed.AccessToken, saveGoogleToken(conf, refreshed)
+			}
+			reason = fmt.Sprintf("Google token is expired and cannot be refreshed (%s)", err)
+		}
+	}
+	if !browserAvailable() {
+		return "", fmt.Errorf("%s and no browser is available to authorize it, run `tlog import gcal` in a desktop session", reason)
+	}
+
+	pterm.Warning.Println(reason)
+	code, err := authorizationCode(googleRedirectURL, func(state string) string {
+		return googleAuthorizeURL + "?" + url.Values{
+			"client_id":     {conf.GoogleClientID},
+			"scope":         {googleScope},
+			"redirect_uri":  {googleRedirectURL},
+			"state":         {state},
+			"response_type": {"code"},
+			// refresh token is issued on consent only
+			"access_type": {"offline"},
+			"prompt":      {"consent"},
+		}.Encode()
+	})
+	if err != nil {
+		return "", err
+	}
+	token, err = requestGoogleToken(conf, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {googleRedirectURL},
+	})
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, saveGoogleToken(conf, token)
+}
+
+// gcalClient reads events of GoogleCalendarID.
+type gcalClient struct {
+	conf   Config
+	client *http.Client
+	token  string
+}
+
+func newGCalClient(conf Config) (*gcalClient, error) {
+	token, err := googleAccessToken(conf)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := backend.BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := backend.NewRetryTransport(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+	return &gcalClient{conf: conf, client: &http.Client{Transport: retry}, token: token}, nil
+}
+
+// get sends GET request to Calendar API and decodes response into out.
+func (c *gcalClient) get(path string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, googleCalendarURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach Google Calendar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+		if failure.Error.Message != "" {
+			return fmt.Errorf("Google Calendar: %s, %s", resp.Status, failure.Error.Message)
+		}
+		return fmt.Errorf("Google Calendar: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// events returns events overlapping [from, to). Recurring events are
+// expanded into instances by Google, moved and cancelled ones included.
+func (c *gcalClient) events(from, to time.Time) ([]gcalEvent, error) {
+	calendar := c.conf.GoogleCalendarID
+	if calendar == "" {
+		calendar = defaultGoogleCalendarID
+	}
+	var events []gcalEvent
+	for page := ""; ; {
+		query := url.Values{
+			"timeMin":      {from.Format(time.RFC3339)},
+			"timeMax":      {to.Format(time.RFC3339)},
+			"singleEvents": {"true"},
+			"orderBy":      {"startTime"},
+			"maxResults":   {"250"},
+		}
+		if page != "" {
+			query.Set("pageToken", page)
+		}
+		var resp struct {
+			Items         []gcalEvent `json:"items"`
+			NextPageToken string      `json:"nextPageToken"`
+		}
+		if err := c.get("/calendars/"+url.PathEscape(calendar)+"/events?"+query.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("list events: %w", err)
+		}
+		events = append(events, resp.Items...)
+		if page = resp.NextPageToken; page == "" {
+			return events, nil
+		}
+	}
+}
+
+// gcalICSEvents converts events to ones of calendar file, so they are mapped
+// like `tlog import ics` ones. Events not accepted by the user count as
+// declined, working location and out of office ones are not meetings.
+func gcalICSEvents(events []gcalEvent) []icsEvent {
+	var converted []icsEvent
+	for _, e := range events {
+		if e.EventType == "workingLocation" || e.EventType == "outOfOffice" {
+			continue
+		}
+		ev := icsEvent{
+			// instances share iCalendar UID with the series, start tells
+			// them apart, as in ics import of the same calendar
+			UID:       e.ICalUID,
+			Summary:   e.Summary,
+			Start:     e.Start.DateTime,
+			End:       e.End.DateTime,
+			AllDay:    e.Start.Date != "",
+			Cancelled: e.Status == "cancelled",
+		}
+		if ev.UID == "" {
+			ev.UID = e.ID
+		}
+		for _, a := range e.Attendees {
+			if a.Self {
+				ev.Declined = a.ResponseStatus != "accepted"
+			}
+		}
+		converted = append(converted, ev)
+	}
+	return converted
+}
+
+func runImportGCal(args []string) error {
+	flags := flag.NewFlagSet("import gcal", flag.ContinueOnError)
+	opts := addImportFlags(flags)
+	opts.hint = "add Meetings.Alias or Meetings.Rules for them"
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return errSilent
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog import gcal [day|from..to] [--yes] [--unmapped <alias>]")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	from, to, err := parseImportDays(conf, safeGet(positional, 0))
+	if err != nil {
+		return err
+	}
+	client, err := newGCalClient(conf)
+	if err != nil {
+		return err
+	}
+	events, err := client.events(from, to)
+	if err != nil {
+		return err
+	}
+	entries, err := icsImportEntries(conf, gcalICSEvents(events))
+	if err != nil {
+		return err
+	}
+
+	selected, err := reviewImport(conf, entries, *opts)
+	if err != nil {
+		return err
+	}
+	return submitImport(conf, selected, *opts)
+}
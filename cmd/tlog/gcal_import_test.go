@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_gcalImport(t *testing.T) {
+	isolateUserDirs(t)
+	var refreshes int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			require.NoError(t, r.ParseForm())
+			require.Equal(t, "refresh_token", r.PostForm.Get("grant_type"))
+			require.Equal(t, "old-refresh", r.PostForm.Get("refresh_token"))
+			require.Equal(t, "client", r.PostForm.Get("client_id"))
+			refreshes++
+			w.Write([]byte(`{"access_token": "new-access", "expires_in": 3600}`))
+		case "/calendars/team@example.com/events":
+			require.Equal(t, "Bearer new-access", r.Header.Get("Authorization"))
+			q := r.URL.Query()
+			require.Equal(t, "true", q.Get("singleEvents"))
+			require.Equal(t, "2024-03-04T00:00:00Z", q.Get("timeMin"))
+			require.Equal(t, "2024-03-05T00:00:00Z", q.Get("timeMax"))
+			if q.Get("pageToken") == "" {
+				w.Write([]byte(`{"items": [
+					{"id": "s_1", "iCalUID": "series@google.com", "status": "confirmed", "summary": "Daily standup",
+						"start": {"dateTime": "2024-03-04T09:30:00+01:00"}, "end": {"dateTime": "2024-03-04T09:45:00+01:00"},
+						"attendees": [{"email": "jane@example.com", "self": true, "responseStatus": "accepted"}]},
+					{"id": "h_1", "iCalUID": "holiday@google.com", "status": "confirmed", "summary": "Offsite",
+						"start": {"date": "2024-03-04"}, "end": {"date": "2024-03-05"}},
+					{"id": "w_1", "iCalUID": "home@google.com", "status": "confirmed", "eventType": "workingLocation", "summary": "Home",
+						"start": {"dateTime": "2024-03-04T08:00:00Z"}, "end": {"dateTime": "2024-03-04T18:00:00Z"}}
+				], "nextPageToken": "p2"}`))
+				return
+			}
+			w.Write([]byte(`{"items": [
+				{"id": "s_2", "iCalUID": "series@google.com", "status": "confirmed", "summary": "Daily standup",
+					"start": {"dateTime": "2024-03-04T15:00:00+01:00"}, "end": {"dateTime": "2024-03-04T15:15:00+01:00"},
+					"attendees": [{"email": "jane@example.com", "self": true, "responseStatus": "accepted"}]},
+				{"id": "d_1", "iCalUID": "declined@google.com", "status": "confirmed", "summary": "All hands",
+					"start": {"dateTime": "2024-03-04T16:00:00Z"}, "end": {"dateTime": "2024-03-04T17:00:00Z"},
+					"attendees": [{"email": "jane@example.com", "self": true, "responseStatus": "tentative"}]},
+				{"id": "c_1", "iCalUID": "cancelled@google.com", "status": "cancelled", "summary": "PRJ-7 review",
+					"start": {"dateTime": "2024-03-04T17:00:00Z"}, "end": {"dateTime": "2024-03-04T18:00:00Z"}},
+				{"id": "o_1", "iCalUID": "own@google.com", "status": "confirmed", "summary": "PRJ-7 design",
+					"start": {"dateTime": "2024-03-04T18:00:00Z"}, "end": {"dateTime": "2024-03-04T19:00:00Z"}}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	oldTokenURL, oldCalendarURL := googleTokenURL, googleCalendarURL
+	googleTokenURL, googleCalendarURL = srv.URL+"/token", srv.URL
+	t.Cleanup(func() { googleTokenURL, googleCalendarURL = oldTokenURL, oldCalendarURL })
+
+	conf := Config{
+		Timezone:       "UTC",
+		GoogleClientID: "client", GoogleClientSecret: "secret", GoogleCalendarID: "team@example.com",
+		TaskAliases: map[string]string{"standup": "MEET-1"},
+		Meetings:    config.Meetings{Rules: []config.MeetingRule{{Match: "(?i)standup", Alias: "standup"}}},
+	}
+	require.NoError(t, saveGoogleToken(conf, &backend.OAuthToken{AccessToken: "old-access", RefreshToken: "old-refresh", Expiry: time.Now().Add(-time.Hour)}))
+
+	client, err := newGCalClient(conf)
+	require.NoError(t, err)
+	require.Equal(t, 1, refreshes)
+	stored, err := loadGoogleToken(conf)
+	require.NoError(t, err)
+	require.Equal(t, "new-access", stored.AccessToken)
+	require.Equal(t, "old-refresh", stored.RefreshToken, "refresh token is kept")
+
+	from, to, err := parseImportDays(conf, "2024-03-04")
+	require.NoError(t, err)
+	events, err := client.events(from, to)
+	require.NoError(t, err)
+	require.Len(t, events, 7)
+	entries, err := icsImportEntries(conf, gcalICSEvents(events))
+	require.NoError(t, err)
+
+	var got []string
+	for _, e := range entries {
+		wl := e.Worklog
+		got = append(got, strings.Join(append([]string{wl.Started.Format("15:04"), wl.Issue, parse.FormatDuration(wl.Spent), wl.Comment}, e.ImportIDs...), " "))
+	}
+	require.Equal(t, []string{
+		"08:30 MEET-1 15m Daily standup ics:series@google.com/2024-03-04T08:30:00Z",
+		"14:00 MEET-1 15m Daily standup ics:series@google.com/2024-03-04T14:00:00Z",
+		"18:00 PRJ-7 1h PRJ-7 design ics:own@google.com/2024-03-04T18:00:00Z",
+	}, got, "all-day, working location, not accepted and cancelled events are skipped")
+}
+
+func Test_googleAccessToken_noBrowser(t *testing.T) {
+	isolateUserDirs(t)
+	old := browserAvailable
+	browserAvailable = func() bool { return false }
+	t.Cleanup(func() { browserAvailable = old })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "invalid_grant", "error_description": "Token has been expired or revoked."}`))
+	}))
+	defer srv.Close()
+	oldTokenURL := googleTokenURL
+	googleTokenURL = srv.URL
+	t.Cleanup(func() { googleTokenURL = oldTokenURL })
+
+	conf := Config{GoogleClientID: "client", GoogleClientSecret: "secret"}
+	_, err := googleAccessToken(conf)
+	require.ErrorContains(t, err, "not authorized to read Google Calendar and no browser is available")
+
+	require.NoError(t, saveGoogleToken(conf, &backend.OAuthToken{AccessToken: "old", RefreshToken: "revoked", Expiry: time.Now().Add(-time.Hour)}))
+	_, err = googleAccessToken(conf)
+	require.ErrorContains(t, err, "cannot be refreshed")
+	require.ErrorContains(t, err, "invalid_grant")
+	require.ErrorContains(t, err, "no browser is available")
+}
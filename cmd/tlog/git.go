@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+)
+
+// branchIssueRe finds issue key in branch name, which is often lowercase, e.g. "feature/prj-12-login".
+var branchIssueRe = regexp.MustCompile(`(?i)\b[a-z][a-z0-9_]*-[0-9]+\b`)
+
+// gitCommit is a commit read from git log.
+type gitCommit struct {
+	SHA     string
+	Author  time.Time
+	Subject string
+	Body    string
+	// ref the commit was reached from, e.g. "refs/heads/PRJ-12-login"
+	Ref string
+}
+
+// issue returns issue key mentioned in commit message, then in its branch name.
+func (c gitCommit) issue() string {
+	if key := issueKeyInTextRe.FindString(c.Subject + "\n" + c.Body); key != "" {
+		return key
+	}
+	if strings.HasPrefix(c.Ref, "refs/heads/") || strings.HasPrefix(c.Ref, "refs/remotes/") {
+		return strings.ToUpper(branchIssueRe.FindString(c.Ref))
+	}
+	return ""
+}
+
+// git runs git in repo and returns its output.
+func git(repo string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// gitLog returns non-merge commits of repo selected by args, e.g. "--since=monday".
+func gitLog(repo string, args ...string) ([]gitCommit, error) {
+	// fields are separated by unit separator, commits by record separator
+	args = append([]string{"log", "--no-merges", "--source", "--format=%H%x1f%aI%x1f%S%x1f%s%x1f%b%x1e"}, args...)
+	out, err := git(repo, args...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []gitCommit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		author, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("commit %s: unexpected author date %q", fields[0], fields[1])
+		}
+		commits = append(commits, gitCommit{
+			SHA:     fields[0],
+			Author:  author,
+			Ref:     fields[2],
+			Subject: fields[3],
+			Body:    strings.TrimSpace(fields[4]),
+		})
+	}
+	return commits, nil
+}
+
+// gitUserEmail returns email commits of the user are authored with in repo, empty if not configured.
+func gitUserEmail(repo string) string {
+	out, err := git(repo, "config", "user.email")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// gitRepos returns GitRepos with home directory expanded, or the current directory.
+func gitRepos(conf Config) ([]string, error) {
+	if len(conf.GitRepos) == 0 {
+		return []string{"."}, nil
+	}
+	repos := make([]string, 0, len(conf.GitRepos))
+	for _, repo := range conf.GitRepos {
+		repo, err := state.ExpandHome(repo)
+		if err != nil {
+			return nil, fmt.Errorf("GitRepos: %w", err)
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
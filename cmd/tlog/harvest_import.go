@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+)
+
+const defaultHarvestURL = "https://api.harvestapp.com/v2"
+
+// harvestClient reads time entries of the current Harvest user.
+type harvestClient struct {
+	conf   Config
+	client *http.Client
+	base   string
+}
+
+type harvestTimeEntry struct {
+	ID          int64   `json:"id"`
+	SpentDate   string  `json:"spent_date"`
+	Hours       float64 `json:"hours"`
+	Notes       string  `json:"notes"`
+	StartedTime string  `json:"started_time"`
+	IsRunning   bool    `json:"is_running"`
+	Project     struct {
+		Name string `json:"name"`
+	} `json:"project"`
+	Task struct {
+		Name string `json:"name"`
+	} `json:"task"`
+}
+
+func newHarvestClient(conf Config) (*harvestClient, error) {
+	if conf.HarvestAccountID == "" || conf.HarvestToken == "" {
+		return nil, errors.New("HarvestAccountID and HarvestToken are not set in config, create personal access token at https://id.getharvest.com/developers")
+	}
+	transport, err := backend.BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := backend.NewRetryTransport(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+	base := conf.HarvestURL
+	if base == "" {
+		base = defaultHarvestURL
+	}
+	return &harvestClient{conf: conf, client: &http.Client{Transport: retry}, base: strings.TrimSuffix(base, "/")}, nil
+}
+
+// get sends GET request to Harvest API and decodes response into out.
+func (c *harvestClient) get(path string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.conf.HarvestToken)
+	req.Header.Set("Harvest-Account-Id", c.conf.HarvestAccountID)
+	// Harvest rejects requests without it
+	req.Header.Set("User-Agent", "tlog")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach Harvest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Message     string `json:"message"`
+			Description string `json:"error_description"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+		if message := strings.Join(nonEmpty(failure.Message, failure.Description), ", "); message != "" {
+			return fmt.Errorf("Harvest: %s, %s", resp.Status, message)
+		}
+		return fmt.Errorf("Harvest: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// timeEntries returns entries of the current user spent in [from, to).
+func (c *harvestClient) timeEntries(from, to time.Time) ([]harvestTimeEntry, error) {
+	var user struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.get("/users/me", &user); err != nil {
+		return nil, fmt.Errorf("get current user: %w", err)
+	}
+
+	var entries []harvestTimeEntry
+	for page := 1; page != 0; {
+		// dates are inclusive
+		query := url.Values{
+			"user_id":  {strconv.FormatInt(user.ID, 10)},
+			"from":     {from.Format("2006-01-02")},
+			"to":       {to.AddDate(0, 0, -1).Format("2006-01-02")},
+			"page":     {strconv.Itoa(page)},
+			"per_page": {"100"},
+		}
+		var resp struct {
+			TimeEntries []harvestTimeEntry `json:"time_entries"`
+			NextPage    int                `json:"next_page"`
+		}
+		if err := c.get("/time_entries?"+query.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("list time entries: %w", err)
+		}
+		entries = append(entries, resp.TimeEntries...)
+		page = resp.NextPage
+	}
+	return entries, nil
+}
+
+// harvestImportEntries maps Harvest entries to worklogs: issue key in notes,
+// then HarvestMapping of "Project/Task" or "Project". Entries tracked without
+// timer have no start time, they start at DefaultStartTime.
+func harvestImportEntries(conf Config, entries []harvestTimeEntry) []importEntry {
+	imported := make([]importEntry, 0, len(entries))
+	for _, e := range entries {
+		task := e.Project.Name + "/" + e.Task.Name
+		ie := importEntry{Source: task, Worklog: Worklog{
+			Spent:   time.Duration(e.Hours * float64(time.Hour)).Round(time.Minute),
+			Comment: e.Notes,
+		}, ImportIDs: []string{"harvest:" + strconv.FormatInt(e.ID, 10)}}
+
+		day, err := time.ParseInLocation("2006-01-02", e.SpentDate, conf.Location())
+		if err != nil {
+			ie.Problem = fmt.Sprintf("unexpected date %q", e.SpentDate)
+			imported = append(imported, ie)
+			continue
+		}
+		ie.Worklog.Started = atStartTime(conf, day)
+		if t, err := time.Parse("3:04pm", e.StartedTime); err == nil {
+			ie.Worklog.Started = day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+		}
+
+		issue, err := mapImported(conf, conf.HarvestMapping, e.Notes, task, e.Project.Name)
+		switch {
+		case e.IsRunning:
+			ie.Problem = "timer is still running"
+		case err != nil:
+			ie.Problem = err.Error()
+		case ie.Worklog.Spent == 0:
+			ie.Problem = "shorter than a minute"
+		}
+		ie.Worklog.Issue = issue
+		imported = append(imported, ie)
+	}
+	return imported
+}
+
+func runImportHarvest(args []string) error {
+	flags := flag.NewFlagSet("import harvest", flag.ContinueOnError)
+	fromInput := flags.String("from", "", "first day to import, today if not set")
+	toInput := flags.String("to", "", "last day to import, same as --from if not set")
+	opts := addImportFlags(flags)
+	opts.hint = "map their project and task in HarvestMapping"
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	from, to, err := importRange(conf, *fromInput, *toInput)
+	if err != nil {
+		return err
+	}
+	client, err := newHarvestClient(conf)
+	if err != nil {
+		return err
+	}
+	entries, err := client.timeEntries(from, to)
+	if err != nil {
+		return err
+	}
+
+	selected, err := reviewImport(conf, harvestImportEntries(conf, entries), *opts)
+	if err != nil {
+		return err
+	}
+	return submitImport(conf, selected, *opts)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_harvestImport(t *testing.T) {
+	isolateUserDirs(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.Equal(t, "42", r.Header.Get("Harvest-Account-Id"))
+		switch r.URL.Path {
+		case "/users/me":
+			w.Write([]byte(`{"id": 7}`))
+		case "/time_entries":
+			require.Equal(t, "7", r.URL.Query().Get("user_id"))
+			require.Equal(t, "2024-03-01", r.URL.Query().Get("from"))
+			require.Equal(t, "2024-03-31", r.URL.Query().Get("to"))
+			if r.URL.Query().Get("page") == "1" {
+				w.Write([]byte(`{"time_entries": [
+					{"id": 1, "spent_date": "2024-03-04", "hours": 1.5, "notes": "review", "started_time": "9:30am",
+						"project": {"name": "Website"}, "task": {"name": "Development"}}
+				], "next_page": 2}`))
+				return
+			}
+			w.Write([]byte(`{"time_entries": [
+				{"id": 2, "spent_date": "2024-03-05", "hours": 0.25, "notes": "WEB-9 hotfix", "project": {"name": "Website"}, "task": {"name": "Support"}},
+				{"id": 3, "spent_date": "2024-03-05", "hours": 1, "project": {"name": "Sales"}, "task": {"name": "Calls"}},
+				{"id": 4, "spent_date": "2024-03-05", "hours": 0.5, "is_running": true, "project": {"name": "website"}, "task": {"name": "Design"}}
+			], "next_page": null}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := Config{
+		HarvestURL: srv.URL, HarvestAccountID: "42", HarvestToken: "token", Timezone: "UTC", DefaultStartTime: "10:00",
+		TaskAliases:    map[string]string{"web": "WEB-1"},
+		HarvestMapping: map[string]string{"Website/Development": "web", "Website": "WEB-2"},
+	}
+	client, err := newHarvestClient(conf)
+	require.NoError(t, err)
+	from, to, err := importRange(conf, "2024-03-01", "2024-03-31")
+	require.NoError(t, err)
+	entries, err := client.timeEntries(from, to)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	imported := harvestImportEntries(conf, entries)
+	require.Equal(t, Worklog{Issue: "WEB-1", Started: time.Date(2024, 3, 4, 9, 30, 0, 0, time.UTC), Spent: 90 * time.Minute, Comment: "review"}, imported[0].Worklog)
+	require.Equal(t, []string{"harvest:1"}, imported[0].ImportIDs)
+	require.Equal(t, "WEB-9", imported[1].Worklog.Issue, "issue key in notes wins")
+	require.Equal(t, time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC), imported[1].Worklog.Started)
+	require.Empty(t, imported[2].Worklog.Issue)
+	require.Equal(t, "timer is still running", imported[3].Problem)
+
+	// entries logged before are not proposed again
+	require.NoError(t, backend.AppendLedger(conf, backend.LedgerEntry{Issue: "WEB-1", ImportIDs: []string{"harvest:1"}}))
+	selected, err := reviewImport(conf, imported, importOptions{yes: true})
+	require.NoError(t, err)
+	require.Len(t, selected, 1)
+	require.Equal(t, []string{"harvest:2"}, selected[0].ImportIDs)
+}
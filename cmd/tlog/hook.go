@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/pterm/pterm"
+)
+
+// tlog part of post-commit hook is kept between these lines, so it can live
+// in hook with other commands and be removed cleanly.
+const (
+	hookBegin = "# >>> tlog >>>"
+	hookEnd   = "# <<< tlog <<<"
+)
+
+const hookUsage = `Usage: tlog hook install [--force]
+       tlog hook uninstall`
+
+func runHook(args []string) error {
+	switch safeGet(args, 0) {
+	case "install":
+		return runHookInstall(args[1:])
+	case "uninstall":
+		return runHookUninstall()
+	case "run":
+		return runHookRun()
+	default:
+		return errors.New(hookUsage)
+	}
+}
+
+// postCommitHookPath returns post-commit hook of the current repository, core.hooksPath is honored.
+func postCommitHookPath() (string, error) {
+	out, err := git(".", "rev-parse", "--git-path", "hooks/post-commit")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(strings.TrimSpace(out))
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// hookBlock is tlog part of the hook. tlog runs detached with output
+// discarded, so commit is not delayed, failures are queued for `tlog sync`.
+func hookBlock(exe string) string {
+	command := []string{shellQuote(exe)}
+	if globalOpts.ConfigPath != "" {
+		command = append(command, "--config", shellQuote(globalOpts.ConfigPath))
+	}
+	if globalOpts.Context != "" {
+		command = append(command, "--context", shellQuote(globalOpts.Context))
+	}
+	return hookBegin + "\n" +
+		"# logs #time directives of the commit, remove with `tlog hook uninstall`\n" +
+		strings.Join(command, " ") + " hook run >/dev/null 2>&1 </dev/null &\n" +
+		hookEnd + "\n"
+}
+
+// installHook returns hook content with block added. Existing hook that is
+// not tlog one is kept and block goes right after its shebang, but only if
+// forced, since it was not expected to run anything else.
+func installHook(content, block string, force bool) (string, error) {
+	if strings.TrimSpace(content) == "" {
+		return "#!/bin/sh\n" + block, nil
+	}
+	if rest, ok := removeHookBlock(content); ok {
+		// reinstall, e.g. tlog moved
+		content = rest
+		force = true
+	}
+	shebang, body, _ := strings.Cut(content, "\n")
+	if !strings.HasPrefix(shebang, "#!") || !strings.Contains(shebang, "sh") {
+		return "", errors.New("it is not a shell script, call `tlog hook run &` from it yourself")
+	}
+	if !force {
+		return "", errors.New("it exists already, pass --force to add tlog to it")
+	}
+	return shebang + "\n" + block + body, nil
+}
+
+// removeHookBlock returns hook content without tlog block, false if there is none.
+func removeHookBlock(content string) (string, bool) {
+	start := strings.Index(content, hookBegin)
+	if start < 0 {
+		return content, false
+	}
+	end := strings.Index(content[start:], hookEnd)
+	if end < 0 {
+		return content, false
+	}
+	end += start + len(hookEnd)
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return content[:start] + content[end:], true
+}
+
+func runHookInstall(args []string) error {
+	flags := flag.NewFlagSet("hook install", flag.ContinueOnError)
+	force := flags.Bool("force", false, "add tlog to existing post-commit hook")
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+	path, err := postCommitHookPath()
+	if err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate tlog executable: %w", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	updated, err := installHook(string(content), hookBlock(exe), *force)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := state.WriteFileAtomic(path, []byte(updated), 0755); err != nil {
+		return err
+	}
+	pterm.Success.Printfln("Installed %s, commits with `ISSUE-1 #time 1h` are logged on commit", path)
+	return nil
+}
+
+func runHookUninstall() error {
+	path, err := postCommitHookPath()
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		pterm.Info.Println("No post-commit hook installed")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rest, ok := removeHookBlock(string(content))
+	if !ok {
+		pterm.Info.Printfln("%s has no tlog in it", path)
+		return nil
+	}
+	shebang, body, _ := strings.Cut(rest, "\n")
+	if strings.HasPrefix(shebang, "#!") && strings.TrimSpace(body) == "" {
+		// nothing but tlog was there
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		pterm.Success.Printfln("Removed %s", path)
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := state.WriteFileAtomic(path, []byte(rest), info.Mode().Perm()); err != nil {
+		return err
+	}
+	pterm.Success.Printfln("Removed tlog from %s", path)
+	return nil
+}
+
+// runHookRun logs directives of the last commit, it is run by the hook.
+// Worklogs that fail are queued for `tlog sync` instead of being retried.
+func runHookRun() error {
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		return err
+	}
+	commits, err := gitLog(".", "-1", "HEAD")
+	if err != nil {
+		return err
+	}
+	imported, err := importedIDs(conf)
+	if err != nil {
+		return err
+	}
+	for _, d := range commitDirectives(conf, commits) {
+		if d.Problem != "" || imported[d.ImportID] {
+			continue
+		}
+		if err := recordWorklog(conf, d.Worklog, []string{d.ImportID}); err != nil {
+			if err == errSilent {
+				err = errors.New("worklog was not created")
+			}
+			if err := enqueueWorklog(conf, d.Worklog, []string{d.ImportID}, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
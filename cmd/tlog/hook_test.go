@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_installHook(t *testing.T) {
+	block := hookBlock("/usr/bin/tlog")
+	require.Contains(t, block, "'/usr/bin/tlog' hook run >/dev/null 2>&1 </dev/null &\n")
+
+	created, err := installHook("", block, false)
+	require.NoError(t, err)
+	require.Equal(t, "#!/bin/sh\n"+block, created)
+	reinstalled, err := installHook(created, hookBlock("/opt/tlog"), false)
+	require.NoError(t, err, "tlog hook is updated without --force")
+	require.Equal(t, "#!/bin/sh\n"+hookBlock("/opt/tlog"), reinstalled)
+
+	existing := "#!/usr/bin/env bash\nset -e\nmake lint\n"
+	_, err = installHook(existing, block, false)
+	require.ErrorContains(t, err, "--force")
+	chained, err := installHook(existing, block, true)
+	require.NoError(t, err)
+	require.Equal(t, "#!/usr/bin/env bash\n"+block+"set -e\nmake lint\n", chained)
+	_, err = installHook("#!/usr/bin/env python3\nprint()\n", block, true)
+	require.ErrorContains(t, err, "not a shell script")
+
+	removed, ok := removeHookBlock(chained)
+	require.True(t, ok)
+	require.Equal(t, existing, removed)
+	_, ok = removeHookBlock(existing)
+	require.False(t, ok)
+}
+
+func Test_shellQuote(t *testing.T) {
+	require.Equal(t, `'/home/o'\''brien/bin/tlog'`, shellQuote("/home/o'brien/bin/tlog"))
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+)
+
+// htmlReport is self-contained HTML page of rangeReport, nothing is loaded
+// from elsewhere, so that it can be mailed as is.
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Time report {{.From}} – {{.To}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; margin: 2em auto; max-width: 960px; padding: 0 1em; }
+h1 { font-size: 1.5em; font-weight: 600; }
+.cards { display: flex; gap: 1em; margin: 1.5em 0; }
+.card { flex: 1; border: 1px solid #ddd; border-radius: 6px; padding: 1em; }
+.card .label { color: #666; font-size: .85em; text-transform: uppercase; }
+.card .value { font-size: 1.8em; font-weight: 600; margin-top: .2em; }
+.ahead { color: #1a7f37; }
+.behind { color: #cf222e; }
+svg { width: 100%; height: auto; }
+svg .bar { fill: #4c8bf5; }
+svg .off { fill: #c8c8c8; }
+svg .target { stroke: #cf222e; stroke-dasharray: 4 3; }
+svg text { font-size: 9px; fill: #666; }
+table { border-collapse: collapse; width: 100%; margin-top: 1.5em; }
+th, td { text-align: left; padding: .4em .6em; border-bottom: 1px solid #eee; vertical-align: top; }
+th { cursor: pointer; user-select: none; border-bottom: 2px solid #ccc; }
+th:after { content: " ↕"; color: #bbb; }
+td.num { text-align: right; white-space: nowrap; }
+a { color: #0969da; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>Time report {{.From}} – {{.To}}</h1>
+<div class="cards">
+<div class="card"><div class="label">Logged</div><div class="value">{{.Logged}}</div></div>
+<div class="card"><div class="label">Target</div><div class="value">{{.Target}}</div></div>
+<div class="card"><div class="label">Delta</div><div class="value {{if .Behind}}behind{{else}}ahead{{end}}">{{.Delta}}</div></div>
+</div>
+<svg viewBox="0 0 {{.Chart.Width}} {{.Chart.Height}}" role="img" aria-label="Time logged per day">
+{{- range .Chart.Bars}}
+<rect class="{{if .Workday}}bar{{else}}off{{end}}" x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}"><title>{{.Day}}: {{.Time}}</title></rect>
+<text x="{{.LabelX}}" y="{{$.Chart.LabelY}}" text-anchor="middle">{{.Label}}</text>
+{{- end}}
+{{- if .Chart.TargetY}}
+<line class="target" x1="0" x2="{{.Chart.Width}}" y1="{{.Chart.TargetY}}" y2="{{.Chart.TargetY}}"/>
+{{- end}}
+</svg>
+<table id="detail">
+<thead><tr><th>Day</th><th>Issue</th><th>Time</th><th>Comment</th></tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr><td data-sort="{{.Started}}">{{.Day}}</td><td>{{if .Link}}<a href="{{.Link}}">{{.Issue}}</a>{{else}}{{.Issue}}{{end}}</td><td class="num" data-sort="{{.Seconds}}">{{.Time}}</td><td>{{.Comment}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+document.querySelectorAll("#detail th").forEach(function (th, column) {
+  var ascending = true;
+  th.addEventListener("click", function () {
+    var body = document.querySelector("#detail tbody");
+    var rows = Array.prototype.slice.call(body.rows);
+    var key = function (row) {
+      var cell = row.cells[column];
+      var sort = cell.getAttribute("data-sort");
+      return sort === null ? cell.textContent.toLowerCase() : Number(sort) || sort;
+    };
+    rows.sort(function (a, b) {
+      var x = key(a), y = key(b);
+      return (x < y ? -1 : x > y ? 1 : 0) * (ascending ? 1 : -1);
+    });
+    ascending = !ascending;
+    rows.forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// htmlBar is bar of a day in chart of htmlReport.
+type htmlBar struct {
+	Day, Time, Label    string
+	Workday             bool
+	X, Y, Width, Height float64
+	LabelX              float64
+}
+
+// htmlRow is worklog in detail table of htmlReport.
+type htmlRow struct {
+	Day, Issue, Time, Comment string
+	Link                      string
+	Started, Seconds          int64
+}
+
+// writeReportHTML writes report of worklogs to path as standalone HTML page.
+func writeReportHTML(path string, conf Config, report rangeReport, worklogs []Worklog) error {
+	const width, height, labels = 800, 200, 14
+	loc := conf.Location()
+	data := struct {
+		From, To, Logged, Target, Delta string
+		Behind                          bool
+		Chart                           struct {
+			Width, Height, LabelY, TargetY float64
+			Bars                           []htmlBar
+		}
+		Rows []htmlRow
+	}{
+		From: report.From, To: report.To,
+		Logged: formatHours(report.Logged), Target: formatHours(report.Target), Delta: formatSignedHours(report.Delta),
+		Behind: report.Delta < 0,
+	}
+
+	// bars are scaled to the longest day, or to workday if every day is shorter
+	scale := conf.Workday().Hours()
+	for _, d := range report.Days {
+		if d.Hours > scale {
+			scale = d.Hours
+		}
+	}
+	chart := &data.Chart
+	chart.Width, chart.Height, chart.LabelY = width, height+labels, height+labels-3
+	if scale > 0 && conf.Workday() > 0 {
+		chart.TargetY = height - height*conf.Workday().Hours()/scale
+	}
+	slot := float64(width) / float64(len(report.Days))
+	for i, d := range report.Days {
+		day, _ := time.ParseInLocation("2006-01-02", d.Day, loc)
+		bar := htmlBar{Day: day.Format("Mon 2006-01-02"), Time: parse.FormatDuration(d.Spent), Label: day.Format("2"), Workday: d.Workday}
+		bar.X, bar.Width, bar.LabelX = float64(i)*slot+slot*0.15, slot*0.7, float64(i)*slot+slot/2
+		if scale > 0 {
+			bar.Height = height * d.Hours / scale
+		}
+		bar.Y = height - bar.Height
+		chart.Bars = append(chart.Bars, bar)
+	}
+
+	sorted := append([]Worklog(nil), worklogs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Started.Before(sorted[j].Started) })
+	for _, wl := range sorted {
+		data.Rows = append(data.Rows, htmlRow{
+			Day: wl.Started.In(loc).Format("2006-01-02 15:04"), Issue: wl.Issue, Time: parse.FormatDuration(wl.Spent), Comment: wl.Comment,
+			Link: issueLink(conf, wl), Started: wl.Started.Unix(), Seconds: int64(wl.Spent.Seconds()),
+		})
+	}
+
+	var b bytes.Buffer
+	if err := htmlReport.Execute(&b, data); err != nil {
+		return fmt.Errorf("cannot render report: %w", err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("cannot write %s: %w", path, err)
+	}
+	return nil
+}
+
+// issueLink returns address of issue of worklog in JIRA, or of worklog
+// itself for other backends, empty if there is none.
+func issueLink(conf Config, wl Worklog) string {
+	switch conf.Backend {
+	case "", config.Jira, config.Tempo:
+		if conf.JiraURL != "" {
+			return strings.TrimSuffix(conf.JiraURL, "/") + "/browse/" + url.PathEscape(wl.Issue)
+		}
+	}
+	return wl.URL
+}
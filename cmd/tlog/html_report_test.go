@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeReportHTML(t *testing.T) {
+	conf := Config{Timezone: "UTC", WorkdayHours: 8, JiraURL: "https://jira.company.com/"}
+	from := time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC)
+	worklogs := []Worklog{
+		{Issue: "APP-1", Started: from.Add(9 * time.Hour), Spent: 4 * time.Hour, Comment: "<script>alert(1)</script>"},
+		{Issue: "APP-2", Started: from.AddDate(0, 0, 1).Add(9 * time.Hour), Spent: 8 * time.Hour},
+	}
+	path := filepath.Join(t.TempDir(), "report.html")
+	require.NoError(t, writeReportHTML(path, conf, rangeReportOf(conf, worklogs, from, to), worklogs))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	page := string(data)
+	require.Contains(t, page, `<div class="value ahead">&#43;4h</div>`, "saturday is off, its time is ahead of target")
+	require.Contains(t, page, `<a href="https://jira.company.com/browse/APP-1">APP-1</a>`)
+	require.Contains(t, page, `&lt;script&gt;alert(1)&lt;/script&gt;`, "comments are escaped")
+	// the longest day is 8h, the half of it is 100 of 200 high
+	require.Contains(t, page, `<rect class="bar" x="60" y="100" width="280" height="100">`)
+	require.Contains(t, page, `<rect class="off" x="460" y="0" width="280" height="200">`)
+	require.NotContains(t, page, `src="http`, "nothing is loaded from elsewhere")
+}
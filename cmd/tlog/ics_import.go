@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+// icsProperty is a content line of iCalendar, e.g. DTSTART;TZID=Europe/Berlin:20240304T090000.
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// icsEvent is a VEVENT of calendar.
+type icsEvent struct {
+	UID          string
+	Summary      string
+	Start, End   time.Time
+	AllDay       bool
+	Cancelled    bool
+	Declined     bool
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID time.Time
+}
+
+// readICSLines returns content lines of calendar with folded lines joined.
+func readICSLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseICSProperty splits content line into name, parameters and value.
+// Parameter values may be quoted and contain ":" and ";".
+func parseICSProperty(line string) icsProperty {
+	p := icsProperty{Params: map[string]string{}}
+	quoted := false
+	var parts []string
+	start := 0
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == ';':
+			parts = append(parts, line[start:i])
+			start = i + 1
+		case r == ':':
+			parts = append(parts, line[start:i])
+			p.Value = line[i+1:]
+			p.Name = strings.ToUpper(parts[0])
+			for _, param := range parts[1:] {
+				if k, v, ok := strings.Cut(param, "="); ok {
+					p.Params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+				}
+			}
+			return p
+		}
+	}
+	p.Name = strings.ToUpper(line)
+	return p
+}
+
+var icsUnescaper = strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+// parseICSTime parses DATE or DATE-TIME value. Times without zone are in loc.
+func parseICSTime(p icsProperty, loc *time.Location) (time.Time, bool, error) {
+	if p.Params["VALUE"] == "DATE" || len(p.Value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", p.Value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(p.Value, "Z") {
+		t, err := time.Parse("20060102T150405Z", p.Value)
+		return t, false, err
+	}
+	if tzid := p.Params["TZID"]; tzid != "" {
+		// zones like "W. Europe Standard Time" are not known, loc is the best guess then
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", p.Value, loc)
+	return t, false, err
+}
+
+var icsDurationRe = regexp.MustCompile(`^([+-])?P(?:([0-9]+)W)?(?:([0-9]+)D)?(?:T(?:([0-9]+)H)?(?:([0-9]+)M)?(?:([0-9]+)S)?)?$`)
+
+// parseICSDuration parses DURATION value, e.g. PT1H30M.
+func parseICSDuration(s string) (time.Duration, error) {
+	m := icsDurationRe.FindStringSubmatch(s)
+	if m == nil || s == "P" || s == "PT" {
+		return 0, fmt.Errorf("%q is not a duration", s)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if n, err := strconv.Atoi(m[i+2]); err == nil {
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// parseICS returns events of calendar. email identifies the user among attendees.
+func parseICS(r io.Reader, loc *time.Location, email string) ([]icsEvent, error) {
+	lines, err := readICSLines(r)
+	if err != nil {
+		return nil, err
+	}
+	var events []icsEvent
+	var ev *icsEvent
+	var duration time.Duration
+	depth := 0
+	for n, line := range lines {
+		p := parseICSProperty(line)
+		switch {
+		case p.Name == "BEGIN" && strings.EqualFold(p.Value, "VEVENT"):
+			ev, duration, depth = &icsEvent{}, 0, 0
+		case ev == nil:
+		case p.Name == "BEGIN":
+			// e.g. VALARM inside of event
+			depth++
+		case p.Name == "END" && depth > 0:
+			depth--
+		case depth > 0:
+		case p.Name == "END" && strings.EqualFold(p.Value, "VEVENT"):
+			if ev.End.IsZero() {
+				ev.End = ev.Start.Add(duration)
+				if ev.AllDay && duration == 0 {
+					ev.End = ev.Start.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *ev)
+			ev = nil
+		default:
+			if err := ev.set(p, loc, email, &duration); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %w", n+1, p.Name, err)
+			}
+		}
+	}
+	return events, nil
+}
+
+func (ev *icsEvent) set(p icsProperty, loc *time.Location, email string, duration *time.Duration) error {
+	var err error
+	switch p.Name {
+	case "UID":
+		ev.UID = p.Value
+	case "SUMMARY":
+		ev.Summary = strings.TrimSpace(icsUnescaper.Replace(p.Value))
+	case "DTSTART":
+		ev.Start, ev.AllDay, err = parseICSTime(p, loc)
+	case "DTEND":
+		ev.End, _, err = parseICSTime(p, loc)
+	case "DURATION":
+		*duration, err = parseICSDuration(p.Value)
+	case "STATUS":
+		ev.Cancelled = strings.EqualFold(p.Value, "CANCELLED")
+	case "RRULE":
+		ev.RRule = p.Value
+	case "RECURRENCE-ID":
+		ev.RecurrenceID, _, err = parseICSTime(p, loc)
+	case "EXDATE":
+		for _, v := range strings.Split(p.Value, ",") {
+			t, _, perr := parseICSTime(icsProperty{Params: p.Params, Value: v}, loc)
+			if perr != nil {
+				return perr
+			}
+			ev.ExDates = append(ev.ExDates, t)
+		}
+	case "ATTENDEE":
+		address := strings.TrimPrefix(strings.ToLower(p.Value), "mailto:")
+		if email != "" && address == strings.ToLower(email) {
+			ev.Declined = strings.EqualFold(p.Params["PARTSTAT"], "DECLINED")
+		}
+	}
+	return err
+}
+
+// expandEvents returns occurrences of events started in [from, to). Recurring
+// events are expanded, occurrences moved or changed by RECURRENCE-ID events
+// are replaced by them. Events with unsupported recurrence are skipped with warning.
+func expandEvents(events []icsEvent, from, to time.Time) []icsEvent {
+	overridden := map[string]bool{}
+	for _, ev := range events {
+		if !ev.RecurrenceID.IsZero() {
+			overridden[ev.UID+" "+ev.RecurrenceID.UTC().Format(time.RFC3339)] = true
+		}
+	}
+
+	var result []icsEvent
+	for _, ev := range events {
+		if ev.RRule == "" || !ev.RecurrenceID.IsZero() {
+			if !ev.Start.Before(from) && ev.Start.Before(to) {
+				result = append(result, ev)
+			}
+			continue
+		}
+		starts, err := recurrences(ev, to)
+		if err != nil {
+			pterm.Warning.Printfln("Event %q is skipped: %s", ev.Summary, err)
+			continue
+		}
+		for _, start := range starts {
+			if start.Before(from) || overridden[ev.UID+" "+start.UTC().Format(time.RFC3339)] {
+				continue
+			}
+			occurrence := ev
+			occurrence.Start, occurrence.End = start, start.Add(ev.End.Sub(ev.Start))
+			result = append(result, occurrence)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+	return result
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// recurrences returns starts of recurring event before until. Daily and
+// weekly rules are supported, they cover most of the meetings.
+func recurrences(ev icsEvent, until time.Time) ([]time.Time, error) {
+	rule := map[string]string{}
+	for _, part := range strings.Split(ev.RRule, ";") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			rule[strings.ToUpper(k)] = v
+		}
+	}
+	interval := 1
+	if v, ok := rule["INTERVAL"]; ok {
+		var err error
+		if interval, err = strconv.Atoi(v); err != nil || interval < 1 {
+			return nil, fmt.Errorf("invalid INTERVAL %q", v)
+		}
+	}
+	count := -1
+	if v, ok := rule["COUNT"]; ok {
+		var err error
+		if count, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid COUNT %q", v)
+		}
+	}
+	if v, ok := rule["UNTIL"]; ok {
+		t, _, err := parseICSTime(icsProperty{Value: v}, ev.Start.Location())
+		if err != nil {
+			return nil, fmt.Errorf("invalid UNTIL %q", v)
+		}
+		if t.Before(until) {
+			// UNTIL is inclusive
+			until = t.Add(time.Second)
+		}
+	}
+
+	days := map[time.Weekday]bool{}
+	switch rule["FREQ"] {
+	case "DAILY":
+	case "WEEKLY":
+		days[ev.Start.Weekday()] = rule["BYDAY"] == ""
+		for _, d := range strings.Split(rule["BYDAY"], ",") {
+			if wd, ok := icsWeekdays[strings.ToUpper(d)]; ok {
+				days[wd] = true
+			} else if d != "" {
+				return nil, fmt.Errorf("BYDAY %q is not supported", d)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("recurrence FREQ=%s is not supported", rule["FREQ"])
+	}
+
+	excluded := map[string]bool{}
+	for _, t := range ev.ExDates {
+		excluded[t.UTC().Format(time.RFC3339)] = true
+	}
+	var starts []time.Time
+	y, m, d := ev.Start.Date()
+	// weeks start on monday
+	offset := (int(ev.Start.Weekday()) + 6) % 7
+	for i := 0; count != 0; i++ {
+		// dates are counted in zone of the event, so it keeps its time of day over DST changes
+		start := time.Date(y, m, d+i, ev.Start.Hour(), ev.Start.Minute(), ev.Start.Second(), 0, ev.Start.Location())
+		if !start.Before(until) {
+			break
+		}
+		if rule["FREQ"] == "DAILY" && i%interval != 0 {
+			continue
+		}
+		if rule["FREQ"] == "WEEKLY" {
+			if (i+offset)/7%interval != 0 || !days[start.Weekday()] {
+				continue
+			}
+		}
+		count--
+		if !excluded[start.UTC().Format(time.RFC3339)] {
+			starts = append(starts, start)
+		}
+	}
+	return starts, nil
+}
+
+// meetingIssue finds issue of event: issue key in summary, then the first
+// matching rule, then Meetings.Alias.
+func meetingIssue(conf Config, summary string) (string, error) {
+	if key := issueKeyInTextRe.FindString(summary); key != "" {
+		return key, nil
+	}
+	for _, rule := range conf.Meetings.Rules {
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return "", fmt.Errorf("invalid Meetings.Rules match %q: %w", rule.Match, err)
+		}
+		if re.MatchString(summary) {
+			return resolveTask(conf, rule.Alias)
+		}
+	}
+	if conf.Meetings.Alias != "" {
+		return resolveTask(conf, conf.Meetings.Alias)
+	}
+	return "", nil
+}
+
+// icsImportEntries proposes worklogs for events, all-day, cancelled and
+// declined events are left out.
+func icsImportEntries(conf Config, events []icsEvent) ([]importEntry, error) {
+	var imported []importEntry
+	for _, ev := range events {
+		if ev.AllDay || ev.Cancelled || ev.Declined {
+			continue
+		}
+		issue, err := meetingIssue(conf, ev.Summary)
+		if err != nil {
+			return nil, err
+		}
+		e := importEntry{
+			Source: "calendar",
+			Worklog: Worklog{
+				Issue:   issue,
+				Started: ev.Start.In(conf.Location()),
+				Spent:   ev.End.Sub(ev.Start).Round(time.Minute),
+				Comment: ev.Summary,
+			},
+			ImportIDs: []string{"ics:" + ev.UID + "/" + ev.Start.UTC().Format(time.RFC3339)},
+		}
+		if e.Worklog.Spent <= 0 {
+			e.Problem = "event has no duration"
+		}
+		imported = append(imported, e)
+	}
+	return imported, nil
+}
+
+// parseImportDays parses "day" or "from..to" argument of import commands.
+func parseImportDays(conf Config, input string) (time.Time, time.Time, error) {
+	from, to, _ := strings.Cut(input, "..")
+	return importRange(conf, from, to)
+}
+
+func runImportICS(args []string) error {
+	flags := flag.NewFlagSet("import ics", flag.ContinueOnError)
+	opts := addImportFlags(flags)
+	opts.hint = "add Meetings.Alias or Meetings.Rules for them"
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return errSilent
+	}
+	if len(positional) == 0 || len(positional) > 2 {
+		return errors.New("Usage: tlog import ics <file> [day|from..to] [--yes] [--unmapped <alias>]")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	from, to, err := parseImportDays(conf, safeGet(positional, 1))
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(positional[0])
+	if err != nil {
+		return fmt.Errorf("cannot read calendar: %w", err)
+	}
+	defer f.Close()
+	events, err := parseICS(f, conf.Location(), conf.Meetings.AttendeeEmail(conf))
+	if err != nil {
+		return fmt.Errorf("%s: %w", positional[0], err)
+	}
+	entries, err := icsImportEntries(conf, expandEvents(events, from, to))
+	if err != nil {
+		return err
+	}
+
+	selected, err := reviewImport(conf, entries, *opts)
+	if err != nil {
+		return err
+	}
+	return submitImport(conf, selected, *opts)
+}
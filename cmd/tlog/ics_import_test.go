@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/stretchr/testify/require"
+)
+
+const testCalendar = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Daily standup
+DTSTART;TZID=Europe/Berlin:20240304T093000
+DTEND;TZID=Europe/Berlin:20240304T094500
+RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=6
+EXDATE;TZID=Europe/Berlin:20240306T093000
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+RECURRENCE-ID;TZID=Europe/Berlin:20240308T093000
+SUMMARY:Daily standup
+DTSTART;TZID=Europe/Berlin:20240308T110000
+DURATION:PT30M
+END:VEVENT
+BEGIN:VEVENT
+UID:review
+SUMMARY:PRJ-7 design review\, part 2
+DTSTART:20240305T130000Z
+DTEND:20240305T140000Z
+ATTENDEE;CN="Doe, Jane";PARTSTAT=ACCEPTED:mailto:jane@example.com
+BEGIN:VALARM
+TRIGGER:-PT15M
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:offsite
+SUMMARY:Offsite
+DTSTART;VALUE=DATE:20240305
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled
+SUMMARY:Planning
+STATUS:CANCELLED
+DTSTART:20240305T150000Z
+DTEND:20240305T160000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:declined
+SUMMARY:All hands
+DTSTART:20240306T150000Z
+DTEND:20240306T160000Z
+ATTENDEE;PARTSTAT=DECLINED:MAILTO:Jane@Example.com
+END:VEVENT
+BEGIN:VEVENT
+UID:1on1
+SUMMARY:1:1 with
+  manager
+DTSTART:20240307T100000Z
+DTEND:20240307T103000Z
+END:VEVENT
+END:VCALENDAR
+`
+
+func Test_icsImport(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	conf := Config{
+		Timezone:    "UTC",
+		JiraLogin:   "jane@example.com",
+		TaskAliases: map[string]string{"standup": "MEET-1", "meetings": "MEET-2"},
+		Meetings: config.Meetings{
+			Alias: "meetings",
+			Rules: []config.MeetingRule{{Match: "(?i)standup", Alias: "standup"}},
+		},
+	}
+	events, err := parseICS(strings.NewReader(strings.ReplaceAll(testCalendar, "\n", "\r\n")), conf.Location(), conf.Meetings.AttendeeEmail(conf))
+	require.NoError(t, err)
+	require.Len(t, events, 7)
+
+	from, to, err := parseImportDays(conf, "2024-03-04..2024-03-10")
+	require.NoError(t, err)
+	entries, err := icsImportEntries(conf, expandEvents(events, from, to))
+	require.NoError(t, err)
+
+	var got []string
+	for _, e := range entries {
+		wl := e.Worklog
+		got = append(got, strings.Join([]string{wl.Started.In(berlin).Format("01-02 15:04"), wl.Issue, parse.FormatDuration(wl.Spent), wl.Comment}, " "))
+	}
+	require.Equal(t, []string{
+		"03-04 09:30 MEET-1 15m Daily standup",
+		"03-05 14:00 PRJ-7 1h PRJ-7 design review, part 2",
+		"03-07 11:00 MEET-2 30m 1:1 with manager",
+		"03-08 11:00 MEET-1 30m Daily standup",
+	}, got, "excluded, moved, all-day, cancelled and declined events")
+
+	// the rest of the series is in the next week
+	from, to, err = parseImportDays(conf, "2024-03-11..2024-03-31")
+	require.NoError(t, err)
+	require.Len(t, expandEvents(events, from, to), 3, "COUNT limits occurrences, excluded ones included")
+}
+
+func Test_parseICSDuration(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"PT45S":   45 * time.Second,
+		"P1W":     7 * 24 * time.Hour,
+	} {
+		got, err := parseICSDuration(input)
+		require.NoError(t, err, input)
+		require.Equal(t, want, got, input)
+	}
+	_, err := parseICSDuration("PT")
+	require.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+)
+
+// Idempotency marker is a random number written with zero-width characters,
+// so it does not show in JIRA, between word joiners.
+const (
+	markerEdge = "\u2060"
+	markerZero = "\u200b"
+	markerOne  = "\u200c"
+)
+
+func newIdempotencyMarker() (string, error) {
+	random := make([]byte, 4)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	var marker strings.Builder
+	marker.WriteString(markerEdge)
+	for _, b := range random {
+		for bit := 7; bit >= 0; bit-- {
+			if b&(1<<bit) != 0 {
+				marker.WriteString(markerOne)
+			} else {
+				marker.WriteString(markerZero)
+			}
+		}
+	}
+	marker.WriteString(markerEdge)
+	return marker.String(), nil
+}
+
+// withoutMarker returns comment without idempotency marker at its end.
+func withoutMarker(comment string) string {
+	return strings.TrimRight(comment, markerEdge+markerZero+markerOne)
+}
+
+// isAmbiguous tells whether failed request may have been processed anyway:
+// it was sent, but response never came.
+func isAmbiguous(err error) bool {
+	var timeout backend.TimeoutError
+	return err != nil && !backend.IsDialError(err) &&
+		(errors.As(err, &timeout) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET))
+}
+
+// submitWorklog creates worklog, creation failed ambiguously is retried
+// only if the worklog did not appear in backend meanwhile.
+func submitWorklog(conf Config, b Backend, wl Worklog) (Worklog, error) {
+	_, retries, _, err := conf.RetryPolicy()
+	if err != nil {
+		return Worklog{}, err
+	}
+	var marker string
+	if conf.IdempotencyMarker {
+		if marker, err = newIdempotencyMarker(); err != nil {
+			return Worklog{}, err
+		}
+		wl.Comment += marker
+	}
+
+	// without marker the same worklogs logged before are told apart only
+	// by id, so they are remembered before the first attempt
+	existing, checkable := map[string]bool{}, true
+	if marker == "" {
+		matching, err := matchingWorklogs(conf, b, wl, marker)
+		if err != nil {
+			debugf("cannot list worklogs, creation of %s will not be checked: %s", wl.Issue, err)
+			checkable = false
+		}
+		for _, listed := range matching {
+			existing[listed.ID] = true
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		created, err := b.AddWorklog(wl)
+		if !isAmbiguous(err) {
+			return created, err
+		}
+		if checkable {
+			if found, ok := findSubmitted(conf, b, wl, marker, existing); ok {
+				debugf("worklog %s of %s was created despite: %s", found.ID, wl.Issue, err)
+				return found, nil
+			}
+		}
+		if attempt >= retries {
+			return created, err
+		}
+		debugf("worklog of %s was not created, retrying: %s", wl.Issue, err)
+	}
+}
+
+// findSubmitted looks for worklog among the ones of its day, skipping
+// existing ones.
+func findSubmitted(conf Config, b Backend, wl Worklog, marker string, existing map[string]bool) (Worklog, bool) {
+	matching, err := matchingWorklogs(conf, b, wl, marker)
+	if err != nil {
+		debugf("cannot check whether worklog was created: %s", err)
+		return Worklog{}, false
+	}
+	for _, listed := range matching {
+		if !existing[listed.ID] {
+			return listed, true
+		}
+	}
+	return Worklog{}, false
+}
+
+// matchingWorklogs returns worklogs of wl's day that may be wl. With marker
+// it is the one having it, otherwise the ones of the same time and comment,
+// backends keeping only date of worklog match by date.
+func matchingWorklogs(conf Config, b Backend, wl Worklog, marker string) ([]Worklog, error) {
+	y, m, d := wl.Started.In(conf.Location()).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, conf.Location())
+	worklogs, err := b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	var matching []Worklog
+	for _, listed := range worklogs {
+		if listed.Issue != wl.Issue {
+			continue
+		}
+		if marker != "" {
+			if strings.Contains(listed.Comment, marker) {
+				matching = append(matching, listed)
+			}
+			continue
+		}
+		started := listed.Started.Equal(day) || listed.Started.Truncate(time.Minute).Equal(wl.Started.Truncate(time.Minute))
+		spent := listed.Spent - wl.Spent
+		if started && spent < time.Minute && spent > -time.Minute && strings.TrimSpace(listed.Comment) == strings.TrimSpace(wl.Comment) {
+			matching = append(matching, listed)
+		}
+	}
+	return matching, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyBackend fails the first `failures` creations with err, worklog is
+// stored anyway if committed.
+type flakyBackend struct {
+	backend.MockBackend
+	failures  int
+	committed bool
+	err       error
+	calls     int
+}
+
+func (b *flakyBackend) AddWorklog(wl Worklog) (Worklog, error) {
+	b.calls++
+	if b.calls > b.failures {
+		return b.MockBackend.AddWorklog(wl)
+	}
+	if b.committed {
+		if _, err := b.MockBackend.AddWorklog(wl); err != nil {
+			return Worklog{}, err
+		}
+	}
+	return Worklog{}, fmt.Errorf("add worklog: %w", b.err)
+}
+
+func (b *flakyBackend) count(t *testing.T, day time.Time) int {
+	worklogs, err := b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	return len(worklogs)
+}
+
+func Test_submitWorklog(t *testing.T) {
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	wl := Worklog{Issue: "PRJ-1", Started: day.Add(9 * time.Hour), Spent: time.Hour, Comment: "review"}
+	timeout := backend.TimeoutError{Timeout: time.Second}
+	conf := Config{Timezone: "UTC", RetryBackoff: "0s"}
+	marked := Config{Timezone: "UTC", RetryBackoff: "0s", IdempotencyMarker: true}
+
+	// created, but response was lost
+	b := &flakyBackend{failures: 1, committed: true, err: timeout}
+	created, err := submitWorklog(conf, b, wl)
+	require.NoError(t, err)
+	require.Equal(t, "1", created.ID)
+	require.Equal(t, 1, b.calls, "worklog found is not created again")
+	require.Equal(t, 1, b.count(t, day))
+
+	// not created before timeout
+	b = &flakyBackend{failures: 1, err: timeout}
+	_, err = submitWorklog(conf, b, wl)
+	require.NoError(t, err)
+	require.Equal(t, 2, b.calls)
+	require.Equal(t, 1, b.count(t, day))
+
+	// refused for sure, e.g. with status, is not retried
+	b = &flakyBackend{failures: 1, err: errors.New("request failed. Status code: 400")}
+	_, err = submitWorklog(conf, b, wl)
+	require.ErrorContains(t, err, "Status code: 400")
+	require.Equal(t, 1, b.calls)
+
+	// the same worklog logged before is not taken for the one submitted
+	b = &flakyBackend{failures: 1, err: timeout}
+	_, err = b.MockBackend.AddWorklog(wl)
+	require.NoError(t, err)
+	created, err = submitWorklog(conf, b, wl)
+	require.NoError(t, err)
+	require.Equal(t, 2, b.calls, "worklog logged before is not the one submitted")
+	require.Equal(t, "2", created.ID)
+	require.Equal(t, 2, b.count(t, day))
+
+	b = &flakyBackend{failures: 1, committed: true, err: timeout}
+	_, err = b.MockBackend.AddWorklog(wl)
+	require.NoError(t, err)
+	created, err = submitWorklog(conf, b, wl)
+	require.NoError(t, err)
+	require.Equal(t, 1, b.calls)
+	require.Equal(t, "2", created.ID)
+	require.Equal(t, 2, b.count(t, day))
+
+	// the same worklog logged before is told apart by marker
+	b = &flakyBackend{failures: 1, err: timeout}
+	_, err = b.MockBackend.AddWorklog(wl)
+	require.NoError(t, err)
+	created, err = submitWorklog(marked, b, wl)
+	require.NoError(t, err)
+	require.Equal(t, 2, b.calls, "worklog without marker is not the one submitted")
+	require.Equal(t, 2, b.count(t, day))
+	require.True(t, strings.HasPrefix(created.Comment, "review"+markerEdge))
+	require.Len(t, []rune(strings.TrimPrefix(created.Comment, "review")), 34, "marker is 32 bits between edges")
+
+	b = &flakyBackend{failures: 1, committed: true, err: timeout}
+	created, err = submitWorklog(marked, b, wl)
+	require.NoError(t, err)
+	require.Equal(t, 1, b.calls)
+	require.Equal(t, "1", created.ID)
+
+	// retries are exhausted
+	b = &flakyBackend{failures: 10, err: timeout}
+	_, err = submitWorklog(Config{Timezone: "UTC", Retries: 1}, b, wl)
+	require.ErrorContains(t, err, "did not respond within 1s")
+	require.Equal(t, 2, b.calls)
+}
+
+func Test_isAmbiguous(t *testing.T) {
+	require.True(t, isAmbiguous(fmt.Errorf("add: %w", backend.TimeoutError{Timeout: time.Second})))
+	require.True(t, isAmbiguous(fmt.Errorf("add: %w", io.ErrUnexpectedEOF)))
+	require.False(t, isAmbiguous(nil))
+	require.False(t, isAmbiguous(&net.OpError{Op: "dial", Err: io.EOF}), "request was never sent")
+	require.False(t, isAmbiguous(errors.New("Tempo: 400 Bad Request")))
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+)
+
+// issueKeyInTextRe finds issue keys mentioned in free text, e.g. "PRJ-12 review".
+var issueKeyInTextRe = regexp.MustCompile(`\b[A-Z][A-Z0-9_]*-[0-9]+\b`)
+
+// importEntry is a time entry of another tracker proposed as a worklog.
+// Entry with no issue and no problem is not mapped, it can be assigned to an alias in review.
+type importEntry struct {
+	// where the entry belongs in the source, e.g. Clockify project, shown for review
+	Source  string
+	Worklog Worklog
+	// why the entry cannot be logged, e.g. timer is still running
+	Problem string
+	// ids of the entry in the source, e.g. "harvest:123", kept in the ledger
+	// so that entries are not imported twice
+	ImportIDs []string
+}
+
+// importOptions are flags shared by import commands.
+type importOptions struct {
+	yes bool
+	// alias or issue not mapped entries are logged to, asked for in review if not set
+	unmapped string
+	// sum entries of the same issue and day into one worklog
+	group bool
+	// how to map entries, shown when some are not mapped
+	hint string
+	// text or json, json prints nothing but the result
+	output string
+}
+
+func addImportFlags(flags *flag.FlagSet) *importOptions {
+	opts := &importOptions{}
+	flags.BoolVar(&opts.yes, "yes", false, "log proposed worklogs without asking")
+	flags.StringVar(&opts.unmapped, "unmapped", "", "alias or issue entries without issue are logged to")
+	flags.StringVar(&opts.output, "output", "text", "output format: text or json, json needs --yes")
+	return opts
+}
+
+// runImport imports time tracked elsewhere as worklogs.
+func runImport(args []string) error {
+	switch safeGet(args, 0) {
+	case "clockify":
+		return runImportClockify(args[1:])
+	case "toggl":
+		return runImportToggl(args[1:])
+	case "harvest":
+		return runImportHarvest(args[1:])
+	case "ics":
+		return runImportICS(args[1:])
+	case "gcal":
+		return runImportGCal(args[1:])
+	case "wakatime":
+		return runImportWakatime(args[1:])
+	default:
+		return errors.New("usage: tlog import clockify|harvest [--from <day>] [--to <day>] | toggl <detailed-report.csv> | ics <file> [day|from..to] | gcal|wakatime [day|from..to] [--yes] [--unmapped <alias>]")
+	}
+}
+
+// parseInterspersed parses flags that may follow positional arguments too,
+// and returns positional ones.
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// importRange parses --from and --to days of import, to is inclusive and
+// defaults to from. Returned range is [from, day after to).
+func importRange(conf Config, fromInput, toInput string) (time.Time, time.Time, error) {
+	from, err := convertToDay(fromInput, conf.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("--from: %w", err)
+	}
+	to := from
+	if toInput != "" {
+		if to, err = convertToDay(toInput, conf.Location()); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("--to: %w", err)
+		}
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--to %s is before --from %s", to.Format("2006-01-02"), from.Format("2006-01-02"))
+	}
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// mapImported finds issue of imported entry: issue key mentioned in text wins,
+// then the first of keys found in mapping, compared case-insensitively.
+// Mapping values are aliases or issues. Empty issue means entry is not mapped.
+func mapImported(conf Config, mapping map[string]string, text string, keys ...string) (string, error) {
+	if key := issueKeyInTextRe.FindString(text); key != "" {
+		return key, nil
+	}
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		for _, name := range sortedKeys(mapping) {
+			if strings.EqualFold(name, key) {
+				return resolveTask(conf, mapping[name])
+			}
+		}
+	}
+	return "", nil
+}
+
+// resolveImportTarget resolves alias or issue given for not mapped entries.
+func resolveImportTarget(conf Config, input string) (string, error) {
+	issue, err := resolveTask(conf, input)
+	if err != nil {
+		return "", err
+	}
+	if !parse.IsIssue(issue) {
+		return "", fmt.Errorf("%q is neither alias nor issue", input)
+	}
+	return issue, nil
+}
+
+// groupEntries sums entries of the same issue and day into one, started
+// at the earliest of them. Distinct comments are joined.
+func groupEntries(entries []importEntry) []importEntry {
+	var grouped []importEntry
+	index := map[string]int{}
+	for _, e := range entries {
+		wl := e.Worklog
+		key := wl.Issue + " " + wl.Started.Format("2006-01-02")
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(grouped)
+			e.ImportIDs = append([]string(nil), e.ImportIDs...)
+			grouped = append(grouped, e)
+			continue
+		}
+		g := &grouped[i]
+		g.Worklog.Spent += wl.Spent
+		if wl.Started.Before(g.Worklog.Started) {
+			g.Worklog.Started = wl.Started
+		}
+		if wl.Comment != "" && !containsString(strings.Split(g.Worklog.Comment, "; "), wl.Comment) {
+			g.Worklog.Comment = strings.Join(nonEmpty(g.Worklog.Comment, wl.Comment), "; ")
+		}
+		g.ImportIDs = append(g.ImportIDs, e.ImportIDs...)
+	}
+	return grouped
+}
+
+// importedIDs returns import ids recorded in the ledger.
+func importedIDs(conf Config) (map[string]bool, error) {
+	entries, err := backend.ReadLedger(conf)
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string]bool{}
+	for _, e := range entries {
+		for _, id := range e.ImportIDs {
+			ids[id] = true
+		}
+	}
+	return ids, nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// nonEmpty returns values that are not empty.
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func importRow(e importEntry) []string {
+	wl := e.Worklog
+	return []string{wl.Started.Format("2006-01-02 15:04"), parse.FormatDuration(wl.Spent), wl.Comment, e.Source}
+}
+
+// reviewImport shows entries that cannot be logged and not mapped ones,
+// which can be assigned to an alias in bulk, then proposed worklogs, and
+// asks to log them. Entries imported before are left out. It returns
+// entries to log.
+func reviewImport(conf Config, entries []importEntry, opts importOptions) ([]importEntry, error) {
+	switch opts.output {
+	case "", "text":
+	case "json":
+		if !opts.yes {
+			return nil, errors.New("--output json needs --yes, worklogs cannot be reviewed")
+		}
+		// review tables and progress would break the JSON
+		pterm.DisableOutput()
+	default:
+		return nil, fmt.Errorf("unknown output format %q, text or json expected", opts.output)
+	}
+	imported, err := importedIDs(conf)
+	if err != nil {
+		return nil, err
+	}
+	var selected []importEntry
+	seen := 0
+	skipped := pterm.TableData{{"Day", "Time", "Comment", "Source", "Problem"}}
+	unmapped := pterm.TableData{{"Day", "Time", "Comment", "Source"}}
+	var unmappedIdx []int
+	var unmappedTotal time.Duration
+	for i, e := range entries {
+		switch {
+		case anyImported(e.ImportIDs, imported):
+			seen++
+		case e.Problem != "":
+			skipped = append(skipped, append(importRow(e), e.Problem))
+		case e.Worklog.Issue == "":
+			unmapped = append(unmapped, importRow(e))
+			unmappedIdx = append(unmappedIdx, i)
+			unmappedTotal += e.Worklog.Spent
+		default:
+			selected = append(selected, e)
+		}
+	}
+
+	if seen > 0 {
+		pterm.Info.Printfln("%d entries are imported already", seen)
+	}
+	if len(skipped) > 1 {
+		pterm.Warning.Printfln("%d entries cannot be logged:", len(skipped)-1)
+		if err := pterm.DefaultTable.WithHasHeader().WithData(skipped).Render(); err != nil {
+			return nil, err
+		}
+	}
+	if len(unmappedIdx) > 0 {
+		pterm.Warning.Printfln("%d entries, %s in total, have no issue, %s:", len(unmappedIdx), parse.FormatDuration(unmappedTotal), opts.hint)
+		if err := pterm.DefaultTable.WithHasHeader().WithData(unmapped).Render(); err != nil {
+			return nil, err
+		}
+		target := opts.unmapped
+		if target == "" && !opts.yes && stdinIsTerminal() {
+			prompt := promptui.Prompt{
+				Label: pterm.LightBlue("Alias or issue to log them to, empty to skip them"),
+				Validate: func(input string) error {
+					if input == "" {
+						return nil
+					}
+					_, err := resolveImportTarget(conf, input)
+					return err
+				},
+			}
+			var err error
+			if target, err = prompt.Run(); err != nil {
+				return nil, errSilent
+			}
+		}
+		if target != "" {
+			issue, err := resolveImportTarget(conf, target)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --unmapped: %w", err)
+			}
+			for _, i := range unmappedIdx {
+				e := entries[i]
+				e.Worklog.Issue = issue
+				selected = append(selected, e)
+			}
+		}
+	}
+	if opts.group {
+		selected = groupEntries(selected)
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return selected[i].Worklog.Started.Before(selected[j].Worklog.Started) })
+
+	if len(selected) == 0 {
+		pterm.Info.Println("Nothing to log")
+		return nil, nil
+	}
+	var total time.Duration
+	proposed := pterm.TableData{{"Day", "Issue", "Time", "Comment"}}
+	for _, e := range selected {
+		wl := e.Worklog
+		proposed = append(proposed, []string{wl.Started.Format("2006-01-02 15:04"), wl.Issue, parse.FormatDuration(wl.Spent), wl.Comment})
+		total += wl.Spent
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(proposed).Render(); err != nil {
+		return nil, err
+	}
+	if opts.yes {
+		return selected, nil
+	}
+	if !stdinIsTerminal() {
+		return nil, errors.New("pass --yes to log them without review")
+	}
+	confirmed, _ := pterm.DefaultInteractiveConfirm.Show(pterm.Sprintf("Log %d worklogs, %s in total?", len(selected), pterm.Yellow(parse.FormatDuration(total))))
+	if !confirmed {
+		return nil, errors.New("nothing is logged")
+	}
+	return selected, nil
+}
+
+func anyImported(ids []string, imported map[string]bool) bool {
+	for _, id := range ids {
+		if imported[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// submitImport logs entries, a failed one does not stop the rest.
+func submitImport(conf Config, entries []importEntry, opts importOptions) error {
+	result, err := logImported(conf, entries)
+	if err != nil {
+		return err
+	}
+	for _, item := range result.Items {
+		switch {
+		case item.Err == nil:
+			pterm.Success.Println(createdMessage(item.Output))
+			finishWorklog(conf, item.Output)
+		case !errors.Is(item.Err, backend.ErrInterrupted) && !errors.Is(item.Err, errSilent):
+			pterm.Error.Printfln("%s: %s", item.Input.Worklog.Issue, item.Err)
+		}
+	}
+
+	if opts.output == "json" {
+		out := bulkResult[hookInput, hookInput]{Items: make([]bulkItem[hookInput, hookInput], len(result.Items))}
+		for i, item := range result.Items {
+			out.Items[i] = bulkItem[hookInput, hookInput]{Input: newHookInput(conf, item.Input.Worklog), Output: newHookInput(conf, item.Output), Err: item.Err}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+	switch failed := result.Failed(); {
+	case result.Interrupted():
+		pterm.Warning.Printfln("Interrupted, %d of %d worklogs are not logged", failed, len(entries))
+		return shownError{backend.ErrInterrupted}
+	case failed > 0:
+		pterm.Error.Printfln("%d of %d worklogs are not logged", failed, len(entries))
+		return errSilent
+	}
+	return nil
+}
+
+// logImported creates worklogs of entries, conf.Parallelism() at once.
+// They are prepared one by one before, preparing may ask user.
+func logImported(conf Config, entries []importEntry) (bulkResult[importEntry, Worklog], error) {
+	result := bulkResult[importEntry, Worklog]{Items: make([]bulkItem[importEntry, Worklog], len(entries))}
+	if len(entries) == 0 {
+		return result, nil
+	}
+	b, err := backend.New(conf)
+	if err != nil {
+		return result, err
+	}
+	var prepared []importEntry
+	var idx []int
+	for i, e := range entries {
+		result.Items[i].Input = e
+		if err := prepareWorklog(conf, b, &e.Worklog); err != nil {
+			result.Items[i].Err = err
+			continue
+		}
+		prepared = append(prepared, e)
+		idx = append(idx, i)
+	}
+
+	spinner := startSpinner(fmt.Sprintf("Logging %d worklogs... (JIRA might be slow🐌)", len(prepared)))
+	backend.OnPacing = func(time.Duration) {
+		spinner.UpdateText(fmt.Sprintf("Logging %d worklogs... pacing to %g requests/s to spare JIRA", len(prepared), conf.RateLimitPerSecond()))
+	}
+	created := runBulk(backend.InterruptCtx, prepared, conf.Parallelism(), func(_ context.Context, e importEntry) (Worklog, error) {
+		// requests are aborted on Ctrl-C by themselves
+		return createWorklog(conf, b, e.Worklog, e.ImportIDs)
+	})
+	backend.OnPacing = nil
+	_ = spinner.Stop()
+	for j, item := range created.Items {
+		result.Items[idx[j]] = item
+	}
+	return result, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+
+	"atomicgo.dev/cursor"
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/pterm/pterm"
+	"golang.org/x/term"
+)
+
+// exitInterrupted is exit code of tlog aborted with Ctrl-C, as shells report SIGINT.
+const exitInterrupted = 130
+
+// handleInterrupt makes Ctrl-C abort requests in flight, so command fails with
+// backend.ErrInterrupted instead of being killed mid-way. Without requests tlog exits right away.
+func handleInterrupt() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	backend.InterruptCtx = ctx
+	go func() {
+		<-ctx.Done()
+		stop() // second Ctrl-C kills tlog
+		if atomic.LoadInt32(&backend.InFlight) == 0 {
+			exitAborted()
+		}
+	}()
+}
+
+// exitAborted tidies the terminal up and exits with exitInterrupted.
+func exitAborted() {
+	stopLive()
+	fmt.Println("aborted")
+	os.Exit(exitInterrupted)
+}
+
+var (
+	liveMu sync.Mutex
+	// spinner shown at the moment, if any
+	liveSpinner *pterm.SpinnerPrinter
+)
+
+// startSpinner starts spinner that is removed if tlog is aborted while it spins.
+// Without terminal, e.g. piped or in tests, it is not started: nothing would
+// see it spin, and Success or Fail print their message all the same.
+func startSpinner(text string) *pterm.SpinnerPrinter {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return pterm.DefaultSpinner.WithText(text)
+	}
+	spinner, _ := pterm.DefaultSpinner.Start(text)
+	liveMu.Lock()
+	liveSpinner = spinner
+	liveMu.Unlock()
+	return spinner
+}
+
+// stopLive removes spinner still spinning and shows cursor hidden by
+// interactive prompts.
+func stopLive() {
+	liveMu.Lock()
+	spinner := liveSpinner
+	liveSpinner = nil
+	liveMu.Unlock()
+	if spinner != nil && spinner.IsActive {
+		spinner.RemoveWhenDone = true
+		_ = spinner.Stop()
+	}
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		cursor.Show()
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/pterm/pterm"
+)
+
+const (
+	issueCacheFile = "issues.json"
+	// summaries rarely change, a day old one is still recognizable
+	defaultIssueCacheTTL = 24 * time.Hour
+)
+
+// cachedIssue is issue as it was the last time it was fetched.
+type cachedIssue struct {
+	Summary   string    `json:"summary"`
+	Status    string    `json:"status,omitempty"`
+	Type      string    `json:"type,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+	// key of epic, empty without one, nil if it was never looked up
+	Epic          *string    `json:"epic,omitempty"`
+	EpicFetchedAt *time.Time `json:"epic_fetched_at,omitempty"`
+}
+
+func (c cachedIssue) issue(key string) Issue {
+	return Issue{Key: key, Summary: c.Summary, Status: c.Status, Type: c.Type}
+}
+
+// issueCacheTTL returns how long cached issue is used before it is fetched again.
+func issueCacheTTL(conf Config) (time.Duration, error) {
+	if conf.IssueCacheTTL == "" {
+		return defaultIssueCacheTTL, nil
+	}
+	d, err := time.ParseDuration(conf.IssueCacheTTL)
+	if err != nil || d < 0 {
+		return 0, config.Error{Field: "IssueCacheTTL", Err: fmt.Errorf("invalid IssueCacheTTL %q in config: duration like 24h expected, 0 disables cache", conf.IssueCacheTTL)}
+	}
+	return d, nil
+}
+
+// loadIssueCache returns cached issues by issue key, stale ones included.
+// Cache is never worth failing for, unreadable one is empty.
+func loadIssueCache(conf Config) map[string]cachedIssue {
+	cached := map[string]cachedIssue{}
+	path, err := state.CachePath(conf.Context(), issueCacheFile)
+	if err != nil {
+		return cached
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			debugf("cannot read issue cache: %s", err)
+		}
+		return cached
+	}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		debugf("issue cache is corrupted, ignoring it: %s", err)
+		return map[string]cachedIssue{}
+	}
+	return cached
+}
+
+func saveIssueCache(conf Config, cached map[string]cachedIssue) error {
+	path, err := state.CachePath(conf.Context(), issueCacheFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	return state.WriteFileAtomic(path, data, 0600)
+}
+
+// cacheIssues stores issues fetched anyway, e.g. by search, so that their
+// summaries are not fetched again.
+func cacheIssues(conf Config, issues []Issue) {
+	if len(issues) == 0 {
+		return
+	}
+	cached := loadIssueCache(conf)
+	now := time.Now()
+	for _, issue := range issues {
+		// epics are looked up separately, see lookupEpics
+		prev := cached[issue.Key]
+		cached[issue.Key] = cachedIssue{Summary: issue.Summary, Status: issue.Status, Type: issue.Type, FetchedAt: now, Epic: prev.Epic, EpicFetchedAt: prev.EpicFetchedAt}
+	}
+	if err := saveIssueCache(conf, cached); err != nil {
+		debugf("cannot save issue cache: %s", err)
+	}
+}
+
+// cachedSummaries returns summaries of the cache, however old, without fetching anything.
+func cachedSummaries(conf Config) map[string]string {
+	summaries := map[string]string{}
+	for key, c := range loadIssueCache(conf) {
+		summaries[key] = c.Summary
+	}
+	return summaries
+}
+
+// lookupIssues returns given issues, fetching from backend the ones that
+// are not cached or are older than IssueCacheTTL. Issue that cannot be
+// fetched is the stale one if cached, otherwise it is missing.
+func lookupIssues(conf Config, keys []string) map[string]Issue {
+	cached := loadIssueCache(conf)
+	ttl, err := issueCacheTTL(conf)
+	if err != nil {
+		ttl = defaultIssueCacheTTL
+	}
+
+	issues := map[string]Issue{}
+	var missing []string
+	for _, key := range keys {
+		c, ok := cached[key]
+		if ok {
+			issues[key] = c.issue(key)
+		}
+		if !ok || time.Since(c.FetchedAt) >= ttl {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 || !conf.HasBackend() {
+		return issues
+	}
+
+	b, err := backend.New(conf)
+	if err != nil {
+		return issues
+	}
+	var fetched []Issue
+	for _, key := range missing {
+		issue, err := b.GetIssue(key)
+		if err != nil {
+			debugf("cannot fetch %s: %s", key, err)
+			continue
+		}
+		issue.Key = key
+		issues[key] = issue
+		fetched = append(fetched, issue)
+	}
+	cacheIssues(conf, fetched)
+	return issues
+}
+
+// issueSummaries returns summaries of given issues, see lookupIssues.
+// Issues that cannot be fetched get empty summary.
+func issueSummaries(conf Config, keys []string) map[string]string {
+	summaries := map[string]string{}
+	for key, issue := range lookupIssues(conf, keys) {
+		summaries[key] = issue.Summary
+	}
+	return summaries
+}
+
+// lookupEpics returns key of epic of given issues, empty for issues without
+// one, like lookupIssues does with issues. All the missing ones are looked
+// up at once. Without backend knowing epics every issue has none.
+func lookupEpics(conf Config, keys []string) (map[string]string, error) {
+	cached := loadIssueCache(conf)
+	ttl, err := issueCacheTTL(conf)
+	if err != nil {
+		ttl = defaultIssueCacheTTL
+	}
+
+	epics := map[string]string{}
+	var missing []string
+	for _, key := range keys {
+		c := cached[key]
+		if c.Epic != nil {
+			epics[key] = *c.Epic
+		}
+		if c.Epic == nil || c.EpicFetchedAt == nil || time.Since(*c.EpicFetchedAt) >= ttl {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 || !conf.HasBackend() {
+		return epics, nil
+	}
+	b, err := backend.New(conf)
+	if err != nil {
+		return nil, err
+	}
+	finder, ok := b.(backend.EpicFinder)
+	if !ok {
+		return nil, fmt.Errorf("%s backend has no epics, JIRA does", conf.Backend)
+	}
+	fetched, err := finder.IssueEpics(missing)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for key, epic := range fetched {
+		epics[key] = epic
+		c := cached[key]
+		c.Epic, c.EpicFetchedAt = toPtr(epic), &now
+		cached[key] = c
+	}
+	if err := saveIssueCache(conf, cached); err != nil {
+		debugf("cannot save issue cache: %s", err)
+	}
+	return epics, nil
+}
+
+// runCache manages the local cache of issues.
+func runCache(args []string) error {
+	switch safeGet(args, 0) {
+	case "clear":
+		return runCacheClear()
+	default:
+		return errors.New("usage: tlog cache clear")
+	}
+}
+
+// runCacheClear removes cache of every context, it is fetched again when needed.
+func runCacheClear() error {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return fmt.Errorf("cannot obtain cache dir: %w", err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "tlog")); err != nil {
+		return fmt.Errorf("clear cache: %w", err)
+	}
+	pterm.Success.Println("Cache cleared")
+	return nil
+}
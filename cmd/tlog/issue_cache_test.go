@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_issueSummaries(t *testing.T) {
+	isolateUserDirs(t)
+	conf := Config{Backend: config.Mock}
+	path, err := state.ContextPath(conf.Context(), backend.MockFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(`{"issues": {"PRJ-1": "Login", "PRJ-2": "Signup"}}`), 0600))
+
+	now := time.Now()
+	require.NoError(t, saveIssueCache(conf, map[string]cachedIssue{
+		"PRJ-1": {Summary: "Old login", FetchedAt: now.Add(-time.Hour)},
+		"PRJ-2": {Summary: "Old signup", Status: "Open", FetchedAt: now.Add(-48 * time.Hour)},
+		"PRJ-3": {Summary: "Gone", FetchedAt: now.Add(-48 * time.Hour)},
+	}))
+
+	summaries := issueSummaries(conf, []string{"PRJ-1", "PRJ-2", "PRJ-3", "PRJ-4"})
+	require.Equal(t, map[string]string{"PRJ-1": "Old login", "PRJ-2": "Signup", "PRJ-3": "Gone"}, summaries, "stale one is refetched, the one that cannot be is kept")
+	cached := loadIssueCache(conf)
+	require.Equal(t, "Signup", cached["PRJ-2"].Summary)
+	require.Empty(t, cached["PRJ-2"].Status)
+	require.WithinDuration(t, time.Now(), cached["PRJ-2"].FetchedAt, time.Minute)
+	require.NotContains(t, cached, "PRJ-4")
+
+	conf.IssueCacheTTL = "0"
+	require.Equal(t, "Login", issueSummaries(conf, []string{"PRJ-1"})["PRJ-1"])
+
+	path, err = state.CachePath(conf.Context(), issueCacheFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("{broken"), 0600))
+	require.Equal(t, map[string]string{"PRJ-2": "Signup"}, issueSummaries(conf, []string{"PRJ-2"}), "broken cache is as good as none")
+
+	require.NoError(t, runCacheClear())
+	require.NoFileExists(t, path)
+	require.Empty(t, cachedSummaries(conf))
+}
+
+func Test_issueCacheTTL(t *testing.T) {
+	ttl, err := issueCacheTTL(Config{})
+	require.NoError(t, err)
+	require.Equal(t, defaultIssueCacheTTL, ttl)
+	ttl, err = issueCacheTTL(Config{IssueCacheTTL: "1h"})
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, ttl)
+	_, err = issueCacheTTL(Config{IssueCacheTTL: "-1h"})
+	require.ErrorContains(t, err, "invalid IssueCacheTTL")
+}
+
+func Test_jiraBackend_IssueEpics(t *testing.T) {
+	isolateUserDirs(t)
+	var searches []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/field":
+			w.Write([]byte(`[{"id": "summary", "name": "Summary", "schema": {}}, {"id": "customfield_10008", "name": "Epic Link", "schema": {"custom": "com.pyxis.greenhopper.jira:gh-epic-link"}}]`))
+		case "/rest/api/2/search":
+			require.Equal(t, "parent,issuetype,customfield_10008", r.URL.Query().Get("fields"))
+			require.Equal(t, "warn", r.URL.Query().Get("validateQuery"))
+			searches = append(searches, r.URL.Query().Get("jql"))
+			if len(searches) == 1 {
+				w.Write([]byte(`{"issues": [
+					{"key": "APP-1", "fields": {"customfield_10008": "APP-100"}},
+					{"key": "NEW-2", "fields": {"customfield_10008": null, "parent": {"key": "NEW-1", "fields": {"issuetype": {"name": "Epic", "hierarchyLevel": 1}}}}},
+					{"key": "APP-3", "fields": {"parent": {"key": "APP-4", "fields": {"issuetype": {"name": "Story"}}}}},
+					{"key": "APP-5", "fields": {"customfield_10008": null}}
+				]}`))
+				return
+			}
+			w.Write([]byte(`{"issues": [{"key": "APP-4", "fields": {"customfield_10008": "APP-200"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	conf := Config{JiraURL: srv.URL, JiraLogin: "me", JiraPassword: "secret"}
+	keys := []string{"APP-1", "NEW-2", "APP-3", "APP-5", "GONE-1"}
+	epics, err := lookupEpics(conf, keys)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"APP-1": "APP-100", "NEW-2": "NEW-1", "APP-3": "APP-200", "APP-5": ""}, epics, "sub-task gets epic of its parent")
+	require.Equal(t, []string{"key in (APP-1,NEW-2,APP-3,APP-5,GONE-1)", "key in (APP-4)"}, searches)
+
+	epics, err = lookupEpics(conf, keys[:4])
+	require.NoError(t, err)
+	require.Equal(t, "APP-200", epics["APP-3"])
+	require.Len(t, searches, 2, "epics are cached")
+}
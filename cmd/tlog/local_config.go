@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/pterm/pterm"
+)
+
+// loadLocalConfig decodes repository config, if there is one.
+func loadLocalConfig() (Config, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return Config{}, nil
+	}
+	path := config.FindLocal(wd)
+	if path == "" {
+		return Config{}, nil
+	}
+
+	local, err := config.Decode(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, key := range []string{"JiraLogin", "JiraPassword"} {
+		if local.Origin(key) != "default" {
+			pterm.Warning.WithWriter(os.Stderr).Printfln(
+				"%s contains %s, such files tend to get committed. Keep credentials in your user config.", path, key,
+			)
+		}
+	}
+	if len(local.Profiles) > 0 {
+		return Config{}, errors.New(path + ": profiles can only be defined in user config")
+	}
+	return local, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+)
+
+// hookInput is what hook gets on stdin, bulk operations print worklogs
+// the same way with --output json.
+type hookInput struct {
+	Issue     string    `json:"issue"`
+	Started   time.Time `json:"started"`
+	Seconds   int       `json:"seconds"`
+	Day       string    `json:"day"`
+	Comment   string    `json:"comment"`
+	WorklogID string    `json:"worklog_id,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	URL       string    `json:"url,omitempty"`
+}
+
+func newHookInput(conf Config, wl Worklog) hookInput {
+	return hookInput{
+		Issue:     wl.Issue,
+		Started:   wl.Started,
+		Seconds:   int(wl.Spent.Seconds()),
+		Day:       wl.Started.In(conf.Location()).Format("2006-01-02"),
+		Comment:   wl.Comment,
+		WorklogID: wl.ID,
+		Author:    wl.Author,
+		URL:       wl.URL,
+	}
+}
+
+// runLogHook runs hook of given name, e.g. "PreLog", with worklog as input.
+// Errors name the hook, so its failure is not mistaken for tlog one.
+func runLogHook(conf Config, name, path string, wl Worklog) error {
+	timeout, err := conf.Hooks.RunTimeout()
+	if err != nil {
+		return err
+	}
+	if path, err = state.ExpandHome(path); err != nil {
+		return err
+	}
+	input := newHookInput(conf, wl)
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stdout
+	// file rather than pipe, so that children left by killed hook do not hold tlog
+	stderr, err := os.CreateTemp("", "tlog-hook-*.stderr")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(stderr.Name())
+	defer stderr.Close()
+	cmd.Stderr = stderr
+	cmd.Env = append(os.Environ(),
+		"TLOG_ISSUE="+input.Issue,
+		"TLOG_SECONDS="+strconv.Itoa(input.Seconds),
+		"TLOG_DAY="+input.Day,
+		"TLOG_WORKLOG_ID="+input.WorklogID,
+	)
+	err = cmd.Run()
+	output, _ := os.ReadFile(stderr.Name())
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%s hook %s did not finish within %s, raise Hooks.Timeout if it is just slow", name, path, timeout)
+	case err != nil:
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("%s hook %s failed (%s): %s", name, path, err, message)
+		}
+		return fmt.Errorf("%s hook %s failed: %s", name, path, err)
+	}
+	os.Stderr.Write(output)
+	return nil
+}
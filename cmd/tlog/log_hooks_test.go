@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+// hookScript creates executable shell script running body.
+func hookScript(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are shell scripts")
+	}
+	path := filepath.Join(t.TempDir(), "hook")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0700))
+	return path
+}
+
+func Test_logHooks(t *testing.T) {
+	isolateUserDirs(t)
+	out := filepath.Join(t.TempDir(), "out")
+	conf := Config{Backend: config.Mock, Timezone: "UTC", Hooks: config.Hooks{
+		PostLog: hookScript(t, `cat > `+out+`; echo "$TLOG_ISSUE $TLOG_SECONDS $TLOG_DAY $TLOG_WORKLOG_ID" >> `+out),
+	}}
+	wl := Worklog{Issue: "PRJ-1", Started: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), Spent: time.Hour, Comment: "review"}
+	require.NoError(t, addWorklog(conf, wl))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var input hookInput
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	require.NoError(t, decoder.Decode(&input))
+	require.Equal(t, hookInput{Issue: "PRJ-1", Started: wl.Started, Seconds: 3600, Day: "2024-03-04", Comment: "review", WorklogID: "1", Author: "mock"}, input)
+	require.Contains(t, string(data), "PRJ-1 3600 2024-03-04 1\n")
+
+	conf.Hooks.PreLog = hookScript(t, `echo "$TLOG_ISSUE is closed" >&2; exit 3`)
+	err = addWorklog(conf, wl)
+	require.ErrorContains(t, err, "worklog is not logged: PreLog hook")
+	require.ErrorContains(t, err, "exit status 3): PRJ-1 is closed")
+	entries, err := backend.ReadLedger(conf)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "vetoed worklog is not logged")
+
+	conf.Hooks = config.Hooks{PostLog: hookScript(t, "exit 1")}
+	require.NoError(t, addWorklog(conf, wl), "failed PostLog does not fail logging")
+
+	conf.Hooks = config.Hooks{PreLog: hookScript(t, "exec sleep 5"), Timeout: "100ms"}
+	started := time.Now()
+	require.ErrorContains(t, addWorklog(conf, wl), "PreLog hook "+conf.Hooks.PreLog+" did not finish within 100ms")
+	require.Less(t, time.Since(started), 3*time.Second)
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+)
+
+// actions offered for worklog picked in `tlog ls --interactive`
+const (
+	actionComment = "Edit comment"
+	actionEditor  = "Edit comment in $EDITOR"
+	actionTime    = "Change time"
+	actionDelete  = "Delete"
+	actionBack    = "Back"
+	lsDone        = "Done"
+)
+
+// runLs lists worklogs of the current month, or of range given like for
+// imports, of one task only if it is given. With --interactive a picked
+// worklog can be edited or deleted, the table is listed again after that.
+func runLs(args []string) error {
+	flags := flag.NewFlagSet("ls", flag.ContinueOnError)
+	interactive := flags.Bool("interactive", false, "pick worklogs to edit or delete")
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 2 {
+		return errors.New("Usage: tlog ls [task] [day|from..to] [--interactive] [--copy]")
+	}
+	if *interactive && !stdinIsTerminal() {
+		return errors.New("--interactive needs terminal to pick worklogs in")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+	issue, days, err := lsArgs(conf, positional)
+	if err != nil {
+		return err
+	}
+	from, to, err := reportRange(conf, days)
+	if err != nil {
+		return err
+	}
+	b, err := backend.New(conf)
+	if err != nil {
+		return err
+	}
+	list := func() ([]Worklog, error) {
+		worklogs, err := listWorklogs(b, from, to)
+		return worklogsOf(worklogs, issue), err
+	}
+
+	if *interactive {
+		return browseWorklogs(conf, b, list)
+	}
+	worklogs, err := list()
+	if err != nil {
+		return err
+	}
+	if len(worklogs) == 0 {
+		pterm.Info.Println("No worklogs")
+		return nil
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(lsRows(conf, worklogs)).Render()
+}
+
+// lsArgs tells task from range among arguments of ls, either may be left
+// out. Alias named like a day is the alias.
+func lsArgs(conf Config, args []string) (string, string, error) {
+	var issue, days string
+	for _, arg := range args {
+		_, isAlias := conf.Aliases()[arg]
+		if _, _, err := parseImportDays(conf, arg); err == nil && !isAlias && days == "" {
+			days = arg
+			continue
+		}
+		if issue != "" {
+			return "", "", fmt.Errorf("%q is not a day or range, e.g. 03.01..03.31", arg)
+		}
+		var err error
+		if issue, err = resolveTask(conf, arg); err != nil {
+			return "", "", err
+		}
+	}
+	return issue, days, nil
+}
+
+// worklogsOf returns worklogs of issue, all of them if issue is empty.
+func worklogsOf(worklogs []Worklog, issue string) []Worklog {
+	if issue == "" {
+		return worklogs
+	}
+	var of []Worklog
+	for _, wl := range worklogs {
+		if strings.EqualFold(wl.Issue, issue) {
+			of = append(of, wl)
+		}
+	}
+	return of
+}
+
+// lsRows renders worklogs as table with total, in order backend listed them
+// and numbered for `tlog split`.
+func lsRows(conf Config, worklogs []Worklog) [][]string {
+	rows := [][]string{{"#", "Day", "Start", "Issue", "Time", "Comment"}}
+	var total time.Duration
+	for i, wl := range worklogs {
+		started := wl.Started.In(conf.Location())
+		rows = append(rows, []string{"#" + strconv.Itoa(i+1), started.Format("Mon 01.02"), started.Format("15:04"), wl.Issue, parse.FormatDuration(wl.Spent), wl.Comment})
+		total += wl.Spent
+	}
+	return append(rows, []string{"", "Total", "", "", parse.FormatDuration(total), ""})
+}
+
+// lsItem is worklog as a line to pick, comment without line breaks.
+func lsItem(conf Config, wl Worklog) string {
+	started := wl.Started.In(conf.Location())
+	comment := strings.Join(strings.Fields(wl.Comment), " ")
+	if comment == "" {
+		comment = "(no comment)"
+	}
+	return fmt.Sprintf("%s  %s  %-6s  %s", started.Format("Mon 01.02 15:04"), wl.Issue, parse.FormatDuration(wl.Spent), comment)
+}
+
+// browseWorklogs prints worklogs and lets user pick one to act upon until
+// Done is picked or prompt is left with Ctrl+C. Failed actions are reported
+// and browsing goes on.
+func browseWorklogs(conf Config, b Backend, list func() ([]Worklog, error)) error {
+	for {
+		worklogs, err := list()
+		if err != nil {
+			return err
+		}
+		if len(worklogs) == 0 {
+			pterm.Info.Println("No worklogs")
+			return nil
+		}
+		if err := pterm.DefaultTable.WithHasHeader().WithData(lsRows(conf, worklogs)).Render(); err != nil {
+			return err
+		}
+		items := []string{lsDone}
+		for _, wl := range worklogs {
+			items = append(items, lsItem(conf, wl))
+		}
+		idx, _, err := (&promptui.Select{
+			Label:        pterm.LightBlue("Which worklog?"),
+			Items:        items,
+			HideSelected: true,
+			Size:         10,
+			Searcher: func(input string, i int) bool {
+				return strings.Contains(strings.ToLower(items[i]), strings.ToLower(input))
+			},
+		}).Run()
+		if err != nil || idx == 0 {
+			return nil
+		}
+		var shown shownError
+		if err := worklogAction(conf, b, worklogs[idx-1]); err != nil && !errors.As(err, &shown) {
+			pterm.Error.Println(err)
+		}
+	}
+}
+
+// worklogAction asks what to do with wl and does it, leaving the prompts
+// changes nothing.
+func worklogAction(conf Config, b Backend, wl Worklog) error {
+	actions := []string{actionComment, actionEditor, actionTime, actionDelete, actionBack}
+	idx, _, err := (&promptui.Select{Label: pterm.LightBlue(lsItem(conf, wl)), Items: actions, HideSelected: true}).Run()
+	if err != nil {
+		return nil
+	}
+	changed := wl
+	switch actions[idx] {
+	case actionComment:
+		prompt := promptui.Prompt{Label: pterm.LightBlue("Comment"), Default: wl.Comment, AllowEdit: true}
+		if changed.Comment, err = prompt.Run(); err != nil {
+			return nil
+		}
+	case actionEditor:
+		if changed.Comment, err = editText(wl.Comment); err != nil {
+			return err
+		}
+	case actionTime:
+		prompt := promptui.Prompt{
+			Label:     pterm.LightBlue("Time"),
+			Default:   parse.FormatDuration(wl.Spent),
+			AllowEdit: true,
+			Validate: func(input string) error {
+				_, err := convertToTimeLog(input, conf.Workday())
+				return err
+			},
+		}
+		input, err := prompt.Run()
+		if err != nil {
+			return nil
+		}
+		if changed.Spent, err = convertToTimeLog(input, conf.Workday()); err != nil {
+			return err
+		}
+	case actionDelete:
+		confirmed, _ := pterm.DefaultInteractiveConfirm.Show(pterm.Sprintf("Delete %s of %s?", pterm.Yellow(parse.FormatDuration(wl.Spent)), wl.Issue))
+		if !confirmed {
+			return nil
+		}
+		return deleteWorklog(conf, b, wl)
+	default:
+		return nil
+	}
+	if changed.Comment == wl.Comment && changed.Spent == wl.Spent {
+		pterm.Info.Println("Nothing changed")
+		return nil
+	}
+	_, err = updateWorklog(conf, b, changed)
+	return err
+}
+
+// updateWorklog replaces worklog with given Issue and ID in backend, and
+// its entry in the ledger, which status and reminders count.
+func updateWorklog(conf Config, b Backend, wl Worklog) (Worklog, error) {
+	wl.Started = localStart(conf, wl.Started)
+	spinner := startSpinner("Updating worklog...")
+	updated, err := b.UpdateWorklog(wl)
+	if err != nil {
+		spinner.Fail(err.Error())
+		return Worklog{}, shownError{err}
+	}
+	spinner.Success(fmt.Sprintf("Updated worklog on issue %s: %s", wl.Issue, formatSpent(updated.Spent)))
+	wl.Spent = updated.Spent
+	if err := backend.UpdateLedgerWorklog(conf, wl); err != nil {
+		pterm.Warning.Printfln("Worklog updated, but local ledger is not: %s", err)
+	}
+	return updated, nil
+}
+
+// deleteWorklog removes worklog from backend and from the ledger.
+func deleteWorklog(conf Config, b Backend, wl Worklog) error {
+	spinner := startSpinner("Deleting worklog...")
+	if err := b.DeleteWorklog(wl.Issue, wl.ID); err != nil {
+		spinner.Fail(err.Error())
+		return shownError{err}
+	}
+	spinner.Success(fmt.Sprintf("Deleted worklog of %s on issue %s", formatSpent(wl.Spent), wl.Issue))
+	if err := backend.RemoveLedgerWorklog(conf, wl.Issue, wl.ID); err != nil {
+		pterm.Warning.Printfln("Worklog deleted, but local ledger is not updated: %s", err)
+	}
+	return nil
+}
+
+// editText opens text in editor and returns it as saved, without trailing
+// line breaks editors add.
+func editText(text string) (string, error) {
+	f, err := os.CreateTemp("", "tlog-comment-*.txt")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	_, err = f.WriteString(text)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := openEditor(path); err != nil {
+		return "", err
+	}
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(edited), "\r\n"), nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_lsArgs(t *testing.T) {
+	conf := Config{TaskAliases: map[string]string{"review": "INT-24", "monday": "INT-7"}}
+	tests := []struct {
+		args        []string
+		issue, days string
+	}{
+		{nil, "", ""},
+		{[]string{"APP-12"}, "APP-12", ""},
+		{[]string{"review", "03.01..03.31"}, "INT-24", "03.01..03.31"},
+		{[]string{"03.04", "APP-12"}, "APP-12", "03.04"},
+		{[]string{"monday"}, "INT-7", ""},
+	}
+	for _, tt := range tests {
+		issue, days, err := lsArgs(conf, tt.args)
+		require.NoError(t, err, tt.args)
+		require.Equal(t, tt.issue, issue, tt.args)
+		require.Equal(t, tt.days, days, tt.args)
+	}
+
+	_, _, err := lsArgs(conf, []string{"APP-1", "APP-2"})
+	require.EqualError(t, err, `"APP-2" is not a day or range, e.g. 03.01..03.31`)
+}
+
+func Test_lsRows(t *testing.T) {
+	conf := Config{Timezone: "UTC"}
+	worklogs := []Worklog{
+		{Issue: "APP-1", Started: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), Spent: 90 * time.Minute, Comment: "review"},
+		{Issue: "OPS-2", Started: time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC), Spent: 30 * time.Minute},
+	}
+	require.Equal(t, [][]string{
+		{"#", "Day", "Start", "Issue", "Time", "Comment"},
+		{"#1", "Mon 03.04", "09:00", "APP-1", "1h30m", "review"},
+		{"#2", "Tue 03.05", "14:30", "OPS-2", "30m", ""},
+		{"", "Total", "", "", "2h", ""},
+	}, lsRows(conf, worklogs))
+	require.Equal(t, worklogs[1:], worklogsOf(worklogs, "ops-2"))
+	require.Equal(t, "Tue 03.05 14:30  OPS-2  30m     (no comment)", lsItem(conf, worklogs[1]))
+	require.Equal(t, "Mon 03.04 09:00  APP-1  1h30m   two lines", lsItem(conf, Worklog{Issue: "APP-1", Started: worklogs[0].Started, Spent: 90 * time.Minute, Comment: "two\nlines"}))
+}
+
+func Test_updateWorklog(t *testing.T) {
+	isolateUserDirs(t)
+	b := &backend.MockBackend{Author: "me"}
+	conf := Config{Timezone: "UTC"}
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	wl, err := createWorklog(conf, b, Worklog{Issue: "APP-1", Started: day, Spent: time.Hour, Comment: "reveiw"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, backend.AppendLedger(conf, backend.LedgerEntry{Issue: "APP-2", WorklogID: wl.ID, Started: day, Seconds: 600}))
+
+	wl.Comment, wl.Spent = "review", 2*time.Hour
+	_, err = updateWorklog(conf, b, wl)
+	require.NoError(t, err)
+	worklogs, err := b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, "review", worklogs[0].Comment)
+	entries, err := backend.ReadLedger(conf)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, []interface{}{"review", 7200}, []interface{}{entries[0].Comment, entries[0].Seconds}, "status counts the new time")
+	require.Equal(t, 600, entries[1].Seconds, "worklog of the same ID on other issue stays")
+
+	require.NoError(t, deleteWorklog(conf, b, wl))
+	worklogs, err = b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Empty(t, worklogs)
+	entries, err = backend.ReadLedger(conf)
+	require.NoError(t, err)
+	require.Equal(t, []string{"APP-2"}, []string{entries[0].Issue}, "deleted worklog is not counted")
+	require.Error(t, deleteWorklog(conf, b, wl))
+}
@@ -0,0 +1,645 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// errSilent signals failure that was already reported to the user.
+var errSilent = errors.New("silent error")
+
+// Types every command works with.
+type (
+	Config  = config.Config
+	Worklog = backend.Worklog
+	Backend = backend.Backend
+	Issue   = backend.Issue
+)
+
+func main() {
+	args, err := parseGlobalFlags(os.Args[1:])
+	if err != nil {
+		fail(os.Args[1:], err)
+	}
+	if globalOpts.Verbose {
+		backend.Debugf = debugf
+	}
+	setupConsole()
+
+	// these never need config, so they never start the setup wizard either
+	switch safeGet(args, 0) {
+	case "", "help", "--help", "-h":
+		printUsage()
+		return
+	case "version", "--version":
+		printVersion()
+		return
+	}
+
+	handleInterrupt()
+	handlePrompts()
+	switch args[0] {
+	case "start":
+		err = runStart(args[1:])
+	case "pause":
+		err = runPause()
+	case "resume":
+		err = runResume()
+	case "stop":
+		err = runStop(args[1:])
+	case "status":
+		err = runStatus(args[1:])
+	case "remind":
+		err = runRemind(args[1:])
+	case "config":
+		err = runConfig(args[1:])
+	case "context":
+		err = runContext(args[1:])
+	case "auth":
+		err = runAuth(args[1:])
+	case "sync-aliases":
+		err = runSyncAliases()
+	case "import":
+		err = runImport(args[1:])
+	case "suggest":
+		err = runSuggest(args[1:])
+	case "commits":
+		err = runCommits(args[1:])
+	case "hook":
+		err = runHook(args[1:])
+	case "sync":
+		err = runSync()
+	case "ls":
+		err = withCopy(args[1:], runLs)
+	case "vacation":
+		err = runVacation(args[1:])
+	case "sick":
+		err = runSick(args[1:])
+	case "standup":
+		err = withCopy(args[1:], runStandup)
+	case "fix":
+		err = runFix(args[1:])
+	case "fill-recurring":
+		err = runFillRecurring(args[1:])
+	case "edit":
+		err = runEdit(args[1:])
+	case "rm":
+		err = runRm(args[1:])
+	case "split":
+		err = runSplit(args[1:])
+	case "report":
+		err = withCopy(args[1:], runReport)
+	case "export":
+		err = runExport(args[1:])
+	case "chart":
+		err = runChart(args[1:])
+	case "balance":
+		err = runBalance(args[1:])
+	case "stats":
+		err = withCopy(args[1:], runStats)
+	case "cache":
+		err = runCache(args[1:])
+	case "setup":
+		err = runSetup(args[1:])
+	default:
+		if plugin, ok := findPlugin(args[0]); ok {
+			err = runPlugin(plugin, args[1:])
+		} else {
+			err = runLog(args)
+		}
+	}
+	if err != nil {
+		fail(args, err)
+	}
+}
+
+// fail reports error of command and exits, with --output json as JSON on
+// stderr, so that stdout holds nothing but the output.
+func fail(args []string, err error) {
+	if outputFormat(args) == "json" {
+		stopLive()
+		if werr := writeJSONError(os.Stderr, err); werr != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(exitCode(err))
+	}
+	if errors.Is(err, backend.ErrInterrupted) {
+		exitAborted()
+	}
+	if !errors.Is(err, errSilent) {
+		fmt.Println(err)
+	}
+	os.Exit(exitCode(err))
+}
+
+func printUsage() {
+	pterm.Println(pterm.Yellow("Usage: tlog [--config <path>] [--context <name>] [--verbose] [--no-notify] [--offline] <time> <task> [date|day] [comment] [--work-type <type>] [--attr <key>=<value>]"))
+	pterm.Println(pterm.Yellow("       tlog start [task] [comment] | pause | resume | stop [--yes] | status | remind"))
+	pterm.Println(pterm.Yellow("       tlog config show | edit | alias ..."))
+	pterm.Println(pterm.Yellow("       tlog context list | use <name>"))
+	pterm.Println(pterm.Yellow("       tlog auth set | migrate | login | logout | test"))
+	pterm.Println(pterm.Yellow("       tlog sync-aliases"))
+	pterm.Println(pterm.Yellow("       tlog import clockify|harvest [--from <day>] [--to <day>] | toggl <detailed-report.csv> | ics <file> [day|from..to] | gcal|wakatime [day|from..to] [--yes] [--unmapped <alias>]"))
+	pterm.Println(pterm.Yellow("       tlog suggest [day] | commits [range] [--dry-run]"))
+	pterm.Println(pterm.Yellow("       tlog hook install [--force] | uninstall"))
+	pterm.Println(pterm.Yellow("       tlog ls [task] [day|from..to] [--interactive] [--copy]"))
+	pterm.Println(pterm.Yellow("       tlog standup [--markdown] [--copy]"))
+	pterm.Println(pterm.Yellow("       tlog fill-recurring [day|from..to] [--dry-run]"))
+	pterm.Println(pterm.Yellow("       tlog vacation <from> [to] [--half] [--yes]"))
+	pterm.Println(pterm.Yellow("       tlog sick [day] [--half] [--yes]"))
+	pterm.Println(pterm.Yellow("       tlog fix [day]"))
+	pterm.Println(pterm.Yellow("       tlog edit <task> <#index|id> [--day <day>] [--time <duration>] [--comment <comment>]"))
+	pterm.Println(pterm.Yellow("       tlog rm --day <day> [--issue <task>] [--yes]"))
+	pterm.Println(pterm.Yellow("       tlog <time> split <task>=<percent|time>... [date|day] [comment]"))
+	pterm.Println(pterm.Yellow("       tlog split <task> <#index|id> <duration> [--to <task>] [--range <day|from..to>] [--yes]"))
+	pterm.Println(pterm.Yellow("       tlog stats projects [day|from..to] [--csv] [--output json] | habits [day|from..to] [--output json]"))
+	pterm.Println(pterm.Yellow("       tlog stats estimates [jql|project] [--mine] [--limit <issues>] [--output json]"))
+	pterm.Println(pterm.Yellow("       tlog report sprint [board] [--sprint <id>] [--all] [--csv|--markdown]"))
+	pterm.Println(pterm.Yellow("       tlog report billable [day|from..to] [--rate <amount>] [--output json]"))
+	pterm.Println(pterm.Yellow("       tlog report range <from> <to> [--force] [--tag <tag>] [--group-by epic [--flat]] [--output json|--xlsx <file>|--html <file> [--open]]"))
+	pterm.Println(pterm.Yellow("       tlog report tags [day|from..to] [--output json]"))
+	pterm.Println(pterm.Yellow("       tlog report team --users <user,...> [--jql <jql>] [--from <day>] [--to <day>] [--csv|--markdown|--output json]"))
+	pterm.Println(pterm.Yellow("       tlog export [day|from..to] [--preset <name>] [--file <path>]"))
+	pterm.Println(pterm.Yellow("       tlog chart [day|from..to] [--tag <tag>]"))
+	pterm.Println(pterm.Yellow("       tlog balance [--since <day>] [--output json]"))
+	pterm.Println(pterm.Yellow("       tlog sync"))
+	pterm.Println(pterm.Yellow("       tlog cache clear"))
+	pterm.Println(pterm.Yellow("       tlog setup [--url <url> --login <login> --password-env <variable> ...]"))
+	pterm.Println(pterm.Yellow("       tlog help | version"))
+	pterm.Println(pterm.Yellow("       tlog <plugin> [args] runs tlog-<plugin> from PATH"))
+}
+
+// Set by goreleaser.
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+func printVersion() {
+	v := version
+	if v == "dev" {
+		// go install knows module version
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			v = strings.TrimPrefix(info.Main.Version, "v")
+		}
+	}
+	if commit != "" {
+		v += fmt.Sprintf(" (%s, %s)", commit, date)
+	}
+	fmt.Println("tlog " + v)
+}
+
+// shownError is error already shown to user, e.g. by spinner. It is
+// errSilent for callers that only print errors, the cause is still there.
+type shownError struct{ err error }
+
+func (e shownError) Error() string        { return e.err.Error() }
+func (e shownError) Unwrap() error        { return e.err }
+func (e shownError) Is(target error) bool { return target == errSilent }
+
+// exitError makes tlog exit with specific code, so scripts can tell failures apart.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// globalOpts are options accepted by every command.
+var globalOpts struct {
+	// ConfigPath overrides config location, set by --config or TLOG_CONFIG
+	ConfigPath string
+	// FixPerms makes tlog restrict permissions of config readable by others
+	FixPerms bool
+	// Context selects config profile, set by --context or TLOG_CONTEXT
+	Context string
+	// Verbose enables diagnostic messages, set by --verbose
+	Verbose bool
+	// NoNotify skips NotifyWebhook, set by --no-notify
+	NoNotify bool
+	// Offline queues worklogs for `tlog sync` without trying to log them, set by --offline
+	Offline bool
+}
+
+// debugf prints diagnostic message to stderr when --verbose is given.
+// Secrets must never be passed here.
+func debugf(format string, args ...interface{}) {
+	if globalOpts.Verbose {
+		fmt.Fprintf(os.Stderr, "tlog: "+format+"\n", args...)
+	}
+}
+
+// parseGlobalFlags extracts global flags from args, wherever they are, and returns the rest.
+func parseGlobalFlags(args []string) ([]string, error) {
+	globalOpts.ConfigPath = os.Getenv("TLOG_CONFIG")
+	globalOpts.Context = os.Getenv("TLOG_CONTEXT")
+
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return append(rest, args[i:]...), nil
+		case arg == "--config":
+			if i+1 >= len(args) {
+				return nil, errors.New("--config requires path to config file")
+			}
+			globalOpts.ConfigPath = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--config="):
+			globalOpts.ConfigPath = strings.TrimPrefix(arg, "--config=")
+		case arg == "--context":
+			if i+1 >= len(args) {
+				return nil, errors.New("--context requires profile name")
+			}
+			globalOpts.Context = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--context="):
+			globalOpts.Context = strings.TrimPrefix(arg, "--context=")
+		case arg == "--fix-perms":
+			globalOpts.FixPerms = true
+		case arg == "--verbose":
+			globalOpts.Verbose = true
+		case arg == "--no-notify":
+			globalOpts.NoNotify = true
+		case arg == "--offline":
+			globalOpts.Offline = true
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest, nil
+}
+
+func runLog(args []string) error {
+	args, attrs, err := takeAttributes(args)
+	if err != nil {
+		return err
+	}
+	args, workType, err := takeWorkType(args)
+	if err != nil {
+		return err
+	}
+	// mistyped command must not start the setup wizard
+	if strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("unknown flag %s, see `tlog --help`", args[0])
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	if len(attrs) > 0 && conf.Backend != config.Tempo {
+		return errors.New("--attr sets Tempo work attributes, it needs tempo backend")
+	}
+
+	timeLogInput := args[0]
+	timeLog, err := convertToTimeLog(timeLogInput, conf.Workday())
+	if err != nil {
+		return err
+	}
+
+	taskInput := safeGet(args, 1)
+	template := Worklog{}
+	if workType != "" {
+		template.Attributes = map[string]string{backend.WorkTypeAttribute: workType}
+	}
+	if len(attrs) > 0 {
+		template.Attributes = attrs
+	}
+	if _, isAlias := conf.Aliases()[taskInput]; taskInput == splitTask && !isAlias {
+		return logShares(conf, timeLog, args[2:], template)
+	}
+	jiraID, err := resolveOrPickTask(conf, taskInput)
+	if err != nil {
+		return err
+	}
+
+	dayInput := safeGet(args, 2)
+	logDay, err := convertToDay(dayInput, conf.Location())
+	if err != nil {
+		return err
+	}
+	logDay = atStartTime(conf, logDay)
+
+	logComment := safeGet(args, 3)
+	if logComment == "" {
+		logComment = conf.AliasComment(taskInput)
+	}
+
+	wl := template
+	wl.Issue, wl.Started, wl.Spent, wl.Comment = jiraID, logDay, timeLog, logComment
+	return addWorklog(conf, wl)
+}
+
+// takeAttributes removes --attr key=value flags from log arguments, flags may be anywhere among them.
+func takeAttributes(args []string) ([]string, map[string]string, error) {
+	rest := make([]string, 0, len(args))
+	var attrs map[string]string
+	for i := 0; i < len(args); i++ {
+		var attr string
+		switch arg := args[i]; {
+		case arg == "--attr":
+			if i+1 >= len(args) {
+				return nil, nil, errors.New("--attr requires key=value")
+			}
+			attr = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--attr="):
+			attr = strings.TrimPrefix(arg, "--attr=")
+		default:
+			rest = append(rest, arg)
+			continue
+		}
+		key, value, ok := strings.Cut(attr, "=")
+		if !ok || key == "" {
+			return nil, nil, fmt.Errorf("--attr %q is not key=value, e.g. --attr _Account_=INTERNAL", attr)
+		}
+		if attrs == nil {
+			attrs = map[string]string{}
+		}
+		attrs[key] = value
+	}
+	return rest, attrs, nil
+}
+
+// takeWorkType removes --work-type flag from log arguments, flag may be anywhere among them.
+func takeWorkType(args []string) ([]string, string, error) {
+	rest := make([]string, 0, len(args))
+	var workType string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--work-type":
+			if i+1 >= len(args) {
+				return nil, "", errors.New("--work-type requires name of work type")
+			}
+			workType = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--work-type="):
+			workType = strings.TrimPrefix(arg, "--work-type=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	if len(rest) == 0 {
+		return nil, "", errors.New("time to log is required")
+	}
+	return rest, workType, nil
+}
+
+// warnOverrun warns when more than a workday is logged at day, which usually means a typo.
+func warnOverrun(conf Config, day time.Time) {
+	entries, err := backend.ReadLedger(conf)
+	if err != nil {
+		return
+	}
+	if logged := loggedOn(entries, day); logged > conf.Workday() {
+		pterm.Warning.Printfln("%s logged on %s, that is more than %s workday", parse.FormatDuration(logged), day.Format("2006-01-02"), parse.FormatDuration(conf.Workday()))
+	}
+}
+
+// addWorklog creates worklog in backend and records it in the local ledger.
+// Worklog is queued for `tlog sync` instead when offline, once it is logged,
+// worklogs queued before are offered to be synced.
+func addWorklog(conf Config, wl Worklog) error {
+	queued, err := logOrQueue(conf, wl)
+	if err != nil || queued {
+		return err
+	}
+	return offerSync(conf)
+}
+
+// logOrQueue is addWorklog without offering to sync, reporting whether the
+// worklog was queued.
+func logOrQueue(conf Config, wl Worklog) (bool, error) {
+	wl.Started = localStart(conf, wl.Started)
+	if globalOpts.Offline {
+		return true, queueOffline(conf, wl, errors.New("logged with --offline"))
+	}
+	err := recordWorklog(conf, wl, nil)
+	if backend.IsDialError(err) {
+		return true, queueOffline(conf, wl, err)
+	}
+	return false, err
+}
+
+// recordWorklog is addWorklog of imported worklog, importIDs are kept in the
+// ledger so that it is not imported again.
+func recordWorklog(conf Config, wl Worklog, importIDs []string) error {
+	b, err := backend.New(conf)
+	if err != nil {
+		return err
+	}
+	if err := prepareWorklog(conf, b, &wl); err != nil {
+		return err
+	}
+
+	spinner := startSpinner("Logging time... (JIRA might be slow🐌)")
+	backend.OnRetry = func(attempt, retries int, pause time.Duration) {
+		spinner.UpdateText(fmt.Sprintf("Logging time... retrying (%d/%d) in %s…", attempt, retries, formatPause(pause)))
+	}
+	created, err := createWorklog(conf, b, wl, importIDs)
+	backend.OnRetry = nil
+	if errors.Is(err, backend.ErrInterrupted) {
+		return err
+	}
+	if err != nil {
+		spinner.Fail(err.Error())
+		return shownError{err}
+	}
+	spinner.Success(createdMessage(created))
+	finishWorklog(conf, created)
+	return nil
+}
+
+// localStart returns started in Timezone, as worklogs are sent: JIRA takes
+// the day from the offset of the timestamp.
+func localStart(conf Config, started time.Time) time.Time {
+	return started.In(conf.Location())
+}
+
+// prepareWorklog completes worklog before it is created. It may ask user,
+// so worklogs are prepared one by one even in bulk.
+func prepareWorklog(conf Config, b Backend, wl *Worklog) error {
+	wl.Started = localStart(conf, wl.Started)
+	if p, ok := b.(backend.WorklogPreparer); ok {
+		if err := p.PrepareWorklog(wl); err != nil {
+			return err
+		}
+	}
+	if conf.Hooks.PreLog != "" {
+		if err := runLogHook(conf, "PreLog", conf.Hooks.PreLog, *wl); err != nil {
+			return fmt.Errorf("worklog is not logged: %w", err)
+		}
+	}
+	return nil
+}
+
+// createWorklog creates prepared worklog and records it in the ledger, it
+// prints nothing but warnings, so worklogs can be created in parallel.
+func createWorklog(conf Config, b Backend, wl Worklog, importIDs []string) (Worklog, error) {
+	created, err := submitWorklog(conf, b, wl)
+	if err != nil {
+		return Worklog{}, err
+	}
+	err = backend.AppendLedger(conf, backend.LedgerEntry{
+		Issue:     wl.Issue,
+		WorklogID: created.ID,
+		Started:   wl.Started,
+		Seconds:   int(created.Spent.Seconds()),
+		Comment:   wl.Comment,
+		LoggedAt:  time.Now(),
+		ImportIDs: importIDs,
+	})
+	if err != nil {
+		pterm.Warning.Printfln("Worklog created, but local ledger is not updated: %s", err)
+	}
+	created.Issue, created.Started, created.Comment = wl.Issue, wl.Started, wl.Comment
+	return created, nil
+}
+
+func createdMessage(created Worklog) string {
+	message := fmt.Sprintf("Created worklog as %s on issue %s for %s", created.Author, created.Issue, formatSpent(created.Spent))
+	if created.URL != "" {
+		message += ": " + created.URL
+	}
+	return message
+}
+
+// finishWorklog runs what follows creation of worklog. Worklog is there,
+// failures of hook and notification are not failures of logging.
+func finishWorklog(conf Config, created Worklog) {
+	warnOverrun(conf, created.Started)
+	if conf.Hooks.PostLog != "" {
+		if err := runLogHook(conf, "PostLog", conf.Hooks.PostLog, created); err != nil {
+			pterm.Warning.Printfln("Worklog created, but %s", err)
+		}
+	}
+	if conf.NotifyWebhook != "" && !globalOpts.NoNotify {
+		if err := notifyWebhook(conf, created); err != nil {
+			pterm.Warning.Printfln("Worklog created, but NotifyWebhook is not notified: %s", err)
+		}
+	}
+}
+
+func convertToTask(input string, defaultProject string, aliases map[string]string) (string, error) {
+	task, err := parse.Parser{DefaultProject: defaultProject, Aliases: aliases}.Task(input)
+	if errors.Is(err, parse.ErrNoDefaultProject) {
+		return "", config.Error{Field: "DefaultProject", Err: err}
+	}
+	return task, err
+}
+
+// jqlAlias prefixes alias value that is a query finding the issue, e.g.
+// "jql:project = OPS AND summary ~ standup ORDER BY created DESC".
+const jqlAlias = "jql:"
+
+// resolveTask is convertToTask with aliases of conf. Unknown alias gets a hint
+// when shared aliases might define it, but were never fetched.
+func resolveTask(conf Config, input string) (string, error) {
+	numeric := conf.Backend == config.Redmine || conf.Backend == config.AzureDevOps
+	if id := strings.TrimPrefix(input, "#"); numeric && parse.IsIssueID(id) {
+		if _, ok := conf.Aliases()[input]; !ok {
+			// issues are numbers already, DefaultProject does not apply
+			return id, nil
+		}
+	}
+	task, err := convertToTask(input, conf.DefaultProject, conf.Aliases())
+	if err == nil && strings.HasPrefix(task, jqlAlias) {
+		return resolveJQL(conf, input, strings.TrimSpace(strings.TrimPrefix(task, jqlAlias)))
+	}
+	if err != nil || task != input || parse.IsIssue(task) || conf.AliasesURL == "" {
+		return task, err
+	}
+	if cached, _ := loadRemoteAliases(conf); cached == nil {
+		return "", fmt.Errorf("unknown alias %q, shared aliases are not fetched yet, run `tlog sync-aliases`", input)
+	}
+	return task, nil
+}
+
+// resolveJQL returns the first issue query of alias finds, in the order the
+// query sets. Backends without JQL search with their own query language.
+func resolveJQL(conf Config, alias, query string) (string, error) {
+	b, err := backend.New(conf)
+	if err != nil {
+		return "", err
+	}
+	issues, err := b.SearchIssues(query)
+	if err != nil {
+		return "", fmt.Errorf("alias %q: %w", alias, err)
+	}
+	if len(issues) == 0 {
+		return "", fmt.Errorf("alias %q: no issue matches %s", alias, query)
+	}
+	return issues[0].Key, nil
+}
+
+// convertToDay returns start of the day described by input, in loc.
+func convertToDay(input string, loc *time.Location) (time.Time, error) {
+	return parse.Parser{Location: loc}.Day(input)
+}
+
+// convertToTimeLog parses duration like "1h30m". Days are also accepted as leading
+// part, e.g. "1d" or "0.5d2h", one day being workday long.
+func convertToTimeLog(inputTime string, workday time.Duration) (time.Duration, error) {
+	return parse.Parser{Workday: workday}.TimeLog(inputTime)
+}
+
+func toPtr[T any](v T) *T {
+	return &v
+}
+
+func safeGet(arr []string, index int) string {
+	if index >= len(arr) {
+		return ""
+	}
+	return arr[index]
+}
+
+// formatSpent renders logged time like formatDuration, but seconds that
+// JIRA keeps are shown too, so 90s is not mistaken for a minute.
+func formatSpent(d time.Duration) string {
+	d = d.Round(time.Second)
+	s := d % time.Minute / time.Second
+	switch {
+	case s == 0:
+		return parse.FormatDuration(d)
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", s)
+	default:
+		return fmt.Sprintf("%s%ds", parse.FormatDuration(d), s)
+	}
+}
+
+// atStartTime moves day start to DefaultStartTime, keeping wall clock time across DST changes.
+func atStartTime(conf Config, day time.Time) time.Time {
+	start, _ := conf.StartTime() // validated at load
+	y, m, d := day.Date()
+	return time.Date(y, m, d, int(start/time.Hour), int(start%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// formatPause formats pause before retry, to a second as it is a guess anyway.
+func formatPause(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// sameDay reports whether a falls on the same day as b, in b's location.
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.In(b.Location()).Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
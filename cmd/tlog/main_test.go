@@ -0,0 +1,329 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_convertToTimeLog(t *testing.T) {
+	tests := []struct {
+		name      string
+		inputTime string
+		want      time.Duration
+		wantErr   bool
+	}{
+		{name: "1h -> 1 hour", inputTime: "1h", want: time.Hour},
+		{name: "1 -> 1 hour", inputTime: "1h", want: time.Hour},
+		{name: "60m -> 60 minutes", inputTime: "60m", want: time.Hour},
+		{name: "30m -> 30 minutes", inputTime: "30m", want: 30 * time.Minute},
+		{name: "1d -> workday", inputTime: "1d", want: 7*time.Hour + 30*time.Minute},
+		{name: "0.5d2h -> half of workday and 2 hours", inputTime: "0.5d2h", want: 5*time.Hour + 45*time.Minute},
+		{name: "d -> error", inputTime: "d", wantErr: true},
+		{name: "1d2 -> error", inputTime: "1d2", wantErr: true},
+		{name: "ahaha -> error", inputTime: "ahaha", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertToTimeLog(tt.inputTime, 7*time.Hour+30*time.Minute)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_convertToTask(t *testing.T) {
+	aliases := map[string]string{"review": "INT-24", "bug": "group/app#7"}
+	tests := []struct {
+		input   string
+		project string
+		want    string
+		wantErr bool
+	}{
+		{input: "review", want: "INT-24"},
+		{input: "bug", want: "group/app#7"},
+		{input: "42", project: "INT", want: "INT-42"},
+		{input: "42", wantErr: true},
+		{input: "42", project: "group/app", want: "group/app#42"},
+		{input: "#42", project: "group/app", want: "group/app#42"},
+		{input: "#42", project: "INT", want: "#42"},
+		{input: "group/other#3", project: "group/app", want: "group/other#3"},
+		{input: "OPS-1", project: "group/app", want: "OPS-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input+" "+tt.project, func(t *testing.T) {
+			got, err := convertToTask(tt.input, tt.project, aliases)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_takeWorkType(t *testing.T) {
+	args, workType, err := takeWorkType([]string{"1h", "--work-type", "Testing", "PRJ-1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"1h", "PRJ-1"}, args)
+	require.Equal(t, "Testing", workType)
+
+	args, workType, err = takeWorkType([]string{"1h", "PRJ-1", "--work-type=Design"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"1h", "PRJ-1"}, args)
+	require.Equal(t, "Design", workType)
+
+	_, _, err = takeWorkType([]string{"1h", "--work-type"})
+	require.Error(t, err)
+}
+
+func Test_takeAttributes(t *testing.T) {
+	args, attrs, err := takeAttributes([]string{"1h", "--attr", "_Account_=ACC", "PRJ-1", "--attr=Billable=true"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"1h", "PRJ-1"}, args)
+	require.Equal(t, map[string]string{"_Account_": "ACC", "Billable": "true"}, attrs)
+
+	_, _, err = takeAttributes([]string{"1h", "--attr", "Billable"})
+	require.ErrorContains(t, err, "not key=value")
+	_, _, err = takeAttributes([]string{"1h", "--attr"})
+	require.Error(t, err)
+}
+
+func Test_convertToDay(t *testing.T) {
+	// far from UTC, so local and UTC dates differ most of the day
+	loc := time.FixedZone("UTC+14", 14*60*60)
+	now := time.Now().In(loc)
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: now},
+		{name: "today", input: "today", want: now},
+		{name: "yesterday", input: "yesterday", want: now.Add(-24 * time.Hour)},
+		{name: "monday", input: "monday", want: now.Add(daysDiff(time.Monday, now.Weekday()))},
+		{name: "tuesday", input: "tuesday", want: now.Add(daysDiff(time.Tuesday, now.Weekday()))},
+		{name: "wednesday", input: "wednesday", want: now.Add(daysDiff(time.Wednesday, now.Weekday()))},
+		{name: "thursday", input: "thursday", want: now.Add(daysDiff(time.Thursday, now.Weekday()))},
+		{name: "friday", input: "friday", want: now.Add(daysDiff(time.Friday, now.Weekday()))},
+		{name: "saturday", input: "saturday", want: now.Add(daysDiff(time.Saturday, now.Weekday()))},
+		{name: "sunday", input: "sunday", want: now.Add(daysDiff(time.Weekday(7), now.Weekday()))},
+		{name: "mm.dd", input: "04.20", want: time.Date(now.Year(), time.April, 20, 0, 0, 0, 0, loc)},
+		{name: "yyyy.mm.dd", input: "1999.04.20", want: time.Date(1999, time.April, 20, 0, 0, 0, 0, loc)},
+		{name: "nonsense", input: "someday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertToDay(tt.input, loc)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			y, m, d := tt.want.Date()
+			require.Equal(t, time.Date(y, m, d, 0, 0, 0, 0, loc), got)
+		})
+	}
+}
+
+func Test_atStartTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	day := time.Date(2022, time.March, 27, 0, 0, 0, 0, berlin) // clocks move forward at 2:00
+
+	require.Equal(t, day, atStartTime(Config{}, day))
+	require.Equal(t, time.Date(2022, time.March, 27, 9, 30, 0, 0, berlin), atStartTime(Config{DefaultStartTime: "09:30"}, day))
+	require.Equal(t, "2022-03-27T09:30:00+02:00", atStartTime(Config{DefaultStartTime: "09:30"}, day).Format(time.RFC3339))
+}
+
+func Test_sameDay(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	day := time.Date(2022, time.October, 10, 0, 0, 0, 0, berlin)
+
+	require.True(t, sameDay(time.Date(2022, time.October, 9, 22, 30, 0, 0, time.UTC), day))
+	require.True(t, sameDay(day.Add(23*time.Hour), day))
+	require.False(t, sameDay(time.Date(2022, time.October, 9, 21, 30, 0, 0, time.UTC), day))
+}
+
+func daysDiff(a, b time.Weekday) time.Duration {
+	diff := a - b
+	return time.Duration(diff*24) * time.Hour
+}
+
+func Test_formatSpent(t *testing.T) {
+	tests := []struct {
+		input time.Duration
+		want  string
+	}{
+		{input: 30 * time.Minute, want: "30m"},
+		{input: 90 * time.Second, want: "1m30s"},
+		{input: 45 * time.Second, want: "45s"},
+		{input: 2*time.Hour + 5*time.Second, want: "2h5s"},
+		{input: 2 * time.Hour, want: "2h"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			require.Equal(t, tt.want, formatSpent(tt.input))
+		})
+	}
+}
+
+func Test_parseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		env        string
+		want       []string
+		wantConfig string
+		wantErr    bool
+	}{
+		{name: "no flags", args: []string{"1h", "review"}, want: []string{"1h", "review"}},
+		{name: "config before command", args: []string{"--config", "work.toml", "status"}, want: []string{"status"}, wantConfig: "work.toml"},
+		{name: "config after command", args: []string{"1h", "review", "--config=work.toml"}, want: []string{"1h", "review"}, wantConfig: "work.toml"},
+		{name: "env", args: []string{"status"}, env: "env.toml", want: []string{"status"}, wantConfig: "env.toml"},
+		{name: "flag wins over env", args: []string{"--config", "work.toml"}, env: "env.toml", want: []string{}, wantConfig: "work.toml"},
+		{name: "after double dash", args: []string{"1h", "review", "--", "--config"}, want: []string{"1h", "review", "--", "--config"}},
+		{name: "missing value", args: []string{"status", "--config"}, wantErr: true},
+	}
+	t.Cleanup(func() { globalOpts.ConfigPath = "" })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TLOG_CONFIG", tt.env)
+
+			got, err := parseGlobalFlags(tt.args)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantConfig, globalOpts.ConfigPath)
+		})
+	}
+}
+
+func Test_runLog_beforeConfig(t *testing.T) {
+	isolateUserDirs(t)
+	old := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	t.Cleanup(func() { stdinIsTerminal = old })
+
+	require.EqualError(t, runLog([]string{"--hepl"}), "unknown flag --hepl, see `tlog --help`")
+	require.EqualError(t, runLog([]string{"--work-type", "dev"}), "time to log is required")
+	require.ErrorContains(t, runLog([]string{"1h", "PRJ-1"}), "tlog setup", "wizard is not started without terminal")
+}
+
+func Test_resolveTask_azureDevOps(t *testing.T) {
+	conf := Config{Backend: config.AzureDevOps, DefaultProject: "OPS", TaskAliases: map[string]string{"deploy": "77"}}
+	for input, want := range map[string]string{"123": "123", "#123": "123", "deploy": "77"} {
+		got, err := resolveTask(conf, input)
+		require.NoError(t, err)
+		require.Equal(t, want, got, input)
+	}
+}
+
+func Test_resolveTask_jql(t *testing.T) {
+	isolateUserDirs(t)
+	var jql string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jql = r.URL.Query().Get("jql")
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasPrefix(jql, "project = DONE") {
+			w.Write([]byte(`{"total": 0, "issues": []}`))
+			return
+		}
+		w.Write([]byte(`{"total": 2, "issues": [{"key": "OPS-7", "fields": {"summary": "Standup"}}, {"key": "OPS-3", "fields": {"summary": "Standup"}}]}`))
+	}))
+	defer srv.Close()
+
+	conf := Config{JiraURL: srv.URL, JiraLogin: "me", JiraPassword: "secret", TaskAliases: map[string]string{
+		"standup": "jql: project = OPS AND summary ~ standup ORDER BY created DESC",
+		"old":     "jql:project = DONE",
+	}}
+	got, err := resolveTask(conf, "standup")
+	require.NoError(t, err)
+	require.Equal(t, "OPS-7", got, "first issue found")
+	require.Equal(t, "project = OPS AND summary ~ standup ORDER BY created DESC", jql)
+
+	_, err = resolveTask(conf, "old")
+	require.EqualError(t, err, `alias "old": no issue matches project = DONE`)
+}
+
+func Test_jiraBackend_AddWorklog_author(t *testing.T) {
+	isolateUserDirs(t)
+	var response string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(response))
+	}))
+	defer srv.Close()
+
+	b, err := backend.New(Config{JiraURL: srv.URL, JiraLogin: "me", JiraPassword: "secret"})
+	require.NoError(t, err)
+	tests := []struct {
+		response string
+		want     string
+	}{
+		{response: `{"id": "1", "timeSpentSeconds": 5400}`, want: "me"},
+		{response: `{"id": "1", "author": {"accountId": "5b10a284"}, "timeSpentSeconds": 5400}`, want: "me"},
+		{response: `{"id": "1", "author": {"name": "jdoe", "emailAddress": "jane@example.com"}, "timeSpentSeconds": 5400}`, want: "jane@example.com"},
+		{response: `{"id": "1", "author": {"name": "jdoe", "displayName": "Jane Doe"}, "timeSpentSeconds": 5400}`, want: "Jane Doe"},
+	}
+	for _, tt := range tests {
+		response = tt.response
+		created, err := b.AddWorklog(Worklog{Issue: "INT-1", Started: time.Now(), Spent: 90 * time.Minute})
+		require.NoError(t, err)
+		require.Equal(t, tt.want, created.Author, tt.response)
+		require.Equal(t, "Created worklog as "+tt.want+" on issue INT-1 for 1h30m", createdMessage(created))
+	}
+}
+
+func Test_resolveTask_redmine(t *testing.T) {
+	conf := Config{Backend: config.Redmine, DefaultProject: "OPS", TaskAliases: map[string]string{"deploy": "77"}}
+	for input, want := range map[string]string{"123": "123", "#123": "123", "deploy": "77"} {
+		got, err := resolveTask(conf, input)
+		require.NoError(t, err)
+		require.Equal(t, want, got, input)
+	}
+}
+
+// isolateUserDirs points home, config and cache dirs of every OS to a temp
+// dir, so that the test neither sees nor touches real tlog state. It returns
+// the config dir.
+func isolateUserDirs(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	// macOS keeps both dirs in home, Windows reads the other variables
+	for key, dir := range map[string]string{
+		"HOME":            root,
+		"USERPROFILE":     root,
+		"XDG_CONFIG_HOME": filepath.Join(root, "config"),
+		"APPDATA":         filepath.Join(root, "config"),
+		"XDG_CACHE_HOME":  filepath.Join(root, "cache"),
+		"LOCALAPPDATA":    filepath.Join(root, "cache"),
+	} {
+		t.Setenv(key, dir)
+	}
+	dir, err := os.UserConfigDir()
+	require.NoError(t, err)
+	return dir
+}
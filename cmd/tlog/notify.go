@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// notify shows desktop notification using whatever the platform provides.
+func notify(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptQuote(message), appleScriptQuote(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		script := fmt.Sprintf(`
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode(%s)) | Out-Null
+$text.Item(1).AppendChild($xml.CreateTextNode(%s)) | Out-Null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('tlog').Show([Windows.UI.Notifications.ToastNotification]::new($xml))
+`, powerShellQuote(title), powerShellQuote(message))
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	default:
+		cmd = exec.Command("notify-send", "--app-name=tlog", title, message)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("send notification: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func appleScriptQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
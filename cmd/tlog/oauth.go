@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/pterm/pterm"
+)
+
+// runAuthLogin obtains OAuth tokens by sending user to Atlassian consent page in browser.
+func runAuthLogin() error {
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	if conf.OAuthClientID == "" || conf.OAuthClientSecret == "" {
+		return errors.New("OAuthClientID and OAuthClientSecret of OAuth app must be configured first, " +
+			"create app at https://developer.atlassian.com/console/myapps/ with callback URL " + backend.OAuthRedirectURL)
+	}
+	if conf.JiraURL == "" {
+		return errors.New("JiraURL must be configured first")
+	}
+
+	code, err := authorizationCode(backend.OAuthRedirectURL, func(state string) string {
+		return backend.OAuthAuthorizeURL + "?" + url.Values{
+			"audience":      {"api.atlassian.com"},
+			"client_id":     {conf.OAuthClientID},
+			"scope":         {backend.OAuthScopes},
+			"redirect_uri":  {backend.OAuthRedirectURL},
+			"state":         {state},
+			"response_type": {"code"},
+			"prompt":        {"consent"},
+		}.Encode()
+	})
+	if err != nil {
+		return err
+	}
+	token, err := backend.RequestToken(conf, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {backend.OAuthRedirectURL},
+	})
+	if err != nil {
+		return err
+	}
+	token.CloudID, err = backend.ResolveCloudID(conf, token.AccessToken)
+	if err != nil {
+		return err
+	}
+	if err := backend.SaveOAuthToken(conf, token); err != nil {
+		return err
+	}
+
+	if conf.AuthType != config.AuthOAuth {
+		err := updateContextConfig(func(p *config.Profile) error {
+			p.AuthType = config.AuthOAuth
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	pterm.Success.Printfln("Logged in to %s", conf.JiraURL)
+	return nil
+}
+
+// authorizationCode opens consent page built by authURL for given state and
+// waits for the provider to redirect back to redirectURL with code.
+func authorizationCode(redirectURL string, authURL func(state string) string) (string, error) {
+	stateBytes := make([]byte, 16)
+	if _, err := rand.Read(stateBytes); err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(stateBytes)
+
+	redirect, _ := url.Parse(redirectURL)
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return "", fmt.Errorf("listen for OAuth callback: %w", err)
+	}
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	// the first callback wins, reloaded tab must not block the handler
+	send := func(r result) {
+		select {
+		case results <- r:
+		default:
+		}
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != redirect.Path {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		switch {
+		case q.Get("state") != state:
+			http.Error(w, "state does not match", http.StatusBadRequest)
+			return
+		case q.Get("error") != "":
+			send(result{err: fmt.Errorf("authorization denied: %s", q.Get("error_description"))})
+		default:
+			send(result{code: q.Get("code")})
+		}
+		fmt.Fprintln(w, "tlog: you can close this tab now")
+	})}
+	go srv.Serve(listener)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}()
+
+	consentURL := authURL(state)
+	pterm.Info.Printfln("Opening browser to authorize tlog. If it does not open, visit:\n%s", consentURL)
+	if err := openBrowser(consentURL); err != nil {
+		pterm.Warning.Printfln("Cannot open browser: %s", err)
+	}
+
+	select {
+	case r := <-results:
+		return r.code, r.err
+	case <-time.After(5 * time.Minute):
+		return "", errors.New("timed out waiting for authorization")
+	case <-backend.InterruptCtx.Done():
+		return "", backend.ErrInterrupted
+	}
+}
+
+// openBrowser opens url in the default browser, a variable so tests do not.
+var openBrowser = func(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_authorizationCode_callbackTwice(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	redirectURL := "http://" + listener.Addr().String() + "/callback"
+	require.NoError(t, listener.Close())
+
+	old := openBrowser
+	t.Cleanup(func() { openBrowser = old })
+	openBrowser = func(consentURL string) error {
+		state := consentURL[len("state="):]
+		go func() {
+			for i := 0; i < 3; i++ {
+				resp, err := http.Get(redirectURL + "?state=" + state + "&code=code" + fmt.Sprint(i))
+				if err == nil {
+					resp.Body.Close()
+				}
+			}
+		}()
+		return nil
+	}
+
+	done := make(chan struct{})
+	var code string
+	go func() {
+		defer close(done)
+		code, err = authorizationCode(redirectURL, func(state string) string { return "state=" + state })
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("reloaded callback blocks authorization")
+	}
+	require.NoError(t, err)
+	require.Equal(t, "code0", code)
+}
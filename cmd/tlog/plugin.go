@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"regexp"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+)
+
+// pluginNameRe is what command has to look like to be looked up as plugin,
+// anything else, e.g. "1h30m", is time to log.
+var pluginNameRe = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// findPlugin returns path of executable tlog-<command> on PATH, like git
+// and kubectl do for commands they do not know. Time to log always wins,
+// so that plugin cannot take over e.g. `tlog day PRJ-1`.
+func findPlugin(command string) (string, bool) {
+	if !pluginNameRe.MatchString(command) {
+		return "", false
+	}
+	if _, err := convertToTimeLog(command, 8*time.Hour); err == nil {
+		return "", false
+	}
+	path, err := exec.LookPath("tlog-" + command)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// runPlugin runs plugin with the rest of arguments, telling it config
+// through TLOG_CONFIG, TLOG_CONTEXT and TLOG_OUTPUT. Plugin talks to the
+// user itself, tlog exits with its exit code.
+func runPlugin(path string, args []string) error {
+	configFile, err := configPath()
+	if err != nil {
+		return err
+	}
+	context := globalOpts.Context
+	// broken config must not stop plugin, it might be the one fixing it
+	if conf, err := LoadConfigQuiet(); err == nil {
+		context = conf.Context()
+	} else {
+		debugf("cannot load config for plugin: %s", err)
+	}
+	if context == "" {
+		context = config.DefaultContext
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"TLOG_CONFIG="+configFile,
+		"TLOG_CONTEXT="+context,
+		"TLOG_OUTPUT="+outputFormat(args),
+	)
+	debugf("running plugin %s", path)
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// plugin has told what is wrong already
+		return &exitError{code: exitErr.ExitCode(), err: errSilent}
+	}
+	return err
+}
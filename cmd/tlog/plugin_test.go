@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin is shell script")
+	}
+	configDir := isolateUserDirs(t)
+	bin := t.TempDir()
+	out := filepath.Join(t.TempDir(), "out")
+	script := "#!/bin/sh\necho \"$* $TLOG_CONFIG $TLOG_CONTEXT $TLOG_OUTPUT\" > " + out + "\nexit 4\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "tlog-timesheet-export"), []byte(script), 0700))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	path, ok := findPlugin("timesheet-export")
+	require.True(t, ok)
+	_, ok = findPlugin("1h30m")
+	require.False(t, ok, "time to log is never plugin")
+	_, ok = findPlugin("missing")
+	require.False(t, ok)
+
+	err := runPlugin(path, []string{"march", "--output", "json"})
+	require.Equal(t, 4, exitCode(err))
+	require.True(t, errors.Is(err, errSilent))
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	want := "march --output json " + filepath.Join(configDir, "tlog", "config.toml") + " default json"
+	require.Equal(t, want, strings.TrimSpace(string(data)))
+}
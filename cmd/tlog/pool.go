@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+)
+
+// bulkItem is outcome of a single item of bulk operation.
+type bulkItem[T, R any] struct {
+	Input  T
+	Output R
+	// nil if item succeeded, backend.ErrInterrupted if it was never started
+	Err error
+}
+
+func (i bulkItem[T, R]) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Input  T      `json:"input"`
+		Output *R     `json:"output,omitempty"`
+		Error  string `json:"error,omitempty"`
+		// see errorKind
+		ErrorKind string `json:"error_kind,omitempty"`
+	}{Input: i.Input}
+	if i.Err != nil {
+		out.Error = i.Err.Error()
+		out.ErrorKind = errorKind(i.Err)
+	} else {
+		out.Output = &i.Output
+	}
+	return json.Marshal(out)
+}
+
+// bulkResult is outcome of bulk operation, items are in order of input.
+type bulkResult[T, R any] struct {
+	Items []bulkItem[T, R]
+}
+
+// Failed returns number of items that failed or were not started.
+func (r bulkResult[T, R]) Failed() int {
+	failed := 0
+	for _, item := range r.Items {
+		if item.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Interrupted reports whether operation was cancelled before all items were done.
+func (r bulkResult[T, R]) Interrupted() bool {
+	for _, item := range r.Items {
+		if errors.Is(item.Err, backend.ErrInterrupted) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r bulkResult[T, R]) MarshalJSON() ([]byte, error) {
+	items := r.Items
+	if items == nil {
+		items = []bulkItem[T, R]{}
+	}
+	return json.Marshal(struct {
+		Items       []bulkItem[T, R] `json:"items"`
+		Succeeded   int              `json:"succeeded"`
+		Failed      int              `json:"failed"`
+		Interrupted bool             `json:"interrupted,omitempty"`
+	}{items, len(r.Items) - r.Failed(), r.Failed(), r.Interrupted()})
+}
+
+// runBulk calls fn for every input by at most parallel workers at once.
+// Failure of an item does not stop the others. Once ctx is done, e.g. on
+// Ctrl-C, items not started yet fail with backend.ErrInterrupted, fn should abort
+// the ones in flight itself.
+func runBulk[T, R any](ctx context.Context, inputs []T, parallel int, fn func(context.Context, T) (R, error)) bulkResult[T, R] {
+	if parallel < 1 {
+		parallel = 1
+	}
+	// Ctrl-C waits for items in flight to finish
+	atomic.AddInt32(&backend.InFlight, 1)
+	defer atomic.AddInt32(&backend.InFlight, -1)
+	result := bulkResult[T, R]{Items: make([]bulkItem[T, R], len(inputs))}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallel && w < len(inputs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				item := &result.Items[i]
+				item.Input = inputs[i]
+				if ctx.Err() != nil {
+					item.Err = backend.ErrInterrupted
+					continue
+				}
+				item.Output, item.Err = fn(ctx, inputs[i])
+			}
+		}()
+	}
+	for i := range inputs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return result
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runBulk_order(t *testing.T) {
+	inputs := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	var running, most int32
+	result := runBulk(context.Background(), inputs, 3, func(_ context.Context, i int) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for m := atomic.LoadInt32(&most); n > m && !atomic.CompareAndSwapInt32(&most, m, n); m = atomic.LoadInt32(&most) {
+		}
+		// the first ones finish the last
+		time.Sleep(time.Duration(len(inputs)-i) * time.Millisecond)
+		if i == 4 {
+			return "", errors.New("no such issue")
+		}
+		return fmt.Sprint(i * i), nil
+	})
+
+	require.Len(t, result.Items, len(inputs))
+	for i, item := range result.Items {
+		require.Equal(t, i, item.Input)
+		if i == 4 {
+			require.EqualError(t, item.Err, "no such issue")
+			continue
+		}
+		require.NoError(t, item.Err)
+		require.Equal(t, fmt.Sprint(i*i), item.Output)
+	}
+	require.Equal(t, 1, result.Failed())
+	require.False(t, result.Interrupted())
+	require.LessOrEqual(t, most, int32(3))
+}
+
+func Test_runBulk_cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	result := runBulk(ctx, []string{"a", "b", "c", "d", "e", "f"}, 2, func(ctx context.Context, s string) (string, error) {
+		switch s {
+		case "a":
+			return "A", nil
+		case "b":
+			cancel()
+		}
+		// in flight, aborted like requests are
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	require.Len(t, result.Items, 6)
+	require.Equal(t, "A", result.Items[0].Output)
+	require.NoError(t, result.Items[0].Err)
+	require.ErrorIs(t, result.Items[1].Err, context.Canceled)
+	// the other worker may have started c before b cancelled
+	require.Error(t, result.Items[2].Err)
+	for _, item := range result.Items[3:] {
+		require.ErrorIs(t, item.Err, backend.ErrInterrupted, item.Input)
+	}
+	require.Equal(t, 5, result.Failed())
+	require.True(t, result.Interrupted())
+}
+
+func Test_bulkResult_json(t *testing.T) {
+	result := bulkResult[string, int]{Items: []bulkItem[string, int]{
+		{Input: "a", Output: 1},
+		{Input: "b", Err: errors.New("failed")},
+		{Input: "c", Err: backend.ErrInterrupted},
+		{Input: "d", Err: backend.IssueNotFoundError{Issue: "d"}},
+	}}
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"items": [
+			{"input": "a", "output": 1},
+			{"input": "b", "error": "failed"},
+			{"input": "c", "error": "interrupted", "error_kind": "interrupted"},
+			{"input": "d", "error": "issue d is not found, or you are not permitted to see it", "error_kind": "issue_not_found"}
+		],
+		"succeeded": 1,
+		"failed": 3,
+		"interrupted": true
+	}`, string(data))
+
+	data, err = json.Marshal(bulkResult[string, int]{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"items": [], "succeeded": 0, "failed": 0}`, string(data))
+}
+
+func Test_logImported(t *testing.T) {
+	isolateUserDirs(t)
+	conf := Config{Backend: config.Mock, Timezone: "UTC", BulkParallelism: 3}
+	started := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	var entries []importEntry
+	for i, issue := range []string{"PRJ-1", "PRJ-2", "oops", "PRJ-4", "PRJ-5"} {
+		entries = append(entries, importEntry{
+			Worklog:   Worklog{Issue: issue, Started: started.Add(time.Duration(i) * time.Hour), Spent: time.Hour},
+			ImportIDs: []string{fmt.Sprintf("test:%d", i)},
+		})
+	}
+
+	result, err := logImported(conf, entries)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 5)
+	for i, item := range result.Items {
+		require.Equal(t, entries[i].Worklog.Issue, item.Input.Worklog.Issue)
+		if item.Input.Worklog.Issue == "oops" {
+			require.Error(t, item.Err)
+			continue
+		}
+		require.NoError(t, item.Err)
+		require.Equal(t, item.Input.Worklog.Issue, item.Output.Issue)
+	}
+	require.Equal(t, 1, result.Failed())
+
+	imported, err := importedIDs(conf)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"test:0": true, "test:1": true, "test:3": true, "test:4": true}, imported)
+}
+
+func Test_logImported_interrupted(t *testing.T) {
+	isolateUserDirs(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	old := backend.InterruptCtx
+	backend.InterruptCtx = ctx
+	t.Cleanup(func() { backend.InterruptCtx = old })
+
+	conf := Config{Backend: config.Mock, Timezone: "UTC"}
+	started := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	entries := []importEntry{{Worklog: Worklog{Issue: "PRJ-1", Started: started, Spent: time.Hour}}, {Worklog: Worklog{Issue: "PRJ-2", Started: started, Spent: time.Hour}}}
+	result, err := logImported(conf, entries)
+	require.NoError(t, err)
+	require.True(t, result.Interrupted())
+	require.Equal(t, 2, result.Failed())
+
+	err = submitImport(conf, entries, importOptions{})
+	require.ErrorIs(t, err, backend.ErrInterrupted, "tlog exits as aborted")
+	require.Zero(t, atomic.LoadInt32(&backend.InFlight))
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+const (
+	queueFile     = "queue.jsonl"
+	queueLockFile = "queue.lock"
+)
+
+// queuedWorklog is a worklog that could not be logged, e.g. by git hook or
+// while JIRA was unreachable, it waits for `tlog sync`.
+type queuedWorklog struct {
+	ID      string    `json:"id"`
+	Issue   string    `json:"issue"`
+	Started time.Time `json:"started"`
+	Seconds int       `json:"seconds"`
+	Comment string    `json:"comment,omitempty"`
+	// e.g. Tempo work attributes given with --attr
+	Attributes map[string]string `json:"attributes,omitempty"`
+	ImportIDs  []string          `json:"import_ids,omitempty"`
+	QueuedAt   time.Time         `json:"queued_at"`
+	// why it was not logged
+	Error string `json:"error,omitempty"`
+}
+
+func (q queuedWorklog) worklog() Worklog {
+	return Worklog{Issue: q.Issue, Started: q.Started, Spent: time.Duration(q.Seconds) * time.Second, Comment: q.Comment, Attributes: q.Attributes}
+}
+
+// updateQueue passes queued worklogs to fn and stores what it returns, other
+// tlog processes wait meanwhile.
+func updateQueue(conf Config, fn func([]queuedWorklog) []queuedWorklog) error {
+	lock, err := state.ContextPath(conf.Context(), queueLockFile)
+	if err != nil {
+		return err
+	}
+	unlock, err := state.Lock(lock)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	queued, err := readQueue(conf)
+	if err != nil {
+		return err
+	}
+	queued = fn(queued)
+
+	path, err := state.ContextPath(conf.Context(), queueFile)
+	if err != nil {
+		return err
+	}
+	if len(queued) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	var data []byte
+	for _, q := range queued {
+		line, err := json.Marshal(q)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	return state.WriteFileAtomic(path, data, 0600)
+}
+
+func readQueue(conf Config) ([]queuedWorklog, error) {
+	path, err := state.ContextPath(conf.Context(), queueFile)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open queue: %w", err)
+	}
+	defer f.Close()
+
+	var queued []queuedWorklog
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var q queuedWorklog
+		if err := json.Unmarshal(scanner.Bytes(), &q); err != nil {
+			return nil, fmt.Errorf("corrupted queue line %q: %w", scanner.Text(), err)
+		}
+		queued = append(queued, q)
+	}
+	return queued, scanner.Err()
+}
+
+// enqueueWorklog keeps worklog that failed to be logged for `tlog sync`.
+func enqueueWorklog(conf Config, wl Worklog, importIDs []string, reason error) error {
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return err
+	}
+	q := queuedWorklog{
+		ID:         hex.EncodeToString(idBytes),
+		Issue:      wl.Issue,
+		Started:    wl.Started,
+		Seconds:    int(wl.Spent.Seconds()),
+		Comment:    wl.Comment,
+		Attributes: wl.Attributes,
+		ImportIDs:  importIDs,
+		QueuedAt:   time.Now(),
+		Error:      reason.Error(),
+	}
+	return updateQueue(conf, func(queued []queuedWorklog) []queuedWorklog { return append(queued, q) })
+}
+
+// queueOffline queues worklog that cannot be logged now and tells so.
+func queueOffline(conf Config, wl Worklog, reason error) error {
+	if err := enqueueWorklog(conf, wl, nil, reason); err != nil {
+		return fmt.Errorf("cannot queue worklog: %w", err)
+	}
+	pterm.Info.Printfln("Queued %s on %s for %s, run `tlog sync` when JIRA is reachable", parse.FormatDuration(wl.Spent), wl.Issue, wl.Started.Format("2006-01-02"))
+	return nil
+}
+
+// offerSync offers to log queued worklogs, it is called once JIRA turns out reachable.
+func offerSync(conf Config) error {
+	queued, err := readQueue(conf)
+	if err != nil || len(queued) == 0 {
+		return err
+	}
+	if !stdinIsTerminal() {
+		pterm.Info.Printfln("%d worklogs are queued, run `tlog sync` to log them", len(queued))
+		return nil
+	}
+	confirmed, _ := pterm.DefaultInteractiveConfirm.WithDefaultValue(true).Show(pterm.Sprintf("%d worklogs are queued, log them now?", len(queued)))
+	if !confirmed {
+		return nil
+	}
+	return syncQueue(conf)
+}
+
+func runSync() error {
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	return syncQueue(conf)
+}
+
+// syncQueue logs queued worklogs in order, it stops at the first failure
+// and the rest stays queued. Worklogs imported by other means meanwhile are
+// dropped.
+func syncQueue(conf Config) error {
+	queued, err := readQueue(conf)
+	if err != nil {
+		return err
+	}
+	if len(queued) == 0 {
+		pterm.Info.Println("Nothing is queued")
+		return nil
+	}
+	imported, err := importedIDs(conf)
+	if err != nil {
+		return err
+	}
+
+	done := map[string]bool{}
+	for _, q := range queued {
+		if anyImported(q.ImportIDs, imported) {
+			pterm.Info.Printfln("%s %s on %s is logged already", parse.FormatDuration(q.worklog().Spent), q.Issue, q.Started.Format("2006-01-02"))
+			done[q.ID] = true
+			continue
+		}
+		if err := recordWorklog(conf, q.worklog(), q.ImportIDs); err != nil {
+			if !errors.Is(err, errSilent) {
+				pterm.Error.Println(err)
+			}
+			break
+		}
+		done[q.ID] = true
+	}
+	// worklogs may have been queued while syncing
+	var left int
+	err = updateQueue(conf, func(current []queuedWorklog) []queuedWorklog {
+		var rest []queuedWorklog
+		for _, q := range current {
+			if !done[q.ID] {
+				rest = append(rest, q)
+			}
+		}
+		left = len(rest)
+		return rest
+	})
+	if err != nil {
+		return err
+	}
+	if left > 0 {
+		pterm.Warning.Printfln("%d worklogs are still queued, run `tlog sync` again later", left)
+		return errSilent
+	}
+	return nil
+}
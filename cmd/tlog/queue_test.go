@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_enqueueWorklog(t *testing.T) {
+	isolateUserDirs(t)
+	conf := Config{}
+	started := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, enqueueWorklog(conf, Worklog{Issue: "PRJ-1", Started: started, Spent: time.Hour, Comment: "fix"}, []string{"git:abc"}, errors.New("offline")))
+	require.NoError(t, enqueueWorklog(conf, Worklog{Issue: "PRJ-2", Started: started, Spent: 30 * time.Minute}, nil, errors.New("offline")))
+
+	queued, err := readQueue(conf)
+	require.NoError(t, err)
+	require.Len(t, queued, 2)
+	require.Equal(t, Worklog{Issue: "PRJ-1", Started: started, Spent: time.Hour, Comment: "fix"}, queued[0].worklog())
+	require.Equal(t, []string{"git:abc"}, queued[0].ImportIDs)
+	require.Equal(t, "offline", queued[0].Error)
+	require.NotEqual(t, queued[0].ID, queued[1].ID)
+
+	require.NoError(t, updateQueue(conf, func([]queuedWorklog) []queuedWorklog { return nil }))
+	queued, err = readQueue(conf)
+	require.NoError(t, err)
+	require.Empty(t, queued)
+}
+
+func Test_addWorklog_offline(t *testing.T) {
+	isolateUserDirs(t)
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	conf := Config{JiraURL: srv.URL, JiraLogin: "me", JiraPassword: "secret", Timezone: "UTC"}
+	conf.SetOrigin("Retries", "config.toml")
+	started := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	// JIRA is unreachable
+	require.NoError(t, addWorklog(conf, Worklog{Issue: "PRJ-1", Started: started, Spent: time.Hour}))
+	old := globalOpts.Offline
+	globalOpts.Offline = true
+	t.Cleanup(func() { globalOpts.Offline = old })
+	require.NoError(t, addWorklog(conf, Worklog{Issue: "PRJ-2", Started: started, Spent: time.Hour, Attributes: map[string]string{"_Account_": "ACC"}}))
+
+	queued, err := readQueue(conf)
+	require.NoError(t, err)
+	require.Len(t, queued, 2)
+	require.Contains(t, queued[0].Error, "connection refused")
+	require.Equal(t, "logged with --offline", queued[1].Error)
+	require.Equal(t, map[string]string{"_Account_": "ACC"}, queued[1].worklog().Attributes)
+}
+
+func Test_syncQueue(t *testing.T) {
+	isolateUserDirs(t)
+	conf := Config{Backend: config.Mock, Timezone: "UTC"}
+	started := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	for _, issue := range []string{"PRJ-1", "oops", "PRJ-3"} {
+		require.NoError(t, enqueueWorklog(conf, Worklog{Issue: issue, Started: started, Spent: time.Hour}, nil, errors.New("offline")))
+	}
+
+	require.ErrorIs(t, syncQueue(conf), errSilent)
+	queued, err := readQueue(conf)
+	require.NoError(t, err)
+	require.Len(t, queued, 2, "sync stops at the first failure")
+	require.Equal(t, "oops", queued[0].Issue)
+	require.Equal(t, "PRJ-3", queued[1].Issue)
+
+	entries, err := backend.ReadLedger(conf)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// weekdayNames are names of days of RecurringEntry.Days.
+var weekdayNames = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+}
+
+// weekday returns weekday named like "mon" or "monday".
+func weekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(name)
+	if len(name) < 3 {
+		return 0, false
+	}
+	d, ok := weekdayNames[name[:3]]
+	if ok && len(name) > 3 && !strings.EqualFold(d.String(), name) {
+		return 0, false
+	}
+	return d, ok
+}
+
+// recurringProblems reports recurring entries that cannot be logged.
+func recurringProblems(cfg Config) []ConfigProblem {
+	var problems []ConfigProblem
+	for i, r := range cfg.Recurring {
+		key := fmt.Sprintf("Recurring[%d]", i)
+		if len(r.Days) == 0 {
+			problems = append(problems, ConfigProblem{Key: key + ".Days", Message: "value is required", Suggestion: `list weekdays, e.g. ["mon", "fri"]`})
+		}
+		for _, d := range r.Days {
+			if _, ok := weekday(d); !ok {
+				problems = append(problems, ConfigProblem{Key: key + ".Days", Message: fmt.Sprintf("unknown weekday %q", d), Suggestion: "use mon, tue, wed, thu, fri, sat or sun"})
+			}
+		}
+		if spent, err := convertToTimeLog(r.Time, cfg.Workday()); err != nil || spent <= 0 {
+			problems = append(problems, ConfigProblem{Key: key + ".Time", Message: fmt.Sprintf("%q is not a duration", r.Time), Suggestion: `use e.g. "15m" or "1h"`})
+		}
+		if _, ok := cfg.Aliases()[r.Task]; !ok && !parse.IsIssue(r.Task) {
+			problems = append(problems, ConfigProblem{Key: key + ".Task", Message: fmt.Sprintf("%q is neither alias nor issue key", r.Task), Suggestion: "add it to TaskAliases"})
+		}
+		if _, err := time.Parse("15:04", r.Start); r.Start != "" && err != nil {
+			problems = append(problems, ConfigProblem{Key: key + ".Start", Message: fmt.Sprintf("%q is not time of day", r.Start), Suggestion: `use e.g. "09:30"`})
+		}
+	}
+	return problems
+}
+
+// runFillRecurring logs recurring entries of every day of range but
+// holidays, the current week up to today by default. Entries logged already,
+// of the same issue and comment on the day, are skipped.
+func runFillRecurring(args []string) error {
+	flags := flag.NewFlagSet("fill-recurring", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only show what would be logged")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog fill-recurring [day|from..to] [--dry-run]")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	if len(conf.Recurring) == 0 {
+		return config.Error{Field: "Recurring", Err: errors.New("no recurring entries, add [[Recurring]] to config")}
+	}
+	from, to, err := recurringRange(conf, safeGet(positional, 0))
+	if err != nil {
+		return err
+	}
+	b, err := backend.New(conf)
+	if err != nil {
+		return err
+	}
+	logged, err := listWorklogs(b, from, to)
+	if err != nil {
+		return err
+	}
+	due, skipped, err := recurringWorklogs(conf, logged, from, to)
+	if err != nil {
+		return err
+	}
+	for _, s := range skipped {
+		pterm.Info.Printfln("Skipped %s of %s on %s: %s", parse.FormatDuration(s.Spent), s.Issue, s.Started.Format("Mon 01.02"), s.Comment)
+	}
+	if len(due) == 0 {
+		pterm.Info.Println("Nothing to fill in")
+		return nil
+	}
+	if *dryRun {
+		return pterm.DefaultTable.WithHasHeader().WithData(sharesRows(conf, due)).Render()
+	}
+
+	created := 0
+	var failed []failedWorklog
+	for _, wl := range due {
+		if _, err := logOrQueue(conf, wl); err != nil {
+			if errors.Is(err, backend.ErrInterrupted) {
+				return err
+			}
+			failed = append(failed, failedWorklog{Worklog: wl, Err: err})
+			continue
+		}
+		created++
+	}
+	pterm.Info.Printfln("%d created, %d skipped as logged already", created, len(skipped))
+	if len(failed) > 0 {
+		for _, f := range failed {
+			pterm.Error.Printfln("%s of %s on %s is not logged: %s", parse.FormatDuration(f.Spent), f.Issue, f.Started.Format("Mon 01.02"), f.Err)
+		}
+		return errSilent
+	}
+	return nil
+}
+
+// recurringRange is reportRange that defaults to the current week up to today.
+func recurringRange(conf Config, input string) (time.Time, time.Time, error) {
+	if input != "" {
+		return reportRange(conf, input)
+	}
+	today, _ := convertToDay("", conf.Location())
+	monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	return monday, today.AddDate(0, 0, 1), nil
+}
+
+// recurringWorklogs returns recurring entries due on days of [from, to) but
+// holidays and the ones skipped since logged has them, by issue, comment and
+// day.
+func recurringWorklogs(conf Config, logged []Worklog, from, to time.Time) ([]Worklog, []Worklog, error) {
+	loc := conf.Location()
+	type key struct{ issue, comment, day string }
+	exists := map[key]bool{}
+	for _, wl := range logged {
+		exists[key{wl.Issue, strings.TrimSpace(withoutMarker(wl.Comment)), wl.Started.In(loc).Format("2006-01-02")}] = true
+	}
+
+	var due, skipped []Worklog
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if conf.Calendar.IsHoliday(day) {
+			continue
+		}
+		for _, r := range conf.Recurring {
+			if !recursOn(r, day.Weekday()) {
+				continue
+			}
+			issue, err := resolveTask(conf, r.Task)
+			if err != nil {
+				return nil, nil, err
+			}
+			spent, err := convertToTimeLog(r.Time, conf.Workday())
+			if err != nil {
+				return nil, nil, config.Error{Field: "Recurring", Err: err}
+			}
+			started := atStartTime(conf, day)
+			if r.Start != "" {
+				start, err := time.Parse("15:04", r.Start)
+				if err != nil {
+					return nil, nil, config.Error{Field: "Recurring", Err: fmt.Errorf("invalid Start %q of %s", r.Start, r.Task)}
+				}
+				started = time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+			}
+			comment := r.Comment
+			if comment == "" {
+				comment = conf.AliasComment(r.Task)
+			}
+			wl := Worklog{Issue: issue, Started: started, Spent: spent, Comment: comment}
+			if exists[key{issue, strings.TrimSpace(comment), day.Format("2006-01-02")}] {
+				skipped = append(skipped, wl)
+				continue
+			}
+			due = append(due, wl)
+		}
+	}
+	return due, skipped, nil
+}
+
+// recursOn tells whether entry is logged on weekday.
+func recursOn(r config.RecurringEntry, day time.Weekday) bool {
+	for _, name := range r.Days {
+		if d, ok := weekday(name); ok && d == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_weekday(t *testing.T) {
+	for name, want := range map[string]time.Weekday{"mon": time.Monday, "Monday": time.Monday, "SUN": time.Sunday, "thursday": time.Thursday} {
+		got, ok := weekday(name)
+		require.True(t, ok, name)
+		require.Equal(t, want, got, name)
+	}
+	for _, name := range []string{"", "mo", "monkey", "xyz"} {
+		_, ok := weekday(name)
+		require.False(t, ok, name)
+	}
+}
+
+func Test_recurringProblems(t *testing.T) {
+	cfg := Config{
+		TaskAliases: map[string]string{"standup": "MEET-1"},
+		Recurring: []config.RecurringEntry{
+			{Days: []string{"mon", "friday"}, Time: "15m", Task: "standup", Start: "09:30"},
+			{Days: []string{"mon", "funday"}, Time: "soon", Task: "nope", Start: "25:00"},
+			{Time: "1h", Task: "ABC-1"},
+		},
+	}
+	var got []string
+	for _, p := range recurringProblems(cfg) {
+		got = append(got, p.Key)
+	}
+	require.Equal(t, []string{"Recurring[1].Days", "Recurring[1].Time", "Recurring[1].Task", "Recurring[1].Start", "Recurring[2].Days"}, got)
+}
+
+func Test_recurringWorklogs(t *testing.T) {
+	conf := Config{
+		Timezone:         "UTC",
+		DefaultStartTime: "10:00",
+		TaskAliases:      map[string]string{"standup": "MEET-1"},
+		Calendar:         config.Calendar{Holidays: []string{"2024-06-12"}},
+		Recurring: []config.RecurringEntry{
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Time: "15m", Task: "standup", Comment: "standup", Start: "09:30"},
+			{Days: []string{"monday"}, Time: "1h", Task: "PLAN-2", Comment: "planning"},
+		},
+	}
+	day := func(d int) time.Time { return time.Date(2024, time.June, d, 0, 0, 0, 0, time.UTC) }
+	marker, err := newIdempotencyMarker()
+	require.NoError(t, err)
+	logged := []Worklog{
+		{Issue: "MEET-1", Started: day(11).Add(9 * time.Hour), Spent: 15 * time.Minute, Comment: " standup" + marker},
+		{Issue: "MEET-1", Started: day(13).Add(9 * time.Hour), Spent: 15 * time.Minute, Comment: "retro"},
+	}
+
+	// Mon 10th to Sun 16th, Wed 12th is a holiday
+	due, skipped, err := recurringWorklogs(conf, logged, day(10), day(17))
+	require.NoError(t, err)
+	require.Equal(t, []Worklog{
+		{Issue: "MEET-1", Started: day(10).Add(9*time.Hour + 30*time.Minute), Spent: 15 * time.Minute, Comment: "standup"},
+		{Issue: "PLAN-2", Started: day(10).Add(10 * time.Hour), Spent: time.Hour, Comment: "planning"},
+		{Issue: "MEET-1", Started: day(13).Add(9*time.Hour + 30*time.Minute), Spent: 15 * time.Minute, Comment: "standup"},
+		{Issue: "MEET-1", Started: day(14).Add(9*time.Hour + 30*time.Minute), Spent: 15 * time.Minute, Comment: "standup"},
+	}, due)
+	require.Equal(t, []Worklog{
+		{Issue: "MEET-1", Started: day(11).Add(9*time.Hour + 30*time.Minute), Spent: 15 * time.Minute, Comment: "standup"},
+	}, skipped)
+}
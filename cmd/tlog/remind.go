@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// runRemind is meant to be run from cron or systemd timer at the end of the day.
+// It notifies and fails when today is not fully logged or timer has been running for too long.
+func runRemind(args []string) error {
+	flags := flag.NewFlagSet("remind", flag.ContinueOnError)
+	remote := flags.Bool("remote", false, "query JIRA for today's total instead of local ledger")
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records with --remote")
+	timerHours := flags.Float64("timer-hours", 4, "complain about timer running longer than this")
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+
+	t, err := loadTimer()
+	if err != nil {
+		return err
+	}
+
+	today, _ := convertToDay("", conf.Location())
+	logged, err := loggedDay(conf, today, *remote)
+	if err != nil {
+		return err
+	}
+
+	workday := conf.Workday()
+	if conf.Calendar.IsHoliday(today) {
+		workday = 0
+	}
+	timerLimit := time.Duration(*timerHours * float64(time.Hour))
+	problems := remindProblems(t, logged, workday, timerLimit, time.Now())
+	if len(problems) == 0 {
+		return nil
+	}
+
+	message := strings.Join(problems, "\n")
+	pterm.Println(message)
+	if err := notify("tlog", message); err != nil {
+		pterm.Warning.Println(err)
+	}
+	return errSilent
+}
+
+func remindProblems(t *Timer, logged, workday, timerLimit time.Duration, now time.Time) []string {
+	var problems []string
+	if logged < workday {
+		problems = append(problems, fmt.Sprintf(
+			"Only %s of %s logged today, %s missing", parse.FormatDuration(logged), parse.FormatDuration(workday), parse.FormatDuration(workday-logged),
+		))
+	}
+	if t != nil && !t.Paused() && t.Elapsed(now) > timerLimit {
+		problems = append(problems, fmt.Sprintf("Timer for %s has been running for %s", t.Issue, parse.FormatDuration(t.Elapsed(now))))
+	}
+	return problems
+}
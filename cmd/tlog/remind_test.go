@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_remindProblems(t *testing.T) {
+	now := time.Date(2022, time.October, 10, 17, 0, 0, 0, time.UTC)
+	pausedAt := now.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		timer  *Timer
+		logged time.Duration
+		want   []string
+	}{
+		{name: "all good", logged: 8 * time.Hour},
+		{name: "overtime", logged: 9 * time.Hour},
+		{name: "missing hours", logged: 5*time.Hour + 30*time.Minute, want: []string{"Only 5h30m of 8h logged today, 2h30m missing"}},
+		{name: "short timer", logged: 8 * time.Hour, timer: &Timer{Issue: "A-1", StartedAt: now.Add(-time.Hour)}},
+		{
+			name:   "long timer",
+			logged: 8 * time.Hour,
+			timer:  &Timer{Issue: "A-1", StartedAt: now.Add(-5 * time.Hour)},
+			want:   []string{"Timer for A-1 has been running for 5h"},
+		},
+		{name: "long paused timer", logged: 8 * time.Hour, timer: &Timer{Issue: "A-1", StartedAt: now.Add(-6 * time.Hour), PausedAt: &pausedAt}},
+		{
+			name:  "everything wrong",
+			timer: &Timer{Issue: "A-1", StartedAt: now.Add(-5 * time.Hour)},
+			want:  []string{"Only 0m of 8h logged today, 8h missing", "Timer for A-1 has been running for 5h"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, remindProblems(tt.timer, tt.logged, 8*time.Hour, 4*time.Hour, now))
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/pterm/pterm"
+)
+
+const (
+	remoteAliasesFile = "remote_aliases.json"
+	// remoteAliasesOrigin is shown as origin of aliases coming from AliasesURL,
+	// the URL itself may contain credentials
+	remoteAliasesOrigin = "AliasesURL"
+)
+
+// remoteAliases is a cached copy of the document at AliasesURL.
+type remoteAliases struct {
+	URL              string                        `json:"url"`
+	ETag             string                        `json:"etag,omitempty"`
+	FetchedAt        time.Time                     `json:"fetched_at"`
+	TaskAliases      map[string]string             `json:"task_aliases,omitempty"`
+	TaskAliasDetails map[string]config.AliasDetail `json:"task_alias_details,omitempty"`
+}
+
+// parseAliasesDocument decodes shared aliases. Document has the same
+// TaskAliases and TaskAliasDetails tables as config, in TOML or JSON.
+func parseAliasesDocument(data []byte) (remoteAliases, error) {
+	var doc struct {
+		TaskAliases      map[string]string
+		TaskAliasDetails map[string]config.AliasDetail
+	}
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(data, &doc)
+	} else {
+		_, err = toml.Decode(string(data), &doc)
+	}
+	if err != nil {
+		return remoteAliases{}, fmt.Errorf("cannot decode aliases: %w", err)
+	}
+	return remoteAliases{TaskAliases: doc.TaskAliases, TaskAliasDetails: doc.TaskAliasDetails}, nil
+}
+
+// loadRemoteAliases returns cached aliases of AliasesURL, nil if they were never fetched.
+func loadRemoteAliases(conf Config) (*remoteAliases, error) {
+	path, err := state.ContextPath(conf.Context(), remoteAliasesFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read aliases cache: %w", err)
+	}
+	var cached remoteAliases
+	if err := json.Unmarshal(data, &cached); err != nil || cached.URL != conf.AliasesURL {
+		// cache of another URL is as good as none
+		return nil, nil
+	}
+	return &cached, nil
+}
+
+func saveRemoteAliases(conf Config, cached remoteAliases) error {
+	path, err := state.ContextPath(conf.Context(), remoteAliasesFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	return state.WriteFileAtomic(path, data, 0600)
+}
+
+// withRemoteAliases adds cached shared aliases to config, aliases defined locally win.
+// It never goes to network, `tlog sync-aliases` does.
+func withRemoteAliases(cfg Config) Config {
+	if cfg.AliasesURL == "" {
+		return cfg
+	}
+	cached, err := loadRemoteAliases(cfg)
+	if err != nil || cached == nil {
+		return cfg
+	}
+
+	cfg = cfg.Clone()
+	local := cfg.Aliases()
+	for name, issue := range cached.TaskAliases {
+		if _, ok := local[name]; ok {
+			continue
+		}
+		if cfg.TaskAliases == nil {
+			cfg.TaskAliases = map[string]string{}
+		}
+		cfg.TaskAliases[name] = issue
+		cfg.SetOrigin("TaskAliases."+name, remoteAliasesOrigin)
+	}
+	for name, d := range cached.TaskAliasDetails {
+		if _, ok := local[name]; ok {
+			continue
+		}
+		if cfg.TaskAliasDetails == nil {
+			cfg.TaskAliasDetails = map[string]config.AliasDetail{}
+		}
+		cfg.TaskAliasDetails[name] = d
+		cfg.SetOrigin("TaskAliasDetails."+name, remoteAliasesOrigin)
+	}
+	return cfg
+}
+
+func runSyncAliases() error {
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	if conf.AliasesURL == "" {
+		return errors.New("AliasesURL is not set in config")
+	}
+
+	cached, err := loadRemoteAliases(conf)
+	if err != nil {
+		return err
+	}
+	fetched, err := fetchRemoteAliases(conf, cached)
+	if err != nil {
+		if cached == nil {
+			return err
+		}
+		pterm.Warning.Printfln("%s, keeping aliases fetched at %s", err, cached.FetchedAt.Format("2006-01-02 15:04"))
+		return nil
+	}
+	if err := saveRemoteAliases(conf, *fetched); err != nil {
+		return err
+	}
+
+	if cached != nil && fetched.ETag != "" && fetched.ETag == cached.ETag {
+		pterm.Success.Println("Shared aliases are up to date")
+		return nil
+	}
+	names := Config{TaskAliases: fetched.TaskAliases, TaskAliasDetails: fetched.TaskAliasDetails}.Aliases()
+	shadowed := 0
+	for name := range names {
+		if origin := aliasOrigin(conf, name); origin != remoteAliasesOrigin && origin != "default" {
+			shadowed++
+		}
+	}
+	pterm.Success.Printfln("Fetched %d shared aliases, %d of them overridden by your config", len(names), shadowed)
+	return nil
+}
+
+// fetchRemoteAliases downloads AliasesURL, reusing cached copy if server says it has not changed.
+func fetchRemoteAliases(conf Config, cached *remoteAliases) (*remoteAliases, error) {
+	transport, err := backend.BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := backend.NewRetryTransport(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, conf.AliasesURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AliasesURL: %w", err)
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	resp, err := (&http.Client{Transport: retry}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch shared aliases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		updated := *cached
+		updated.FetchedAt = time.Now()
+		return &updated, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("cannot fetch shared aliases: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch shared aliases: %w", err)
+	}
+	fetched, err := parseAliasesDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	fetched.URL = conf.AliasesURL
+	fetched.ETag = resp.Header.Get("ETag")
+	fetched.FetchedAt = time.Now()
+	return &fetched, nil
+}
+
+// aliasOrigin returns where alias is defined.
+func aliasOrigin(conf Config, name string) string {
+	if _, ok := conf.TaskAliasDetails[name]; ok {
+		return conf.Origin("TaskAliasDetails." + name)
+	}
+	return conf.Origin("TaskAliases." + name)
+}
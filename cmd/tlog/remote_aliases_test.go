@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseAliasesDocument(t *testing.T) {
+	want := remoteAliases{
+		TaskAliases:      map[string]string{"planning": "MEET-2"},
+		TaskAliasDetails: map[string]config.AliasDetail{"standup": {Issue: "MEET-1", Comment: "daily standup"}},
+	}
+
+	got, err := parseAliasesDocument([]byte(`
+[TaskAliases]
+planning = "MEET-2"
+
+[TaskAliasDetails.standup]
+Issue = "MEET-1"
+Comment = "daily standup"
+`))
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	got, err = parseAliasesDocument([]byte(`{"TaskAliases": {"planning": "MEET-2"}, "TaskAliasDetails": {"standup": {"Issue": "MEET-1", "Comment": "daily standup"}}}`))
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	_, err = parseAliasesDocument([]byte(`planning = `))
+	require.Error(t, err)
+}
+
+func Test_syncAliases(t *testing.T) {
+	isolateUserDirs(t)
+
+	var requests int
+	down := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case down:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Header.Get("If-None-Match") == `"v1"`:
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			fmt.Fprint(w, "[TaskAliases]\nstandup = \"MEET-1\"\nreview = \"TEAM-24\"\n")
+		}
+	}))
+	defer srv.Close()
+
+	conf := Config{AliasesURL: srv.URL}
+	conf.SetOrigin("Retries", "test")
+	require.Equal(t, conf, withRemoteAliases(conf), "nothing is cached yet")
+
+	fetched, err := fetchRemoteAliases(conf, nil)
+	require.NoError(t, err)
+	require.Equal(t, `"v1"`, fetched.ETag)
+	require.NoError(t, saveRemoteAliases(conf, *fetched))
+
+	cached, err := loadRemoteAliases(conf)
+	require.NoError(t, err)
+	again, err := fetchRemoteAliases(conf, cached)
+	require.NoError(t, err, "not modified response reuses cache")
+	require.Equal(t, cached.TaskAliases, again.TaskAliases)
+
+	down = true
+	_, err = fetchRemoteAliases(conf, cached)
+	require.ErrorContains(t, err, "404")
+	require.Equal(t, 3, requests)
+
+	conf.TaskAliases = map[string]string{"review": "INT-24"}
+	conf.SetOrigin("TaskAliases.review", "config.toml")
+	got := withRemoteAliases(conf)
+	require.Equal(t, map[string]string{"review": "INT-24", "standup": "MEET-1"}, got.TaskAliases, "local alias wins")
+	require.Equal(t, remoteAliasesOrigin, got.Origin("TaskAliases.standup"))
+
+	conf.AliasesURL = srv.URL + "/other"
+	require.Equal(t, conf.TaskAliases, withRemoteAliases(conf).TaskAliases, "cache of another URL is ignored")
+}
@@ -0,0 +1,542 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// reportRange parses "day" or "from..to" argument of reports, empty one is
+// the current month. Returned range is [from, day after to).
+func reportRange(conf Config, input string) (time.Time, time.Time, error) {
+	if input != "" {
+		return parseImportDays(conf, input)
+	}
+	today, _ := convertToDay("", conf.Location())
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	return from, from.AddDate(0, 1, 0), nil
+}
+
+// reportWorklogs returns worklogs of current user in [from, to) from backend,
+// which is what reports are built of. When there are more of them than
+// --limit allows, the listed ones are returned with a warning.
+func reportWorklogs(conf Config, from, to time.Time) ([]Worklog, error) {
+	b, err := backend.New(conf)
+	if err != nil {
+		return nil, err
+	}
+	return listWorklogs(b, from, to)
+}
+
+// listWorklogs is ListWorklogs of backend that warns about truncation.
+func listWorklogs(b Backend, from, to time.Time) ([]Worklog, error) {
+	// no spinner, it would end up in CSV or JSON written to stdout
+	worklogs, err := b.ListWorklogs(from, to)
+	var truncated backend.TruncatedError
+	if errors.As(err, &truncated) {
+		pterm.Warning.WithWriter(os.Stderr).Printfln("Only the first %d worklog records are counted, pass --limit to read more", truncated.Limit)
+	} else if err != nil {
+		return nil, err
+	}
+	return worklogs, nil
+}
+
+// runReport prints reports of logged time.
+func runReport(args []string) error {
+	switch safeGet(args, 0) {
+	case "sprint":
+		return runReportSprint(args[1:])
+	case "billable":
+		return runReportBillable(args[1:])
+	case "range":
+		return runReportRange(args[1:])
+	case "tags":
+		return runReportTags(args[1:])
+	case "team":
+		return runReportTeam(args[1:])
+	default:
+		return errors.New("Usage: tlog report sprint [board] [--all] | billable|tags [day|from..to] | range <from> <to> | team --users <user,...>")
+	}
+}
+
+// rangeReport is time logged over a range by issue, project and day.
+type rangeReport struct {
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Issues   []issueTotal   `json:"issues"`
+	Projects []projectStats `json:"projects"`
+	Days     []dayTotal     `json:"days"`
+	// issues by epic, with --group-by epic only
+	Epics []epicGroup `json:"epics,omitempty"`
+	// logged minus WorkdayHours of every working day in range
+	Logged float64 `json:"logged_hours"`
+	Target float64 `json:"target_hours"`
+	Delta  float64 `json:"delta_hours"`
+}
+
+// issueTotal is time logged to one issue.
+type issueTotal struct {
+	Issue string        `json:"issue"`
+	Spent time.Duration `json:"-"`
+	Hours float64       `json:"hours"`
+}
+
+// noEpic names group of issues without epic.
+const noEpic = "No epic"
+
+// epicGroup is time logged to issues of one epic.
+type epicGroup struct {
+	Epic    string        `json:"epic"` // empty for issues without one
+	Summary string        `json:"summary"`
+	Spent   time.Duration `json:"-"`
+	Hours   float64       `json:"hours"`
+	Issues  []epicIssue   `json:"issues"`
+}
+
+// epicIssue is issue of epicGroup.
+type epicIssue struct {
+	Issue   string        `json:"issue"`
+	Summary string        `json:"summary"`
+	Spent   time.Duration `json:"-"`
+	Hours   float64       `json:"hours"`
+}
+
+// dayTotal is time logged on one day, days with nothing logged included.
+type dayTotal struct {
+	Day     string        `json:"day"` // "2006-01-02"
+	Workday bool          `json:"workday"`
+	Spent   time.Duration `json:"-"`
+	Hours   float64       `json:"hours"`
+}
+
+// runReportRange prints time logged from one day to another, both included,
+// e.g. from the 21st to the 20th for accounting.
+func runReportRange(args []string) error {
+	flags := flag.NewFlagSet("report range", flag.ContinueOnError)
+	force := flags.Bool("force", false, "allow range longer than a year")
+	tag := addTagFlag(flags)
+	xlsx := addXLSXFlag(flags)
+	groupBy := flags.String("group-by", "", "group issues: epic")
+	flat := flags.Bool("flat", false, "with --group-by, print totals of groups without their issues")
+	html := flags.String("html", "", "write standalone HTML report to this file instead")
+	open := flags.Bool("open", false, "open HTML report in browser once written")
+	output := flags.String("output", "text", "output format: text or json")
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) != 2 {
+		return errors.New("Usage: tlog report range <from> <to> [--force] [--tag <tag>] [--group-by epic [--flat]] [--output json|--xlsx <file>|--html <file> [--open]]")
+	}
+	switch *output {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q, text or json expected", *output)
+	}
+	if *html != "" && (*xlsx != "" || *output == "json") {
+		return errors.New("--html cannot be used with --xlsx or --output json")
+	}
+	if *open && *html == "" {
+		return errors.New("--open opens HTML report, pass --html <file> too")
+	}
+	if *groupBy != "" && *groupBy != "epic" {
+		return fmt.Errorf("cannot group by %q, only by epic", *groupBy)
+	}
+	if *flat && *groupBy == "" {
+		return errors.New("--flat collapses groups, pass --group-by epic too")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+	from, err := convertToDay(positional[0], conf.Location())
+	if err != nil {
+		return fmt.Errorf("invalid start of range: %w", err)
+	}
+	last, err := convertToDay(positional[1], conf.Location())
+	if err != nil {
+		return fmt.Errorf("invalid end of range: %w", err)
+	}
+	if last.Before(from) {
+		pterm.Warning.WithWriter(os.Stderr).Printfln("%s is before %s, swapped them", last.Format("2006-01-02"), from.Format("2006-01-02"))
+		from, last = last, from
+	}
+	to := last.AddDate(0, 0, 1)
+	if from.AddDate(1, 0, 0).Before(to) && !*force {
+		return fmt.Errorf("%s – %s is longer than a year, pass --force if it is meant to be", from.Format("2006-01-02"), last.Format("2006-01-02"))
+	}
+	worklogs, err := reportWorklogs(conf, from, to)
+	if err != nil {
+		return err
+	}
+	worklogs = withTag(worklogs, *tag)
+	if *xlsx != "" {
+		return saveReportXLSX(*xlsx, conf, worklogs)
+	}
+	report := rangeReportOf(conf, worklogs, from, to)
+	if *groupBy == "epic" {
+		if report.Epics, err = reportEpics(conf, report.Issues); err != nil {
+			return err
+		}
+	}
+
+	if *html != "" {
+		if err := writeReportHTML(*html, conf, report, worklogs); err != nil {
+			return err
+		}
+		pterm.Success.Printfln("Report is written to %s", *html)
+		if *open {
+			path, _ := filepath.Abs(*html)
+			if err := openBrowser("file://" + filepath.ToSlash(path)); err != nil {
+				return fmt.Errorf("cannot open browser: %w", err)
+			}
+		}
+		return nil
+	}
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	pterm.Printfln("%s – %s", report.From, report.To)
+	if len(report.Issues) > 0 {
+		data := pterm.TableData{{"Issue", "Time"}}
+		for _, it := range report.Issues {
+			data = append(data, []string{it.Issue, parse.FormatDuration(it.Spent)})
+		}
+		if *groupBy == "epic" {
+			data = epicRows(report.Epics, *flat)
+		}
+		if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+			return err
+		}
+		pterm.Println()
+		data = pterm.TableData{{"Project", "Time", "%", "Issues"}}
+		for _, s := range report.Projects {
+			data = append(data, []string{s.Project, parse.FormatDuration(s.Spent), formatPercent(s.Percent), strconv.Itoa(s.Issues)})
+		}
+		if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+			return err
+		}
+		pterm.Println()
+	}
+	data := pterm.TableData{{"Day", "Time"}}
+	for _, d := range report.Days {
+		day, _ := time.ParseInLocation("2006-01-02", d.Day, conf.Location())
+		spent := parse.FormatDuration(d.Spent)
+		if !d.Workday {
+			spent = pterm.Gray(spent)
+		}
+		data = append(data, []string{day.Format("Mon 2006-01-02"), spent})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+		return err
+	}
+	pterm.Printfln("Logged %s of %s, %s", formatHours(report.Logged), formatHours(report.Target), formatSignedHours(report.Delta))
+	return nil
+}
+
+// reportEpics looks epics of issues up, through issue cache, and groups
+// issues by them.
+func reportEpics(conf Config, issues []issueTotal) ([]epicGroup, error) {
+	keys := make([]string, 0, len(issues))
+	for _, it := range issues {
+		keys = append(keys, it.Issue)
+	}
+	epics, err := lookupEpics(conf, keys)
+	if err != nil {
+		return nil, err
+	}
+	// summaries of epics and of their issues at once
+	for _, epic := range epics {
+		if epic != "" && !containsString(keys, epic) {
+			keys = append(keys, epic)
+		}
+	}
+	return groupByEpic(issues, epics, issueSummaries(conf, keys)), nil
+}
+
+// groupByEpic groups issues by their epic, the most time first, issues
+// without epic in the last group.
+func groupByEpic(issues []issueTotal, epics, summaries map[string]string) []epicGroup {
+	byEpic := map[string]*epicGroup{}
+	var groups []*epicGroup
+	for _, it := range issues {
+		epic := epics[it.Issue]
+		g, ok := byEpic[epic]
+		if !ok {
+			g = &epicGroup{Epic: epic, Summary: summaries[epic]}
+			if epic == "" {
+				g.Summary = noEpic
+			}
+			byEpic[epic] = g
+			groups = append(groups, g)
+		}
+		g.Spent += it.Spent
+		g.Issues = append(g.Issues, epicIssue{Issue: it.Issue, Summary: summaries[it.Issue], Spent: it.Spent, Hours: it.Hours})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].Epic == "") != (groups[j].Epic == "") {
+			return groups[j].Epic == ""
+		}
+		return groups[i].Spent > groups[j].Spent
+	})
+
+	grouped := make([]epicGroup, 0, len(groups))
+	for _, g := range groups {
+		g.Hours = roundTo(g.Spent.Hours(), 2)
+		grouped = append(grouped, *g)
+	}
+	return grouped
+}
+
+// epicRows renders epics with their issues below them, flat ones without.
+func epicRows(groups []epicGroup, flat bool) pterm.TableData {
+	data := pterm.TableData{{"Epic", "Summary", "Time"}}
+	if !flat {
+		data[0] = []string{"Epic / issue", "Summary", "Time"}
+	}
+	for _, g := range groups {
+		data = append(data, []string{pterm.Bold.Sprint(g.Epic), pterm.Bold.Sprint(g.Summary), pterm.Bold.Sprint(parse.FormatDuration(g.Spent))})
+		if flat {
+			continue
+		}
+		for _, it := range g.Issues {
+			data = append(data, []string{"  " + it.Issue, it.Summary, parse.FormatDuration(it.Spent)})
+		}
+	}
+	return data
+}
+
+// rangeReportOf sums worklogs in [from, to) up by issue and project, the
+// most time first, and by day.
+func rangeReportOf(conf Config, worklogs []Worklog, from, to time.Time) rangeReport {
+	loc := conf.Location()
+	report := rangeReport{From: from.Format("2006-01-02"), To: to.AddDate(0, 0, -1).Format("2006-01-02"), Issues: []issueTotal{}}
+	byIssue := map[string]time.Duration{}
+	byDay := map[string]time.Duration{}
+	var logged time.Duration
+	for _, wl := range worklogs {
+		byIssue[wl.Issue] += wl.Spent
+		byDay[wl.Started.In(loc).Format("2006-01-02")] += wl.Spent
+		logged += wl.Spent
+	}
+	for issue, spent := range byIssue {
+		report.Issues = append(report.Issues, issueTotal{Issue: issue, Spent: spent, Hours: roundTo(spent.Hours(), 2)})
+	}
+	sort.Slice(report.Issues, func(i, j int) bool {
+		if report.Issues[i].Spent != report.Issues[j].Spent {
+			return report.Issues[i].Spent > report.Issues[j].Spent
+		}
+		return report.Issues[i].Issue < report.Issues[j].Issue
+	})
+	report.Projects, _ = statsByProject(worklogs)
+
+	var target time.Duration
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		d := dayTotal{Day: key, Workday: conf.Calendar.IsWorkday(day), Spent: byDay[key], Hours: roundTo(byDay[key].Hours(), 2)}
+		if d.Workday {
+			target += conf.Workday()
+		}
+		report.Days = append(report.Days, d)
+	}
+	report.Logged = roundTo(logged.Hours(), 2)
+	report.Target = roundTo(target.Hours(), 2)
+	report.Delta = roundTo((logged - target).Hours(), 2)
+	return report
+}
+
+// issueTime is time logged to issue, by person.
+type issueTime struct {
+	Issue
+	Spent    time.Duration
+	ByPerson map[string]time.Duration
+}
+
+// personTime is time logged by person.
+type personTime struct {
+	Person string
+	Spent  time.Duration
+}
+
+// runReportSprint prints time logged during sprint to its issues, by issue
+// and by person. Active sprint of the board is taken without --sprint.
+func runReportSprint(args []string) error {
+	flags := flag.NewFlagSet("report sprint", flag.ContinueOnError)
+	sprintID := flags.Int("sprint", 0, "report on sprint of this id, e.g. a closed one")
+	all := flags.Bool("all", false, "count time logged by the whole team, not only yours")
+	asCSV := flags.Bool("csv", false, "print CSV, a row for every issue and person")
+	asMarkdown := flags.Bool("markdown", false, "print Markdown tables")
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records")
+	tag := addTagFlag(flags)
+	xlsx := addXLSXFlag(flags)
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 1 || *asCSV && *asMarkdown {
+		return errors.New("Usage: tlog report sprint [board] [--sprint <id>] [--all] [--csv|--markdown]")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+	board := conf.SprintBoard
+	if input := safeGet(positional, 0); input != "" {
+		if board, err = strconv.Atoi(input); err != nil || board <= 0 {
+			return fmt.Errorf("invalid board %q, id of the board expected, e.g. 42 of .../RapidBoard.jspa?rapidView=42", input)
+		}
+	}
+	b, err := backend.New(conf)
+	if err != nil {
+		return err
+	}
+	reporter, ok := b.(backend.SprintReporter)
+	if !ok {
+		return fmt.Errorf("%s backend has no sprints, JIRA does", conf.Backend)
+	}
+	s, err := reporter.Sprint(board, *sprintID)
+	if err != nil {
+		return err
+	}
+	issues, worklogs, err := reporter.SprintWorklogs(s, *all)
+	var truncated backend.TruncatedError
+	if errors.As(err, &truncated) {
+		pterm.Warning.WithWriter(os.Stderr).Printfln("Only the first %d worklog records are counted, pass --limit to read more", truncated.Limit)
+	} else if err != nil {
+		return err
+	}
+	worklogs = withTag(worklogs, *tag)
+	if *xlsx != "" {
+		return saveReportXLSX(*xlsx, conf, worklogs)
+	}
+	byIssue, byPerson, total := sprintTotals(issues, worklogs)
+
+	if *asCSV {
+		return writeSprintCSV(byIssue, byPerson)
+	}
+	loc := conf.Location()
+	title := fmt.Sprintf("%s, %s – %s", s.Name, s.Start.In(loc).Format("2006-01-02 15:04"), s.End.In(loc).Format("2006-01-02 15:04"))
+	issueRows := [][]string{{"Issue", "Summary", "Time"}}
+	for _, it := range byIssue {
+		issueRows = append(issueRows, []string{it.Key, it.Summary, parse.FormatDuration(it.Spent)})
+	}
+	issueRows = append(issueRows, []string{"Total", "", parse.FormatDuration(total)})
+	personRows := [][]string{{"Person", "Time"}}
+	for _, p := range byPerson {
+		personRows = append(personRows, []string{p.Person, parse.FormatDuration(p.Spent)})
+	}
+	// without --all time is only yours, there is nobody to compare with
+	showPeople := *all
+
+	if *asMarkdown {
+		fmt.Printf("### %s\n\n%s", title, markdownTable(issueRows))
+		if showPeople {
+			fmt.Printf("\n%s", markdownTable(personRows))
+		}
+		return nil
+	}
+	pterm.Println(pterm.Bold.Sprint(title))
+	if total == 0 {
+		pterm.Println("Nothing is logged during the sprint")
+		return nil
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(issueRows).Render(); err != nil {
+		return err
+	}
+	if showPeople {
+		pterm.Println()
+		return pterm.DefaultTable.WithHasHeader().WithData(personRows).Render()
+	}
+	return nil
+}
+
+// sprintTotals sums worklogs up by issue, issues of sprint without time
+// included, and by person, the most time first. Worklogs of issues that
+// left the sprint count too.
+func sprintTotals(issues []Issue, worklogs []Worklog) ([]issueTime, []personTime, time.Duration) {
+	byKey := map[string]*issueTime{}
+	var keys []string
+	add := func(issue Issue) *issueTime {
+		if it, ok := byKey[issue.Key]; ok {
+			return it
+		}
+		it := &issueTime{Issue: issue, ByPerson: map[string]time.Duration{}}
+		byKey[issue.Key] = it
+		keys = append(keys, issue.Key)
+		return it
+	}
+	for _, issue := range issues {
+		add(issue)
+	}
+
+	people := map[string]time.Duration{}
+	var total time.Duration
+	for _, wl := range worklogs {
+		it := add(Issue{Key: wl.Issue})
+		it.Spent += wl.Spent
+		it.ByPerson[wl.Author] += wl.Spent
+		people[wl.Author] += wl.Spent
+		total += wl.Spent
+	}
+
+	byIssue := make([]issueTime, 0, len(keys))
+	for _, key := range keys {
+		byIssue = append(byIssue, *byKey[key])
+	}
+	sort.SliceStable(byIssue, func(i, j int) bool { return byIssue[i].Spent > byIssue[j].Spent })
+	byPerson := make([]personTime, 0, len(people))
+	for _, person := range sortedKeys(people) {
+		byPerson = append(byPerson, personTime{Person: person, Spent: people[person]})
+	}
+	sort.SliceStable(byPerson, func(i, j int) bool { return byPerson[i].Spent > byPerson[j].Spent })
+	return byIssue, byPerson, total
+}
+
+func writeSprintCSV(byIssue []issueTime, byPerson []personTime) error {
+	w := csv.NewWriter(os.Stdout)
+	records := [][]string{{"issue", "summary", "person", "hours"}}
+	for _, it := range byIssue {
+		for _, p := range byPerson {
+			if spent, ok := it.ByPerson[p.Person]; ok {
+				records = append(records, []string{it.Key, it.Summary, p.Person, strconv.FormatFloat(roundTo(spent.Hours(), 2), 'f', 2, 64)})
+			}
+		}
+	}
+	return w.WriteAll(records)
+}
+
+// markdownTable renders rows as Markdown table, the first one is header.
+func markdownTable(rows [][]string) string {
+	var b strings.Builder
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			cells[j] = strings.ReplaceAll(cell, "|", `\|`)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", len(row)) + "|\n")
+		}
+	}
+	return b.String()
+}
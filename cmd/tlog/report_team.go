@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/pterm/pterm"
+)
+
+// teamMatrix is time logged by every user on every day of range.
+type teamMatrix struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Days  []string  `json:"days"` // "2006-01-02"
+	Users []teamRow `json:"users"`
+	// of every day, all users together
+	DayHours []float64 `json:"day_hours"`
+	Hours    float64   `json:"total_hours"`
+	// issues the current user could not see
+	Notes []string `json:"notes,omitempty"`
+}
+
+// teamRow is time logged by one user, by day.
+type teamRow struct {
+	User     string          `json:"user"`
+	Spent    []time.Duration `json:"-"`
+	DayHours []float64       `json:"day_hours"`
+	Hours    float64         `json:"total_hours"`
+}
+
+// runReportTeam prints hours of every user by day, from worklogs of issues
+// found by --jql.
+func runReportTeam(args []string) error {
+	flags := flag.NewFlagSet("report team", flag.ContinueOnError)
+	jql := flags.String("jql", "", "issues to read worklogs of, e.g. \"project = APP\", DefaultProject if not set")
+	since := flags.String("from", "monday", "the first day")
+	until := flags.String("to", "", "the last day, today if not set")
+	userList := flags.String("users", "", "comma separated users, by username, email or account id")
+	asCSV := flags.Bool("csv", false, "print CSV")
+	asMarkdown := flags.Bool("markdown", false, "print Markdown table")
+	output := flags.String("output", "text", "output format: text or json")
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	var users []string
+	for _, user := range strings.Split(*userList, ",") {
+		if user = strings.TrimSpace(user); user != "" && !containsString(users, user) {
+			users = append(users, user)
+		}
+	}
+	if len(positional) > 0 || len(users) == 0 {
+		return errors.New("Usage: tlog report team --users <user,...> [--jql <jql>] [--from <day>] [--to <day>] [--csv|--markdown|--output json]")
+	}
+	switch *output {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q, text or json expected", *output)
+	}
+	formats := 0
+	for _, set := range []bool{*asCSV, *asMarkdown, *output == "json"} {
+		if set {
+			formats++
+		}
+	}
+	if formats > 1 {
+		return errors.New("--csv, --markdown and --output json cannot be used together")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+	from, err := convertToDay(*since, conf.Location())
+	if err != nil {
+		return fmt.Errorf("--from: %w", err)
+	}
+	last, err := convertToDay(*until, conf.Location())
+	if err != nil {
+		return fmt.Errorf("--to: %w", err)
+	}
+	if last.Before(from) {
+		return fmt.Errorf("%s is before %s", last.Format("2006-01-02"), from.Format("2006-01-02"))
+	}
+	to := last.AddDate(0, 0, 1)
+	query := *jql
+	if query == "" && conf.DefaultProject != "" {
+		query = fmt.Sprintf("project = %q", conf.DefaultProject)
+	}
+	if query == "" {
+		return errors.New("pass --jql, e.g. --jql \"project = APP\", or set DefaultProject")
+	}
+
+	b, err := backend.New(conf)
+	if err != nil {
+		return err
+	}
+	reporter, ok := b.(backend.TeamReporter)
+	if !ok {
+		return fmt.Errorf("%s backend does not list worklogs of others, JIRA does", conf.Backend)
+	}
+	worklogs, notes, err := reporter.TeamWorklogs(query, users, from, to)
+	var truncated backend.TruncatedError
+	if errors.As(err, &truncated) {
+		pterm.Warning.WithWriter(os.Stderr).Printfln("Only the first %d worklog records are counted, pass --limit to read more", truncated.Limit)
+	} else if err != nil {
+		return err
+	}
+	matrix := teamMatrixOf(conf, worklogs, users, from, to)
+	matrix.Notes = notes
+
+	if *output != "json" {
+		for _, note := range notes {
+			pterm.Warning.WithWriter(os.Stderr).Println(note)
+		}
+	}
+	switch {
+	case *output == "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(matrix)
+	case *asCSV:
+		return writeTeamCSV(matrix)
+	case *asMarkdown:
+		fmt.Print(markdownTable(teamRows(conf, matrix)))
+		return nil
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(teamRows(conf, matrix)).Render()
+}
+
+// teamMatrixOf sums worklogs up by user and day of [from, to), users
+// without any get zeros.
+func teamMatrixOf(conf Config, worklogs []Worklog, users []string, from, to time.Time) teamMatrix {
+	loc := conf.Location()
+	m := teamMatrix{From: from.Format("2006-01-02"), To: to.AddDate(0, 0, -1).Format("2006-01-02")}
+	column := map[string]int{}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		column[day.Format("2006-01-02")] = len(m.Days)
+		m.Days = append(m.Days, day.Format("2006-01-02"))
+	}
+	row := map[string]int{}
+	for i, user := range users {
+		row[user] = i
+		m.Users = append(m.Users, teamRow{User: user, Spent: make([]time.Duration, len(m.Days))})
+	}
+	daySpent := make([]time.Duration, len(m.Days))
+	var total time.Duration
+	for _, wl := range worklogs {
+		i, ok := row[wl.Author]
+		j, inRange := column[wl.Started.In(loc).Format("2006-01-02")]
+		if !ok || !inRange {
+			continue
+		}
+		m.Users[i].Spent[j] += wl.Spent
+		daySpent[j] += wl.Spent
+		total += wl.Spent
+	}
+
+	for i := range m.Users {
+		r := &m.Users[i]
+		var spent time.Duration
+		for _, s := range r.Spent {
+			r.DayHours = append(r.DayHours, roundTo(s.Hours(), 2))
+			spent += s
+		}
+		r.Hours = roundTo(spent.Hours(), 2)
+	}
+	for _, s := range daySpent {
+		m.DayHours = append(m.DayHours, roundTo(s.Hours(), 2))
+	}
+	m.Hours = roundTo(total.Hours(), 2)
+	return m
+}
+
+// teamRows renders matrix with a column of every day and totals.
+func teamRows(conf Config, m teamMatrix) [][]string {
+	header := []string{"User"}
+	for _, d := range m.Days {
+		day, _ := time.ParseInLocation("2006-01-02", d, conf.Location())
+		header = append(header, day.Format("Mon 01.02"))
+	}
+	rows := [][]string{append(header, "Total")}
+	for _, r := range m.Users {
+		row := []string{r.User}
+		for _, hours := range r.DayHours {
+			row = append(row, formatHours(hours))
+		}
+		rows = append(rows, append(row, formatHours(r.Hours)))
+	}
+	total := []string{"Total"}
+	for _, hours := range m.DayHours {
+		total = append(total, formatHours(hours))
+	}
+	return append(rows, append(total, formatHours(m.Hours)))
+}
+
+func writeTeamCSV(m teamMatrix) error {
+	w := csv.NewWriter(os.Stdout)
+	records := [][]string{append(append([]string{"user"}, m.Days...), "total")}
+	for _, r := range m.Users {
+		record := []string{r.User}
+		for _, hours := range r.DayHours {
+			record = append(record, strconv.FormatFloat(hours, 'f', 2, 64))
+		}
+		records = append(records, append(record, strconv.FormatFloat(r.Hours, 'f', 2, 64)))
+	}
+	return w.WriteAll(records)
+}
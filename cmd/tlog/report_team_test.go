@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_teamMatrixOf(t *testing.T) {
+	conf := Config{Timezone: "UTC"}
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	worklogs := []Worklog{
+		{Issue: "APP-1", Author: "alice", Started: from.Add(9 * time.Hour), Spent: time.Hour},
+		{Issue: "APP-2", Author: "alice", Started: from.Add(13 * time.Hour), Spent: 30 * time.Minute},
+		{Issue: "APP-1", Author: "bob", Started: from.AddDate(0, 0, 2).Add(9 * time.Hour), Spent: 4 * time.Hour},
+	}
+
+	m := teamMatrixOf(conf, worklogs, []string{"alice", "bob", "carol"}, from, from.AddDate(0, 0, 3))
+	require.Equal(t, []string{"2024-03-04", "2024-03-05", "2024-03-06"}, m.Days)
+	require.Equal(t, []float64{1.5, 0, 0}, m.Users[0].DayHours)
+	require.Equal(t, 4.0, m.Users[1].Hours)
+	require.Equal(t, teamRow{User: "carol", Spent: make([]time.Duration, 3), DayHours: []float64{0, 0, 0}}, m.Users[2], "user without worklogs gets zeros")
+	require.Equal(t, []float64{1.5, 0, 4}, m.DayHours)
+	require.Equal(t, 5.5, m.Hours)
+
+	rows := teamRows(conf, m)
+	require.Equal(t, []string{"User", "Mon 03.04", "Tue 03.05", "Wed 03.06", "Total"}, rows[0])
+	require.Equal(t, []string{"Total", "1h30m", "0m", "4h", "5h30m"}, rows[len(rows)-1])
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_sprintTotals(t *testing.T) {
+	issues := []Issue{{Key: "APP-1", Summary: "Login"}, {Key: "APP-2", Summary: "Logout"}, {Key: "APP-3", Summary: "Signup"}}
+	worklogs := []Worklog{
+		{Issue: "APP-1", Author: "me", Spent: time.Hour},
+		{Issue: "APP-2", Author: "me", Spent: 2 * time.Hour},
+		{Issue: "APP-2", Author: "colleague", Spent: 30 * time.Minute},
+		{Issue: "APP-9", Author: "colleague", Spent: 3 * time.Hour},
+	}
+
+	byIssue, byPerson, total := sprintTotals(issues, worklogs)
+	require.Equal(t, 6*time.Hour+30*time.Minute, total)
+	var keys []string
+	for _, it := range byIssue {
+		keys = append(keys, it.Key)
+	}
+	require.Equal(t, []string{"APP-9", "APP-2", "APP-1", "APP-3"}, keys, "the most time first, issues left the sprint and untouched ones too")
+	require.Equal(t, "Logout", byIssue[1].Summary)
+	require.Equal(t, map[string]time.Duration{"me": 2 * time.Hour, "colleague": 30 * time.Minute}, byIssue[1].ByPerson)
+	require.Equal(t, []personTime{{"colleague", 3*time.Hour + 30*time.Minute}, {"me", 3 * time.Hour}}, byPerson)
+}
+
+func Test_markdownTable(t *testing.T) {
+	require.Equal(t, "| Issue | Summary |\n| --- | --- |\n| APP-1 | a \\| b |\n", markdownTable([][]string{{"Issue", "Summary"}, {"APP-1", "a | b"}}))
+}
+
+func Test_rangeReportOf(t *testing.T) {
+	conf := Config{Timezone: "UTC", WorkdayHours: 8}
+	// friday to monday
+	from := time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 26, 0, 0, 0, 0, time.UTC)
+	worklogs := []Worklog{
+		{Issue: "APP-1", Started: from.Add(9 * time.Hour), Spent: 6 * time.Hour},
+		{Issue: "OPS-2", Started: from.Add(15 * time.Hour), Spent: time.Hour},
+		{Issue: "APP-1", Started: from.AddDate(0, 0, 3).Add(9 * time.Hour), Spent: 10 * time.Hour},
+	}
+
+	report := rangeReportOf(conf, worklogs, from, to)
+	require.Equal(t, "2024-03-22", report.From)
+	require.Equal(t, "2024-03-25", report.To)
+	require.Equal(t, []issueTotal{{"APP-1", 16 * time.Hour, 16}, {"OPS-2", time.Hour, 1}}, report.Issues)
+	require.Len(t, report.Projects, 2)
+	require.Equal(t, "APP", report.Projects[0].Project)
+	require.Equal(t, []dayTotal{
+		{Day: "2024-03-22", Workday: true, Spent: 7 * time.Hour, Hours: 7},
+		{Day: "2024-03-23"},
+		{Day: "2024-03-24"},
+		{Day: "2024-03-25", Workday: true, Spent: 10 * time.Hour, Hours: 10},
+	}, report.Days)
+	require.Equal(t, 16.0, report.Target)
+	require.Equal(t, 1.0, report.Delta)
+}
+
+func Test_groupByEpic(t *testing.T) {
+	issues := []issueTotal{
+		{Issue: "APP-1", Spent: 5 * time.Hour, Hours: 5},
+		{Issue: "OPS-1", Spent: 4 * time.Hour, Hours: 4},
+		{Issue: "APP-2", Spent: time.Hour, Hours: 1},
+		{Issue: "APP-3", Spent: 3 * time.Hour, Hours: 3},
+	}
+	epics := map[string]string{"APP-1": "", "APP-2": "APP-100", "APP-3": "APP-100", "OPS-1": "OPS-9"}
+	summaries := map[string]string{"APP-100": "Checkout", "OPS-9": "Monitoring", "APP-3": "Pay button"}
+
+	groups := groupByEpic(issues, epics, summaries)
+	require.Equal(t, []epicGroup{
+		{Epic: "OPS-9", Summary: "Monitoring", Spent: 4 * time.Hour, Hours: 4, Issues: []epicIssue{{Issue: "OPS-1", Spent: 4 * time.Hour, Hours: 4}}},
+		{Epic: "APP-100", Summary: "Checkout", Spent: 4 * time.Hour, Hours: 4, Issues: []epicIssue{
+			{Issue: "APP-2", Spent: time.Hour, Hours: 1},
+			{Issue: "APP-3", Summary: "Pay button", Spent: 3 * time.Hour, Hours: 3},
+		}},
+		{Summary: noEpic, Spent: 5 * time.Hour, Hours: 5, Issues: []epicIssue{{Issue: "APP-1", Spent: 5 * time.Hour, Hours: 5}}},
+	}, groups, "issues without epic last, however long")
+}
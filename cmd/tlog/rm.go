@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+)
+
+// runRm deletes every worklog of the current user on a day, of one issue
+// only with --issue. Deletion has to be confirmed by typing yes, as it is
+// what resets a day after a wrong import.
+func runRm(args []string) error {
+	flags := flag.NewFlagSet("rm", flag.ContinueOnError)
+	dayInput := flags.String("day", "", "day to delete worklogs of, e.g. today or 03.04")
+	task := flags.String("issue", "", "delete worklogs of this task only")
+	yes := flags.Bool("yes", false, "delete without confirmation")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 0 || *dayInput == "" {
+		return errors.New("Usage: tlog rm --day <day> [--issue <task>] [--yes]")
+	}
+	if !*yes && !stdinIsTerminal() {
+		return errors.New("deletion has to be confirmed, pass --yes to delete without terminal")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	day, err := convertToDay(*dayInput, conf.Location())
+	if err != nil {
+		return err
+	}
+	var issue string
+	if *task != "" {
+		if issue, err = resolveTask(conf, *task); err != nil {
+			return err
+		}
+	}
+	b, err := backend.New(conf)
+	if err != nil {
+		return err
+	}
+	worklogs, err := listWorklogs(b, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	worklogs = worklogsOf(worklogs, issue)
+	if len(worklogs) == 0 {
+		pterm.Info.Printfln("No worklogs on %s", day.Format("Mon 01.02"))
+		return nil
+	}
+
+	var total time.Duration
+	for _, wl := range worklogs {
+		total += wl.Spent
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(lsRows(conf, worklogs)).Render(); err != nil {
+		return err
+	}
+	if !*yes {
+		prompt := promptui.Prompt{Label: pterm.Sprintf("Delete %d entries / %s? Type 'yes'", len(worklogs), pterm.Yellow(parse.FormatDuration(total)))}
+		input, err := prompt.Run()
+		if err != nil || !strings.EqualFold(strings.TrimSpace(input), "yes") {
+			return errors.New("nothing is deleted")
+		}
+	}
+
+	remaining := deleteWorklogs(conf, b, worklogs)
+	if len(remaining) == 0 {
+		pterm.Success.Printfln("Deleted %d worklogs, %s", len(worklogs), parse.FormatDuration(total))
+		return nil
+	}
+	pterm.Warning.Printfln("%d of %d worklogs are left:", len(remaining), len(worklogs))
+	if err := pterm.DefaultTable.WithHasHeader().WithData(rmRows(conf, remaining)).Render(); err != nil {
+		return err
+	}
+	return errSilent
+}
+
+// failedWorklog is worklog that could not be deleted, with why.
+type failedWorklog struct {
+	Worklog
+	Err error
+}
+
+// deleteWorklogs deletes worklogs one by one, reporting each, and returns the
+// ones that are left. Ledger entries of deleted ones are removed with them.
+func deleteWorklogs(conf Config, b Backend, worklogs []Worklog) []failedWorklog {
+	var remaining []failedWorklog
+	for _, wl := range worklogs {
+		if err := deleteWorklog(conf, b, wl); err != nil {
+			var shown shownError
+			if errors.As(err, &shown) {
+				err = shown.err
+			}
+			remaining = append(remaining, failedWorklog{Worklog: wl, Err: err})
+		}
+	}
+	return remaining
+}
+
+// rmRows renders worklogs left by deletion, with their IDs and errors.
+func rmRows(conf Config, remaining []failedWorklog) [][]string {
+	rows := [][]string{{"ID", "Start", "Issue", "Time", "Comment", "Error"}}
+	for _, f := range remaining {
+		started := f.Started.In(conf.Location())
+		rows = append(rows, []string{f.ID, started.Format("Mon 01.02 15:04"), f.Issue, formatSpent(f.Spent), f.Comment, f.Err.Error()})
+	}
+	return rows
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/stretchr/testify/require"
+)
+
+// failingDelete is mock backend that cannot delete worklog of ID.
+type failingDelete struct {
+	*backend.MockBackend
+	id string
+}
+
+func (b failingDelete) DeleteWorklog(issue, id string) error {
+	if id == b.id {
+		return errors.New("worklog is locked")
+	}
+	return b.MockBackend.DeleteWorklog(issue, id)
+}
+
+func Test_deleteWorklogs(t *testing.T) {
+	isolateUserDirs(t)
+	mock := &backend.MockBackend{Author: "me"}
+	conf := Config{Timezone: "UTC"}
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	var worklogs []Worklog
+	for i, issue := range []string{"APP-1", "APP-2", "OPS-3"} {
+		wl, err := createWorklog(conf, mock, Worklog{Issue: issue, Started: day.Add(time.Duration(i) * time.Hour), Spent: time.Hour}, nil)
+		require.NoError(t, err)
+		worklogs = append(worklogs, wl)
+	}
+
+	remaining := deleteWorklogs(conf, failingDelete{mock, worklogs[1].ID}, worklogs)
+	require.Equal(t, []failedWorklog{{Worklog: worklogs[1], Err: errors.New("worklog is locked")}}, remaining)
+	left, err := mock.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, []Worklog{worklogs[1]}, left)
+	entries, err := backend.ReadLedger(conf)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "status counts only the worklog left")
+	require.Equal(t, worklogs[1].ID, entries[0].WorklogID)
+
+	require.Equal(t, [][]string{
+		{"ID", "Start", "Issue", "Time", "Comment", "Error"},
+		{worklogs[1].ID, "Mon 03.04 10:00", "APP-2", "1h", "", "worklog is locked"},
+	}, rmRows(Config{Timezone: "UTC"}, remaining))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+	"golang.org/x/term"
+)
+
+// stdinIsTerminal reports whether someone can answer prompts, variable for tests.
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// validateRequired and validateURL check values entered in setup, both in wizard and flags.
+func validateRequired(input string) error {
+	if input == "" {
+		return errors.New("value is required")
+	}
+	return nil
+}
+
+func validateURL(input string) error {
+	u, err := url.ParseRequestURI(input)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return errors.New("host is missing")
+	}
+	return nil
+}
+
+// handlePrompts lets backends ask the user what config does not tell, when
+// there is someone to answer.
+func handlePrompts() {
+	if !stdinIsTerminal() {
+		return
+	}
+	backend.PromptChoose = func(label string, items []string) (int, error) {
+		idx, _, err := (&promptui.Select{Label: pterm.LightBlue(label), Items: items, HideSelected: true}).Run()
+		if err != nil {
+			return 0, errSilent
+		}
+		return idx, nil
+	}
+	backend.PromptInput = func(label string) (string, error) {
+		value, err := (&promptui.Prompt{Label: pterm.LightBlue(label), Validate: validateRequired}).Run()
+		if err != nil {
+			return "", errSilent
+		}
+		return value, nil
+	}
+}
+
+// Steps of the setup wizard that failed credentials check sends user back to.
+const (
+	stepDeployment = iota
+	stepCredentials
+	stepURL
+)
+
+// checkCredentials asks JIRA who the configured user is and returns display name.
+// On failure it explains why and tells which wizard step to repeat.
+func checkCredentials(cfg Config) (string, int, error) {
+	if cfg.Backend == config.YouTrack {
+		name, err := backend.YouTrackUser(cfg)
+		var auth backend.AuthError
+		switch {
+		case errors.As(err, &auth):
+			return "", stepCredentials, err
+		case err != nil:
+			return "", stepURL, err
+		}
+		return name, 0, nil
+	}
+	client, err := backend.NewJiraClient(cfg)
+	if err != nil {
+		return "", stepCredentials, err
+	}
+	user, resp, err := client.User.GetSelf()
+	if err != nil {
+		var httpResp *http.Response
+		if resp != nil {
+			httpResp = resp.Response
+		}
+		reason, code := diagnoseAuthFailure(httpResp, err)
+		if code == exitCredentials {
+			return "", stepCredentials, errors.New(reason)
+		}
+		return "", stepURL, errors.New(reason)
+	}
+
+	name := user.DisplayName
+	if name == "" {
+		name = cfg.JiraLogin
+	}
+	return name, 0, nil
+}
+
+const setupUsage = "Usage: tlog setup [--force] [--skip-verify] --url <url> [--login <login>] [--password-env <variable>] [--auth-type <type>] [--project <project>]"
+
+// runSetup creates config. Without flags on a terminal it runs the wizard,
+// otherwise it takes everything from flags, so it works in CI and containers.
+// Only the wizard checks credentials, flags are often used where JIRA is not reachable yet.
+func runSetup(args []string) error {
+	flags := flag.NewFlagSet("setup", flag.ContinueOnError)
+	jiraURL := flags.String("url", "", "JIRA address, e.g. https://company.atlassian.net")
+	login := flags.String("login", "", "JIRA username, or Atlassian account email for cloud-token")
+	passwordEnv := flags.String("password-env", "", "environment variable holding password or token")
+	authType := flags.String("auth-type", "", "basic (default), cloud-token, pat, oauth or session")
+	project := flags.String("project", "", "DefaultProject")
+	force := flags.Bool("force", false, "overwrite existing config")
+	skipVerify := flags.Bool("skip-verify", false, "do not check credentials in JIRA, e.g. when offline")
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+	if flags.NArg() > 0 {
+		return errors.New(setupUsage)
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil && !*force {
+		return fmt.Errorf("config %s already exists, pass --force to overwrite it", path)
+	}
+
+	values := flags.NFlag()
+	for _, set := range []bool{*force, *skipVerify} {
+		if set {
+			values--
+		}
+	}
+
+	var cfg Config
+	if values == 0 {
+		if !stdinIsTerminal() {
+			return errors.New(setupUsage)
+		}
+		cfg = setupConfig(!*skipVerify)
+	} else {
+		cfg, err = setupFromFlags(*jiraURL, *login, *passwordEnv, *authType, *project)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.JiraPassword != "" {
+		cfg = moveToKeyring(cfg)
+	}
+	if err := config.Write(cfg, path); err != nil {
+		return fmt.Errorf("create config: %w", err)
+	}
+	pterm.Success.Printfln("Config saved at %s", path)
+	return nil
+}
+
+// setupFromFlags builds config from setup flags, validating it the way wizard does.
+func setupFromFlags(jiraURL, login, passwordEnv, authType, project string) (Config, error) {
+	if err := validateURL(jiraURL); err != nil {
+		return Config{}, fmt.Errorf("--url: %w", err)
+	}
+	if authType != config.AuthPAT && authType != config.AuthOAuth {
+		if err := validateRequired(login); err != nil {
+			return Config{}, fmt.Errorf("--login: %w", err)
+		}
+	}
+
+	var password string
+	if authType != config.AuthOAuth {
+		if passwordEnv == "" {
+			return Config{}, errors.New("--password-env: value is required, pass name of environment variable holding password")
+		}
+		password = os.Getenv(passwordEnv)
+		if password == "" {
+			return Config{}, fmt.Errorf("--password-env: environment variable %s is empty", passwordEnv)
+		}
+	}
+
+	cfg := Config{
+		JiraURL:        jiraURL,
+		JiraLogin:      login,
+		JiraPassword:   password,
+		AuthType:       authType,
+		DefaultProject: strings.ToUpper(project),
+	}
+	problems := validateConfig(cfg)
+	for _, p := range problems {
+		pterm.Error.Println(p.String())
+	}
+	if len(problems) > 0 {
+		return Config{}, errSilent
+	}
+	return cfg, nil
+}
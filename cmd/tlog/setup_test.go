@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func Test_runSetup(t *testing.T) {
+	keyring.MockInit()
+	isolateUserDirs(t)
+	t.Setenv("CI_JIRA_TOKEN", "secret")
+
+	err := runSetup([]string{"--url", "https://company.atlassian.net", "--login", "me@example.com", "--auth-type", "cloud-token", "--password-env", "CI_JIRA_TOKEN", "--project", "int"})
+	require.NoError(t, err)
+
+	path, err := configPath()
+	require.NoError(t, err)
+	cfg, err := config.Decode(path)
+	require.NoError(t, err)
+	require.Equal(t, "https://company.atlassian.net", cfg.JiraURL)
+	require.Equal(t, "INT", cfg.DefaultProject)
+	require.Equal(t, config.PasswordSourceKeyring, cfg.PasswordSource)
+	require.Empty(t, cfg.JiraPassword)
+	password, err := cfg.Password()
+	require.NoError(t, err)
+	require.Equal(t, "secret", password)
+
+	err = runSetup([]string{"--url", "https://company.atlassian.net", "--login", "me@example.com", "--password-env", "CI_JIRA_TOKEN"})
+	require.ErrorContains(t, err, "already exists")
+}
+
+func Test_setupFromFlags(t *testing.T) {
+	t.Setenv("CI_JIRA_PASSWORD", "secret")
+	t.Setenv("CI_EMPTY", "")
+
+	tests := []struct {
+		name                      string
+		url, login, env, authType string
+		wantErr                   string
+	}{
+		{name: "basic", url: "https://company.jira.ru", login: "user", env: "CI_JIRA_PASSWORD"},
+		{name: "pat without login", url: "https://company.jira.ru", env: "CI_JIRA_PASSWORD", authType: config.AuthPAT},
+		{name: "no url", login: "user", env: "CI_JIRA_PASSWORD", wantErr: "--url"},
+		{name: "relative url", url: "company.jira.ru", login: "user", env: "CI_JIRA_PASSWORD", wantErr: "--url"},
+		{name: "no login", url: "https://company.jira.ru", env: "CI_JIRA_PASSWORD", wantErr: "--login"},
+		{name: "no password", url: "https://company.jira.ru", login: "user", wantErr: "--password-env"},
+		{name: "empty password", url: "https://company.jira.ru", login: "user", env: "CI_EMPTY", wantErr: "CI_EMPTY is empty"},
+		{name: "cloud with username", url: "https://company.atlassian.net", login: "user", env: "CI_JIRA_PASSWORD", authType: config.AuthCloudToken, wantErr: errSilent.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := setupFromFlags(tt.url, tt.login, tt.env, tt.authType, "")
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadConfig_noTerminal(t *testing.T) {
+	isolateUserDirs(t)
+	old := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	t.Cleanup(func() { stdinIsTerminal = old })
+
+	_, err := LoadConfig()
+	require.ErrorContains(t, err, "tlog setup")
+}
+
+func Test_checkCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if user != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"name": "user", "displayName": "Jane Doe"}`))
+	}))
+	defer srv.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name     string
+		cfg      Config
+		want     string
+		wantStep int
+		wantErr  string
+	}{
+		{name: "ok", cfg: Config{JiraURL: srv.URL, JiraLogin: "user", JiraPassword: "secret"}, want: "Jane Doe"},
+		{name: "wrong password", cfg: Config{JiraURL: srv.URL, JiraLogin: "user", JiraPassword: "oops"}, wantStep: stepCredentials, wantErr: "rejected credentials"},
+		{name: "unreachable", cfg: Config{JiraURL: closed.URL, JiraLogin: "user", JiraPassword: "secret"}, wantStep: stepURL, wantErr: "cannot reach JIRA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Retries = 0
+			tt.cfg.SetOrigin("Retries", "test")
+			got, step, err := checkCredentials(tt.cfg)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				require.Equal(t, tt.wantStep, step)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// splitTask in place of task splits time among several, unless it is an alias.
+const splitTask = "split"
+
+// shareRe matches task=25% or task=2h share of split time, ":" works too.
+var shareRe = regexp.MustCompile(`^([^=:\s]+)[=:]([0-9.]+%|[0-9][0-9.dhms]*)$`)
+
+// timeShare is part of split time, either percentage or explicit duration.
+type timeShare struct {
+	Task    string
+	Percent float64
+	Spent   time.Duration
+}
+
+// takeShares returns shares at the start of args and what follows them.
+func takeShares(args []string, workday time.Duration) ([]timeShare, []string, error) {
+	var shares []timeShare
+	for len(args) > 0 {
+		m := shareRe.FindStringSubmatch(args[0])
+		if m == nil {
+			break
+		}
+		s := timeShare{Task: m[1]}
+		if strings.HasSuffix(m[2], "%") {
+			p, err := strconv.ParseFloat(strings.TrimSuffix(m[2], "%"), 64)
+			if err != nil || p <= 0 {
+				return nil, nil, fmt.Errorf("invalid share %q, e.g. review=25%% expected", args[0])
+			}
+			s.Percent = p
+		} else {
+			spent, err := convertToTimeLog(m[2], workday)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid share %q: %w", args[0], err)
+			}
+			s.Spent = spent
+		}
+		shares = append(shares, s)
+		args = args[1:]
+	}
+	if len(shares) < 2 {
+		return nil, nil, errors.New("split needs at least two shares, e.g. `tlog 8h split review=25% ABC-12=75%`")
+	}
+	return shares, args, nil
+}
+
+// distribute returns duration of every share of total. Percentages have to
+// sum up to 100 and durations to total, the two cannot be mixed. Shares of
+// percentage are whole minutes, what rounding leaves goes to the largest
+// share, the first of equal ones.
+func distribute(total time.Duration, shares []timeShare) ([]time.Duration, error) {
+	parts := make([]time.Duration, len(shares))
+	var percent float64
+	var sum time.Duration
+	for i, s := range shares {
+		if (s.Percent > 0) != (shares[0].Percent > 0) {
+			return nil, errors.New("shares are either all percentages or all durations")
+		}
+		percent += s.Percent
+		parts[i] = s.Spent
+		sum += s.Spent
+	}
+	if shares[0].Percent == 0 {
+		if sum != total {
+			return nil, fmt.Errorf("shares sum up to %s, not %s", formatSpent(sum), formatSpent(total))
+		}
+		return parts, nil
+	}
+	if math.Abs(percent-100) > 1e-9 {
+		return nil, fmt.Errorf("shares sum up to %s%%, not 100%%", strconv.FormatFloat(percent, 'f', -1, 64))
+	}
+
+	largest := 0
+	sum = 0
+	for i, s := range shares {
+		parts[i] = time.Duration(float64(total) * s.Percent / 100).Truncate(time.Minute)
+		sum += parts[i]
+		if s.Percent > shares[largest].Percent {
+			largest = i
+		}
+	}
+	parts[largest] += total - sum
+	for i, part := range parts {
+		if part <= 0 {
+			return nil, fmt.Errorf("share of %s rounds down to zero", shares[i].Task)
+		}
+	}
+	return parts, nil
+}
+
+// logShares logs worklogs of shares of spent one after another, starting
+// at the start of day. They are previewed first, and all of them are tried:
+// failing ones are listed at the end.
+func logShares(conf Config, spent time.Duration, args []string, template Worklog) error {
+	shares, rest, err := takeShares(args, conf.Workday())
+	if err != nil {
+		return err
+	}
+	if len(rest) > 2 {
+		return errors.New("Usage: tlog <time> split <task>=<share>... [date|day] [comment]")
+	}
+	parts, err := distribute(spent, shares)
+	if err != nil {
+		return err
+	}
+	day, err := convertToDay(safeGet(rest, 0), conf.Location())
+	if err != nil {
+		return err
+	}
+	started := atStartTime(conf, day)
+	var worklogs []Worklog
+	for i, s := range shares {
+		issue, err := resolveTask(conf, s.Task)
+		if err != nil {
+			return err
+		}
+		wl := template
+		wl.Issue, wl.Started, wl.Spent = issue, started, parts[i]
+		if wl.Comment = safeGet(rest, 1); wl.Comment == "" {
+			wl.Comment = conf.AliasComment(s.Task)
+		}
+		worklogs = append(worklogs, wl)
+		started = started.Add(parts[i])
+	}
+
+	if err := pterm.DefaultTable.WithHasHeader().WithData(sharesRows(conf, worklogs)).Render(); err != nil {
+		return err
+	}
+	if stdinIsTerminal() {
+		confirmed, _ := pterm.DefaultInteractiveConfirm.WithDefaultValue(true).Show(pterm.Sprintf("Log %d worklogs, %s in total?", len(worklogs), pterm.Yellow(parse.FormatDuration(spent))))
+		if !confirmed {
+			return errors.New("nothing is logged")
+		}
+	}
+
+	var failed []failedWorklog
+	anyQueued := false
+	for _, wl := range worklogs {
+		queued, err := logOrQueue(conf, wl)
+		if err != nil {
+			if errors.Is(err, backend.ErrInterrupted) {
+				return err
+			}
+			failed = append(failed, failedWorklog{Worklog: wl, Err: err})
+		}
+		anyQueued = anyQueued || queued
+	}
+	if len(failed) > 0 {
+		pterm.Warning.Printfln("%d of %d worklogs are not logged:", len(failed), len(worklogs))
+		var left []Worklog
+		for _, f := range failed {
+			left = append(left, f.Worklog)
+		}
+		rows := sharesRows(conf, left)
+		rows[0] = append(rows[0], "Error")
+		for i, f := range failed {
+			rows[i+1] = append(rows[i+1], f.Err.Error())
+		}
+		if err := pterm.DefaultTable.WithHasHeader().WithData(rows).Render(); err != nil {
+			return err
+		}
+		return errSilent
+	}
+	if anyQueued {
+		return nil
+	}
+	return offerSync(conf)
+}
+
+// sharesRows renders worklogs split time is logged as.
+func sharesRows(conf Config, worklogs []Worklog) [][]string {
+	rows := [][]string{{"Issue", "Start", "Time", "Comment"}}
+	for _, wl := range worklogs {
+		rows = append(rows, []string{wl.Issue, wl.Started.In(conf.Location()).Format("Mon 01.02 15:04"), formatSpent(wl.Spent), wl.Comment})
+	}
+	return rows
+}
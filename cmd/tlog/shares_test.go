@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_takeShares(t *testing.T) {
+	shares, rest, err := takeShares([]string{"review=25%", "ABC-12:2h30m", "meetings=12.5%", "friday", "a=b"}, 8*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []timeShare{
+		{Task: "review", Percent: 25},
+		{Task: "ABC-12", Spent: 150 * time.Minute},
+		{Task: "meetings", Percent: 12.5},
+	}, shares)
+	require.Equal(t, []string{"friday", "a=b"}, rest)
+
+	_, _, err = takeShares([]string{"review=25%", "friday"}, 8*time.Hour)
+	require.Error(t, err, "one share")
+	_, _, err = takeShares([]string{"review=0%", "ABC-12=100%"}, 8*time.Hour)
+	require.Error(t, err)
+}
+
+func Test_distribute(t *testing.T) {
+	tests := []struct {
+		name   string
+		total  time.Duration
+		shares []timeShare
+		want   []time.Duration
+		err    string
+	}{
+		{
+			name:   "percentages",
+			total:  8 * time.Hour,
+			shares: []timeShare{{Task: "review", Percent: 25}, {Task: "ABC-12", Percent: 50}, {Task: "meetings", Percent: 25}},
+			want:   []time.Duration{2 * time.Hour, 4 * time.Hour, 2 * time.Hour},
+		},
+		{
+			name:   "remainder to the largest share",
+			total:  time.Hour + time.Minute,
+			shares: []timeShare{{Task: "a", Percent: 33.3}, {Task: "b", Percent: 33.4}, {Task: "c", Percent: 33.3}},
+			want:   []time.Duration{20 * time.Minute, 21 * time.Minute, 20 * time.Minute},
+		},
+		{
+			name:   "durations",
+			total:  8 * time.Hour,
+			shares: []timeShare{{Task: "ABC-12", Spent: 4 * time.Hour}, {Task: "DEF-3", Spent: 4 * time.Hour}},
+			want:   []time.Duration{4 * time.Hour, 4 * time.Hour},
+		},
+		{
+			name:   "percentages over 100",
+			total:  8 * time.Hour,
+			shares: []timeShare{{Task: "a", Percent: 60}, {Task: "b", Percent: 50}},
+			err:    "shares sum up to 110%, not 100%",
+		},
+		{
+			name:   "durations short of total",
+			total:  8 * time.Hour,
+			shares: []timeShare{{Task: "a", Spent: 4 * time.Hour}, {Task: "b", Spent: 3 * time.Hour}},
+			err:    "shares sum up to 7h, not 8h",
+		},
+		{
+			name:   "mixed",
+			total:  8 * time.Hour,
+			shares: []timeShare{{Task: "a", Percent: 50}, {Task: "b", Spent: 4 * time.Hour}},
+			err:    "shares are either all percentages or all durations",
+		},
+		{
+			name:   "share of nothing",
+			total:  10 * time.Minute,
+			shares: []timeShare{{Task: "a", Percent: 99}, {Task: "b", Percent: 1}},
+			err:    "share of b rounds down to zero",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := distribute(tt.total, tt.shares)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, parts)
+			var sum time.Duration
+			for _, part := range parts {
+				sum += part
+			}
+			require.Equal(t, tt.total, sum)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/pterm/pterm"
+)
+
+// runSplit moves part of worklog to a new one, on the same issue or on
+// --to. The part starts where the shrunk worklog ends, so the day and the
+// total stay as they were.
+func runSplit(args []string) error {
+	if _, err := convertToTimeLog(safeGet(args, 0), time.Hour); err == nil {
+		// tlog split 8h ABC-12:4h DEF-3:4h splits time being logged
+		return runLog(append([]string{args[0], splitTask}, args[1:]...))
+	}
+	flags := flag.NewFlagSet("split", flag.ContinueOnError)
+	target := flags.String("to", "", "task to log the split part to, the same issue if not set")
+	days := flags.String("range", "", "day or from..to to find worklog in, the current month if not set")
+	comment := flags.String("comment", "", "comment of the split part, the original one if not set")
+	yes := flags.Bool("yes", false, "split without confirmation")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) != 3 {
+		return errors.New("Usage: tlog split <task> <#index|id> <duration> [--to <task>] [--range <day|from..to>] [--yes]")
+	}
+	if !*yes && !stdinIsTerminal() {
+		return errors.New("split has to be confirmed, pass --yes to split without terminal")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	issue, err := resolveTask(conf, positional[0])
+	if err != nil {
+		return err
+	}
+	part, err := convertToTimeLog(positional[2], conf.Workday())
+	if err != nil {
+		return err
+	}
+	to := issue
+	if *target != "" {
+		if to, err = resolveTask(conf, *target); err != nil {
+			return err
+		}
+	}
+	from, until, err := reportRange(conf, *days)
+	if err != nil {
+		return err
+	}
+	b, err := backend.New(conf)
+	if err != nil {
+		return err
+	}
+	worklogs, err := listWorklogs(b, from, until)
+	if err != nil {
+		return err
+	}
+	wl, err := pickWorklog(worklogsOf(worklogs, issue), positional[1])
+	if err != nil {
+		return fmt.Errorf("%w, see `tlog ls %s`", err, positional[0])
+	}
+	shrunk, split, err := splitWorklog(wl, part, to)
+	if err != nil {
+		return err
+	}
+	if *comment != "" {
+		split.Comment = *comment
+	}
+
+	if err := pterm.DefaultTable.WithHasHeader().WithData(splitRows(conf, wl, shrunk, split)).Render(); err != nil {
+		return err
+	}
+	if !*yes {
+		confirmed, _ := pterm.DefaultInteractiveConfirm.WithDefaultValue(true).Show("Split the worklog?")
+		if !confirmed {
+			return errors.New("nothing is changed")
+		}
+	}
+
+	return applySplit(conf, b, shrunk, split)
+}
+
+// applySplit logs split and shrinks the original worklog to shrunk, in
+// backend and in the ledger.
+func applySplit(conf Config, b Backend, shrunk, split Worklog) error {
+	// the new part goes first: if shrinking fails, it can be deleted again,
+	// while time shrunk off with nowhere to go would be lost
+	if err := prepareWorklog(conf, b, &split); err != nil {
+		return err
+	}
+	created, err := createWorklog(conf, b, split, nil)
+	if err != nil {
+		return fmt.Errorf("worklog is not split: %w", err)
+	}
+	pterm.Success.Println(createdMessage(created))
+	if _, err := updateWorklog(conf, b, shrunk); err != nil {
+		if derr := discardWorklog(conf, b, created); derr != nil {
+			return fmt.Errorf("worklog is not shrunk and the new one %s of %s is left, delete it: %w", created.ID, created.Issue, derr)
+		}
+		pterm.Info.Println("The new worklog is deleted again, nothing is changed")
+		return err
+	}
+	return nil
+}
+
+// pickWorklog finds worklog by its 1-based index among worklogs prefixed
+// with #, e.g. "#2" as `tlog ls` numbers them, or else by its ID, so small
+// numeric IDs are never taken for indexes.
+func pickWorklog(worklogs []Worklog, ref string) (Worklog, error) {
+	if index := strings.TrimPrefix(ref, "#"); index != ref {
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 1 || i > len(worklogs) {
+			return Worklog{}, fmt.Errorf("no worklog %s among %d worklogs", ref, len(worklogs))
+		}
+		return worklogs[i-1], nil
+	}
+	for _, wl := range worklogs {
+		if wl.ID == ref {
+			return wl, nil
+		}
+	}
+	return Worklog{}, fmt.Errorf("no worklog %s among %d worklogs", ref, len(worklogs))
+}
+
+// splitWorklog returns wl shrunk by part and the part as a new worklog on
+// issue, starting where the shrunk one ends.
+func splitWorklog(wl Worklog, part time.Duration, issue string) (Worklog, Worklog, error) {
+	if part <= 0 || part >= wl.Spent {
+		return Worklog{}, Worklog{}, fmt.Errorf("%s cannot be split off %s, less than the worklog expected", formatSpent(part), formatSpent(wl.Spent))
+	}
+	shrunk := wl
+	shrunk.Spent -= part
+	split := Worklog{
+		Issue:      issue,
+		Started:    wl.Started.Add(shrunk.Spent),
+		Spent:      part,
+		Comment:    wl.Comment,
+		Attributes: wl.Attributes,
+	}
+	return shrunk, split, nil
+}
+
+// splitRows renders worklog before and after split.
+func splitRows(conf Config, wl, shrunk, split Worklog) [][]string {
+	row := func(label string, wl Worklog) []string {
+		started := wl.Started.In(conf.Location())
+		return []string{label, started.Format("Mon 01.02 15:04"), wl.Issue, formatSpent(wl.Spent), wl.Comment}
+	}
+	return [][]string{
+		{"", "Start", "Issue", "Time", "Comment"},
+		row("Before", wl),
+		row("After", shrunk),
+		row("New", split),
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pickWorklog(t *testing.T) {
+	worklogs := []Worklog{{ID: "10201", Issue: "APP-1"}, {ID: "10355", Issue: "APP-1"}}
+	wl, err := pickWorklog(worklogs, "#2")
+	require.NoError(t, err)
+	require.Equal(t, "10355", wl.ID)
+	wl, err = pickWorklog(worklogs, "10201")
+	require.NoError(t, err)
+	require.Equal(t, "10201", wl.ID)
+	_, err = pickWorklog(worklogs, "#3")
+	require.EqualError(t, err, "no worklog #3 among 2 worklogs")
+
+	small := []Worklog{{ID: "2", Issue: "APP-1"}, {ID: "1", Issue: "APP-1"}}
+	wl, err = pickWorklog(small, "1")
+	require.NoError(t, err)
+	require.Equal(t, "1", wl.ID, "number without # is ID")
+	wl, err = pickWorklog(small, "#1")
+	require.NoError(t, err)
+	require.Equal(t, "2", wl.ID)
+	_, err = pickWorklog(small, "3")
+	require.EqualError(t, err, "no worklog 3 among 2 worklogs")
+}
+
+func Test_splitWorklog(t *testing.T) {
+	wl := Worklog{ID: "7", Issue: "APP-1", Started: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), Spent: 6 * time.Hour, Comment: "migration"}
+	shrunk, split, err := splitWorklog(wl, 2*time.Hour, "OPS-7")
+	require.NoError(t, err)
+	require.Equal(t, Worklog{ID: "7", Issue: "APP-1", Started: wl.Started, Spent: 4 * time.Hour, Comment: "migration"}, shrunk)
+	require.Equal(t, Worklog{Issue: "OPS-7", Started: wl.Started.Add(4 * time.Hour), Spent: 2 * time.Hour, Comment: "migration"}, split)
+	require.Equal(t, wl.Spent, shrunk.Spent+split.Spent)
+
+	_, _, err = splitWorklog(wl, 6*time.Hour, "APP-1")
+	require.EqualError(t, err, "6h cannot be split off 6h, less than the worklog expected")
+	_, _, err = splitWorklog(wl, 0, "APP-1")
+	require.Error(t, err)
+}
+
+func Test_applySplit(t *testing.T) {
+	isolateUserDirs(t)
+	b := &backend.MockBackend{Author: "me"}
+	conf := Config{Timezone: "UTC"}
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	logged := func() map[string]int {
+		entries, err := backend.ReadLedger(conf)
+		require.NoError(t, err)
+		seconds := map[string]int{}
+		for _, e := range entries {
+			seconds[e.Issue] += e.Seconds
+		}
+		return seconds
+	}
+
+	wl, err := createWorklog(conf, b, Worklog{Issue: "APP-1", Started: day, Spent: 6 * time.Hour, Comment: "migration"}, nil)
+	require.NoError(t, err)
+	shrunk, split, err := splitWorklog(wl, 2*time.Hour, "OPS-7")
+	require.NoError(t, err)
+	require.NoError(t, applySplit(conf, b, shrunk, split))
+	require.Equal(t, map[string]int{"APP-1": 4 * 3600, "OPS-7": 2 * 3600}, logged(), "the total stays 6h")
+
+	// shrinking fails, the split part is gone from the ledger too
+	shrunk, split, err = splitWorklog(shrunk, time.Hour, "OPS-8")
+	require.NoError(t, err)
+	require.Error(t, applySplit(conf, closedPeriodBackend{b}, shrunk, split))
+	require.Equal(t, map[string]int{"APP-1": 4 * 3600, "OPS-7": 2 * 3600}, logged())
+}
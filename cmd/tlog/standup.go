@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/parse"
+)
+
+// standupItem is an issue of standup with time and comments of its worklogs.
+type standupItem struct {
+	Issue    string
+	Summary  string
+	Link     string
+	Spent    time.Duration
+	Comments []string
+	// running timer, Spent is tracked so far
+	Running bool
+	Paused  bool
+}
+
+// runStandup prints what was logged on the previous working day and what
+// the timer is running for, as a list to paste into chat.
+func runStandup(args []string) error {
+	flags := flag.NewFlagSet("standup", flag.ContinueOnError)
+	markdown := flags.Bool("markdown", false, "print Markdown with issues linked")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 0 {
+		return errors.New("Usage: tlog standup [--markdown] [--copy]")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	today, _ := convertToDay("", conf.Location())
+	day := previousWorkday(conf, today)
+	b, err := backend.New(conf)
+	if err != nil {
+		return err
+	}
+	worklogs, err := listWorklogs(b, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	items := standupItems(conf, worklogs)
+
+	timer, err := loadTimer()
+	if err != nil {
+		return err
+	}
+	var running []standupItem
+	if timer != nil {
+		item := standupItem{Issue: timer.Issue, Spent: timer.Elapsed(time.Now()).Truncate(time.Minute), Running: true, Paused: timer.Paused()}
+		item.Link = issueLink(conf, Worklog{Issue: timer.Issue})
+		if c := strings.TrimSpace(timer.Comment); c != "" {
+			item.Comments = []string{c}
+		}
+		running = append(running, item)
+	}
+
+	var keys []string
+	for _, item := range append(items, running...) {
+		keys = append(keys, item.Issue)
+	}
+	summaries := issueSummaries(conf, keys)
+	for _, list := range [][]standupItem{items, running} {
+		for i := range list {
+			list[i].Summary = summaries[list[i].Issue]
+		}
+	}
+
+	title := day.Format("Monday 01.02")
+	if sameDay(day, today.AddDate(0, 0, -1)) {
+		title = "Yesterday"
+	}
+	fmt.Print(formatStandup(title, items, *markdown))
+	if len(running) > 0 {
+		fmt.Print(formatStandup("Today", running, *markdown))
+	}
+	return nil
+}
+
+// previousWorkday returns the last working day before day, so Monday gets
+// Friday unless it is a holiday.
+func previousWorkday(conf Config, day time.Time) time.Time {
+	prev := day.AddDate(0, 0, -1)
+	// a year of holidays is a misconfiguration, yesterday is as good as any
+	for i := 0; i < 366 && !conf.Calendar.IsWorkday(prev); i++ {
+		prev = prev.AddDate(0, 0, -1)
+	}
+	if !conf.Calendar.IsWorkday(prev) {
+		return day.AddDate(0, 0, -1)
+	}
+	return prev
+}
+
+// standupItems sums worklogs up per issue, in order of the first worklog of
+// every issue, with their distinct comments.
+func standupItems(conf Config, worklogs []Worklog) []standupItem {
+	var items []standupItem
+	index := map[string]int{}
+	for _, wl := range sortedByStart(worklogs) {
+		i, ok := index[wl.Issue]
+		if !ok {
+			i = len(items)
+			index[wl.Issue] = i
+			items = append(items, standupItem{Issue: wl.Issue, Link: issueLink(conf, Worklog{Issue: wl.Issue})})
+		}
+		items[i].Spent += wl.Spent
+		comment := strings.TrimSpace(withoutMarker(wl.Comment))
+		if comment != "" && !containsString(items[i].Comments, comment) {
+			items[i].Comments = append(items[i].Comments, comment)
+		}
+	}
+	return items
+}
+
+// formatStandup renders items as a bullet list under title, Markdown one
+// with bold title and linked issues.
+func formatStandup(title string, items []standupItem, markdown bool) string {
+	var b strings.Builder
+	bullet := "• "
+	if markdown {
+		fmt.Fprintf(&b, "**%s**\n", title)
+		bullet = "- "
+	} else {
+		fmt.Fprintf(&b, "%s:\n", title)
+	}
+	if len(items) == 0 {
+		b.WriteString(bullet + "nothing logged\n")
+	}
+	for _, item := range items {
+		b.WriteString(bullet)
+		if markdown && item.Link != "" {
+			fmt.Fprintf(&b, "[%s](%s)", item.Issue, item.Link)
+		} else {
+			b.WriteString(item.Issue)
+		}
+		if item.Summary != "" {
+			b.WriteString(" " + item.Summary)
+		}
+		spent := parse.FormatDuration(item.Spent)
+		switch {
+		case item.Paused:
+			spent += " so far, paused"
+		case item.Running:
+			spent += " so far"
+		}
+		fmt.Fprintf(&b, " (%s)", spent)
+		if len(item.Comments) > 0 {
+			b.WriteString(": " + strings.Join(item.Comments, "; "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
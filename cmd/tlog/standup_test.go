@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_previousWorkday(t *testing.T) {
+	conf := Config{Calendar: config.Calendar{Holidays: []string{"2024-12-25", "2024-12-26"}}}
+	day := func(d int) time.Time { return time.Date(2024, time.December, d, 0, 0, 0, 0, time.UTC) }
+	require.Equal(t, day(10), previousWorkday(conf, day(11)))
+	require.Equal(t, day(13), previousWorkday(conf, day(16)), "Monday shows Friday")
+	require.Equal(t, day(13), previousWorkday(conf, day(15)), "and so does Sunday")
+	require.Equal(t, day(24), previousWorkday(conf, day(27)), "holidays are skipped")
+}
+
+func Test_standupItems(t *testing.T) {
+	conf := Config{JiraURL: "https://jira.example.com/"}
+	nine := time.Date(2024, time.December, 13, 9, 0, 0, 0, time.UTC)
+	items := standupItems(conf, []Worklog{
+		{Issue: "ABC-2", Started: nine.Add(2 * time.Hour), Spent: time.Hour, Comment: "review"},
+		{Issue: "ABC-1", Started: nine, Spent: 2 * time.Hour, Comment: "fixed redirect"},
+		{Issue: "ABC-1", Started: nine.Add(5 * time.Hour), Spent: 30 * time.Minute, Comment: " fixed redirect"},
+		{Issue: "ABC-1", Started: nine.Add(6 * time.Hour), Spent: 30 * time.Minute},
+	})
+	require.Equal(t, []standupItem{
+		{Issue: "ABC-1", Link: "https://jira.example.com/browse/ABC-1", Spent: 3 * time.Hour, Comments: []string{"fixed redirect"}},
+		{Issue: "ABC-2", Link: "https://jira.example.com/browse/ABC-2", Spent: time.Hour, Comments: []string{"review"}},
+	}, items)
+}
+
+func Test_formatStandup(t *testing.T) {
+	items := []standupItem{
+		{Issue: "ABC-1", Summary: "Login page", Link: "https://jira.example.com/browse/ABC-1", Spent: 3 * time.Hour, Comments: []string{"fixed redirect", "tests"}},
+		{Issue: "ABC-2", Spent: 90 * time.Minute},
+	}
+	require.Equal(t, "Yesterday:\n• ABC-1 Login page (3h): fixed redirect; tests\n• ABC-2 (1h30m)\n", formatStandup("Yesterday", items, false))
+	require.Equal(t, "**Yesterday**\n- [ABC-1](https://jira.example.com/browse/ABC-1) Login page (3h): fixed redirect; tests\n- ABC-2 (1h30m)\n", formatStandup("Yesterday", items, true))
+	require.Equal(t, "Friday 12.13:\n• nothing logged\n", formatStandup("Friday 12.13", nil, false))
+
+	running := []standupItem{{Issue: "ABC-3", Spent: 20 * time.Minute, Running: true, Paused: true}}
+	require.Equal(t, "Today:\n• ABC-3 (20m so far, paused)\n", formatStandup("Today", running, false))
+}
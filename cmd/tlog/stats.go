@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// projectStats is time logged to issues of one project.
+type projectStats struct {
+	Project string        `json:"project"`
+	Spent   time.Duration `json:"-"`
+	Hours   float64       `json:"hours"`
+	Percent float64       `json:"percent"`
+	Issues  int           `json:"issues"`
+}
+
+// runStats prints statistics of logged time.
+func runStats(args []string) error {
+	switch safeGet(args, 0) {
+	case "projects":
+		return runStatsProjects(args[1:])
+	case "habits":
+		return runStatsHabits(args[1:])
+	case "estimates":
+		return runStatsEstimates(args[1:])
+	default:
+		return errors.New("Usage: tlog stats projects|habits [day|from..to] [--output json] | estimates [jql|project] [--mine]")
+	}
+}
+
+// runStatsProjects prints time logged per project over range, the current
+// month by default.
+func runStatsProjects(args []string) error {
+	flags := flag.NewFlagSet("stats projects", flag.ContinueOnError)
+	asCSV := flags.Bool("csv", false, "print CSV")
+	tag := addTagFlag(flags)
+	xlsx := addXLSXFlag(flags)
+	output := flags.String("output", "text", "output format: text or json")
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog stats projects [day|from..to] [--csv] [--tag <tag>] [--output json]")
+	}
+	switch *output {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q, text or json expected", *output)
+	}
+	if *asCSV && *output == "json" {
+		return errors.New("--csv and --output json cannot be used together")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+	from, to, err := reportRange(conf, safeGet(positional, 0))
+	if err != nil {
+		return err
+	}
+	worklogs, err := reportWorklogs(conf, from, to)
+	if err != nil {
+		return err
+	}
+	worklogs = withTag(worklogs, *tag)
+	if *xlsx != "" {
+		return saveReportXLSX(*xlsx, conf, worklogs)
+	}
+	stats, total := statsByProject(worklogs)
+
+	switch {
+	case *asCSV:
+		return writeProjectStatsCSV(stats, total)
+	case *output == "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			From     string         `json:"from"`
+			To       string         `json:"to"`
+			Projects []projectStats `json:"projects"`
+			Total    projectStats   `json:"total"`
+		}{from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"), stats, total})
+	}
+
+	pterm.Printfln("%s – %s", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+	if len(stats) == 0 {
+		pterm.Println("Nothing is logged")
+		return nil
+	}
+	data := pterm.TableData{{"Project", "Time", "%", "Issues"}}
+	for _, s := range append(stats, total) {
+		data = append(data, []string{s.Project, parse.FormatDuration(s.Spent), formatPercent(s.Percent), strconv.Itoa(s.Issues)})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+// statsByProject sums worklogs up by project, the most time consuming one
+// first, and returns total of all of them too.
+func statsByProject(worklogs []Worklog) ([]projectStats, projectStats) {
+	byProject := map[string]*projectStats{}
+	issues := map[string]map[string]bool{}
+	total := projectStats{Project: "Total"}
+	allIssues := map[string]bool{}
+	for _, wl := range worklogs {
+		project := parse.Project(wl.Issue)
+		s, ok := byProject[project]
+		if !ok {
+			s = &projectStats{Project: project}
+			byProject[project] = s
+			issues[project] = map[string]bool{}
+		}
+		s.Spent += wl.Spent
+		issues[project][wl.Issue] = true
+		total.Spent += wl.Spent
+		allIssues[wl.Issue] = true
+	}
+
+	stats := make([]projectStats, 0, len(byProject))
+	for project, s := range byProject {
+		s.Issues = len(issues[project])
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Spent != stats[j].Spent {
+			return stats[i].Spent > stats[j].Spent
+		}
+		return stats[i].Project < stats[j].Project
+	})
+	for i := range stats {
+		stats[i].Hours = roundTo(stats[i].Spent.Hours(), 2)
+		if total.Spent > 0 {
+			stats[i].Percent = roundTo(100*float64(stats[i].Spent)/float64(total.Spent), 1)
+		}
+	}
+	total.Hours = roundTo(total.Spent.Hours(), 2)
+	total.Issues = len(allIssues)
+	if total.Spent > 0 {
+		total.Percent = 100
+	}
+	return stats, total
+}
+
+func writeProjectStatsCSV(stats []projectStats, total projectStats) error {
+	w := csv.NewWriter(os.Stdout)
+	records := [][]string{{"project", "hours", "percent", "issues"}}
+	for _, s := range append(stats, total) {
+		records = append(records, []string{
+			s.Project,
+			strconv.FormatFloat(s.Hours, 'f', 2, 64),
+			strconv.FormatFloat(s.Percent, 'f', 1, 64),
+			strconv.Itoa(s.Issues),
+		})
+	}
+	return w.WriteAll(records)
+}
+
+func formatPercent(p float64) string {
+	return strconv.FormatFloat(p, 'f', 1, 64) + "%"
+}
+
+func roundTo(v float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits))
+	return math.Round(v*scale) / scale
+}
+
+// habitStats tells how regularly time is logged on working days.
+type habitStats struct {
+	WorkingDays int     `json:"working_days"`
+	AverageDay  float64 `json:"average_hours"`
+	MedianDay   float64 `json:"median_hours"`
+	// the most working days in a row with at least WorkdayHours logged,
+	// days off between them do not break it
+	LongestStreak int     `json:"longest_streak"`
+	EmptyDays     int     `json:"empty_days"`
+	Logged        float64 `json:"logged_hours"`
+	Expected      float64 `json:"expected_hours"`
+	Verdict       string  `json:"verdict"`
+}
+
+// runStatsHabits prints averages and streaks of working days in range, the
+// current month by default. Days after today are not counted.
+func runStatsHabits(args []string) error {
+	flags := flag.NewFlagSet("stats habits", flag.ContinueOnError)
+	output := flags.String("output", "text", "output format: text or json")
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog stats habits [day|from..to] [--output json]")
+	}
+	switch *output {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q, text or json expected", *output)
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+	input := safeGet(positional, 0)
+	from, to, err := reportRange(conf, input)
+	if err != nil {
+		return err
+	}
+	today, _ := convertToDay("", conf.Location())
+	if tomorrow := today.AddDate(0, 0, 1); to.After(tomorrow) {
+		to = tomorrow
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("%s is in the future", from.Format("2006-01-02"))
+	}
+	// the whole range at once, days are counted up locally
+	worklogs, err := reportWorklogs(conf, from, to)
+	if err != nil {
+		return err
+	}
+
+	period := "this month"
+	if input != "" {
+		period = fmt.Sprintf("in %s – %s", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	stats := habitsOf(conf, worklogs, from, to, period)
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	pterm.Printfln("Working days: %d, %d of them with nothing logged", stats.WorkingDays, stats.EmptyDays)
+	pterm.Printfln("Average day: %s, median %s", formatHours(stats.AverageDay), formatHours(stats.MedianDay))
+	pterm.Printfln("Longest streak of full days: %d", stats.LongestStreak)
+	pterm.Println(pterm.Bold.Sprint(stats.Verdict))
+	return nil
+}
+
+// habitsOf computes habitStats of working days in [from, to), period names
+// the range in verdict.
+func habitsOf(conf Config, worklogs []Worklog, from, to time.Time, period string) habitStats {
+	loc := conf.Location()
+	perDay := map[string]time.Duration{}
+	var logged time.Duration
+	for _, wl := range worklogs {
+		perDay[wl.Started.In(loc).Format("2006-01-02")] += wl.Spent
+		logged += wl.Spent
+	}
+
+	var stats habitStats
+	var days []time.Duration
+	var onWorkdays time.Duration
+	streak := 0
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !conf.Calendar.IsWorkday(day) {
+			continue
+		}
+		spent := perDay[day.Format("2006-01-02")]
+		days = append(days, spent)
+		onWorkdays += spent
+		if spent == 0 {
+			stats.EmptyDays++
+		}
+		if spent >= conf.Workday() {
+			streak++
+			if streak > stats.LongestStreak {
+				stats.LongestStreak = streak
+			}
+		} else {
+			streak = 0
+		}
+	}
+
+	stats.WorkingDays = len(days)
+	if len(days) > 0 {
+		stats.AverageDay = roundTo((onWorkdays / time.Duration(len(days))).Hours(), 2)
+		sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+		median := days[len(days)/2]
+		if len(days)%2 == 0 {
+			median = (days[len(days)/2-1] + median) / 2
+		}
+		stats.MedianDay = roundTo(median.Hours(), 2)
+	}
+	expected := conf.Workday() * time.Duration(len(days))
+	stats.Logged = roundTo(logged.Hours(), 2)
+	stats.Expected = roundTo(expected.Hours(), 2)
+	switch diff := logged - expected; {
+	case diff < 0:
+		stats.Verdict = fmt.Sprintf("You are %s short %s", parse.FormatDuration(-diff), period)
+	case diff > 0:
+		stats.Verdict = fmt.Sprintf("You are %s ahead %s", parse.FormatDuration(diff), period)
+	default:
+		stats.Verdict = fmt.Sprintf("You are right on target %s", period)
+	}
+	return stats
+}
+
+// formatHours renders hours as duration, e.g. 6.5 as 6h30m.
+func formatHours(hours float64) string {
+	return parse.FormatDuration(time.Duration(hours * float64(time.Hour)).Round(time.Minute))
+}
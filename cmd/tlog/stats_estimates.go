@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// projectKeyRe is argument of `tlog stats estimates` meaning project rather than JQL.
+var projectKeyRe = regexp.MustCompile(`^[A-Z][A-Z0-9_]+$`)
+
+// estimateFinder is implemented by backends that know estimates of issues.
+type estimateFinder interface {
+	// IssueEstimates returns estimated issues with time logged found by
+	// jql, at most limit of them, and whether there are more. With mine
+	// only time logged by current user is counted.
+	IssueEstimates(jql string, limit int, mine bool) ([]backend.IssueEstimate, bool, error)
+}
+
+// overrun is issue logged beyond its estimate.
+type overrun struct {
+	backend.IssueEstimate
+	EstimateHours  float64 `json:"estimate_hours"`
+	LoggedHours    float64 `json:"logged_hours"`
+	RemainingHours float64 `json:"remaining_hours"`
+	Percent        float64 `json:"overrun_percent"`
+}
+
+// runStatsEstimates lists issues logged beyond their original estimate, the
+// worst first.
+func runStatsEstimates(args []string) error {
+	flags := flag.NewFlagSet("stats estimates", flag.ContinueOnError)
+	mine := flags.Bool("mine", false, "count only time logged by you")
+	limit := flags.Int("limit", 0, "read at most this many issues, EstimateIssueLimit if not set")
+	output := flags.String("output", "text", "output format: text or json")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog stats estimates [jql|project] [--mine] [--limit <issues>] [--output json], quote JQL")
+	}
+	switch *output {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q, text or json expected", *output)
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	if *limit > 0 {
+		conf.EstimateIssueLimit = *limit
+	}
+	b, err := backend.New(conf)
+	if err != nil {
+		return err
+	}
+	finder, ok := b.(estimateFinder)
+	if !ok {
+		return fmt.Errorf("%s backend does not know estimates of issues, JIRA does", conf.Backend)
+	}
+
+	estimates, more, err := finder.IssueEstimates(estimatesQuery(conf, safeGet(positional, 0)), conf.EstimateIssues(), *mine)
+	if err != nil {
+		return err
+	}
+	if more {
+		pterm.Warning.WithWriter(os.Stderr).Printfln("Only the first %d issues are compared, pass --limit or raise EstimateIssueLimit to read more", conf.EstimateIssues())
+	}
+	overruns := overrunsOf(estimates)
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(overruns)
+	}
+	if len(overruns) == 0 {
+		pterm.Println("No issue is logged beyond its estimate")
+		return nil
+	}
+	data := pterm.TableData{{"Issue", "Summary", "Estimate", "Logged", "Remaining", "Overrun"}}
+	for _, o := range overruns {
+		data = append(data, []string{
+			o.Issue, o.Summary, parse.FormatDuration(o.Estimate), parse.FormatDuration(o.Logged), parse.FormatDuration(o.Remaining), "+" + formatPercent(o.Percent),
+		})
+	}
+	return pterm.DefaultTable.WithHasHeader().WithData(data).Render()
+}
+
+// estimatesQuery returns JQL of issues to compare: given JQL, project of
+// given key or, without argument, DefaultProject or issues logged to by the user.
+func estimatesQuery(conf Config, input string) string {
+	switch {
+	case projectKeyRe.MatchString(input):
+		return fmt.Sprintf("project = %q", input)
+	case input != "":
+		return input
+	case conf.DefaultProject != "":
+		return fmt.Sprintf("project = %q", conf.DefaultProject)
+	}
+	return "worklogAuthor = currentUser()"
+}
+
+// overrunsOf returns issues logged beyond their estimate, by overrun
+// percentage, the biggest first.
+func overrunsOf(estimates []backend.IssueEstimate) []overrun {
+	overruns := []overrun{}
+	for _, e := range estimates {
+		if e.Estimate <= 0 || e.Logged <= e.Estimate {
+			continue
+		}
+		overruns = append(overruns, overrun{
+			IssueEstimate:  e,
+			EstimateHours:  roundTo(e.Estimate.Hours(), 2),
+			LoggedHours:    roundTo(e.Logged.Hours(), 2),
+			RemainingHours: roundTo(e.Remaining.Hours(), 2),
+			Percent:        roundTo(100*float64(e.Logged-e.Estimate)/float64(e.Estimate), 1),
+		})
+	}
+	sort.SliceStable(overruns, func(i, j int) bool {
+		if overruns[i].Percent != overruns[j].Percent {
+			return overruns[i].Percent > overruns[j].Percent
+		}
+		return overruns[i].Issue < overruns[j].Issue
+	})
+	return overruns
+}
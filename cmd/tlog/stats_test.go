@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_statsByProject(t *testing.T) {
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	worklogs := []Worklog{
+		{Issue: "APP-1", Started: day, Spent: 2 * time.Hour},
+		{Issue: "APP-2", Started: day, Spent: 3 * time.Hour},
+		{Issue: "OPS-7", Started: day, Spent: time.Hour},
+		{Issue: "APP-1", Started: day.AddDate(0, 0, 1), Spent: time.Hour},
+		{Issue: "group/web#12", Started: day, Spent: 2 * time.Hour},
+	}
+	stats, total := statsByProject(worklogs)
+	require.Equal(t, []projectStats{
+		{Project: "APP", Spent: 6 * time.Hour, Hours: 6, Percent: 66.7, Issues: 2},
+		{Project: "group/web", Spent: 2 * time.Hour, Hours: 2, Percent: 22.2, Issues: 1},
+		{Project: "OPS", Spent: time.Hour, Hours: 1, Percent: 11.1, Issues: 1},
+	}, stats)
+	require.Equal(t, projectStats{Project: "Total", Spent: 9 * time.Hour, Hours: 9, Percent: 100, Issues: 4}, total)
+
+	stats, total = statsByProject(nil)
+	require.Empty(t, stats)
+	require.Equal(t, projectStats{Project: "Total"}, total)
+}
+
+func Test_reportRange(t *testing.T) {
+	conf := Config{Timezone: "UTC"}
+	from, to, err := reportRange(conf, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, from.Day())
+	require.Equal(t, from.AddDate(0, 1, 0), to)
+
+	from, to, err = reportRange(conf, "2024.03.01..2024.03.15")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), from)
+	require.Equal(t, time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), to)
+}
+
+func Test_habitsOf(t *testing.T) {
+	conf := Config{Timezone: "UTC", Calendar: config.Calendar{Holidays: []string{"2024-03-06"}}}
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	at := func(days int, spent time.Duration) Worklog {
+		return Worklog{Issue: "APP-1", Started: monday.AddDate(0, 0, days).Add(9 * time.Hour), Spent: spent}
+	}
+	worklogs := []Worklog{
+		at(0, 8*time.Hour),
+		at(1, 6*time.Hour), at(1, 2*time.Hour),
+		at(2, time.Hour), // holiday
+		at(3, 8*time.Hour),
+		// friday is empty
+		at(5, 2*time.Hour), // saturday
+		at(7, 8*time.Hour),
+		at(8, 4*time.Hour),
+	}
+	// up to tuesday of the next week, 6 working days
+	stats := habitsOf(conf, worklogs, monday, monday.AddDate(0, 0, 9), "this month")
+	require.Equal(t, habitStats{
+		WorkingDays:   6,
+		AverageDay:    6,
+		MedianDay:     8,
+		LongestStreak: 3,
+		EmptyDays:     1,
+		Logged:        39,
+		Expected:      48,
+		Verdict:       "You are 9h short this month",
+	}, stats)
+
+	stats = habitsOf(conf, nil, monday.AddDate(0, 0, 5), monday.AddDate(0, 0, 7), "this month")
+	require.Equal(t, habitStats{Verdict: "You are right on target this month"}, stats)
+}
+
+func Test_overrunsOf(t *testing.T) {
+	overruns := overrunsOf([]backend.IssueEstimate{
+		{Issue: "APP-1", Estimate: time.Hour, Logged: 2 * time.Hour},
+		{Issue: "APP-2", Estimate: 2 * time.Hour, Logged: time.Hour},
+		{Issue: "APP-3", Estimate: time.Hour, Logged: 90 * time.Minute, Remaining: 30 * time.Minute},
+		{Issue: "APP-4", Logged: time.Hour},
+	})
+	require.Len(t, overruns, 2)
+	require.Equal(t, "APP-1", overruns[0].Issue)
+	require.Equal(t, 100.0, overruns[0].Percent)
+	require.Equal(t, overrun{
+		IssueEstimate:  backend.IssueEstimate{Issue: "APP-3", Estimate: time.Hour, Logged: 90 * time.Minute, Remaining: 30 * time.Minute},
+		EstimateHours:  1,
+		LoggedHours:    1.5,
+		RemainingHours: 0.5,
+		Percent:        50,
+	}, overruns[1])
+
+	require.Equal(t, `project = "APP"`, estimatesQuery(Config{}, "APP"))
+	require.Equal(t, `sprint in openSprints()`, estimatesQuery(Config{DefaultProject: "APP"}, "sprint in openSprints()"))
+	require.Equal(t, `project = "OPS"`, estimatesQuery(Config{DefaultProject: "OPS"}, ""))
+	require.Equal(t, "worklogAuthor = currentUser()", estimatesQuery(Config{}, ""))
+}
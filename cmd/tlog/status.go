@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// runStatus prints active timer and today's progress.
+// It is meant to be called from shell prompts, so by default it only reads local state.
+func runStatus(args []string) error {
+	flags := flag.NewFlagSet("status", flag.ContinueOnError)
+	remote := flags.Bool("remote", false, "query JIRA for today's total instead of local ledger")
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records with --remote")
+	bar := flags.Bool("bar", false, "print single-line JSON for waybar/polybar custom modules")
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+
+	if *bar {
+		return runStatusBar()
+	}
+
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+
+	now := time.Now()
+	t, err := loadTimer()
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		pterm.Println("No timer running")
+	} else {
+		state := pterm.Green("running")
+		if t.Paused() {
+			state = pterm.Yellow("paused")
+		}
+		elapsed := t.Elapsed(now)
+		pterm.Printfln("%s %s (%s)", pterm.Cyan(t.Issue), parse.FormatDuration(elapsed), state)
+		if elapsed > conf.StaleTimer() {
+			pterm.Println(pterm.Yellow("Timer might have been left running, use `tlog stop --trim <duration>` to log only part of it"))
+		}
+	}
+
+	today, _ := convertToDay("", conf.Location())
+	logged, err := loggedDay(conf, today, *remote)
+	if err != nil {
+		return err
+	}
+
+	workday := conf.Workday()
+	left := workday - logged
+	if left > 0 {
+		pterm.Printfln("Today: %s of %s logged, %s left", parse.FormatDuration(logged), parse.FormatDuration(workday), pterm.Yellow(parse.FormatDuration(left)))
+	} else {
+		pterm.Printfln("Today: %s of %s logged %s", parse.FormatDuration(logged), parse.FormatDuration(workday), pterm.Green("✔"))
+	}
+
+	if !*remote {
+		entries, err := backend.ReadLedger(conf)
+		if err != nil {
+			return err
+		}
+		week := loggedWeek(entries, today)
+		target := weekTarget(conf, today)
+		pterm.Printfln("Week: %s of %s logged (%s)", parse.FormatDuration(week), parse.FormatDuration(target), formatDelta(week-target))
+	}
+
+	queued, err := readQueue(conf)
+	if err != nil {
+		return err
+	}
+	if len(queued) > 0 {
+		var total time.Duration
+		for _, q := range queued {
+			total += q.worklog().Spent
+		}
+		pterm.Println(pterm.Yellow(fmt.Sprintf("Queued: %d worklogs, %s, run `tlog sync` to log them", len(queued), parse.FormatDuration(total))))
+	}
+
+	return nil
+}
+
+// loggedWeek returns time logged from monday of day's week up to day.
+func loggedWeek(entries []backend.LedgerEntry, day time.Time) time.Duration {
+	offset := (int(day.Weekday()) + 6) % 7 // days since monday
+	var total time.Duration
+	for i := 0; i <= offset; i++ {
+		total += loggedOn(entries, day.AddDate(0, 0, -i))
+	}
+	return total
+}
+
+// formatDelta renders difference from target with explicit sign, e.g. +1h or -30m.
+func formatDelta(d time.Duration) string {
+	if d < 0 {
+		return pterm.Yellow(parse.FormatDuration(d))
+	}
+	return pterm.Green("+" + parse.FormatDuration(d))
+}
+
+// loggedDay returns time logged at given day, according to local ledger or JIRA.
+func loggedDay(conf Config, day time.Time, remote bool) (time.Duration, error) {
+	if remote {
+		return remoteLoggedOn(conf, day)
+	}
+	entries, err := backend.ReadLedger(conf)
+	if err != nil {
+		return 0, err
+	}
+	return loggedOn(entries, day), nil
+}
+
+// remoteLoggedOn asks backend how much time current user logged at given day.
+func remoteLoggedOn(conf Config, day time.Time) (time.Duration, error) {
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	worklogs, err := reportWorklogs(conf, from, from.AddDate(0, 0, 1))
+	if err != nil {
+		return 0, err
+	}
+
+	var total time.Duration
+	for _, wl := range worklogs {
+		total += wl.Spent
+	}
+	return total, nil
+}
+
+// BarStatus is a waybar custom module output.
+// See https://github.com/Alexays/Waybar/wiki/Module:-Custom
+type BarStatus struct {
+	Text       string `json:"text"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Percentage int    `json:"percentage"`
+}
+
+// runStatusBar prints status for status bars. It is polled every few seconds,
+// so it must never touch network and never fail loudly.
+func runStatusBar() error {
+	conf, err := LoadConfigQuiet()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	t, err := loadTimer()
+	if err != nil {
+		return err
+	}
+	entries, err := backend.ReadLedger(conf)
+	if err != nil {
+		return err
+	}
+
+	today, _ := convertToDay("", conf.Location())
+	status := barStatus(t, entries, conf.Workday(), today, time.Now())
+	return json.NewEncoder(os.Stdout).Encode(status)
+}
+
+func barStatus(t *Timer, entries []backend.LedgerEntry, workday time.Duration, today, now time.Time) BarStatus {
+	perIssue := map[string]time.Duration{}
+	var logged time.Duration
+	for _, e := range entries {
+		if !sameDay(e.Started, today) {
+			continue
+		}
+		spent := time.Duration(e.Seconds) * time.Second
+		perIssue[e.Issue] += spent
+		logged += spent
+	}
+
+	issues := make([]string, 0, len(perIssue))
+	for issue := range perIssue {
+		issues = append(issues, issue)
+	}
+	sort.Strings(issues)
+
+	tooltip := []string{fmt.Sprintf("Today: %s of %s", parse.FormatDuration(logged), parse.FormatDuration(workday))}
+	for _, issue := range issues {
+		tooltip = append(tooltip, fmt.Sprintf("%s %s", issue, parse.FormatDuration(perIssue[issue])))
+	}
+
+	status := BarStatus{Class: "idle", Text: parse.FormatDuration(logged)}
+	progress := logged
+	if t != nil {
+		elapsed := t.Elapsed(now)
+		progress += elapsed
+		status.Text = fmt.Sprintf("%s %s", t.Issue, parse.FormatDuration(elapsed))
+		status.Class = "running"
+		if t.Paused() {
+			status.Class = "paused"
+		}
+		tooltip = append(tooltip, fmt.Sprintf("%s %s on timer (%s)", t.Issue, parse.FormatDuration(elapsed), status.Class))
+	}
+	status.Tooltip = strings.Join(tooltip, "\n")
+
+	if workday > 0 {
+		status.Percentage = int(100 * progress / workday)
+		if status.Percentage > 100 {
+			status.Percentage = 100
+		}
+	}
+	return status
+}
+
+// loggedOn sums time of entries started on given day.
+func loggedOn(entries []backend.LedgerEntry, day time.Time) time.Duration {
+	var total time.Duration
+	for _, e := range entries {
+		if sameDay(e.Started, day) {
+			total += time.Duration(e.Seconds) * time.Second
+		}
+	}
+	return total
+}
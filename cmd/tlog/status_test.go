@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_barStatus(t *testing.T) {
+	today := time.Date(2022, time.October, 10, 0, 0, 0, 0, time.UTC)
+	now := today.Add(15 * time.Hour)
+	pausedAt := now.Add(-30 * time.Minute)
+	entries := []backend.LedgerEntry{
+		{Issue: "B-2", Started: today, Seconds: 3600},
+		{Issue: "A-1", Started: today, Seconds: 1800},
+		{Issue: "B-2", Started: today, Seconds: 1800},
+		{Issue: "C-3", Started: today.Add(-24 * time.Hour), Seconds: 3600},
+	}
+
+	tests := []struct {
+		name  string
+		timer *Timer
+		want  BarStatus
+	}{
+		{
+			name: "idle",
+			want: BarStatus{
+				Text:       "2h",
+				Tooltip:    "Today: 2h of 8h\nA-1 30m\nB-2 1h30m",
+				Class:      "idle",
+				Percentage: 25,
+			},
+		},
+		{
+			name:  "running",
+			timer: &Timer{Issue: "A-1", StartedAt: now.Add(-2 * time.Hour)},
+			want: BarStatus{
+				Text:       "A-1 2h",
+				Tooltip:    "Today: 2h of 8h\nA-1 30m\nB-2 1h30m\nA-1 2h on timer (running)",
+				Class:      "running",
+				Percentage: 50,
+			},
+		},
+		{
+			name:  "paused",
+			timer: &Timer{Issue: "A-1", StartedAt: now.Add(-90 * time.Minute), PausedAt: &pausedAt},
+			want: BarStatus{
+				Text:       "A-1 1h",
+				Tooltip:    "Today: 2h of 8h\nA-1 30m\nB-2 1h30m\nA-1 1h on timer (paused)",
+				Class:      "paused",
+				Percentage: 37,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, barStatus(tt.timer, entries, 8*time.Hour, today, now))
+		})
+	}
+}
+
+func Test_loggedWeek(t *testing.T) {
+	wednesday := time.Date(2022, time.October, 12, 0, 0, 0, 0, time.UTC)
+	entries := []backend.LedgerEntry{
+		{Issue: "A-1", Started: wednesday, Seconds: 3600},
+		{Issue: "A-1", Started: wednesday.AddDate(0, 0, -2), Seconds: 1800}, // monday
+		{Issue: "A-1", Started: wednesday.AddDate(0, 0, -3), Seconds: 3600}, // previous sunday
+		{Issue: "A-1", Started: wednesday.AddDate(0, 0, 1), Seconds: 3600},  // tomorrow
+	}
+	require.Equal(t, 90*time.Minute, loggedWeek(entries, wednesday))
+	require.Equal(t, 30*time.Minute, loggedWeek(entries, wednesday.AddDate(0, 0, -2)))
+	require.Equal(t, time.Hour, loggedWeek(entries, wednesday.AddDate(0, 0, -3)))
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+)
+
+const (
+	// commits further apart than that are separate sessions of work
+	suggestMaxGap = 2 * time.Hour
+	// work done before the first commit of a session
+	suggestFirstCommit = 30 * time.Minute
+)
+
+// repoCommit is a commit of one of scanned repositories.
+type repoCommit struct {
+	gitCommit
+	Repo string
+}
+
+// dayCommits returns commits of the user authored in [from, to) across repos,
+// oldest first. Repositories that cannot be read are skipped.
+func dayCommits(repos []string, from, to time.Time) []repoCommit {
+	var commits []repoCommit
+	seen := map[string]bool{}
+	for _, repo := range repos {
+		args := []string{"--all", "--reverse", "--since=" + from.Format(time.RFC3339), "--until=" + to.Format(time.RFC3339)}
+		if email := gitUserEmail(repo); email != "" {
+			args = append(args, "--author="+email)
+		}
+		log, err := gitLog(repo, args...)
+		if err != nil {
+			debugf("skip %s: %s", repo, err)
+			continue
+		}
+		name := repo
+		if abs, err := filepath.Abs(repo); err == nil {
+			name = filepath.Base(abs)
+		}
+		for _, c := range log {
+			// clones of the same repository share commits
+			if seen[c.SHA] || c.Author.Before(from) || !c.Author.Before(to) {
+				continue
+			}
+			seen[c.SHA] = true
+			commits = append(commits, repoCommit{gitCommit: c, Repo: name})
+		}
+	}
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].Author.Before(commits[j].Author) })
+	return commits
+}
+
+// suggestEntries estimates time spent on issues from commits sorted by time:
+// a commit took the time since the previous one, unless they are more than
+// suggestMaxGap apart, then it took suggestFirstCommit. Commits logged before
+// are left out. It returns worklog per issue and number of commits that
+// mention no issue.
+func suggestEntries(conf Config, commits []repoCommit, imported map[string]bool) ([]importEntry, int, error) {
+	step, mode, err := conf.Rounding()
+	if err != nil {
+		return nil, 0, err
+	}
+	var entries []importEntry
+	index := map[string]int{}
+	var repos [][]string
+	noIssue := 0
+	for i, c := range commits {
+		start := c.Author.Add(-suggestFirstCommit)
+		if i > 0 && c.Author.Sub(commits[i-1].Author) <= suggestMaxGap {
+			start = commits[i-1].Author
+		}
+		id := "git:" + c.SHA
+		issue := c.issue()
+		switch {
+		case imported[id]:
+			continue
+		case issue == "":
+			noIssue++
+			continue
+		}
+
+		j, ok := index[issue]
+		if !ok {
+			j = len(entries)
+			index[issue] = j
+			entries = append(entries, importEntry{Worklog: Worklog{Issue: issue, Started: start.In(conf.Location())}})
+			repos = append(repos, nil)
+		}
+		e := &entries[j]
+		e.Worklog.Spent += c.Author.Sub(start)
+		if start.Before(e.Worklog.Started) {
+			e.Worklog.Started = start.In(conf.Location())
+		}
+		if comment := trimIssuePrefix(c.Subject, issue); !containsString(strings.Split(e.Worklog.Comment, "; "), comment) {
+			e.Worklog.Comment = strings.Join(nonEmpty(e.Worklog.Comment, comment), "; ")
+		}
+		if !containsString(repos[j], c.Repo) {
+			repos[j] = append(repos[j], c.Repo)
+		}
+		e.ImportIDs = append(e.ImportIDs, id)
+	}
+	for i := range entries {
+		e := &entries[i]
+		e.Source = strings.Join(repos[i], ", ")
+		if e.Worklog.Spent = roundDuration(e.Worklog.Spent, step, mode); e.Worklog.Spent < step {
+			e.Worklog.Spent = step
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Worklog.Started.Before(entries[j].Worklog.Started) })
+	return entries, noIssue, nil
+}
+
+// trimIssuePrefix removes issue key commit subject starts with, e.g. "[PRJ-1] fix" becomes "fix".
+func trimIssuePrefix(subject, issue string) string {
+	re := regexp.MustCompile(`^\[?` + regexp.QuoteMeta(issue) + `\]?[\s:,-]*`)
+	if trimmed := re.ReplaceAllString(subject, ""); trimmed != "" {
+		return trimmed
+	}
+	return subject
+}
+
+// editSuggestion lets user change issue, time and comment of suggested worklog,
+// empty time skips it.
+func editSuggestion(conf Config, e importEntry) (importEntry, bool, error) {
+	pterm.Info.Printfln("%s, %d commits in %s: %s", e.Worklog.Issue, len(e.ImportIDs), e.Source, e.Worklog.Comment)
+	issuePrompt := promptui.Prompt{
+		Label:     pterm.LightBlue("Issue or alias"),
+		Default:   e.Worklog.Issue,
+		AllowEdit: true,
+		Validate: func(input string) error {
+			_, err := resolveImportTarget(conf, input)
+			return err
+		},
+	}
+	input, err := issuePrompt.Run()
+	if err != nil {
+		return e, false, errSilent
+	}
+	if e.Worklog.Issue, err = resolveImportTarget(conf, input); err != nil {
+		return e, false, err
+	}
+
+	timePrompt := promptui.Prompt{
+		Label:     pterm.LightBlue("Time to log, empty to skip"),
+		Default:   parse.FormatDuration(e.Worklog.Spent),
+		AllowEdit: true,
+		Validate: func(input string) error {
+			if input == "" {
+				return nil
+			}
+			_, err := convertToTimeLog(input, conf.Workday())
+			return err
+		},
+	}
+	if input, err = timePrompt.Run(); err != nil {
+		return e, false, errSilent
+	}
+	if input == "" {
+		return e, false, nil
+	}
+	if e.Worklog.Spent, err = convertToTimeLog(input, conf.Workday()); err != nil {
+		return e, false, err
+	}
+
+	commentPrompt := promptui.Prompt{Label: pterm.LightBlue("Comment"), Default: e.Worklog.Comment, AllowEdit: true}
+	if e.Worklog.Comment, err = commentPrompt.Run(); err != nil {
+		return e, false, errSilent
+	}
+	return e, true, nil
+}
+
+// runSuggest drafts worklogs of the day from git commits, nothing is logged
+// until user reviews them.
+func runSuggest(args []string) error {
+	flags := flag.NewFlagSet("suggest", flag.ContinueOnError)
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return errSilent
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog suggest [day]")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	from, err := convertToDay(safeGet(positional, 0), conf.Location())
+	if err != nil {
+		return err
+	}
+	repos, err := gitRepos(conf)
+	if err != nil {
+		return err
+	}
+	imported, err := importedIDs(conf)
+	if err != nil {
+		return err
+	}
+	suggested, noIssue, err := suggestEntries(conf, dayCommits(repos, from, from.AddDate(0, 0, 1)), imported)
+	if err != nil {
+		return err
+	}
+	if noIssue > 0 {
+		pterm.Info.Printfln("%d commits mention no issue in message or branch name, they are left out", noIssue)
+	}
+	if len(suggested) == 0 {
+		pterm.Info.Printfln("No commits to suggest worklogs from on %s", from.Format("2006-01-02"))
+		return nil
+	}
+
+	drafts := pterm.TableData{{"Day", "Issue", "Time", "Commits", "Comment", "Repository"}}
+	for _, e := range suggested {
+		wl := e.Worklog
+		drafts = append(drafts, []string{wl.Started.Format("2006-01-02 15:04"), wl.Issue, parse.FormatDuration(wl.Spent), strconv.Itoa(len(e.ImportIDs)), wl.Comment, e.Source})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(drafts).Render(); err != nil {
+		return err
+	}
+	if !stdinIsTerminal() {
+		pterm.Info.Println("Nothing is logged, run `tlog suggest` in terminal to review and log suggestions")
+		return nil
+	}
+
+	var edited []importEntry
+	for _, e := range suggested {
+		e, keep, err := editSuggestion(conf, e)
+		if err != nil {
+			return err
+		}
+		if keep {
+			edited = append(edited, e)
+		}
+	}
+	selected, err := reviewImport(conf, edited, importOptions{})
+	if err != nil {
+		return err
+	}
+	return submitImport(conf, selected, importOptions{})
+}
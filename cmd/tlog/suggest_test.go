@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/stretchr/testify/require"
+)
+
+// gitRepo creates repository whose commits are authored by jane@example.com.
+func gitRepo(t *testing.T) (string, func(args ...string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	run("init", "-q", "-b", "main")
+	run("config", "user.email", "jane@example.com")
+	run("config", "user.name", "Jane")
+	return dir, run
+}
+
+// commitAt creates empty commit with given author and commit time.
+func commitAt(t *testing.T, run func(args ...string), at, message string) {
+	t.Setenv("GIT_COMMITTER_DATE", at)
+	run("commit", "-q", "--allow-empty", "--date="+at, "-m", message)
+}
+
+func Test_suggestEntries(t *testing.T) {
+	dir, run := gitRepo(t)
+	commitAt(t, run, "2024-03-03T18:00:00Z", "PRJ-1 yesterday")
+	commitAt(t, run, "2024-03-04T09:00:00Z", "PRJ-1 start")
+	commitAt(t, run, "2024-03-04T09:40:00Z", "[PRJ-1] tests")
+	run("checkout", "-q", "-b", "feature/prj-3-ui")
+	commitAt(t, run, "2024-03-04T10:10:00Z", "ui")
+	run("checkout", "-q", "main")
+	commitAt(t, run, "2024-03-04T14:00:00Z", "PRJ-1: start")
+	commitAt(t, run, "2024-03-04T14:20:00Z", "readme")
+
+	conf := Config{Timezone: "UTC"}
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	commits := dayCommits([]string{dir, t.TempDir()}, from, from.AddDate(0, 0, 1))
+	require.Len(t, commits, 5, "other days and not a repository are skipped")
+
+	entries, noIssue, err := suggestEntries(conf, commits, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, noIssue)
+	var got []string
+	for _, e := range entries {
+		wl := e.Worklog
+		got = append(got, strings.Join([]string{wl.Started.Format("15:04"), wl.Issue, parse.FormatDuration(wl.Spent), wl.Comment}, " "))
+	}
+	require.Equal(t, []string{
+		"08:30 PRJ-1 1h40m start; tests",
+		"09:40 PRJ-3 30m ui",
+	}, got, "first commit of a session takes 30m, the rest time since previous commit")
+
+	entries, _, err = suggestEntries(conf, commits, map[string]bool{entries[0].ImportIDs[1]: true})
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, entries[0].Worklog.Spent, "logged commits are left out")
+}
+
+func Test_trimIssuePrefix(t *testing.T) {
+	require.Equal(t, "fix login", trimIssuePrefix("PRJ-1: fix login", "PRJ-1"))
+	require.Equal(t, "fix login", trimIssuePrefix("[PRJ-1] fix login", "PRJ-1"))
+	require.Equal(t, "fix PRJ-1 login", trimIssuePrefix("fix PRJ-1 login", "PRJ-1"))
+	require.Equal(t, "PRJ-1", trimIssuePrefix("PRJ-1", "PRJ-1"))
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// tagRe finds tags in worklog comments: # and a letter followed by letters,
+// digits, "_" or "-", at the start of comment or after a space, e.g.
+// "#oncall". GitLab references like group/app#12 are not tags.
+var tagRe = regexp.MustCompile(`(?i)(?:^|\s)#(\pL[\pL\pN_-]*)`)
+
+// untagged is the bucket of time logged without tags.
+const untagged = "(untagged)"
+
+// worklogTags returns tags of comment, lowercase and without repetitions.
+func worklogTags(comment string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, m := range tagRe.FindAllStringSubmatch(comment, -1) {
+		tag := strings.ToLower(m[1])
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// addTagFlag adds --tag of reports to flags.
+func addTagFlag(flags *flag.FlagSet) *string {
+	return flags.String("tag", "", "count only worklogs tagged with it in comment, e.g. oncall")
+}
+
+// withTag returns worklogs tagged with tag, all of them without tag.
+func withTag(worklogs []Worklog, tag string) []Worklog {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if tag == "" {
+		return worklogs
+	}
+	var tagged []Worklog
+	for _, wl := range worklogs {
+		for _, t := range worklogTags(wl.Comment) {
+			if t == tag {
+				tagged = append(tagged, wl)
+				break
+			}
+		}
+	}
+	return tagged
+}
+
+// tagTotal is time logged with one tag.
+type tagTotal struct {
+	Tag   string        `json:"tag"`
+	Spent time.Duration `json:"-"`
+	Hours float64       `json:"hours"`
+	// worklogs tagged with it
+	Entries int `json:"entries"`
+}
+
+// runReportTags prints time logged over range, the current month by
+// default, by tag of worklog comments.
+func runReportTags(args []string) error {
+	flags := flag.NewFlagSet("report tags", flag.ContinueOnError)
+	xlsx := addXLSXFlag(flags)
+	output := flags.String("output", "text", "output format: text or json")
+	limit := flags.Int("limit", config.DefaultWorklogLimit, "read at most this many worklog records")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog report tags [day|from..to] [--output json]")
+	}
+	switch *output {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown output format %q, text or json expected", *output)
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	conf.SetWorklogLimit(*limit)
+	from, to, err := reportRange(conf, safeGet(positional, 0))
+	if err != nil {
+		return err
+	}
+	worklogs, err := reportWorklogs(conf, from, to)
+	if err != nil {
+		return err
+	}
+	if *xlsx != "" {
+		return saveReportXLSX(*xlsx, conf, worklogs)
+	}
+	tags, total := tagTotals(worklogs)
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			From        string     `json:"from"`
+			To          string     `json:"to"`
+			Tags        []tagTotal `json:"tags"`
+			LoggedHours float64    `json:"logged_hours"`
+		}{from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"), tags, roundTo(total.Hours(), 2)})
+	}
+	pterm.Printfln("%s – %s", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02"))
+	if len(tags) == 0 {
+		pterm.Println("Nothing is logged")
+		return nil
+	}
+	data := pterm.TableData{{"Tag", "Time", "Entries"}}
+	for _, t := range tags {
+		name := "#" + t.Tag
+		if t.Tag == untagged {
+			name = t.Tag
+		}
+		data = append(data, []string{name, parse.FormatDuration(t.Spent), fmt.Sprint(t.Entries)})
+	}
+	if err := pterm.DefaultTable.WithHasHeader().WithData(data).Render(); err != nil {
+		return err
+	}
+	pterm.Printfln("Logged %s, worklogs with several tags count to each of them", parse.FormatDuration(total))
+	return nil
+}
+
+// tagTotals sums worklogs up by tag, the most time first, untagged ones
+// last. Worklog with several tags counts to each. Total of all worklogs is
+// returned too.
+func tagTotals(worklogs []Worklog) ([]tagTotal, time.Duration) {
+	byTag := map[string]*tagTotal{}
+	var total time.Duration
+	for _, wl := range worklogs {
+		total += wl.Spent
+		tags := worklogTags(wl.Comment)
+		if len(tags) == 0 {
+			tags = []string{untagged}
+		}
+		for _, tag := range tags {
+			t, ok := byTag[tag]
+			if !ok {
+				t = &tagTotal{Tag: tag}
+				byTag[tag] = t
+			}
+			t.Spent += wl.Spent
+			t.Entries++
+		}
+	}
+
+	totals := make([]tagTotal, 0, len(byTag))
+	for _, t := range byTag {
+		t.Hours = roundTo(t.Spent.Hours(), 2)
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		a, b := totals[i], totals[j]
+		if (a.Tag == untagged) != (b.Tag == untagged) {
+			return b.Tag == untagged
+		}
+		if a.Spent != b.Spent {
+			return a.Spent > b.Spent
+		}
+		return a.Tag < b.Tag
+	})
+	return totals, total
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_worklogTags(t *testing.T) {
+	require.Equal(t, []string{"oncall", "review"}, worklogTags("#OnCall paged at night, #review of group/app#12 #oncall"))
+	require.Equal(t, []string{"größe"}, worklogTags("#Größe"))
+	require.Empty(t, worklogTags("fixed #12 and a#b"))
+}
+
+func Test_tagTotals(t *testing.T) {
+	worklogs := []Worklog{
+		{Issue: "OPS-1", Spent: 2 * time.Hour, Comment: "#oncall #review"},
+		{Issue: "OPS-2", Spent: time.Hour, Comment: "#oncall"},
+		{Issue: "APP-1", Spent: 5 * time.Hour, Comment: "coding"},
+		{Issue: "APP-2", Spent: 30 * time.Minute, Comment: "#Review"},
+	}
+
+	tags, total := tagTotals(worklogs)
+	require.Equal(t, 8*time.Hour+30*time.Minute, total)
+	require.Equal(t, []tagTotal{
+		{Tag: "oncall", Spent: 3 * time.Hour, Hours: 3, Entries: 2},
+		{Tag: "review", Spent: 150 * time.Minute, Hours: 2.5, Entries: 2},
+		{Tag: untagged, Spent: 5 * time.Hour, Hours: 5, Entries: 1},
+	}, tags, "worklog with two tags counts to both, untagged last")
+
+	require.Len(t, withTag(worklogs, "#REVIEW"), 2)
+	require.Len(t, withTag(worklogs, ""), 4)
+}
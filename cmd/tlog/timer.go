@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+)
+
+const (
+	timerFile     = "timer.json"
+	timerLockFile = "timer.lock"
+)
+
+// Timer is a running (or paused) time tracking session started with `tlog start`.
+// Only wall-clock timestamps are stored, so timer survives reboots and crashes.
+type Timer struct {
+	Issue     string     `json:"issue"`
+	Context   string     `json:"context,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+	StartedAt time.Time  `json:"started_at"`
+	PausedAt  *time.Time `json:"paused_at,omitempty"`
+	Pauses    []Pause    `json:"pauses,omitempty"`
+}
+
+// Pause is a finished pause of the timer.
+type Pause struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+func (t Timer) Paused() bool {
+	return t.PausedAt != nil
+}
+
+// Elapsed returns tracked time excluding pauses.
+func (t Timer) Elapsed(now time.Time) time.Duration {
+	end := now
+	if t.PausedAt != nil {
+		end = *t.PausedAt
+	}
+	elapsed := end.Sub(t.StartedAt)
+	for _, p := range t.Pauses {
+		elapsed -= p.To.Sub(p.From)
+	}
+	return elapsed
+}
+
+// loadTimer returns active timer or nil if there is none.
+func loadTimer() (*Timer, error) {
+	path, err := state.Path(timerFile)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read timer: %w", err)
+	}
+
+	var t Timer
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("corrupted timer file %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+func saveTimer(t Timer) error {
+	path, err := state.Path(timerFile)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return state.WriteFileAtomic(path, data, 0600)
+}
+
+func removeTimer() error {
+	path, err := state.Path(timerFile)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// updateTimer runs fn holding timer lock, so concurrent tlog processes
+// do not overwrite each other's changes.
+func updateTimer(fn func(t *Timer) error) error {
+	path, err := state.Path(timerLockFile)
+	if err != nil {
+		return err
+	}
+	unlock, err := state.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	t, err := loadTimer()
+	if err != nil {
+		return err
+	}
+	return fn(t)
+}
+
+func runStart(args []string) error {
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+
+	taskInput := safeGet(args, 0)
+	jiraID, err := resolveOrPickTask(conf, taskInput)
+	if err != nil {
+		return err
+	}
+
+	comment := safeGet(args, 1)
+	if comment == "" {
+		comment = conf.AliasComment(taskInput)
+	}
+
+	err = updateTimer(func(active *Timer) error {
+		if active != nil {
+			return fmt.Errorf("timer for %s is already running, stop it first", active.Issue)
+		}
+		return saveTimer(Timer{Issue: jiraID, Context: conf.Context(), Comment: comment, StartedAt: time.Now()})
+	})
+	if err != nil {
+		return err
+	}
+
+	pterm.Success.Printfln("Timer started for %s", jiraID)
+	return nil
+}
+
+func runPause() error {
+	var paused Timer
+	err := updateTimer(func(t *Timer) error {
+		if t == nil {
+			return errors.New("no timer is running")
+		}
+		if t.Paused() {
+			return fmt.Errorf("timer for %s is already paused", t.Issue)
+		}
+
+		t.PausedAt = toPtr(time.Now())
+		paused = *t
+		return saveTimer(*t)
+	})
+	if err != nil {
+		return err
+	}
+
+	pterm.Success.Printfln("Timer for %s paused at %s", paused.Issue, parse.FormatDuration(paused.Elapsed(time.Now())))
+	return nil
+}
+
+func runResume() error {
+	var resumed Timer
+	err := updateTimer(func(t *Timer) error {
+		if t == nil {
+			return errors.New("no timer is running")
+		}
+		if !t.Paused() {
+			return fmt.Errorf("timer for %s is not paused", t.Issue)
+		}
+
+		t.Pauses = append(t.Pauses, Pause{From: *t.PausedAt, To: time.Now()})
+		t.PausedAt = nil
+		resumed = *t
+		return saveTimer(*t)
+	})
+	if err != nil {
+		return err
+	}
+
+	pterm.Success.Printfln("Timer for %s resumed", resumed.Issue)
+	return nil
+}
+
+func runStop(args []string) error {
+	flags := flag.NewFlagSet("stop", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "log rounded duration without confirmation")
+	trim := flags.String("trim", "", "log this duration instead of the whole tracked time")
+	if err := flags.Parse(args); err != nil {
+		return shownError{err}
+	}
+
+	t, err := loadTimer()
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return errors.New("no timer is running")
+	}
+
+	// time is logged to JIRA the timer was started for
+	globalOpts.Context = t.Context
+	if t.Context == "" {
+		globalOpts.Context = config.DefaultContext
+	}
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+
+	step, mode, err := conf.Rounding()
+	if err != nil {
+		return err
+	}
+
+	raw := t.Elapsed(time.Now())
+	switch {
+	case *trim != "":
+		raw, err = convertToTimeLog(*trim, conf.Workday())
+		if err != nil {
+			return fmt.Errorf("--trim: %w", err)
+		}
+	case raw > conf.StaleTimer():
+		pterm.Warning.Printfln("Timer for %s has been running for %s, it might have been left running.", t.Issue, parse.FormatDuration(raw))
+		if *yes {
+			return errors.New("pass --trim <duration> to log only part of it")
+		}
+		raw, err = promptTrim(raw, conf.Workday())
+		if err != nil {
+			return err
+		}
+	}
+	spent := roundDuration(raw, step, mode)
+
+	if spent == 0 {
+		// JIRA does not accept empty worklogs, so either log minimal increment or drop the timer
+		logMin := fmt.Sprintf("Log %s", parse.FormatDuration(step))
+		choice := logMin
+		if !*yes {
+			choice, _ = pterm.DefaultInteractiveSelect.
+				WithDefaultText(fmt.Sprintf("%s rounds down to zero", parse.FormatDuration(raw))).
+				WithOptions([]string{logMin, "Discard timer"}).
+				Show()
+		}
+		if choice != logMin {
+			pterm.Info.Printfln("Timer for %s discarded", t.Issue)
+			return updateTimer(func(*Timer) error { return removeTimer() })
+		}
+		spent = step
+	} else if !*yes {
+		confirmed, _ := pterm.DefaultInteractiveConfirm.WithDefaultValue(true).Show(pterm.Sprintf(
+			"Tracked %s, log %s to %s?", parse.FormatDuration(raw), pterm.Yellow(parse.FormatDuration(spent)), t.Issue,
+		))
+		if !confirmed {
+			return errors.New("timer is still running")
+		}
+	}
+
+	if err := addWorklog(conf, Worklog{Issue: t.Issue, Started: t.StartedAt, Spent: spent, Comment: t.Comment}); err != nil {
+		return err
+	}
+
+	return updateTimer(func(current *Timer) error {
+		// someone might have restarted the timer while we were talking to JIRA
+		if current == nil || !current.StartedAt.Equal(t.StartedAt) {
+			return nil
+		}
+		return removeTimer()
+	})
+}
+
+func promptTrim(tracked, workday time.Duration) (time.Duration, error) {
+	prompt := promptui.Prompt{
+		Label:   pterm.LightBlue("How much time should be logged?"),
+		Default: parse.FormatDuration(tracked),
+		Validate: func(input string) error {
+			_, err := convertToTimeLog(input, workday)
+			return err
+		},
+	}
+	result, err := prompt.Run()
+	if err != nil {
+		return 0, errors.New("timer is still running")
+	}
+	return convertToTimeLog(result, workday)
+}
+
+// roundDuration rounds d to a multiple of step.
+func roundDuration(d, step time.Duration, mode string) time.Duration {
+	switch mode {
+	case config.RoundUp:
+		rounded := d.Truncate(step)
+		if rounded < d {
+			rounded += step
+		}
+		return rounded
+	case config.RoundDown:
+		return d.Truncate(step)
+	default:
+		return d.Round(step)
+	}
+}
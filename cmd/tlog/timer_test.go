@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimer_Elapsed(t *testing.T) {
+	start := time.Date(2022, time.October, 10, 9, 0, 0, 0, time.UTC)
+	now := start.Add(2 * time.Hour)
+	pausedAt := start.Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		timer Timer
+		want  time.Duration
+	}{
+		{name: "running", timer: Timer{StartedAt: start}, want: 2 * time.Hour},
+		{name: "running after pause", timer: Timer{StartedAt: start, Pauses: []Pause{{From: start, To: start.Add(30 * time.Minute)}}}, want: 90 * time.Minute},
+		{name: "paused", timer: Timer{StartedAt: start, PausedAt: &pausedAt}, want: time.Hour},
+		{name: "paused twice", timer: Timer{StartedAt: start, PausedAt: &pausedAt, Pauses: []Pause{{From: start, To: start.Add(15 * time.Minute)}}}, want: 45 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.timer.Elapsed(now))
+		})
+	}
+}
+
+func Test_loggedOn(t *testing.T) {
+	day := time.Date(2022, time.October, 10, 0, 0, 0, 0, time.UTC)
+	entries := []backend.LedgerEntry{
+		{Issue: "A-1", Started: day, Seconds: 3600},
+		{Issue: "A-2", Started: day.Add(13 * time.Hour), Seconds: 1800},
+		{Issue: "A-3", Started: day.Add(-time.Hour), Seconds: 7200},
+	}
+
+	require.Equal(t, 90*time.Minute, loggedOn(entries, day))
+	require.Equal(t, time.Duration(0), loggedOn(entries, day.Add(48*time.Hour)))
+}
+
+func Test_roundDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		step time.Duration
+		mode string
+		want time.Duration
+	}{
+		{name: "nearest down", d: 47*time.Minute + 13*time.Second, step: 15 * time.Minute, mode: config.RoundNearest, want: 45 * time.Minute},
+		{name: "nearest up", d: 53 * time.Minute, step: 15 * time.Minute, mode: config.RoundNearest, want: time.Hour},
+		{name: "up", d: 46 * time.Minute, step: 15 * time.Minute, mode: config.RoundUp, want: time.Hour},
+		{name: "up exact", d: 45 * time.Minute, step: 15 * time.Minute, mode: config.RoundUp, want: 45 * time.Minute},
+		{name: "down", d: 59 * time.Minute, step: 15 * time.Minute, mode: config.RoundDown, want: 45 * time.Minute},
+		{name: "down to zero", d: 14 * time.Minute, step: 15 * time.Minute, mode: config.RoundDown, want: 0},
+		{name: "minutes", d: 47*time.Minute + 31*time.Second, step: time.Minute, mode: config.RoundNearest, want: 48 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, roundDuration(tt.d, tt.step, tt.mode))
+		})
+	}
+}
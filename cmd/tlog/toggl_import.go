@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/parse"
+)
+
+// togglDateLayouts are date formats Toggl exports depending on user settings.
+var togglDateLayouts = []string{"2006-01-02", "01/02/2006", "02.01.2006", "02-01-2006"}
+
+var togglTimeLayouts = []string{"15:04:05", "15:04", "3:04:05 PM", "3:04 PM"}
+
+// togglColumns are required columns of Toggl detailed report.
+var togglColumns = []string{"description", "start date", "start time", "duration"}
+
+// parseTogglDuration parses "1:30:00", "01:30" or decimal hours, e.g. "1.50 h".
+func parseTogglDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if parts := strings.Split(s, ":"); len(parts) == 2 || len(parts) == 3 {
+		var d time.Duration
+		units := []time.Duration{time.Hour, time.Minute, time.Second}
+		for i, p := range parts {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("%q is not a duration", s)
+			}
+			d += time.Duration(n) * units[i]
+		}
+		return d, nil
+	}
+	hours, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, "h")), 64)
+	if err != nil || hours < 0 {
+		return 0, fmt.Errorf("%q is not a duration", s)
+	}
+	return time.Duration(hours * float64(time.Hour)), nil
+}
+
+func parseTogglStart(date, clock string, loc *time.Location) (time.Time, error) {
+	for _, dl := range togglDateLayouts {
+		for _, tl := range togglTimeLayouts {
+			if t, err := time.ParseInLocation(dl+" "+tl, date+" "+clock, loc); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date and time", date+" "+clock)
+}
+
+// togglIssue finds issue of Toggl entry: issue key in description, then a tag
+// that is an issue key or alias.
+func togglIssue(conf Config, description, tags string) (string, error) {
+	if key := issueKeyInTextRe.FindString(description); key != "" {
+		return key, nil
+	}
+	aliases := conf.Aliases()
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if parse.IsIssueKey(tag) {
+			return tag, nil
+		}
+		if _, ok := aliases[tag]; ok {
+			return resolveTask(conf, tag)
+		}
+	}
+	return "", nil
+}
+
+// parseTogglCSV reads Toggl Track detailed report, rows are kept as separate
+// entries, they are grouped by day and issue in review.
+func parseTogglCSV(conf Config, r io.Reader) ([]importEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range togglColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("no %q column, export detailed report as CSV", name)
+		}
+	}
+	get := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var entries []importEntry
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		// descriptions may span lines, worklog comment is a single one
+		description := strings.Join(strings.Fields(get(record, "description")), " ")
+		e := importEntry{Source: get(record, "project"), Worklog: Worklog{Comment: description}}
+
+		started, err := parseTogglStart(get(record, "start date"), get(record, "start time"), conf.Location())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		spent, err := parseTogglDuration(get(record, "duration"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		e.Worklog.Started, e.Worklog.Spent = started, spent.Round(time.Minute)
+
+		issue, err := togglIssue(conf, description, get(record, "tags"))
+		switch {
+		case err != nil:
+			e.Problem = err.Error()
+		case spent <= 0:
+			e.Problem = "no time tracked"
+		}
+		e.Worklog.Issue = issue
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func runImportToggl(args []string) error {
+	flags := flag.NewFlagSet("import toggl", flag.ContinueOnError)
+	opts := addImportFlags(flags)
+	opts.group = true
+	opts.hint = "put issue key into description or tag entry with issue key or alias"
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return errSilent
+	}
+	if len(positional) != 1 {
+		return errors.New("Usage: tlog import toggl <detailed-report.csv> [--yes] [--unmapped <alias>]")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	file := positional[0]
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("cannot read Toggl report: %w", err)
+	}
+	defer f.Close()
+	entries, err := parseTogglCSV(conf, f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	selected, err := reviewImport(conf, entries, *opts)
+	if err != nil {
+		return err
+	}
+	return submitImport(conf, selected, *opts)
+}
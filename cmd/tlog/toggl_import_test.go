@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseTogglDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"01:30:00", 90 * time.Minute},
+		{"10:05:30", 10*time.Hour + 5*time.Minute + 30*time.Second},
+		{"0:45", 45 * time.Minute},
+		{"1.50", 90 * time.Minute},
+		{"0.25 h", 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := parseTogglDuration(tt.input)
+		require.NoError(t, err, tt.input)
+		require.Equal(t, tt.want, got, tt.input)
+	}
+	_, err := parseTogglDuration("1:xx:00")
+	require.Error(t, err)
+}
+
+func Test_parseTogglCSV(t *testing.T) {
+	report := "\ufeffUser,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags,Amount ()\n" +
+		"Jane,jane@example.com,,Website,,PRJ-7 review,No,2024-03-04,09:00:00,2024-03-04,10:00:00,01:00:00,,\n" +
+		"Jane,jane@example.com,,Website,,\"PRJ-7 fixes\nafter review\",No,2024-03-04,14:00:00,2024-03-04,14:30:00,00:30:00,,\n" +
+		"Jane,jane@example.com,,Internal,,sync,No,2024-03-04,11:00:00,2024-03-04,11:15:00,00:15:00,\"billable, meet\",\n" +
+		"Jane,jane@example.com,,Internal,,lunch,No,03/05/2024,12:00 PM,03/05/2024,1:00 PM,01:00:00,,\n"
+	conf := Config{Timezone: "UTC", TaskAliases: map[string]string{"meet": "MEET-1"}}
+	entries, err := parseTogglCSV(conf, strings.NewReader(report))
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	require.Equal(t, "PRJ-7 fixes after review", entries[1].Worklog.Comment)
+	require.Equal(t, "MEET-1", entries[2].Worklog.Issue, "alias tag")
+	require.Equal(t, time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC), entries[3].Worklog.Started)
+	require.Empty(t, entries[3].Worklog.Issue)
+
+	grouped := groupEntries(entries[:3])
+	require.Len(t, grouped, 2)
+	require.Equal(t, Worklog{
+		Issue: "PRJ-7", Started: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), Spent: 90 * time.Minute, Comment: "PRJ-7 review; PRJ-7 fixes after review",
+	}, grouped[0].Worklog)
+	require.Equal(t, 15*time.Minute, grouped[1].Worklog.Spent)
+
+	_, err = parseTogglCSV(conf, strings.NewReader("Project,Description\nX,y\n"))
+	require.ErrorContains(t, err, "export detailed report")
+}
+
+func Test_reviewImport_unmapped(t *testing.T) {
+	isolateUserDirs(t)
+	old := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	t.Cleanup(func() { stdinIsTerminal = old })
+
+	day := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	entries := []importEntry{
+		{Worklog: Worklog{Issue: "PRJ-1", Started: day, Spent: time.Hour}},
+		{Worklog: Worklog{Started: day.Add(time.Hour), Spent: 30 * time.Minute}, ImportIDs: []string{"x:1"}},
+		{Worklog: Worklog{Started: day.Add(2 * time.Hour), Spent: 15 * time.Minute}, ImportIDs: []string{"x:2"}},
+		{Worklog: Worklog{Issue: "PRJ-2", Started: day, Spent: time.Hour}, ImportIDs: []string{"x:3"}},
+		{Worklog: Worklog{Started: day.Add(3 * time.Hour)}, Problem: "timer is still running"},
+	}
+	conf := Config{TaskAliases: map[string]string{"misc": "OPS-9"}}
+	require.NoError(t, backend.AppendLedger(conf, backend.LedgerEntry{Issue: "PRJ-2", ImportIDs: []string{"x:3"}}))
+
+	selected, err := reviewImport(conf, entries, importOptions{yes: true})
+	require.NoError(t, err)
+	require.Len(t, selected, 1, "not mapped and imported entries are left out")
+
+	selected, err = reviewImport(conf, entries, importOptions{yes: true, unmapped: "misc", group: true})
+	require.NoError(t, err)
+	require.Equal(t, []importEntry{
+		{Worklog: Worklog{Issue: "PRJ-1", Started: day, Spent: time.Hour}},
+		{Worklog: Worklog{Issue: "OPS-9", Started: day.Add(time.Hour), Spent: 45 * time.Minute}, ImportIDs: []string{"x:1", "x:2"}},
+	}, selected)
+
+	_, err = reviewImport(conf, entries, importOptions{unmapped: "nope"})
+	require.ErrorContains(t, err, "neither alias nor issue")
+	_, err = reviewImport(conf, entries, importOptions{})
+	require.ErrorContains(t, err, "--yes")
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// timeOff is kind of days off logged by `tlog vacation` and `tlog sick`.
+type timeOff struct {
+	// command logging it
+	command string
+	// key of Calendar with the alias it is logged to
+	key string
+	// comment of worklogs, unless the alias has one
+	comment string
+}
+
+var (
+	vacationOff = timeOff{command: "vacation", key: "VacationAlias", comment: "Vacation"}
+	sickOff     = timeOff{command: "sick", key: "SickAlias", comment: "Sick leave"}
+)
+
+// alias returns alias or issue key days off are logged to.
+func (o timeOff) alias(conf Config) string {
+	if o == sickOff {
+		return conf.Calendar.SickAlias
+	}
+	return conf.Calendar.VacationAlias
+}
+
+// runVacation logs a workday to VacationAlias for every working day from
+// the first day to the second one, inclusive.
+func runVacation(args []string) error {
+	return runTimeOff(vacationOff, args, 1, 2, "Usage: tlog vacation <from> [to] [--half] [--yes]")
+}
+
+// runSick logs a workday to SickAlias for the day, today if not given.
+func runSick(args []string) error {
+	return runTimeOff(sickOff, args, 0, 1, "Usage: tlog sick [day] [--half] [--yes]")
+}
+
+// runTimeOff logs days off given by minDays to maxDays positional args.
+func runTimeOff(off timeOff, args []string, minDays, maxDays int, usage string) error {
+	flags := flag.NewFlagSet(off.command, flag.ContinueOnError)
+	half := flags.Bool("half", false, "log half of the workday")
+	yes := flags.Bool("yes", false, "log without confirmation")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return shownError{err}
+	}
+	if len(positional) < minDays || len(positional) > maxDays {
+		return errors.New(usage)
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	alias := off.alias(conf)
+	if alias == "" {
+		return config.Error{Field: "Calendar." + off.key, Err: fmt.Errorf("Calendar.%s is not set, run `tlog config edit` and add alias or issue key %s is logged to under [Calendar], e.g. %s = \"HR-1\"", off.key, off.command, off.key)}
+	}
+	issue, err := resolveTask(conf, alias)
+	if err != nil {
+		return err
+	}
+	from, to, err := timeOffRange(conf, safeGet(positional, 0), safeGet(positional, 1))
+	if err != nil {
+		return err
+	}
+	spent := conf.Workday()
+	if *half {
+		spent /= 2
+	}
+	comment := conf.AliasComment(alias)
+	if comment == "" {
+		comment = off.comment
+	}
+	worklogs := timeOffWorklogs(conf, issue, comment, spent, from, to)
+	if len(worklogs) == 0 {
+		return fmt.Errorf("no working days from %s to %s, weekends and holidays are skipped", from.Format("Mon 2006-01-02"), to.AddDate(0, 0, -1).Format("Mon 2006-01-02"))
+	}
+
+	total := spent * time.Duration(len(worklogs))
+	if err := pterm.DefaultTable.WithHasHeader().WithData(timeOffRows(conf, worklogs)).Render(); err != nil {
+		return err
+	}
+	if !*yes && stdinIsTerminal() {
+		confirmed, _ := pterm.DefaultInteractiveConfirm.WithDefaultValue(true).Show(pterm.Sprintf("Log %d days, %s in total to %s?", len(worklogs), pterm.Yellow(parse.FormatDuration(total)), issue))
+		if !confirmed {
+			return errors.New("nothing is logged")
+		}
+	}
+
+	var failed []failedWorklog
+	anyQueued := false
+	for _, wl := range worklogs {
+		queued, err := logOrQueue(conf, wl)
+		if err != nil {
+			if errors.Is(err, backend.ErrInterrupted) {
+				return err
+			}
+			failed = append(failed, failedWorklog{Worklog: wl, Err: err})
+		}
+		anyQueued = anyQueued || queued
+	}
+	if len(failed) > 0 {
+		for _, f := range failed {
+			pterm.Error.Printfln("%s is not logged: %s", f.Started.Format("Mon 2006-01-02"), f.Err)
+		}
+		return errSilent
+	}
+	if anyQueued {
+		return nil
+	}
+	return offerSync(conf)
+}
+
+// timeOffRange returns [from, day after to) of days off, to defaults to from
+// and from to today.
+func timeOffRange(conf Config, fromInput, toInput string) (time.Time, time.Time, error) {
+	from, err := convertToDay(fromInput, conf.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to := from
+	if toInput != "" {
+		if to, err = convertToDay(toInput, conf.Location()); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s is before %s", to.Format("2006-01-02"), from.Format("2006-01-02"))
+	}
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// timeOffWorklogs returns worklog of spent on issue for every working day of
+// [from, to), weekends and holidays are skipped.
+func timeOffWorklogs(conf Config, issue, comment string, spent time.Duration, from, to time.Time) []Worklog {
+	var worklogs []Worklog
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !conf.Calendar.IsWorkday(day) {
+			continue
+		}
+		worklogs = append(worklogs, Worklog{Issue: issue, Started: atStartTime(conf, day), Spent: spent, Comment: comment})
+	}
+	return worklogs
+}
+
+// timeOffRows renders days off with their total.
+func timeOffRows(conf Config, worklogs []Worklog) [][]string {
+	rows := [][]string{{"Day", "Issue", "Time", "Comment"}}
+	var total time.Duration
+	for _, wl := range worklogs {
+		rows = append(rows, []string{wl.Started.In(conf.Location()).Format("Mon 2006-01-02"), wl.Issue, formatSpent(wl.Spent), wl.Comment})
+		total += wl.Spent
+	}
+	return append(rows, []string{"Total", "", formatSpent(total), ""})
+}
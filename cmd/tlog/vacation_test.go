@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_timeOffWorklogs(t *testing.T) {
+	conf := Config{Timezone: "UTC", DefaultStartTime: "09:00", Calendar: config.Calendar{Holidays: []string{"2024-12-25"}}}
+	day := func(d int) time.Time { return time.Date(2024, time.December, d, 0, 0, 0, 0, time.UTC) }
+
+	// Fri 20th to Fri 27th, the weekend and Christmas are skipped
+	from, to, err := timeOffRange(conf, "2024-12-20", "2024-12-27")
+	require.NoError(t, err)
+	var days []int
+	for _, wl := range timeOffWorklogs(conf, "HR-1", "Vacation", 4*time.Hour, from, to) {
+		require.Equal(t, Worklog{Issue: "HR-1", Started: day(wl.Started.Day()).Add(9 * time.Hour), Spent: 4 * time.Hour, Comment: "Vacation"}, wl)
+		days = append(days, wl.Started.Day())
+	}
+	require.Equal(t, []int{20, 23, 24, 26, 27}, days)
+
+	from, to, err = timeOffRange(conf, "2024-12-21", "")
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{day(21), day(22)}, []time.Time{from, to})
+	require.Empty(t, timeOffWorklogs(conf, "HR-1", "Vacation", 8*time.Hour, from, to))
+
+	_, _, err = timeOffRange(conf, "2024-12-27", "2024-12-20")
+	require.Error(t, err)
+}
+
+func Test_timeOffRows(t *testing.T) {
+	conf := Config{Timezone: "UTC"}
+	started := time.Date(2024, time.December, 23, 9, 0, 0, 0, time.UTC)
+	rows := timeOffRows(conf, []Worklog{
+		{Issue: "HR-1", Started: started, Spent: 8 * time.Hour, Comment: "Vacation"},
+		{Issue: "HR-1", Started: started.AddDate(0, 0, 1), Spent: 8 * time.Hour, Comment: "Vacation"},
+	})
+	require.Equal(t, [][]string{
+		{"Day", "Issue", "Time", "Comment"},
+		{"Mon 2024-12-23", "HR-1", "8h", "Vacation"},
+		{"Tue 2024-12-24", "HR-1", "8h", "Vacation"},
+		{"Total", "", "16h", ""},
+	}, rows)
+}
+
+func Test_runTimeOff_withoutAlias(t *testing.T) {
+	dir := filepath.Join(isolateUserDirs(t), "tlog")
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte("Backend = \"mock\"\n\n[Calendar]\nVacationAlias = \"HR-1\"\n"), 0600))
+
+	err := runSick([]string{"--yes"})
+	var cerr config.Error
+	require.ErrorAs(t, err, &cerr)
+	require.Equal(t, "Calendar.SickAlias", cerr.Field)
+	require.ErrorContains(t, err, `SickAlias = "HR-1"`)
+
+	require.EqualError(t, runVacation(nil), "Usage: tlog vacation <from> [to] [--half] [--yes]")
+}
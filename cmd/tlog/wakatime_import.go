@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+const (
+	defaultWakatimeURL = "https://wakatime.com/api/v1"
+	// less coding per project and day is noise, e.g. a file opened by accident
+	defaultWakatimeMinimum = 10 * time.Minute
+)
+
+// wakatimeClient reads summaries of the current WakaTime user.
+type wakatimeClient struct {
+	conf   Config
+	client *http.Client
+	base   string
+}
+
+// wakatimeSummary is coding time of a day.
+type wakatimeSummary struct {
+	Range struct {
+		Date string `json:"date"`
+	} `json:"range"`
+	Projects []struct {
+		Name         string  `json:"name"`
+		TotalSeconds float64 `json:"total_seconds"`
+	} `json:"projects"`
+}
+
+func newWakatimeClient(conf Config) (*wakatimeClient, error) {
+	if conf.WakatimeAPIKey == "" {
+		return nil, errors.New("WakatimeAPIKey is not set in config, copy it from https://wakatime.com/settings/api-key")
+	}
+	transport, err := backend.BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := backend.NewRetryTransport(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+	base := conf.WakatimeURL
+	if base == "" {
+		base = defaultWakatimeURL
+	}
+	return &wakatimeClient{conf: conf, client: &http.Client{Transport: retry}, base: strings.TrimSuffix(base, "/")}, nil
+}
+
+// wakatimeMinimum returns coding time per project and day below which it is not logged.
+func wakatimeMinimum(conf Config) (time.Duration, error) {
+	if conf.WakatimeMinimum == "" {
+		return defaultWakatimeMinimum, nil
+	}
+	d, err := time.ParseDuration(conf.WakatimeMinimum)
+	if err != nil || d < 0 {
+		return 0, config.Error{Field: "WakatimeMinimum", Err: fmt.Errorf("invalid WakatimeMinimum %q in config: duration like 15m expected", conf.WakatimeMinimum)}
+	}
+	return d, nil
+}
+
+// summaries returns coding time of days in [from, to).
+func (c *wakatimeClient) summaries(from, to time.Time) ([]wakatimeSummary, error) {
+	// days are inclusive
+	query := url.Values{
+		"start": {from.Format("2006-01-02")},
+		"end":   {to.AddDate(0, 0, -1).Format("2006-01-02")},
+	}
+	if c.conf.Timezone != "" {
+		query.Set("timezone", c.conf.Timezone)
+	}
+	req, err := http.NewRequest(http.MethodGet, c.base+"/users/current/summaries?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.conf.WakatimeAPIKey)))
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach WakaTime: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+		if failure.Error != "" {
+			return nil, fmt.Errorf("WakaTime: %s, %s", resp.Status, failure.Error)
+		}
+		return nil, fmt.Errorf("WakaTime: %s", resp.Status)
+	}
+	var result struct {
+		Data []wakatimeSummary `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode WakaTime summaries: %w", err)
+	}
+	return result.Data, nil
+}
+
+// wakatimeImportEntries maps projects of summaries to worklogs by
+// WakatimeMapping. WakaTime tells no time of day, worklogs of a day follow
+// each other from DefaultStartTime. Projects coded less than minimum are
+// left out, their number is returned.
+func wakatimeImportEntries(conf Config, summaries []wakatimeSummary, minimum time.Duration) ([]importEntry, int, error) {
+	step, mode, err := conf.Rounding()
+	if err != nil {
+		return nil, 0, err
+	}
+	var imported []importEntry
+	short := 0
+	for _, s := range summaries {
+		day, err := time.ParseInLocation("2006-01-02", s.Range.Date, conf.Location())
+		if err != nil {
+			return nil, 0, fmt.Errorf("unexpected date %q of WakaTime summary", s.Range.Date)
+		}
+		started := atStartTime(conf, day)
+		for _, p := range s.Projects {
+			spent := time.Duration(p.TotalSeconds * float64(time.Second))
+			if spent < minimum || spent <= 0 {
+				short++
+				continue
+			}
+			e := importEntry{Source: p.Name, Worklog: Worklog{
+				Started: started,
+				Spent:   roundDuration(spent, step, mode),
+			}, ImportIDs: []string{"wakatime:" + s.Range.Date + "/" + p.Name}}
+			started = started.Add(e.Worklog.Spent)
+
+			issue, err := mapImported(conf, conf.WakatimeMapping, "", p.Name)
+			switch {
+			case err != nil:
+				e.Problem = err.Error()
+			case e.Worklog.Spent == 0:
+				e.Problem = "rounds down to zero"
+			}
+			e.Worklog.Issue = issue
+			imported = append(imported, e)
+		}
+	}
+	return imported, short, nil
+}
+
+func runImportWakatime(args []string) error {
+	flags := flag.NewFlagSet("import wakatime", flag.ContinueOnError)
+	opts := addImportFlags(flags)
+	opts.hint = "map their projects in WakatimeMapping"
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return errSilent
+	}
+	if len(positional) > 1 {
+		return errors.New("Usage: tlog import wakatime [day|from..to] [--yes] [--unmapped <alias>]")
+	}
+
+	conf, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %s", err)
+	}
+	from, to, err := parseImportDays(conf, safeGet(positional, 0))
+	if err != nil {
+		return err
+	}
+	minimum, err := wakatimeMinimum(conf)
+	if err != nil {
+		return err
+	}
+	client, err := newWakatimeClient(conf)
+	if err != nil {
+		return err
+	}
+	summaries, err := client.summaries(from, to)
+	if err != nil {
+		return err
+	}
+	entries, short, err := wakatimeImportEntries(conf, summaries, minimum)
+	if err != nil {
+		return err
+	}
+	if short > 0 {
+		pterm.Info.Printfln("%d projects coded less than %s a day are skipped", short, parse.FormatDuration(minimum))
+	}
+
+	selected, err := reviewImport(conf, entries, *opts)
+	if err != nil {
+		return err
+	}
+	return submitImport(conf, selected, *opts)
+}
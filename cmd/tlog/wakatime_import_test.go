@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_wakatimeImport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Basic a2V5", r.Header.Get("Authorization"))
+		require.Equal(t, "/users/current/summaries", r.URL.Path)
+		require.Equal(t, "2024-03-04", r.URL.Query().Get("start"))
+		require.Equal(t, "2024-03-05", r.URL.Query().Get("end"))
+		require.Equal(t, "UTC", r.URL.Query().Get("timezone"))
+		w.Write([]byte(`{"data": [
+			{"range": {"date": "2024-03-04"}, "projects": [
+				{"name": "backend", "total_seconds": 5350.5},
+				{"name": "Dotfiles", "total_seconds": 1500},
+				{"name": "scratch", "total_seconds": 300}
+			]},
+			{"range": {"date": "2024-03-05"}, "projects": [
+				{"name": "unknown", "total_seconds": 3600}
+			]}
+		]}`))
+	}))
+	defer srv.Close()
+
+	conf := Config{
+		WakatimeURL: srv.URL, WakatimeAPIKey: "key", Timezone: "UTC", DefaultStartTime: "09:00", RoundTo: "15m",
+		TaskAliases:     map[string]string{"internal": "OPS-1"},
+		WakatimeMapping: map[string]string{"backend": "WEB-1", "dotfiles": "internal", "scratch": "WEB-2"},
+	}
+	client, err := newWakatimeClient(conf)
+	require.NoError(t, err)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	summaries, err := client.summaries(day, day.AddDate(0, 0, 2))
+	require.NoError(t, err)
+
+	imported, short, err := wakatimeImportEntries(conf, summaries, 10*time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, 1, short, "scratch is coded less than minimum")
+	require.Len(t, imported, 3)
+	require.Equal(t, Worklog{Issue: "WEB-1", Started: day.Add(9 * time.Hour), Spent: 90 * time.Minute}, imported[0].Worklog)
+	require.Equal(t, []string{"wakatime:2024-03-04/backend"}, imported[0].ImportIDs)
+	require.Equal(t, Worklog{Issue: "OPS-1", Started: day.Add(10*time.Hour + 30*time.Minute), Spent: 30 * time.Minute}, imported[1].Worklog,
+		"worklogs of a day follow each other, project is matched regardless of case")
+	require.Equal(t, day.AddDate(0, 0, 1).Add(9*time.Hour), imported[2].Worklog.Started)
+	require.Empty(t, imported[2].Worklog.Issue)
+	require.Empty(t, imported[2].Problem, "not mapped project can be assigned in review")
+}
+
+func Test_wakatimeErrors(t *testing.T) {
+	_, err := newWakatimeClient(Config{})
+	require.ErrorContains(t, err, "WakatimeAPIKey is not set")
+
+	_, err = wakatimeMinimum(Config{WakatimeMinimum: "soon"})
+	require.ErrorContains(t, err, "invalid WakatimeMinimum")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": "Unauthorized"}`))
+	}))
+	defer srv.Close()
+	client, err := newWakatimeClient(Config{WakatimeURL: srv.URL, WakatimeAPIKey: "bad"})
+	require.NoError(t, err)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	_, err = client.summaries(day, day.AddDate(0, 0, 1))
+	require.EqualError(t, err, "WakaTime: 401 Unauthorized, Unauthorized")
+}
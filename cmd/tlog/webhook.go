@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/What-If-I/tlog/pkg/parse"
+)
+
+// defaultNotifyTemplate renders "text" of webhook payload when NotifyTemplate is not set.
+const defaultNotifyTemplate = `{{.Author}} logged {{.Duration}} to {{.Issue}} on {{.Day}}{{if .Comment}}: {{.Comment}}{{end}}`
+
+// webhookPayload is posted to NotifyWebhook after worklog is created. Text
+// makes it a valid Slack message, the rest is for other receivers.
+type webhookPayload struct {
+	Text     string `json:"text"`
+	Issue    string `json:"issue"`
+	Duration string `json:"duration"`
+	Seconds  int    `json:"seconds"`
+	Day      string `json:"day"`
+	Comment  string `json:"comment"`
+	Author   string `json:"author"`
+	URL      string `json:"url,omitempty"`
+}
+
+func parseNotifyTemplate(conf Config) (*template.Template, error) {
+	text := conf.NotifyTemplate
+	if text == "" {
+		text = defaultNotifyTemplate
+	}
+	return template.New("NotifyTemplate").Option("missingkey=error").Parse(text)
+}
+
+// newWebhookPayload describes created worklog, template sees payload fields.
+func newWebhookPayload(conf Config, created Worklog) (webhookPayload, error) {
+	p := webhookPayload{
+		Issue:    created.Issue,
+		Duration: parse.FormatDuration(created.Spent),
+		Seconds:  int(created.Spent.Seconds()),
+		Day:      created.Started.In(conf.Location()).Format("2006-01-02"),
+		Comment:  created.Comment,
+		Author:   created.Author,
+		URL:      created.URL,
+	}
+	tmpl, err := parseNotifyTemplate(conf)
+	if err != nil {
+		return p, fmt.Errorf("invalid NotifyTemplate: %w", err)
+	}
+	var text strings.Builder
+	if err := tmpl.Execute(&text, p); err != nil {
+		return p, fmt.Errorf("invalid NotifyTemplate: %w", err)
+	}
+	p.Text = text.String()
+	return p, nil
+}
+
+// notifyWebhook posts created worklog to NotifyWebhook.
+func notifyWebhook(conf Config, created Worklog) error {
+	payload, err := newWebhookPayload(conf, created)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	transport, err := backend.BaseTransport(conf)
+	if err != nil {
+		return err
+	}
+	retry, err := backend.NewRetryTransport(conf, transport)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Transport: retry}).Post(conf.NotifyWebhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		// URL carries a token in case of Slack, it must not be shown
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("cannot reach webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if message := strings.TrimSpace(string(body)); message != "" {
+			return fmt.Errorf("webhook: %s, %s", resp.Status, message)
+		}
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
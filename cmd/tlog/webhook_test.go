@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_notifyWebhook(t *testing.T) {
+	isolateUserDirs(t)
+	var received []webhookPayload
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var p webhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+		received = append(received, p)
+		w.WriteHeader(status)
+		w.Write([]byte("invalid_payload"))
+	}))
+	defer srv.Close()
+
+	conf := Config{Backend: config.Mock, JiraLogin: "jane", Timezone: "UTC", NotifyWebhook: srv.URL + "/hook"}
+	wl := Worklog{Issue: "SUP-1", Started: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), Spent: 90 * time.Minute, Comment: "customer call"}
+	require.NoError(t, addWorklog(conf, wl))
+	require.Len(t, received, 1)
+	require.Equal(t, webhookPayload{
+		Text: "jane logged 1h30m to SUP-1 on 2024-03-04: customer call", Issue: "SUP-1", Duration: "1h30m",
+		Seconds: 5400, Day: "2024-03-04", Comment: "customer call", Author: "jane",
+	}, received[0])
+
+	conf.NotifyTemplate = "{{.Issue}} +{{.Duration}}"
+	status = http.StatusBadRequest
+	require.NoError(t, addWorklog(conf, wl), "failed notification does not fail logging")
+	require.Len(t, received, 2)
+	require.Equal(t, "SUP-1 +1h30m", received[1].Text)
+	require.EqualError(t, notifyWebhook(conf, wl), "webhook: 400 Bad Request, invalid_payload")
+
+	globalOpts.NoNotify = true
+	t.Cleanup(func() { globalOpts.NoNotify = false })
+	require.NoError(t, addWorklog(conf, wl))
+	require.Len(t, received, 3, "--no-notify skips webhook")
+
+	conf.NotifyTemplate = "{{.Minutes}}"
+	require.ErrorContains(t, notifyWebhook(conf, wl), "invalid NotifyTemplate")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+)
+
+const (
+	// workedTask picks issue among the recently worked on ones, unless it is an alias
+	workedTask = "worked"
+	// how far back JIRA activity is looked at
+	workedDays = 14
+	// picker longer than that is scrolled through rather than read
+	workedMaxIssues = 20
+)
+
+// workedIssuesFinder is implemented by backends that know which issues user
+// was active in, e.g. commented on or transitioned, within given days.
+type workedIssuesFinder interface {
+	WorkedIssues(days int) ([]Issue, error)
+}
+
+// recentIssues returns issues of the ledger, the last logged first.
+func recentIssues(conf Config) ([]string, error) {
+	entries, err := backend.ReadLedger(conf)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].LoggedAt.After(entries[j].LoggedAt) })
+	var keys []string
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if e.Issue != "" && !seen[e.Issue] {
+			seen[e.Issue] = true
+			keys = append(keys, e.Issue)
+		}
+	}
+	return keys, nil
+}
+
+// workedIssues merges issues user was active in on backend, which are not
+// logged to yet most likely, with the recently logged ones. Backend failure
+// leaves only the latter.
+func workedIssues(conf Config) ([]Issue, error) {
+	var issues []Issue
+	seen := map[string]bool{}
+	add := func(issue Issue) {
+		if !seen[issue.Key] && len(issues) < workedMaxIssues {
+			seen[issue.Key] = true
+			issues = append(issues, issue)
+		}
+	}
+
+	if conf.HasBackend() {
+		b, err := backend.New(conf)
+		if err == nil {
+			if finder, ok := b.(workedIssuesFinder); ok {
+				var found []Issue
+				found, err = finder.WorkedIssues(workedDays)
+				cacheIssues(conf, found)
+				for _, issue := range found {
+					add(issue)
+				}
+			}
+		}
+		if err != nil {
+			pterm.Warning.Printfln("Only recently logged issues are shown, cannot get your activity: %s", err)
+		}
+	}
+
+	recent, err := recentIssues(conf)
+	if err != nil {
+		return nil, err
+	}
+	var rest []string
+	for _, key := range recent {
+		if !seen[key] && len(issues)+len(rest) < workedMaxIssues {
+			rest = append(rest, key)
+		}
+	}
+	summaries := issueSummaries(conf, rest)
+	for _, key := range rest {
+		add(Issue{Key: key, Summary: summaries[key]})
+	}
+	return issues, nil
+}
+
+// pickIssue asks which of recently worked on issues time goes to.
+func pickIssue(conf Config) (string, error) {
+	if !stdinIsTerminal() {
+		return "", errors.New("task is required, issues to pick from are offered in terminal only")
+	}
+	issues, err := workedIssues(conf)
+	if err != nil {
+		return "", err
+	}
+	if len(issues) == 0 {
+		return "", errors.New("no recently worked on issues, give task explicitly")
+	}
+
+	items := make([]string, len(issues))
+	for i, issue := range issues {
+		items[i] = issue.Key
+		if issue.Summary != "" {
+			items[i] = fmt.Sprintf("%s  %s", issue.Key, issue.Summary)
+		}
+	}
+	idx, _, err := (&promptui.Select{
+		Label:        pterm.LightBlue("Which issue is it?"),
+		Items:        items,
+		HideSelected: true,
+		Size:         10,
+		Searcher: func(input string, i int) bool {
+			return strings.Contains(strings.ToLower(items[i]), strings.ToLower(input))
+		},
+	}).Run()
+	if err != nil {
+		return "", errSilent
+	}
+	return issues[idx].Key, nil
+}
+
+// resolveOrPickTask is resolveTask that offers recently worked on issues
+// when task is missing or is "worked".
+func resolveOrPickTask(conf Config, input string) (string, error) {
+	if _, isAlias := conf.Aliases()[input]; input == "" || input == workedTask && !isAlias {
+		return pickIssue(conf)
+	}
+	return resolveTask(conf, input)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_workedIssues(t *testing.T) {
+	isolateUserDirs(t)
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/rest/api/2/search", r.URL.Path)
+		jql := r.URL.Query().Get("jql")
+		queries = append(queries, jql)
+		if strings.Contains(jql, "updatedBy") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errorMessages": ["Unable to find JQL function 'updatedBy()'"]}`))
+			return
+		}
+		w.Write([]byte(`{"issues": [
+			{"key": "PRJ-3", "fields": {"summary": "Login page"}},
+			{"key": "PRJ-1", "fields": {"summary": "Signup"}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	conf := Config{JiraURL: srv.URL, JiraLogin: "me", JiraPassword: "secret"}
+	now := time.Now()
+	for i, issue := range []string{"PRJ-1", "OPS-7", "PRJ-1"} {
+		require.NoError(t, backend.AppendLedger(conf, backend.LedgerEntry{Issue: issue, Started: now, Seconds: 60, LoggedAt: now.Add(time.Duration(i) * time.Minute)}))
+	}
+	require.NoError(t, saveIssueCache(conf, map[string]cachedIssue{"OPS-7": {Summary: "Deploy", FetchedAt: now}}))
+
+	issues, err := workedIssues(conf)
+	require.NoError(t, err)
+	require.Len(t, queries, 2, "older JIRA gets query without updatedBy()")
+	require.Contains(t, queries[1], "status changed by currentUser() after -14d")
+	require.Equal(t, []Issue{{Key: "PRJ-3", Summary: "Login page"}, {Key: "PRJ-1", Summary: "Signup"}, {Key: "OPS-7", Summary: "Deploy"}}, issues)
+
+	require.Equal(t, "Login page", loadIssueCache(conf)["PRJ-3"].Summary, "found issues are cached")
+
+	recent, err := recentIssues(conf)
+	require.NoError(t, err)
+	require.Equal(t, []string{"PRJ-1", "OPS-7"}, recent, "the last logged first")
+}
+
+func Test_resolveOrPickTask(t *testing.T) {
+	old := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	t.Cleanup(func() { stdinIsTerminal = old })
+
+	conf := Config{TaskAliases: map[string]string{"review": "PRJ-9"}}
+	_, err := resolveOrPickTask(conf, "")
+	require.ErrorContains(t, err, "task is required")
+	_, err = resolveOrPickTask(conf, "worked")
+	require.ErrorContains(t, err, "task is required")
+	got, err := resolveOrPickTask(conf, "review")
+	require.NoError(t, err)
+	require.Equal(t, "PRJ-9", got)
+
+	conf.TaskAliases["worked"] = "PRJ-10"
+	got, err = resolveOrPickTask(conf, "worked")
+	require.NoError(t, err)
+	require.Equal(t, "PRJ-10", got, "alias wins")
+}
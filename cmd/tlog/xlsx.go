@@ -0,0 +1,245 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+// xlsxSheet is a worksheet of workbook written by writeXLSX. Cells are
+// string, float64, int, time.Duration, time.Time or xlsxDate, nil leaves
+// cell empty. The first row is header, it stays in place when scrolling.
+type xlsxSheet struct {
+	Name   string
+	Widths []float64 // of columns, in characters
+	Rows   [][]interface{}
+}
+
+// xlsxDate is a day without time of day.
+type xlsxDate time.Time
+
+// styles of cells, positions in cellXfs of xlsxStyles
+const (
+	xlsxStyleNone = iota
+	xlsxStyleHeader
+	xlsxStyleDate
+	xlsxStyleDateTime
+	xlsxStyleDuration
+)
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="3"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm"/><numFmt numFmtId="166" formatCode="[h]:mm"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="5"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>
+<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>
+</styleSheet>`
+
+// xlsxEpoch is day 0 of Excel dates, 1900 leap year bug included.
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// writeXLSX writes sheets as Office Open XML workbook. Dates and durations
+// are numbers Excel can sum, formatted as such.
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	z := zip.NewWriter(w)
+	var workbook, rels, types bytes.Buffer
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	types.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i, sheet := range sheets {
+		n := i + 1
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxEscape(sheet.Name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+	}
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`, len(sheets)+1)
+	types.WriteString(`</Types>`)
+
+	parts := []struct {
+		name    string
+		content []byte
+	}{
+		{"[Content_Types].xml", types.Bytes()},
+		{"_rels/.rels", []byte(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`)},
+		{"xl/workbook.xml", workbook.Bytes()},
+		{"xl/_rels/workbook.xml.rels", rels.Bytes()},
+		{"xl/styles.xml", []byte(xlsxStyles)},
+	}
+	for i, sheet := range sheets {
+		content, err := xlsxWorksheet(sheet)
+		if err != nil {
+			return fmt.Errorf("sheet %s: %w", sheet.Name, err)
+		}
+		parts = append(parts, struct {
+			name    string
+			content []byte
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), content})
+	}
+	for _, part := range parts {
+		f, err := z.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(part.content); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+func xlsxWorksheet(sheet xlsxSheet) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>` +
+		`<selection pane="bottomLeft" activeCell="A2" sqref="A2"/></sheetView></sheetViews>`)
+	if len(sheet.Widths) > 0 {
+		b.WriteString(`<cols>`)
+		for i, width := range sheet.Widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(width, 'f', -1, 64))
+		}
+		b.WriteString(`</cols>`)
+	}
+	b.WriteString(`<sheetData>`)
+	for i, row := range sheet.Rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, value := range row {
+			ref := xlsxColumn(j) + strconv.Itoa(i+1)
+			style := xlsxStyleNone
+			if i == 0 {
+				style = xlsxStyleHeader
+			}
+			switch v := value.(type) {
+			case nil:
+				continue
+			case string:
+				fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xlsxEscape(v))
+			case int:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, v)
+			case float64:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'f', -1, 64))
+			case time.Duration:
+				// fraction of day
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleDuration, strconv.FormatFloat(v.Hours()/24, 'f', -1, 64))
+			case time.Time:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleDateTime, strconv.FormatFloat(xlsxSerial(v), 'f', -1, 64))
+			case xlsxDate:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleDate, strconv.FormatFloat(float64(int(xlsxSerial(time.Time(v)))), 'f', -1, 64))
+			default:
+				return nil, fmt.Errorf("cell %s: unsupported value %T", ref, value)
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.Bytes(), nil
+}
+
+// xlsxSerial returns t as Excel date: days since xlsxEpoch, time of day as
+// fraction. Excel knows no zones, wall clock of t is taken.
+func xlsxSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return wall.Sub(xlsxEpoch).Hours() / 24
+}
+
+// xlsxColumn returns name of column i, e.g. A for 0 and AA for 26.
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func xlsxEscape(s string) string {
+	var b bytes.Buffer
+	// invalid characters are replaced, not failed on
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// addXLSXFlag adds --xlsx of reports to flags.
+func addXLSXFlag(flags *flag.FlagSet) *string {
+	return flags.String("xlsx", "", "write Excel workbook with worklogs and summary to this file instead")
+}
+
+// saveReportXLSX writes worklogs of report to Excel workbook at path.
+func saveReportXLSX(path string, conf Config, worklogs []Worklog) error {
+	if err := writeReportXLSX(path, conf, worklogs); err != nil {
+		return err
+	}
+	pterm.Success.Printfln("%d worklogs are written to %s", len(worklogs), path)
+	return nil
+}
+
+// writeReportXLSX writes worklogs of report to path: Worklogs sheet with
+// every worklog and Summary one with time per issue and per day.
+func writeReportXLSX(path string, conf Config, worklogs []Worklog) error {
+	loc := conf.Location()
+	sorted := append([]Worklog(nil), worklogs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Started.Before(sorted[j].Started) })
+
+	detail := xlsxSheet{Name: "Worklogs", Widths: []float64{12, 18, 14, 10, 20, 60}, Rows: [][]interface{}{
+		{"Day", "Started", "Issue", "Time", "Author", "Comment"},
+	}}
+	byIssue := map[string]time.Duration{}
+	byDay := map[string]time.Duration{}
+	for _, wl := range sorted {
+		started := wl.Started.In(loc)
+		detail.Rows = append(detail.Rows, []interface{}{xlsxDate(started), started, wl.Issue, wl.Spent, wl.Author, wl.Comment})
+		byIssue[wl.Issue] += wl.Spent
+		byDay[started.Format("2006-01-02")] += wl.Spent
+	}
+
+	summary := xlsxSheet{Name: "Summary", Widths: []float64{14, 10, 4, 12, 10}, Rows: [][]interface{}{
+		{"Issue", "Time", nil, "Day", "Time"},
+	}}
+	issues, days := sortedKeys(byIssue), sortedKeys(byDay)
+	for i := 0; i < len(issues) || i < len(days); i++ {
+		row := make([]interface{}, 5)
+		if i < len(issues) {
+			row[0], row[1] = issues[i], byIssue[issues[i]]
+		}
+		if i < len(days) {
+			day, _ := time.ParseInLocation("2006-01-02", days[i], loc)
+			row[3], row[4] = xlsxDate(day), byDay[days[i]]
+		}
+		summary.Rows = append(summary.Rows, row)
+	}
+	var total time.Duration
+	for _, spent := range byIssue {
+		total += spent
+	}
+	summary.Rows = append(summary.Rows, []interface{}{"Total", total, nil, "Total", total})
+
+	var b bytes.Buffer
+	if err := writeXLSX(&b, []xlsxSheet{detail, summary}); err != nil {
+		return fmt.Errorf("cannot write %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("cannot write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeReportXLSX(t *testing.T) {
+	conf := Config{Timezone: "UTC"}
+	started := time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC)
+	worklogs := []Worklog{
+		{Issue: "APP-2", Author: "me", Started: started.AddDate(0, 0, 1), Spent: 90 * time.Minute, Comment: "review <b> & fix"},
+		{Issue: "APP-1", Author: "me", Started: started, Spent: 2 * time.Hour},
+	}
+	path := filepath.Join(t.TempDir(), "report.xlsx")
+	require.NoError(t, writeReportXLSX(path, conf, worklogs))
+
+	z, err := zip.OpenReader(path)
+	require.NoError(t, err)
+	defer z.Close()
+	parts := map[string]string{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+		// every part is well formed XML
+		dec := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else {
+				require.NoError(t, err, f.Name)
+			}
+		}
+		parts[f.Name] = string(data)
+	}
+	require.Contains(t, parts, "[Content_Types].xml")
+	require.Contains(t, parts["xl/workbook.xml"], `<sheet name="Worklogs" sheetId="1" r:id="rId1"/><sheet name="Summary" sheetId="2" r:id="rId2"/>`)
+
+	detail := parts["xl/worksheets/sheet1.xml"]
+	require.Contains(t, detail, `state="frozen"`)
+	// the earliest first, 2024-03-05 is day 45356 of Excel
+	require.Contains(t, detail, `<row r="2"><c r="A2" s="2"><v>45356</v></c><c r="B2" s="3"><v>45356.395833333336</v></c>`)
+	require.Contains(t, detail, `<c r="D3" s="4"><v>0.0625</v></c>`, "duration is fraction of day")
+	require.Contains(t, detail, `review &lt;b&gt; &amp; fix`)
+
+	summary := parts["xl/worksheets/sheet2.xml"]
+	require.Contains(t, summary, `<row r="4"><c r="A4" s="0" t="inlineStr"><is><t xml:space="preserve">Total</t></is></c><c r="B4" s="4"><v>0.14583333333333334</v></c>`)
+}
+
+func Test_xlsxColumn(t *testing.T) {
+	require.Equal(t, "A", xlsxColumn(0))
+	require.Equal(t, "Z", xlsxColumn(25))
+	require.Equal(t, "AA", xlsxColumn(26))
+	require.Equal(t, "BA", xlsxColumn(52))
+}
@@ -4,19 +4,25 @@ go 1.19
 
 require (
 	atomicgo.dev/cursor v0.1.1
+	atomicgo.dev/keyboard v0.2.8
 	github.com/BurntSushi/toml v1.2.0
 	github.com/andygrunwald/go-jira v1.16.0
 	github.com/manifoldco/promptui v0.9.0
 	github.com/pterm/pterm v0.12.47
 	github.com/stretchr/testify v1.8.0
+	github.com/zalando/go-keyring v0.2.1
+	golang.org/x/sys v0.0.0-20220928140112-f11e5e49a4ec
+	golang.org/x/term v0.0.0-20220919170432-7a66f970e087
 )
 
 require (
-	atomicgo.dev/keyboard v0.2.8 // indirect
+	github.com/alessio/shellescape v1.4.1 // indirect
 	github.com/chzyer/readline v1.5.1 // indirect
 	github.com/containerd/console v1.0.3 // indirect
+	github.com/danieljoos/wincred v1.1.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/fatih/structs v1.1.0 // indirect
+	github.com/godbus/dbus/v5 v5.0.6 // indirect
 	github.com/golang-jwt/jwt/v4 v4.4.2 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect
 	github.com/gookit/color v1.5.2 // indirect
@@ -28,8 +34,6 @@ require (
 	github.com/rivo/uniseg v0.4.2 // indirect
 	github.com/trivago/tgo v1.0.7 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
-	golang.org/x/sys v0.0.0-20220928140112-f11e5e49a4ec // indirect
-	golang.org/x/term v0.0.0-20220919170432-7a66f970e087 // indirect
 	golang.org/x/text v0.3.7 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,122 @@
+// Package state keeps what tlog remembers between runs, e.g. running timer
+// or ledger, in files of the user config dir.
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Path returns path of the file used to persist local tlog state
+// (running timer, ledger, etc.), creating state directory if needed.
+func Path(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot obtain config dir: %w", err)
+	}
+	path := filepath.Join(dir, "tlog", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("cannot create state dir: %w", err)
+	}
+	return path, nil
+}
+
+// ExpandHome replaces leading ~ of path with home directory, ~\ works on Windows too.
+func ExpandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot expand ~: %w", err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+// ContextPath is like Path, but keeps separate file for every context,
+// since data of different JIRA instances must not mix.
+func ContextPath(context, name string) (string, error) {
+	if context == "" {
+		return Path(name)
+	}
+	return Path(filepath.Join("contexts", context, name))
+}
+
+// CachePath is like ContextPath, but in the user cache dir: files
+// there can be removed any time, tlog fetches their data again.
+func CachePath(context, name string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot obtain cache dir: %w", err)
+	}
+	path := filepath.Join(dir, "tlog", name)
+	if context != "" {
+		path = filepath.Join(dir, "tlog", "contexts", context, name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("cannot create cache dir: %w", err)
+	}
+	return path, nil
+}
+
+// WriteFileAtomic writes data to a temp file and renames it over path,
+// so readers never observe partially written state.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+const (
+	lockRetryInterval = 50 * time.Millisecond
+	lockWait          = 3 * time.Second
+	// lock older than this is considered left behind by crashed process
+	lockStaleAfter = 30 * time.Second
+)
+
+// Lock acquires exclusive lock by creating path. Returned func releases the lock.
+func Lock(path string) (func(), error) {
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d", os.Getpid())
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("create lock file: %w", err)
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("another tlog process holds %s, remove it if that's not the case", path)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
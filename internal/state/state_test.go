@@ -0,0 +1,97 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// isolateUserDirs points home, config and cache dirs of every OS to a temp
+// dir, so that the test neither sees nor touches real tlog state. It returns
+// the config dir.
+func isolateUserDirs(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	// macOS keeps both dirs in home, Windows reads the other variables
+	for key, dir := range map[string]string{
+		"HOME":            root,
+		"USERPROFILE":     root,
+		"XDG_CONFIG_HOME": filepath.Join(root, "config"),
+		"APPDATA":         filepath.Join(root, "config"),
+		"XDG_CACHE_HOME":  filepath.Join(root, "cache"),
+		"LOCALAPPDATA":    filepath.Join(root, "cache"),
+	} {
+		t.Setenv(key, dir)
+	}
+	dir, err := os.UserConfigDir()
+	require.NoError(t, err)
+	return dir
+}
+
+func Test_writeFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	require.NoError(t, WriteFileAtomic(path, []byte("first"), 0600))
+	require.NoError(t, WriteFileAtomic(path, []byte("second"), 0600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(data))
+
+	files, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, files, 1, "temp files must be cleaned up")
+}
+
+func Test_lockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+
+	unlock, err := Lock(path)
+	require.NoError(t, err)
+
+	acquired := make(chan struct{})
+	go func() {
+		unlockSecond, err := Lock(path)
+		require.NoError(t, err)
+		close(acquired)
+		unlockSecond()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired twice")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	unlock()
+	<-acquired
+}
+
+func Test_lockFile_stale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.lock")
+	require.NoError(t, os.WriteFile(path, []byte("12345"), 0600))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	unlock, err := Lock(path)
+	require.NoError(t, err)
+	unlock()
+}
+
+func Test_expandHome(t *testing.T) {
+	isolateUserDirs(t)
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	for _, path := range []string{"~/notes.ics", "~" + string(filepath.Separator) + "notes.ics"} {
+		expanded, err := ExpandHome(path)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(home, "notes.ics"), expanded, path)
+	}
+	expanded, err := ExpandHome("~other/notes.ics")
+	require.NoError(t, err)
+	require.Equal(t, "~other/notes.ics", expanded)
+}
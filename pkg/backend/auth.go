@@ -0,0 +1,109 @@
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/andygrunwald/go-jira"
+)
+
+// ErrCloudPassword is returned when JIRA Cloud rejects basic auth with real password.
+var ErrCloudPassword = errors.New("JIRA Cloud does not accept passwords, " +
+	`set AuthType = "cloud-token" and use API token (` + config.CloudTokenURL + `) as password`)
+
+// jiraHTTPClient returns HTTP client that authenticates requests as configured
+// and base URL of JIRA API, which is not JiraURL for OAuth.
+func jiraHTTPClient(conf config.Config) (*http.Client, string, error) {
+	base, err := BaseTransport(conf)
+	if err != nil {
+		return nil, "", err
+	}
+	transport, err := NewRetryTransport(conf, base)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch conf.AuthType {
+	case "", config.AuthBasic, config.AuthCloudToken, config.AuthPAT, config.AuthSession:
+	case config.AuthOAuth:
+		token, err := loadOAuthToken(conf)
+		if err != nil {
+			return nil, "", err
+		}
+		tp := &oauthTransport{conf: conf, next: transport, token: token}
+		return &http.Client{Transport: tp}, oauthBaseURL(token.CloudID), nil
+	default:
+		return nil, "", config.Error{Field: "AuthType", Err: fmt.Errorf("unknown AuthType %q in config: basic, cloud-token, pat, oauth or session expected", conf.AuthType)}
+	}
+
+	password, err := conf.Password()
+	if err != nil {
+		return nil, "", err
+	}
+	if password == "" {
+		return nil, "", errors.New("no password configured, run `tlog auth set`")
+	}
+	switch conf.AuthType {
+	case config.AuthPAT:
+		tp := &jira.PATAuthTransport{Token: password, Transport: transport}
+		return tp.Client(), conf.JiraURL, nil
+	case config.AuthSession:
+		tp := &sessionTransport{conf: conf, password: password, next: transport}
+		return &http.Client{Transport: tp}, conf.JiraURL, nil
+	}
+	tp := &jira.BasicAuthTransport{
+		Username:  conf.JiraLogin,
+		Password:  password,
+		Transport: cloudPasswordTransport{next: transport},
+	}
+	if conf.ReusesSession() {
+		reuse := &sessionReuseTransport{conf: conf, basic: tp, next: transport}
+		return &http.Client{Transport: reuse}, conf.JiraURL, nil
+	}
+	return tp.Client(), conf.JiraURL, nil
+}
+
+// cloudPasswordTransport detects JIRA Cloud response to deprecated password authentication,
+// which otherwise looks like plain 401 to the user.
+type cloudPasswordTransport struct {
+	next http.RoundTripper
+}
+
+func (t cloudPasswordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if isCloudPasswordRejection(body) {
+		return nil, ErrCloudPassword
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func isCloudPasswordRejection(body []byte) bool {
+	return bytes.Contains(bytes.ToLower(body), []byte("basic authentication with passwords is deprecated"))
+}
+
+// NewJiraClient returns JIRA client authenticating as configured.
+func NewJiraClient(conf config.Config) (*jira.Client, error) {
+	httpClient, baseURL, err := jiraHTTPClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	jiraClient, err := jira.NewClient(httpClient, baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create JIRA client: %w", err)
+	}
+	return jiraClient, nil
+}
@@ -0,0 +1,66 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cloudPasswordTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		if r.URL.Path == "/cloud" {
+			fmt.Fprint(w, "Basic authentication with passwords is deprecated. For more information, see: https://developer.atlassian.com/cloud/confluence/deprecation-notice-basic-auth/")
+			return
+		}
+		fmt.Fprint(w, "Unauthorized")
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: cloudPasswordTransport{next: http.DefaultTransport}}
+
+	_, err := client.Get(srv.URL + "/cloud")
+	require.ErrorIs(t, err, ErrCloudPassword)
+
+	resp, err := client.Get(srv.URL + "/server")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.Equal(t, "Unauthorized", string(body), "body is still readable")
+}
+
+func Test_jiraHTTPClient(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		conf config.Config
+		want string
+	}{
+		{name: "basic", conf: config.Config{JiraLogin: "user", JiraPassword: "secret"}, want: "Basic dXNlcjpzZWNyZXQ="},
+		{name: "pat", conf: config.Config{AuthType: config.AuthPAT, JiraPassword: "token"}, want: "Bearer token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, err := jiraHTTPClient(tt.conf)
+			require.NoError(t, err)
+			resp, err := client.Get(srv.URL)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	_, _, err := jiraHTTPClient(config.Config{AuthType: "kerberos"})
+	require.ErrorContains(t, err, `unknown AuthType "kerberos"`)
+}
@@ -0,0 +1,341 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+const (
+	azureAPIVersion     = "7.0"
+	azureCompletedField = "Microsoft.VSTS.Scheduling.CompletedWork"
+	azureRemainingField = "Microsoft.VSTS.Scheduling.RemainingWork"
+)
+
+// azureDevOpsBackend logs time by raising Completed Work of work items.
+// Azure DevOps keeps no worklogs, so the ledger is what tells them apart:
+// worklog ID is revision of work item it was logged in.
+type azureDevOpsBackend struct {
+	conf   config.Config
+	client *http.Client
+	base   string
+}
+
+type azureWorkItem struct {
+	ID     int                    `json:"id"`
+	Rev    int                    `json:"rev"`
+	Fields map[string]interface{} `json:"fields"`
+	Links  struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"_links"`
+}
+
+// hours returns numeric field of work item, false if it is not set.
+func (w azureWorkItem) hours(field string) (float64, bool) {
+	v, ok := w.Fields[field].(float64)
+	return v, ok
+}
+
+// changedBy returns name of who changed work item the last.
+func (w azureWorkItem) changedBy() string {
+	if by, ok := w.Fields["System.ChangedBy"].(map[string]interface{}); ok {
+		name, _ := by["displayName"].(string)
+		return name
+	}
+	return ""
+}
+
+type azurePatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+func newAzureDevOpsBackend(conf config.Config) (*azureDevOpsBackend, error) {
+	if conf.AzureDevOpsURL == "" {
+		return nil, errors.New("AzureDevOpsURL is not set in config")
+	}
+	if conf.AzureDevOpsToken == "" {
+		return nil, errors.New("AzureDevOpsToken is not set in config, create personal access token with Work Items (Read & write) scope in User settings > Personal access tokens")
+	}
+	transport, err := BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := NewRetryTransport(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+	return &azureDevOpsBackend{conf: conf, client: &http.Client{Transport: retry}, base: strings.TrimSuffix(conf.AzureDevOpsURL, "/")}, nil
+}
+
+// do sends request to Azure DevOps REST API and decodes response into out, if given.
+func (b *azureDevOpsBackend) do(method, path, contentType string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	req, err := http.NewRequest(method, b.base+path+sep+"api-version="+azureAPIVersion, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("", b.conf.AzureDevOpsToken)
+	req.Header.Set("Content-Type", contentType)
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach Azure DevOps: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// expired token gets sign-in page rather than 401
+	if resp.StatusCode >= 300 || strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		var failure struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+		if failure.Message != "" {
+			return StatusError(resp.StatusCode, fmt.Errorf("Azure DevOps: %s, %s", resp.Status, failure.Message))
+		}
+		if resp.StatusCode < 300 {
+			return AuthError{Err: errors.New("Azure DevOps: sign-in page is returned, AzureDevOpsToken is probably expired")}
+		}
+		return StatusError(resp.StatusCode, fmt.Errorf("Azure DevOps: %s", resp.Status))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (b *azureDevOpsBackend) workItem(id string) (azureWorkItem, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return azureWorkItem{}, fmt.Errorf("%q is not an Azure DevOps work item, work items are numbers", id)
+	}
+	var item azureWorkItem
+	if err := b.do(http.MethodGet, "/_apis/wit/workitems/"+url.PathEscape(id), "application/json", nil, &item); err != nil {
+		return azureWorkItem{}, err
+	}
+	return item, nil
+}
+
+// adjust adds delta to Completed Work of work item and takes it from
+// Remaining Work if AzureDevOpsReduceRemaining is set. Note goes to
+// Discussion. Updated work item is returned.
+func (b *azureDevOpsBackend) adjust(id string, delta time.Duration, note string) (azureWorkItem, error) {
+	item, err := b.workItem(id)
+	if err != nil {
+		return azureWorkItem{}, err
+	}
+	completed, _ := item.hours(azureCompletedField)
+	// revision test fails the update if someone changed work item meanwhile
+	ops := []azurePatchOp{
+		{Op: "test", Path: "/rev", Value: item.Rev},
+		{Op: "add", Path: "/fields/" + azureCompletedField, Value: azureHours(math.Max(0, completed+delta.Hours()))},
+	}
+	if remaining, ok := item.hours(azureRemainingField); ok && b.conf.AzureDevOpsReduceRemaining {
+		ops = append(ops, azurePatchOp{Op: "add", Path: "/fields/" + azureRemainingField, Value: azureHours(math.Max(0, remaining-delta.Hours()))})
+	}
+	ops = append(ops, azurePatchOp{Op: "add", Path: "/fields/System.History", Value: html.EscapeString(note)})
+
+	var updated azureWorkItem
+	if err := b.do(http.MethodPatch, "/_apis/wit/workitems/"+url.PathEscape(id), "application/json-patch+json", ops, &updated); err != nil {
+		return azureWorkItem{}, err
+	}
+	return updated, nil
+}
+
+// azureHours rounds hours to two decimal places, as Azure DevOps shows them.
+func azureHours(h float64) float64 {
+	return math.Round(h*100) / 100
+}
+
+// ledgerWorklog returns ledger entry of worklog logged by tlog, Azure DevOps
+// does not know how much time it was.
+func (b *azureDevOpsBackend) ledgerWorklog(issue, id string) (LedgerEntry, error) {
+	entries, err := ReadLedger(b.conf)
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+	for _, e := range entries {
+		if e.Issue == issue && e.WorklogID == id {
+			return e, nil
+		}
+	}
+	return LedgerEntry{}, fmt.Errorf("worklog %s of work item %s is not in the local ledger, change Completed Work in Azure DevOps instead", id, issue)
+}
+
+func (b *azureDevOpsBackend) AddWorklog(wl Worklog) (Worklog, error) {
+	note := fmt.Sprintf("Logged %s on %s", parse.FormatDuration(wl.Spent), wl.Started.In(b.conf.Location()).Format("2006-01-02"))
+	if wl.Comment != "" {
+		note += ": " + wl.Comment
+	}
+	item, err := b.adjust(wl.Issue, wl.Spent, note)
+	if err != nil {
+		return Worklog{}, err
+	}
+	wl.ID = strconv.Itoa(item.Rev)
+	wl.Author = item.changedBy()
+	wl.URL = item.Links.HTML.Href
+	return wl, nil
+}
+
+// UpdateWorklog applies difference to the time in the ledger, worklog keeps
+// its ID. The ledger entry is rewritten with the new time, as the next
+// change is applied against it.
+func (b *azureDevOpsBackend) UpdateWorklog(wl Worklog) (Worklog, error) {
+	logged, err := b.ledgerWorklog(wl.Issue, wl.ID)
+	if err != nil {
+		return Worklog{}, err
+	}
+	old := time.Duration(logged.Seconds) * time.Second
+	note := fmt.Sprintf("Changed %s logged on %s to %s", parse.FormatDuration(old), logged.Started.In(b.conf.Location()).Format("2006-01-02"), parse.FormatDuration(wl.Spent))
+	if wl.Comment != "" {
+		note += ": " + wl.Comment
+	}
+	item, err := b.adjust(wl.Issue, wl.Spent-old, note)
+	if err != nil {
+		return Worklog{}, err
+	}
+	wl.Started = logged.Started
+	if err := UpdateLedgerWorklog(b.conf, wl); err != nil {
+		pterm.Warning.Printfln("Completed Work is changed, but local ledger is not, change further in Azure DevOps: %s", err)
+	}
+	wl.Author = item.changedBy()
+	wl.URL = item.Links.HTML.Href
+	return wl, nil
+}
+
+// relogsToRedate tells that UpdateWorklog only adjusts Completed Work, the
+// day of worklog is in the ledger entry created along.
+func (b *azureDevOpsBackend) relogsToRedate() {}
+
+func (b *azureDevOpsBackend) DeleteWorklog(issue, id string) error {
+	logged, err := b.ledgerWorklog(issue, id)
+	if err != nil {
+		return err
+	}
+	spent := time.Duration(logged.Seconds) * time.Second
+	note := fmt.Sprintf("Removed %s logged on %s", parse.FormatDuration(spent), logged.Started.In(b.conf.Location()).Format("2006-01-02"))
+	if _, err := b.adjust(issue, -spent, note); err != nil {
+		return err
+	}
+	if err := RemoveLedgerWorklog(b.conf, issue, id); err != nil {
+		pterm.Warning.Printfln("Completed Work is changed, but local ledger is not, do not delete the worklog again: %s", err)
+	}
+	return nil
+}
+
+// query runs WIQL query and returns ids of found work items.
+func (b *azureDevOpsBackend) query(wiql string, top int) ([]int, error) {
+	var resp struct {
+		WorkItems []struct {
+			ID int `json:"id"`
+		} `json:"workItems"`
+	}
+	path := "/_apis/wit/wiql?$top=" + strconv.Itoa(top)
+	if err := b.do(http.MethodPost, path, "application/json", map[string]string{"query": wiql}, &resp); err != nil {
+		return nil, fmt.Errorf("query work items: %w", err)
+	}
+	ids := make([]int, 0, len(resp.WorkItems))
+	for _, w := range resp.WorkItems {
+		ids = append(ids, w.ID)
+	}
+	return ids, nil
+}
+
+// ListWorklogs returns worklogs of the ledger logged to work items the
+// current user changed since from, Azure DevOps has only their sum.
+func (b *azureDevOpsBackend) ListWorklogs(from, to time.Time) ([]Worklog, error) {
+	wiql := fmt.Sprintf("SELECT [System.Id] FROM WorkItems WHERE [System.ChangedDate] >= '%s' AND EVER [System.ChangedBy] = @Me",
+		from.In(b.conf.Location()).Format("2006-01-02"))
+	ids, err := b.query(wiql, 20000)
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		changed[strconv.Itoa(id)] = true
+	}
+	entries, err := ReadLedger(b.conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var worklogs []Worklog
+	for _, e := range entries {
+		if !changed[e.Issue] || e.WorklogID == "" || e.Started.Before(from) || !e.Started.Before(to) {
+			continue
+		}
+		worklogs = append(worklogs, Worklog{
+			ID:      e.WorklogID,
+			Issue:   e.Issue,
+			Started: e.Started.In(b.conf.Location()),
+			Spent:   time.Duration(e.Seconds) * time.Second,
+			Comment: e.Comment,
+		})
+	}
+	sort.SliceStable(worklogs, func(i, j int) bool { return worklogs[i].Started.Before(worklogs[j].Started) })
+	return worklogs, nil
+}
+
+func (b *azureDevOpsBackend) GetIssue(id string) (Issue, error) {
+	item, err := b.workItem(id)
+	if err != nil {
+		return Issue{}, err
+	}
+	title, _ := item.Fields["System.Title"].(string)
+	state, _ := item.Fields["System.State"].(string)
+	kind, _ := item.Fields["System.WorkItemType"].(string)
+	return Issue{Key: id, Summary: title, Status: state, Type: kind}, nil
+}
+
+// SearchIssues finds work items with query in title, recently changed first.
+func (b *azureDevOpsBackend) SearchIssues(query string) ([]Issue, error) {
+	wiql := fmt.Sprintf("SELECT [System.Id] FROM WorkItems WHERE [System.Title] CONTAINS '%s' ORDER BY [System.ChangedDate] DESC",
+		strings.ReplaceAll(query, "'", "''"))
+	ids, err := b.query(wiql, 100)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	list := make([]string, len(ids))
+	for i, id := range ids {
+		list[i] = strconv.Itoa(id)
+	}
+	var resp struct {
+		Value []azureWorkItem `json:"value"`
+	}
+	path := "/_apis/wit/workitems?fields=System.Title&ids=" + strings.Join(list, ",")
+	if err := b.do(http.MethodGet, path, "application/json", nil, &resp); err != nil {
+		return nil, err
+	}
+	issues := make([]Issue, 0, len(resp.Value))
+	for _, item := range resp.Value {
+		title, _ := item.Fields["System.Title"].(string)
+		issues = append(issues, Issue{Key: strconv.Itoa(item.ID), Summary: title})
+	}
+	return issues, nil
+}
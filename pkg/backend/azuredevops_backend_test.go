@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_azureDevOpsBackend(t *testing.T) {
+	isolateUserDirs(t)
+	item := map[string]interface{}{
+		"System.Title":      "Login page",
+		azureRemainingField: 2.0,
+	}
+	rev := 3
+	var history []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, token, _ := r.BasicAuth()
+		require.Equal(t, "", user)
+		require.Equal(t, "pat", token)
+		require.Equal(t, "7.0", r.URL.Query().Get("api-version"))
+		switch {
+		case r.URL.Path == "/_apis/wit/workitems/42" && r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": 42, "rev": rev, "fields": item})
+		case r.URL.Path == "/_apis/wit/workitems/42" && r.Method == http.MethodPatch:
+			require.Equal(t, "application/json-patch+json", r.Header.Get("Content-Type"))
+			var ops []azurePatchOp
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ops))
+			require.Equal(t, azurePatchOp{Op: "test", Path: "/rev", Value: float64(rev)}, ops[0])
+			for _, op := range ops[1:] {
+				field := op.Path[len("/fields/"):]
+				if field == "System.History" {
+					history = append(history, op.Value.(string))
+					continue
+				}
+				item[field] = op.Value
+			}
+			rev++
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": 42, "rev": rev, "fields": map[string]interface{}{
+				"System.ChangedBy": map[string]interface{}{"displayName": "Jane Doe"},
+			}, "_links": map[string]interface{}{"html": map[string]string{"href": "https://dev.azure.com/company/web/_workitems/edit/42"}}})
+		case r.URL.Path == "/_apis/wit/wiql":
+			var q map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&q))
+			require.Contains(t, q["query"], "EVER [System.ChangedBy] = @Me")
+			w.Write([]byte(`{"workItems": [{"id": 42}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := config.Config{Backend: config.AzureDevOps, AzureDevOpsURL: srv.URL, AzureDevOpsToken: "pat", AzureDevOpsReduceRemaining: true, Timezone: "UTC"}
+	b, err := newAzureDevOpsBackend(conf)
+	require.NoError(t, err)
+
+	started := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	added, err := b.AddWorklog(Worklog{Issue: "42", Started: started, Spent: 90 * time.Minute, Comment: "<b>review</b>"})
+	require.NoError(t, err)
+	require.Equal(t, "4", added.ID, "worklog is revision it was logged in")
+	require.Equal(t, "Jane Doe", added.Author)
+	require.Equal(t, "https://dev.azure.com/company/web/_workitems/edit/42", added.URL)
+	require.Equal(t, 1.5, item[azureCompletedField])
+	require.Equal(t, 0.5, item[azureRemainingField])
+	require.Equal(t, []string{"Logged 1h30m on 2024-03-04: &lt;b&gt;review&lt;/b&gt;"}, history)
+
+	require.NoError(t, AppendLedger(conf, LedgerEntry{Issue: "42", WorklogID: added.ID, Started: started, Seconds: 5400, Comment: "review"}))
+	// another backend logged it
+	require.NoError(t, AppendLedger(conf, LedgerEntry{Issue: "PRJ-1", WorklogID: "9", Started: started, Seconds: 600}))
+
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	worklogs, err := b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, []Worklog{{ID: "4", Issue: "42", Started: started, Spent: 90 * time.Minute, Comment: "review"}}, worklogs)
+
+	// other work logged by hand in Azure DevOps
+	item[azureCompletedField] = 3.5
+	_, err = b.UpdateWorklog(Worklog{ID: "4", Issue: "42", Started: started, Spent: time.Hour})
+	require.NoError(t, err)
+	require.Equal(t, 3.0, item[azureCompletedField])
+	require.Equal(t, 1.0, item[azureRemainingField])
+	_, err = b.UpdateWorklog(Worklog{ID: "4", Issue: "42", Started: started, Spent: 2 * time.Hour})
+	require.NoError(t, err)
+	require.Equal(t, 4.0, item[azureCompletedField], "the second change is against the first one")
+	require.Equal(t, "Changed 1h logged on 2024-03-04 to 2h", history[len(history)-1])
+
+	require.NoError(t, b.DeleteWorklog("42", "4"))
+	require.Equal(t, 2.0, item[azureCompletedField], "hours logged by hand stay")
+	require.Equal(t, "Removed 2h logged on 2024-03-04", history[len(history)-1])
+	worklogs, err = b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Empty(t, worklogs, "deleted worklog is not listed")
+	require.ErrorContains(t, b.DeleteWorklog("42", "4"), "not in the local ledger", "nothing is subtracted twice")
+	require.Equal(t, 2.0, item[azureCompletedField])
+
+	err = b.DeleteWorklog("42", "7")
+	require.ErrorContains(t, err, "not in the local ledger")
+	_, err = b.AddWorklog(Worklog{Issue: "PRJ-1"})
+	require.ErrorContains(t, err, "work items are numbers")
+}
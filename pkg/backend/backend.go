@@ -0,0 +1,112 @@
+// Package backend talks to the trackers worklogs are kept in: JIRA, Tempo,
+// GitLab, YouTrack, Redmine, Azure DevOps, or a local file for demos.
+package backend
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"sort"
+)
+
+// Worklog is time logged to an issue.
+type Worklog struct {
+	ID      string
+	Issue   string
+	Author  string
+	Started time.Time
+	Spent   time.Duration
+	Comment string
+	// link to the worklog, empty if backend has none
+	URL string
+	// backend specific values, e.g. Tempo work attributes
+	Attributes map[string]string
+}
+
+// Issue is a task worklogs are logged to.
+type Issue struct {
+	Key     string
+	Summary string
+	// empty if backend has no such thing
+	Status string
+	Type   string
+}
+
+// Backend is a tracker worklogs are stored in. Commands talk to it instead
+// of JIRA, so they work the same with every tracker.
+type Backend interface {
+	// AddWorklog creates worklog and returns it as stored by backend.
+	AddWorklog(wl Worklog) (Worklog, error)
+	// ListWorklogs returns worklogs of the current user started in [from, to).
+	ListWorklogs(from, to time.Time) ([]Worklog, error)
+	DeleteWorklog(issue, id string) error
+	// UpdateWorklog replaces worklog with given Issue and ID.
+	UpdateWorklog(wl Worklog) (Worklog, error)
+	GetIssue(key string) (Issue, error)
+	// SearchIssues finds issues by query in backend's language, e.g. JQL for JIRA.
+	SearchIssues(query string) ([]Issue, error)
+}
+
+// WorklogPreparer is implemented by backends that need more than time and comment
+// for a worklog. PrepareWorklog may ask user, so it runs before spinner starts.
+type WorklogPreparer interface {
+	PrepareWorklog(wl *Worklog) error
+}
+
+// Relogger is implemented by backends whose UpdateWorklog cannot move worklog
+// to another day. It is logged again there and the original deleted instead.
+type Relogger interface {
+	relogsToRedate()
+}
+
+// New returns backend selected by Backend config key, JIRA by default.
+func New(conf config.Config) (Backend, error) {
+	switch conf.Backend {
+	case "", config.Jira:
+		return newJiraBackend(conf)
+	case config.Tempo:
+		return newTempoBackend(conf)
+	case config.GitLab:
+		return newGitLabBackend(conf)
+	case config.YouTrack:
+		return newYouTrackBackend(conf)
+	case config.Redmine:
+		return newRedmineBackend(conf)
+	case config.AzureDevOps:
+		return newAzureDevOpsBackend(conf)
+	case config.Mock:
+		return newMockBackend(conf)
+	default:
+		return nil, config.Error{Field: "Backend", Err: fmt.Errorf("unknown Backend %q in config: jira, tempo, gitlab, youtrack, redmine, azuredevops or mock expected", conf.Backend)}
+	}
+}
+
+// listedDates returns from and to of ListWorklogs as dates of Timezone for
+// backends that list worklogs by day. Dates are inclusive, so the worklogs
+// they list have to be checked against the range again.
+func listedDates(conf config.Config, from, to time.Time) (string, string) {
+	return from.In(conf.Location()).Format("2006-01-02"), to.In(conf.Location()).Format("2006-01-02")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func toPtr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,65 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// IssueNotFoundError is returned when backend has no issue of the key, or
+// the user cannot see it.
+type IssueNotFoundError struct {
+	Issue string
+	Err   error
+}
+
+func (e IssueNotFoundError) Error() string {
+	return fmt.Sprintf("issue %s is not found, or you are not permitted to see it", e.Issue)
+}
+
+func (e IssueNotFoundError) Unwrap() error { return e.Err }
+
+// AuthError is returned when backend rejects credentials, Err tells what to check.
+type AuthError struct {
+	Err error
+}
+
+func (e AuthError) Error() string { return e.Err.Error() }
+
+func (e AuthError) Unwrap() error { return e.Err }
+
+// StatusError returns err of failed response of given status, as AuthError
+// if backend rejected credentials, as RedateError if it rejected the day.
+func StatusError(status int, err error) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return AuthError{Err: err}
+	case (status == http.StatusBadRequest || status == http.StatusUnprocessableEntity) && startedRe.MatchString(err.Error()):
+		return RedateError{Err: err}
+	}
+	return err
+}
+
+// startedRe matches what backends name start of worklog by in their errors,
+// e.g. "started" of JIRA, "startDate" of Tempo or "spent_on" of Redmine.
+var startedRe = regexp.MustCompile(`(?i)\b(started?|start ?date|start_date|start ?time|spent_?on|date)\b`)
+
+// RedateError is returned when backend refuses to change start of worklog
+// in place, e.g. to a day of another period. `tlog edit` logs it anew then.
+type RedateError struct {
+	Err error
+}
+
+func (e RedateError) Error() string { return e.Err.Error() }
+
+func (e RedateError) Unwrap() error { return e.Err }
+
+// TruncatedError is returned with worklogs listed until limit of records to
+// read was reached, counting them up misses the rest.
+type TruncatedError struct {
+	Limit int
+}
+
+func (e TruncatedError) Error() string {
+	return fmt.Sprintf("more than %d worklogs, only part of them is listed", e.Limit)
+}
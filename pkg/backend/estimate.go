@@ -0,0 +1,12 @@
+package backend
+
+import "time"
+
+// IssueEstimate is time tracking of issue.
+type IssueEstimate struct {
+	Issue     string        `json:"issue"`
+	Summary   string        `json:"summary"`
+	Estimate  time.Duration `json:"-"`
+	Logged    time.Duration `json:"-"`
+	Remaining time.Duration `json:"-"`
+}
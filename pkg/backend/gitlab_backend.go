@@ -0,0 +1,320 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+)
+
+// DefaultGitLabURL is used when GitLabURL is not set.
+const DefaultGitLabURL = "https://gitlab.com"
+
+// gitlabBackend logs time to GitLab issues. REST API has no time entries,
+// only totals, so they are created, listed and deleted through GraphQL.
+type gitlabBackend struct {
+	conf   config.Config
+	client *http.Client
+	base   string
+
+	// mu guards username, imports create worklogs in parallel
+	mu       sync.Mutex
+	username string
+}
+
+type gitlabIssue struct {
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	State      string `json:"state"`
+	Type       string `json:"issue_type"`
+	References struct {
+		Full string `json:"full"`
+	} `json:"references"`
+}
+
+type gitlabTimelog struct {
+	ID        string    `json:"id"`
+	SpentAt   time.Time `json:"spentAt"`
+	TimeSpent int       `json:"timeSpent"`
+	Summary   string    `json:"summary"`
+	User      struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Issue *struct {
+		Reference string `json:"reference"`
+		WebURL    string `json:"webUrl"`
+	} `json:"issue"`
+}
+
+func newGitLabBackend(conf config.Config) (*gitlabBackend, error) {
+	if conf.GitLabToken == "" {
+		return nil, errors.New("GitLabToken is not set in config, create personal access token with api scope in GitLab > Preferences > Access tokens")
+	}
+	transport, err := BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := NewRetryTransport(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+	base := conf.GitLabURL
+	if base == "" {
+		base = DefaultGitLabURL
+	}
+	return &gitlabBackend{conf: conf, client: &http.Client{Transport: retry}, base: strings.TrimSuffix(base, "/")}, nil
+}
+
+// parseGitLabRef splits group/project#123 into project path and issue iid.
+func parseGitLabRef(ref string) (string, int, error) {
+	if !parse.IsGitLabRef(ref) {
+		return "", 0, fmt.Errorf("%q is not a GitLab issue, use group/project#123", ref)
+	}
+	i := strings.LastIndex(ref, "#")
+	iid, err := strconv.Atoi(ref[i+1:])
+	return ref[:i], iid, err
+}
+
+// do sends request to GitLab REST API and decodes response into out, if given.
+func (b *gitlabBackend) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, b.base+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", b.conf.GitLabToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach GitLab: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Message interface{} `json:"message"`
+			Error   string      `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+		switch {
+		case failure.Message != nil:
+			return StatusError(resp.StatusCode, fmt.Errorf("GitLab: %s, %v", resp.Status, failure.Message))
+		case failure.Error != "":
+			return StatusError(resp.StatusCode, fmt.Errorf("GitLab: %s, %s", resp.Status, failure.Error))
+		}
+		return StatusError(resp.StatusCode, fmt.Errorf("GitLab: %s", resp.Status))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// graphql runs GraphQL query and decodes its data into out.
+func (b *gitlabBackend) graphql(query string, vars map[string]interface{}, out interface{}) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	err := b.do(http.MethodPost, "/api/graphql", map[string]interface{}{"query": query, "variables": vars}, &resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		messages := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("GitLab: %s", strings.Join(messages, ", "))
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+func (b *gitlabBackend) issue(ref string) (gitlabIssue, error) {
+	project, iid, err := parseGitLabRef(ref)
+	if err != nil {
+		return gitlabIssue{}, err
+	}
+	var issue gitlabIssue
+	err = b.do(http.MethodGet, fmt.Sprintf("/api/v4/projects/%s/issues/%d", url.PathEscape(project), iid), nil, &issue)
+	return issue, err
+}
+
+func (b *gitlabBackend) currentUser() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.username != "" {
+		return b.username, nil
+	}
+	var user struct {
+		Username string `json:"username"`
+	}
+	if err := b.do(http.MethodGet, "/api/v4/user", nil, &user); err != nil {
+		return "", fmt.Errorf("get current user: %w", err)
+	}
+	b.username = user.Username
+	return b.username, nil
+}
+
+// timelogID returns numeric part of GraphQL id, e.g. gid://gitlab/Timelog/12.
+func timelogID(gid string) string {
+	return gid[strings.LastIndex(gid, "/")+1:]
+}
+
+func (b *gitlabBackend) fromTimelog(tl gitlabTimelog) Worklog {
+	wl := Worklog{
+		ID:      timelogID(tl.ID),
+		Author:  tl.User.Username,
+		Started: tl.SpentAt.In(b.conf.Location()),
+		Spent:   time.Duration(tl.TimeSpent) * time.Second,
+		Comment: tl.Summary,
+	}
+	if tl.Issue != nil {
+		wl.Issue, wl.URL = tl.Issue.Reference, tl.Issue.WebURL
+	}
+	return wl
+}
+
+const gitlabTimelogFields = `id spentAt timeSpent summary user { username } issue { reference(full: true) webUrl }`
+
+func (b *gitlabBackend) AddWorklog(wl Worklog) (Worklog, error) {
+	issue, err := b.issue(wl.Issue)
+	if err != nil {
+		return Worklog{}, err
+	}
+	var data struct {
+		TimelogCreate struct {
+			Timelog gitlabTimelog `json:"timelog"`
+			Errors  []string      `json:"errors"`
+		} `json:"timelogCreate"`
+	}
+	err = b.graphql(`mutation($input: TimelogCreateInput!) {
+		timelogCreate(input: $input) { timelog { `+gitlabTimelogFields+` } errors }
+	}`, map[string]interface{}{"input": map[string]interface{}{
+		"issuableId": fmt.Sprintf("gid://gitlab/Issue/%d", issue.ID),
+		// GitLab counts a day as 8h, so days are never sent
+		"timeSpent": parse.FormatDuration(wl.Spent),
+		"spentAt":   wl.Started.Format(time.RFC3339),
+		"summary":   wl.Comment,
+	}}, &data)
+	if err != nil {
+		return Worklog{}, err
+	}
+	if errs := data.TimelogCreate.Errors; len(errs) > 0 {
+		return Worklog{}, fmt.Errorf("GitLab: %s", strings.Join(errs, ", "))
+	}
+	return b.fromTimelog(data.TimelogCreate.Timelog), nil
+}
+
+// UpdateWorklog is not supported, GitLab time entries cannot be edited.
+func (b *gitlabBackend) UpdateWorklog(wl Worklog) (Worklog, error) {
+	return Worklog{}, errors.New("GitLab cannot edit time entries, delete the entry and log it again")
+}
+
+func (b *gitlabBackend) relogsToRedate() {}
+
+func (b *gitlabBackend) DeleteWorklog(issue, id string) error {
+	var data struct {
+		TimelogDelete struct {
+			Errors []string `json:"errors"`
+		} `json:"timelogDelete"`
+	}
+	err := b.graphql(`mutation($id: TimelogID!) { timelogDelete(input: {id: $id}) { errors } }`,
+		map[string]interface{}{"id": "gid://gitlab/Timelog/" + id}, &data)
+	if err != nil {
+		return err
+	}
+	if errs := data.TimelogDelete.Errors; len(errs) > 0 {
+		return fmt.Errorf("GitLab: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (b *gitlabBackend) ListWorklogs(from, to time.Time) ([]Worklog, error) {
+	username, err := b.currentUser()
+	if err != nil {
+		return nil, err
+	}
+
+	var worklogs []Worklog
+	var after interface{}
+	for {
+		var data struct {
+			Timelogs struct {
+				Nodes    []gitlabTimelog `json:"nodes"`
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+			} `json:"timelogs"`
+		}
+		err := b.graphql(`query($username: String, $start: Time, $end: Time, $after: String) {
+			timelogs(username: $username, startTime: $start, endTime: $end, after: $after) {
+				nodes { `+gitlabTimelogFields+` }
+				pageInfo { hasNextPage endCursor }
+			}
+		}`, map[string]interface{}{
+			"username": username,
+			"start":    from.Format(time.RFC3339),
+			"end":      to.Format(time.RFC3339),
+			"after":    after,
+		}, &data)
+		if err != nil {
+			return nil, fmt.Errorf("list time entries: %w", err)
+		}
+		for _, tl := range data.Timelogs.Nodes {
+			// endTime is inclusive
+			if wl := b.fromTimelog(tl); wl.Started.Before(to) {
+				worklogs = append(worklogs, wl)
+			}
+		}
+		if !data.Timelogs.PageInfo.HasNextPage {
+			break
+		}
+		after = data.Timelogs.PageInfo.EndCursor
+	}
+	sort.SliceStable(worklogs, func(i, j int) bool { return worklogs[i].Started.Before(worklogs[j].Started) })
+	return worklogs, nil
+}
+
+func (b *gitlabBackend) GetIssue(ref string) (Issue, error) {
+	issue, err := b.issue(ref)
+	if err != nil {
+		return Issue{}, err
+	}
+	return Issue{Key: ref, Summary: issue.Title, Status: issue.State, Type: issue.Type}, nil
+}
+
+// SearchIssues finds issues visible to the user by text in title or description.
+func (b *gitlabBackend) SearchIssues(query string) ([]Issue, error) {
+	var found []gitlabIssue
+	params := url.Values{"scope": {"all"}, "search": {query}, "per_page": {"100"}}
+	if err := b.do(http.MethodGet, "/api/v4/issues?"+params.Encode(), nil, &found); err != nil {
+		return nil, err
+	}
+	issues := make([]Issue, 0, len(found))
+	for _, issue := range found {
+		issues = append(issues, Issue{Key: issue.References.Full, Summary: issue.Title, Status: issue.State, Type: issue.Type})
+	}
+	return issues, nil
+}
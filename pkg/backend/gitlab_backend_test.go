@@ -0,0 +1,75 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseGitLabRef(t *testing.T) {
+	project, iid, err := parseGitLabRef("group/sub/project#12")
+	require.NoError(t, err)
+	require.Equal(t, "group/sub/project", project)
+	require.Equal(t, 12, iid)
+
+	for _, ref := range []string{"PROJ-12", "project#12", "group/project#", "group/project"} {
+		_, _, err := parseGitLabRef(ref)
+		require.Error(t, err, ref)
+	}
+}
+
+func Test_gitlabBackend(t *testing.T) {
+	var input map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "token", r.Header.Get("PRIVATE-TOKEN"))
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fproject/issues/12":
+			w.Write([]byte(`{"id": 345, "iid": 12, "title": "Fix login"}`))
+		case "/api/v4/user":
+			w.Write([]byte(`{"username": "me"}`))
+		case "/api/graphql":
+			var req struct {
+				Query     string                 `json:"query"`
+				Variables map[string]interface{} `json:"variables"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			timelog := `{"id": "gid://gitlab/Timelog/9", "spentAt": "2024-03-04T09:00:00Z", "timeSpent": 5400, "summary": "review",
+				"user": {"username": "me"}, "issue": {"reference": "group/project#12", "webUrl": "https://gitlab.com/group/project/-/issues/12"}}`
+			if strings.Contains(req.Query, "timelogCreate") {
+				input = req.Variables["input"].(map[string]interface{})
+				w.Write([]byte(`{"data": {"timelogCreate": {"timelog": ` + timelog + `, "errors": []}}}`))
+				return
+			}
+			require.Equal(t, "me", req.Variables["username"])
+			w.Write([]byte(`{"data": {"timelogs": {"nodes": [` + timelog + `], "pageInfo": {"hasNextPage": false}}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	b, err := newGitLabBackend(config.Config{GitLabURL: srv.URL, GitLabToken: "token", Timezone: "UTC"})
+	require.NoError(t, err)
+
+	started := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	wl, err := b.AddWorklog(Worklog{Issue: "group/project#12", Started: started, Spent: 90 * time.Minute, Comment: "review"})
+	require.NoError(t, err)
+	require.Equal(t, "9", wl.ID)
+	require.Equal(t, "gid://gitlab/Issue/345", input["issuableId"])
+	require.Equal(t, "1h30m", input["timeSpent"])
+	require.Equal(t, "2024-03-04T09:00:00Z", input["spentAt"])
+
+	worklogs, err := b.ListWorklogs(started.Truncate(24*time.Hour), started.Truncate(24*time.Hour).AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, []Worklog{wl}, worklogs)
+
+	issue, err := b.GetIssue("group/project#12")
+	require.NoError(t, err)
+	require.Equal(t, "Fix login", issue.Summary)
+}
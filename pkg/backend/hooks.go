@@ -0,0 +1,21 @@
+package backend
+
+// Prompts backends ask the user for what config does not tell, e.g.
+// activity of Redmine time entry. Nil ones mean nobody can answer, e.g.
+// without terminal, and backends fail with what to configure instead.
+var (
+	// PromptChoose returns index of the item picked
+	PromptChoose func(label string, items []string) (int, error)
+	// PromptInput returns non-empty value entered
+	PromptInput func(label string) (string, error)
+)
+
+// Debugf prints diagnostic message, nil unless --verbose is given.
+// Secrets must never be passed here.
+var Debugf func(format string, args ...interface{})
+
+func debugf(format string, args ...interface{}) {
+	if Debugf != nil {
+		Debugf(format, args...)
+	}
+}
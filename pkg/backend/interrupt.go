@@ -0,0 +1,17 @@
+package backend
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInterrupted is returned by requests aborted with Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+var (
+	// InterruptCtx is cancelled on Ctrl-C, aborting requests in flight
+	InterruptCtx = context.Background()
+	// InFlight is the number of requests being sent and bulk operations
+	// running, Ctrl-C lets them stop rather than killing tlog
+	InFlight int32
+)
@@ -0,0 +1,333 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/andygrunwald/go-jira"
+)
+
+// jiraBackend stores worklogs in JIRA through its REST API.
+type jiraBackend struct {
+	conf   config.Config
+	client *jira.Client
+}
+
+func newJiraBackend(conf config.Config) (*jiraBackend, error) {
+	client, err := NewJiraClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &jiraBackend{conf: conf, client: client}, nil
+}
+
+func (b *jiraBackend) AddWorklog(wl Worklog) (Worklog, error) {
+	rec, resp, err := b.client.Issue.AddWorklogRecord(wl.Issue, toJiraWorklog(wl))
+	if err != nil {
+		return Worklog{}, jiraError(resp, err, wl.Issue)
+	}
+	return fromJiraWorklog(wl.Issue, rec, b.conf.JiraLogin), nil
+}
+
+func (b *jiraBackend) UpdateWorklog(wl Worklog) (Worklog, error) {
+	rec, resp, err := b.client.Issue.UpdateWorklogRecord(wl.Issue, wl.ID, toJiraWorklog(wl))
+	if err != nil {
+		return Worklog{}, jiraError(resp, err, wl.Issue)
+	}
+	return fromJiraWorklog(wl.Issue, rec, b.conf.JiraLogin), nil
+}
+
+func (b *jiraBackend) DeleteWorklog(issue, id string) error {
+	// go-jira has no method for it
+	req, err := b.client.NewRequest(http.MethodDelete, fmt.Sprintf("rest/api/2/issue/%s/worklog/%s", issue, id), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Do(req, nil)
+	if err != nil && resp != nil {
+		// go-jira leaves body of the error response unread here
+		err = jira.NewJiraError(resp, err)
+	}
+	return jiraError(resp, err, issue)
+}
+
+// ListWorklogs reads every page of search and of worklogs of every found
+// issue, up to WorklogLimit records. Worklogs read until then are returned
+// with TruncatedError.
+func (b *jiraBackend) ListWorklogs(from, to time.Time) ([]Worklog, error) {
+	limit := b.conf.WorklogLimit()
+	// worklogDate is a day in JIRA user's timezone, so the range is checked again below
+	jql := fmt.Sprintf(`worklogAuthor = currentUser() AND worklogDate >= "%s" AND worklogDate <= "%s"`,
+		from.Format("2006-01-02"), to.Format("2006-01-02"))
+	var issues []jira.Issue
+	moreIssues := false
+	for {
+		page, resp, err := b.client.Issue.Search(jql, &jira.SearchOptions{Fields: []string{"key"}, StartAt: len(issues), MaxResults: 100})
+		if err != nil {
+			return nil, fmt.Errorf("search worklogs: %w", jiraError(resp, err, ""))
+		}
+		issues = append(issues, page...)
+		if len(page) == 0 || len(issues) >= resp.Total {
+			break
+		}
+		if len(issues) >= limit {
+			moreIssues = true
+			break
+		}
+	}
+
+	me, err := b.self()
+	if err != nil {
+		return nil, err
+	}
+
+	var worklogs []Worklog
+	read := 0
+	for _, issue := range issues {
+		for startAt := 0; ; {
+			if read >= limit {
+				return worklogs, TruncatedError{Limit: limit}
+			}
+			records, err := b.worklogPage(issue.Key, startAt)
+			if err != nil {
+				return nil, err
+			}
+			for i := range records.Worklogs {
+				rec := &records.Worklogs[i]
+				if rec.Started == nil || !isAuthor(rec.Author, me) {
+					continue
+				}
+				if started := time.Time(*rec.Started); started.Before(from) || !started.Before(to) {
+					continue
+				}
+				worklogs = append(worklogs, fromJiraWorklog(issue.Key, rec, b.conf.JiraLogin))
+			}
+			read += len(records.Worklogs)
+			// JIRA decides page size, it may be smaller than asked
+			startAt += len(records.Worklogs)
+			if len(records.Worklogs) == 0 || startAt >= records.Total {
+				break
+			}
+		}
+	}
+	if moreIssues {
+		return worklogs, TruncatedError{Limit: limit}
+	}
+	return worklogs, nil
+}
+
+// jiraSelf is current user, worklogs are told apart by it: by AccountID on
+// JIRA Cloud, by login as name, key or email on Server and Data Center.
+type jiraSelf struct {
+	AccountID string
+	Logins    []string
+}
+
+// self asks JIRA who the current user is. If it cannot tell, JiraLogin is
+// all there is to match authors on.
+func (b *jiraBackend) self() (jiraSelf, error) {
+	user, resp, err := b.client.User.GetSelf()
+	if err != nil {
+		if b.conf.JiraLogin != "" {
+			return jiraSelf{Logins: []string{b.conf.JiraLogin}}, nil
+		}
+		return jiraSelf{}, fmt.Errorf("get current user: %w", jiraError(resp, err, ""))
+	}
+	me := jiraSelf{AccountID: user.AccountID}
+	for _, login := range []string{b.conf.JiraLogin, user.Name, user.Key, user.EmailAddress} {
+		if login != "" {
+			me.Logins = append(me.Logins, login)
+		}
+	}
+	return me, nil
+}
+
+// worklogPage returns worklogs of issue starting at startAt, JIRA decides how many.
+func (b *jiraBackend) worklogPage(issue string, startAt int) (*jira.Worklog, error) {
+	opts := jira.WithQueryOptions(&struct {
+		StartAt    int `url:"startAt"`
+		MaxResults int `url:"maxResults"`
+	}{startAt, 1000})
+	records, resp, err := b.client.Issue.GetWorklogs(issue, opts)
+	if err != nil {
+		return nil, fmt.Errorf("get worklogs of %s: %w", issue, jiraError(resp, err, issue))
+	}
+	return records, nil
+}
+
+func (b *jiraBackend) GetIssue(key string) (Issue, error) {
+	issue, resp, err := b.client.Issue.Get(key, &jira.GetQueryOptions{Fields: "summary,status,issuetype"})
+	if err != nil {
+		return Issue{}, jiraError(resp, err, key)
+	}
+	return fromJiraIssue(*issue), nil
+}
+
+func (b *jiraBackend) SearchIssues(query string) ([]Issue, error) {
+	found, resp, err := b.client.Issue.Search(query, &jira.SearchOptions{Fields: []string{"summary", "status", "issuetype"}, MaxResults: 100})
+	if err != nil {
+		return nil, jiraError(resp, err, "")
+	}
+	issues := make([]Issue, 0, len(found))
+	for _, issue := range found {
+		issues = append(issues, fromJiraIssue(issue))
+	}
+	return issues, nil
+}
+
+// WorkedIssues finds issues user updated, e.g. commented on, or transitioned.
+func (b *jiraBackend) WorkedIssues(days int) ([]Issue, error) {
+	issues, err := b.SearchIssues(fmt.Sprintf(`issuekey in updatedBy(currentUser(), "-%dd") OR status changed by currentUser() after -%dd ORDER BY updated DESC`, days, days))
+	if err != nil {
+		// JIRA Server before 8.1 has no updatedBy()
+		return b.SearchIssues(fmt.Sprintf(`status changed by currentUser() after -%dd OR assignee = currentUser() AND updated >= -%dd ORDER BY updated DESC`, days, days))
+	}
+	return issues, nil
+}
+
+// orderByRe finds ORDER BY clause, which cannot be combined with other conditions.
+var orderByRe = regexp.MustCompile(`(?i)\s*\border\s+by\b.*$`)
+
+// IssueEstimates reads every page of estimated issues found by jql which
+// have time logged, up to limit of them. With mine, worklogs of each issue
+// are read to count time of current user only.
+func (b *jiraBackend) IssueEstimates(jql string, limit int, mine bool) ([]IssueEstimate, bool, error) {
+	query := "originalEstimate > 0 AND timespent > 0"
+	if mine {
+		query += " AND worklogAuthor = currentUser()"
+	}
+	if jql = orderByRe.ReplaceAllString(jql, ""); jql != "" {
+		query = fmt.Sprintf("(%s) AND %s", jql, query)
+	}
+	fields := []string{"summary", "timeoriginalestimate", "timespent", "timeestimate"}
+	var issues []jira.Issue
+	more := false
+	for {
+		page, resp, err := b.client.Issue.Search(query, &jira.SearchOptions{Fields: fields, StartAt: len(issues), MaxResults: 100})
+		if err != nil {
+			return nil, false, fmt.Errorf("search estimated issues: %w", jiraError(resp, err, ""))
+		}
+		issues = append(issues, page...)
+		if len(page) == 0 || len(issues) >= resp.Total {
+			break
+		}
+		if len(issues) >= limit {
+			more = true
+			break
+		}
+	}
+	if len(issues) > limit {
+		issues, more = issues[:limit], true
+	}
+
+	var me jiraSelf
+	if mine {
+		var err error
+		if me, err = b.self(); err != nil {
+			return nil, false, err
+		}
+	}
+	estimates := make([]IssueEstimate, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Fields == nil {
+			continue
+		}
+		e := IssueEstimate{
+			Issue:     issue.Key,
+			Summary:   issue.Fields.Summary,
+			Estimate:  time.Duration(issue.Fields.TimeOriginalEstimate) * time.Second,
+			Logged:    time.Duration(issue.Fields.TimeSpent) * time.Second,
+			Remaining: time.Duration(issue.Fields.TimeEstimate) * time.Second,
+		}
+		if mine {
+			e.Logged = 0
+			for startAt := 0; ; {
+				records, err := b.worklogPage(issue.Key, startAt)
+				if err != nil {
+					return nil, false, err
+				}
+				for _, rec := range records.Worklogs {
+					if isAuthor(rec.Author, me) {
+						e.Logged += time.Duration(rec.TimeSpentSeconds) * time.Second
+					}
+				}
+				startAt += len(records.Worklogs)
+				if len(records.Worklogs) == 0 || startAt >= records.Total {
+					break
+				}
+			}
+		}
+		estimates = append(estimates, e)
+	}
+	return estimates, more, nil
+}
+
+// isAuthor reports whether u is me. Cloud hides name, key and often email,
+// so account ID decides whenever JIRA has one.
+func isAuthor(u *jira.User, me jiraSelf) bool {
+	if u == nil {
+		return false
+	}
+	if me.AccountID != "" && u.AccountID != "" {
+		return u.AccountID == me.AccountID
+	}
+	for _, login := range me.Logins {
+		if login == u.Name || login == u.Key || login == u.EmailAddress {
+			return true
+		}
+	}
+	return false
+}
+
+func toJiraWorklog(wl Worklog) *jira.WorklogRecord {
+	return &jira.WorklogRecord{
+		Comment:          wl.Comment,
+		Started:          toPtr(jira.Time(wl.Started)),
+		TimeSpentSeconds: int(wl.Spent.Seconds()),
+	}
+}
+
+// fromJiraWorklog converts worklog of JIRA, login is its author when JIRA does not tell.
+func fromJiraWorklog(issue string, rec *jira.WorklogRecord, login string) Worklog {
+	wl := Worklog{
+		ID:      rec.ID,
+		Issue:   issue,
+		Spent:   time.Duration(rec.TimeSpentSeconds) * time.Second,
+		Comment: rec.Comment,
+		URL:     rec.Self,
+	}
+	wl.Author = worklogAuthor(rec.Author, login)
+	if rec.Started != nil {
+		wl.Started = time.Time(*rec.Started)
+	}
+	return wl
+}
+
+// worklogAuthor names author the best way JIRA tells. JIRA Cloud hides
+// usernames and some Server configurations send no author at all.
+func worklogAuthor(u *jira.User, login string) string {
+	if u == nil {
+		return login
+	}
+	for _, name := range []string{u.DisplayName, u.EmailAddress, u.Name} {
+		if name != "" {
+			return name
+		}
+	}
+	return login
+}
+
+func fromJiraIssue(issue jira.Issue) Issue {
+	converted := Issue{Key: issue.Key}
+	if issue.Fields != nil {
+		converted.Summary = issue.Fields.Summary
+		converted.Type = issue.Fields.Type.Name
+		if issue.Fields.Status != nil {
+			converted.Status = issue.Fields.Status.Name
+		}
+	}
+	return converted
+}
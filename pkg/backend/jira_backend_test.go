@@ -0,0 +1,279 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_jiraBackend_ListWorklogs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			require.Contains(t, r.URL.Query().Get("jql"), `worklogDate >= "2024-03-04"`)
+			w.Write([]byte(`{"issues": [{"key": "INT-1"}]}`))
+		case "/rest/api/2/issue/INT-1/worklog":
+			w.Write([]byte(`{"worklogs": [
+				{"id": "1", "author": {"name": "me"}, "started": "2024-03-04T09:00:00.000+0000", "timeSpentSeconds": 3600},
+				{"id": "2", "author": {"name": "colleague"}, "started": "2024-03-04T10:00:00.000+0000", "timeSpentSeconds": 3600},
+				{"id": "3", "author": {"name": "me"}, "started": "2024-03-05T09:00:00.000+0000", "timeSpentSeconds": 3600}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	b, err := newJiraBackend(config.Config{JiraURL: srv.URL, JiraLogin: "me", JiraPassword: "secret"})
+	require.NoError(t, err)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	worklogs, err := b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, worklogs, 1)
+	require.Equal(t, "1", worklogs[0].ID)
+	require.Equal(t, "INT-1", worklogs[0].Issue)
+	require.Equal(t, time.Hour, worklogs[0].Spent)
+}
+
+func Test_jiraBackend_ListWorklogs_cloud(t *testing.T) {
+	var self string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/myself":
+			w.Write([]byte(self))
+		case "/rest/api/2/search":
+			w.Write([]byte(`{"issues": [{"key": "INT-1"}]}`))
+		case "/rest/api/2/issue/INT-1/worklog":
+			// Cloud has neither name nor key, and hides email
+			w.Write([]byte(`{"worklogs": [
+				{"id": "1", "author": {"accountId": "5b10a284", "name": ""}, "started": "2024-03-04T09:00:00.000+0000", "timeSpentSeconds": 3600},
+				{"id": "2", "author": {"accountId": "5b10ac8d", "name": ""}, "started": "2024-03-04T10:00:00.000+0000", "timeSpentSeconds": 3600}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	ids := func(conf config.Config) []string {
+		b, err := newJiraBackend(conf)
+		require.NoError(t, err)
+		worklogs, err := b.ListWorklogs(day, day.AddDate(0, 0, 1))
+		require.NoError(t, err)
+		var ids []string
+		for _, wl := range worklogs {
+			ids = append(ids, wl.ID)
+		}
+		return ids
+	}
+
+	self = `{"accountId": "5b10a284", "name": ""}`
+	require.Equal(t, []string{"1"}, ids(config.Config{JiraURL: srv.URL, AuthType: config.AuthPAT, JiraPassword: "token"}), "token without login")
+	require.Equal(t, []string{"1"}, ids(config.Config{JiraURL: srv.URL, AuthType: config.AuthCloudToken, JiraLogin: "me@example.com", JiraPassword: "token"}), "email login Cloud hides")
+
+	self = `{"name": ""}`
+	require.Empty(t, ids(config.Config{JiraURL: srv.URL, AuthType: config.AuthPAT, JiraPassword: "token"}), "nobody is matched by empty login")
+}
+
+func Test_jiraBackend_ListWorklogs_pages(t *testing.T) {
+	isolateUserDirs(t)
+	// JIRA pages by its own page size, whatever maxResults asks
+	const pageSize = 2
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			issues := []string{`{"key": "INT-1"}`, `{"key": "INT-2"}`, `{"key": "INT-3"}`}
+			end := startAt + pageSize
+			if end > len(issues) {
+				end = len(issues)
+			}
+			fmt.Fprintf(w, `{"startAt": %d, "maxResults": %d, "total": %d, "issues": [%s]}`, startAt, pageSize, len(issues), strings.Join(issues[startAt:end], ","))
+		case "/rest/api/2/issue/INT-1/worklog":
+			var records []string
+			for i := startAt; i < startAt+pageSize && i < 5; i++ {
+				records = append(records, fmt.Sprintf(`{"id": "%d", "author": {"name": "me"}, "started": "2024-03-04T0%d:00:00.000+0000", "timeSpentSeconds": 1800}`, i, i))
+			}
+			fmt.Fprintf(w, `{"startAt": %d, "maxResults": %d, "total": 5, "worklogs": [%s]}`, startAt, pageSize, strings.Join(records, ","))
+		default:
+			w.Write([]byte(`{"startAt": 0, "maxResults": 2, "total": 1, "worklogs": [
+				{"id": "9", "author": {"name": "me"}, "started": "2024-03-04T12:00:00.000+0000", "timeSpentSeconds": 600}
+			]}`))
+		}
+	}))
+	defer srv.Close()
+
+	conf := config.Config{JiraURL: srv.URL, JiraLogin: "me", JiraPassword: "secret"}
+	b, err := newJiraBackend(conf)
+	require.NoError(t, err)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	worklogs, err := b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, worklogs, 7, "three pages of INT-1 and the other two issues")
+	var ids []string
+	for _, wl := range worklogs {
+		ids = append(ids, wl.ID)
+	}
+	require.Equal(t, []string{"0", "1", "2", "3", "4", "9", "9"}, ids)
+
+	conf.SetWorklogLimit(3)
+	b, err = newJiraBackend(conf)
+	require.NoError(t, err)
+	worklogs, err = b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.ErrorAs(t, err, &TruncatedError{})
+	require.Len(t, worklogs, 4, "whole page is kept")
+}
+
+func Test_jiraBackend_IssueEstimates(t *testing.T) {
+	isolateUserDirs(t)
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			queries = append(queries, r.URL.Query().Get("jql"))
+			issues := []string{
+				`{"key": "APP-1", "fields": {"summary": "Login", "timeoriginalestimate": 3600, "timespent": 7200, "timeestimate": 0}}`,
+				`{"key": "APP-2", "fields": {"summary": "Logout", "timeoriginalestimate": 7200, "timespent": 3600, "timeestimate": 3600}}`,
+				`{"key": "APP-3", "fields": {"summary": "Signup", "timeoriginalestimate": 3600, "timespent": 5400, "timeestimate": 1800}}`,
+			}
+			end := startAt + 2
+			if end > len(issues) {
+				end = len(issues)
+			}
+			fmt.Fprintf(w, `{"startAt": %d, "maxResults": 2, "total": %d, "issues": [%s]}`, startAt, len(issues), strings.Join(issues[startAt:end], ","))
+		default:
+			w.Write([]byte(`{"startAt": 0, "maxResults": 2, "total": 2, "worklogs": [
+				{"id": "1", "author": {"name": "me"}, "timeSpentSeconds": 600},
+				{"id": "2", "author": {"name": "colleague"}, "timeSpentSeconds": 3000}
+			]}`))
+		}
+	}))
+	defer srv.Close()
+
+	b, err := newJiraBackend(config.Config{JiraURL: srv.URL, JiraLogin: "me", JiraPassword: "secret"})
+	require.NoError(t, err)
+	estimates, more, err := b.IssueEstimates(`project = APP ORDER BY created`, 10, false)
+	require.NoError(t, err)
+	require.False(t, more)
+	require.Equal(t, []string{
+		"(project = APP) AND originalEstimate > 0 AND timespent > 0",
+		"(project = APP) AND originalEstimate > 0 AND timespent > 0",
+	}, queries, "every page is read, ORDER BY is dropped")
+	require.Len(t, estimates, 3)
+	require.Equal(t, IssueEstimate{Issue: "APP-3", Summary: "Signup", Estimate: time.Hour, Logged: 90 * time.Minute, Remaining: 30 * time.Minute}, estimates[2])
+
+	estimates, more, err = b.IssueEstimates("", 2, true)
+	require.NoError(t, err)
+	require.True(t, more)
+	require.Len(t, estimates, 2)
+	require.Equal(t, 10*time.Minute, estimates[0].Logged, "only my worklogs")
+	require.Equal(t, "originalEstimate > 0 AND timespent > 0 AND worklogAuthor = currentUser()", queries[len(queries)-1])
+}
+
+func Test_jiraBackend_Sprint(t *testing.T) {
+	isolateUserDirs(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/agile/1.0/board":
+			require.Equal(t, "APP", r.URL.Query().Get("projectKeyOrId"))
+			w.Write([]byte(`{"values": [{"id": 7, "name": "APP board", "type": "scrum"}]}`))
+		case "/rest/agile/1.0/board/7/sprint":
+			require.Equal(t, "active", r.URL.Query().Get("state"))
+			w.Write([]byte(`{"values": [{"id": 30, "name": "Sprint 30", "state": "active", "startDate": "2024-03-04T10:00:00.000Z"}]}`))
+		case "/rest/agile/1.0/sprint/29":
+			w.Write([]byte(`{"id": 29, "name": "Sprint 29", "state": "closed", "startDate": "2024-02-19T10:00:00.000Z", "completeDate": "2024-03-04T09:00:00.000Z"}`))
+		case "/rest/agile/1.0/sprint/29/issue":
+			startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+			issues := []string{
+				`{"key": "APP-1", "fields": {"summary": "Login"}}`,
+				`{"key": "APP-2", "fields": {"summary": "Logout"}}`,
+			}
+			fmt.Fprintf(w, `{"total": 2, "issues": [%s]}`, issues[startAt])
+		case "/rest/api/2/issue/APP-1/worklog":
+			w.Write([]byte(`{"startAt": 0, "maxResults": 3, "total": 3, "worklogs": [
+				{"id": "1", "author": {"name": "me"}, "started": "2024-02-19T09:00:00.000+0000", "timeSpentSeconds": 600},
+				{"id": "2", "author": {"name": "me"}, "started": "2024-02-20T09:00:00.000+0000", "timeSpentSeconds": 3600},
+				{"id": "3", "author": {"name": "colleague"}, "started": "2024-02-21T09:00:00.000+0000", "timeSpentSeconds": 1800}
+			]}`))
+		default:
+			w.Write([]byte(`{"startAt": 0, "maxResults": 0, "total": 0, "worklogs": []}`))
+		}
+	}))
+	defer srv.Close()
+
+	b, err := newJiraBackend(config.Config{JiraURL: srv.URL, JiraLogin: "me", JiraPassword: "secret", DefaultProject: "APP"})
+	require.NoError(t, err)
+	active, err := b.Sprint(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, "Sprint 30", active.Name)
+	require.Equal(t, time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC), active.Start.UTC())
+
+	closed, err := b.Sprint(0, 29)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), closed.End.UTC())
+
+	issues, worklogs, err := b.SprintWorklogs(closed, false)
+	require.NoError(t, err)
+	require.Len(t, issues, 2, "every page is read")
+	require.Equal(t, "Logout", issues[1].Summary)
+	require.Len(t, worklogs, 1, "only mine, started within the sprint")
+	require.Equal(t, time.Hour, worklogs[0].Spent)
+
+	_, worklogs, err = b.SprintWorklogs(closed, true)
+	require.NoError(t, err)
+	require.Len(t, worklogs, 2)
+	require.Equal(t, "colleague", worklogs[1].Author)
+}
+
+func Test_jiraBackend_TeamWorklogs(t *testing.T) {
+	isolateUserDirs(t)
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			queries = append(queries, r.URL.Query().Get("jql"))
+			if r.URL.Query().Get("startAt") == "0" {
+				w.Write([]byte(`{"total": 3, "issues": [{"key": "APP-1"}, {"key": "APP-2"}], "warningMessages": ["An issue with key 'SEC-1' does not exist for field 'key'."]}`))
+				return
+			}
+			w.Write([]byte(`{"total": 3, "issues": [{"key": "APP-3"}]}`))
+		case "/rest/api/2/issue/APP-1/worklog":
+			w.Write([]byte(`{"startAt": 0, "maxResults": 4, "total": 4, "worklogs": [
+				{"id": "1", "author": {"name": "alice"}, "started": "2024-03-04T09:00:00.000+0000", "timeSpentSeconds": 3600},
+				{"id": "2", "author": {"accountId": "5b10a", "displayName": "Bob"}, "started": "2024-03-05T09:00:00.000+0000", "timeSpentSeconds": 7200},
+				{"id": "3", "author": {"name": "mallory"}, "started": "2024-03-05T09:00:00.000+0000", "timeSpentSeconds": 7200},
+				{"id": "4", "author": {"name": "alice"}, "started": "2024-03-11T09:00:00.000+0000", "timeSpentSeconds": 7200}
+			]}`))
+		case "/rest/api/2/issue/APP-2/worklog":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errorMessages": ["Issue does not exist or you do not have permission to see it."]}`))
+		default:
+			w.Write([]byte(`{"startAt": 0, "maxResults": 1, "total": 1, "worklogs": [
+				{"id": "5", "author": {"name": "alice"}, "started": "2024-03-06T09:00:00.000+0000", "timeSpentSeconds": 1800}
+			]}`))
+		}
+	}))
+	defer srv.Close()
+
+	b, err := newJiraBackend(config.Config{JiraURL: srv.URL, JiraLogin: "me", JiraPassword: "secret"})
+	require.NoError(t, err)
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	worklogs, notes, err := b.TeamWorklogs("project = APP ORDER BY key", []string{"alice", "5b10a", "carol"}, from, from.AddDate(0, 0, 5))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`(project = APP) AND worklogDate >= "2024-03-04" AND worklogDate < "2024-03-09"`,
+		`(project = APP) AND worklogDate >= "2024-03-04" AND worklogDate < "2024-03-09"`,
+	}, queries, "every page is read")
+	require.Equal(t, []string{"An issue with key 'SEC-1' does not exist for field 'key'.", "Worklogs of APP-2 are not visible to you"}, notes)
+	require.Len(t, worklogs, 3, "only given users within range")
+	require.Equal(t, "5b10a", worklogs[1].Author, "author is the user as given")
+	require.Equal(t, "APP-3", worklogs[2].Issue)
+}
@@ -0,0 +1,162 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// EpicFinder is implemented by backends that know epics of issues.
+type EpicFinder interface {
+	// IssueEpics returns key of epic of every given issue, empty for
+	// issues without one. Issues not found are left out.
+	IssueEpics(keys []string) (map[string]string, error)
+}
+
+// epicBatch is how many issues are looked up by a single search.
+const epicBatch = 100
+
+// IssueEpics searches issues in batches and takes their Epic Link or, in
+// team-managed projects, parent of Epic type. Sub-tasks get epic of their
+// parent, which is looked up too if it is not among keys.
+func (b *jiraBackend) IssueEpics(keys []string) (map[string]string, error) {
+	field, err := b.epicLinkField()
+	if err != nil {
+		return nil, err
+	}
+	requested := map[string]bool{}
+	for _, key := range keys {
+		requested[key] = true
+	}
+	epics := map[string]string{}
+	// issue whose epic is epic of its parent
+	parents := map[string]string{}
+	pending := keys
+	for round := 0; round < 2 && len(pending) > 0; round++ {
+		var next []string
+		for start := 0; start < len(pending); start += epicBatch {
+			end := start + epicBatch
+			if end > len(pending) {
+				end = len(pending)
+			}
+			found, err := b.epicsOf(pending[start:end], field)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range found {
+				switch {
+				case f.epic != "":
+					epics[f.key] = f.epic
+				case f.parent != "":
+					parents[f.key] = f.parent
+					if !requested[f.parent] && round == 0 {
+						next = append(next, f.parent)
+					}
+				default:
+					epics[f.key] = ""
+				}
+			}
+		}
+		pending = next
+	}
+	for key, parent := range parents {
+		epics[key] = epics[parent]
+	}
+	for key := range epics {
+		if !requested[key] {
+			delete(epics, key)
+		}
+	}
+	return epics, nil
+}
+
+// issueParents is what epicsOf tells about one issue.
+type issueParents struct {
+	key, epic string
+	// parent of sub-task, whose epic is also epic of the issue
+	parent string
+}
+
+// epicsOf searches given issues, issue keys that do not exist are only
+// warned about by JIRA.
+func (b *jiraBackend) epicsOf(keys []string, field string) ([]issueParents, error) {
+	fields := "parent,issuetype"
+	if field != "" {
+		fields += "," + field
+	}
+	query := url.Values{
+		"jql":           {fmt.Sprintf("key in (%s)", strings.Join(keys, ","))},
+		"fields":        {fields},
+		"maxResults":    {fmt.Sprint(len(keys))},
+		"validateQuery": {"warn"},
+	}
+	var page struct {
+		Issues []struct {
+			Key    string                     `json:"key"`
+			Fields map[string]json.RawMessage `json:"fields"`
+		} `json:"issues"`
+	}
+	resp, err := b.get("rest/api/2/search?"+query.Encode(), &page)
+	if err != nil {
+		return nil, fmt.Errorf("find epics: %w", jiraError(resp, err, ""))
+	}
+
+	var found []issueParents
+	for _, issue := range page.Issues {
+		p := issueParents{key: issue.Key}
+		if raw := issue.Fields[field]; field != "" && len(raw) > 0 {
+			// key of epic, null without one
+			_ = json.Unmarshal(raw, &p.epic)
+		}
+		var parent struct {
+			Key    string `json:"key"`
+			Fields struct {
+				IssueType struct {
+					Name           string `json:"name"`
+					HierarchyLevel int    `json:"hierarchyLevel"`
+				} `json:"issuetype"`
+			} `json:"fields"`
+		}
+		if raw := issue.Fields["parent"]; p.epic == "" && len(raw) > 0 && json.Unmarshal(raw, &parent) == nil && parent.Key != "" {
+			if parent.Fields.IssueType.Name == "Epic" || parent.Fields.IssueType.HierarchyLevel == 1 {
+				p.epic = parent.Key
+			} else {
+				p.parent = parent.Key
+			}
+		}
+		found = append(found, p)
+	}
+	return found, nil
+}
+
+// epicLinkField returns id of Epic Link field, EpicLinkField if set. It is
+// custom field of a different id on every JIRA, team-managed projects and
+// JIRA Cloud of late have none and link epics as parents.
+func (b *jiraBackend) epicLinkField() (string, error) {
+	if b.conf.EpicLinkField != "" {
+		return b.conf.EpicLinkField, nil
+	}
+	var fields []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Schema struct {
+			Custom string `json:"custom"`
+		} `json:"schema"`
+	}
+	resp, err := b.get("rest/api/2/field", &fields)
+	if err != nil {
+		return "", fmt.Errorf("find Epic Link field: %w", jiraError(resp, err, ""))
+	}
+	for _, f := range fields {
+		if f.Schema.Custom == "com.pyxis.greenhopper.jira:gh-epic-link" || f.Name == "Epic Link" {
+			return f.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// IssueEpics asks JIRA, Tempo keeps no issues.
+func (b *tempoBackend) IssueEpics(keys []string) (map[string]string, error) {
+	return b.jira.IssueEpics(keys)
+}
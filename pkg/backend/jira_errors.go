@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+var (
+	// JIRA says so for worklogs in a project whose field configuration hides time tracking, too
+	timetrackingDisabledRe = regexp.MustCompile(`(?i)time ?tracking (is )?(disabled|not enabled|turned off)|field 'timetracking' cannot be set`)
+	// e.g. "The time logged must be at least 1 minute."
+	worklogMinimumRe = regexp.MustCompile(`(?i)(?:at least|minimum(?: of| is)?|less than)\s+(\d+(?:[.,]\d+)?\s*[a-z]+)`)
+)
+
+// jiraError turns error of go-jira, e.g. "Request failed. Please analyze the
+// request body for more details. Status code: 400", into one telling what is
+// wrong and what to do about it. Issue is the one request was about, if any.
+// Errors without response, e.g. network ones, are returned as they are.
+func jiraError(resp *jira.Response, err error, issue string) error {
+	if err == nil || resp == nil || resp.Response == nil {
+		return err
+	}
+	message := jiraMessage(err)
+	switch status := resp.StatusCode; {
+	case status == http.StatusUnauthorized:
+		return AuthError{Err: errors.New("JIRA rejected credentials (401 Unauthorized), check login and password or token. " +
+			`JIRA Cloud needs API token with AuthType = "cloud-token", personal access token of Server or Data Center needs AuthType = "pat", ` +
+			"`tlog auth test` tells more")}
+	case status == http.StatusForbidden && strings.Contains(resp.Header.Get("X-Authentication-Denied-Reason"), "CAPTCHA"):
+		return AuthError{Err: errors.New("JIRA requires CAPTCHA after failed logins, log in through browser once to clear it")}
+	case status == http.StatusNotFound && issue != "":
+		return IssueNotFoundError{Issue: issue, Err: err}
+	case timetrackingDisabledRe.MatchString(message):
+		return fmt.Errorf("time tracking is off in JIRA: admin has to enable it in Administration > Issues > Time tracking, "+
+			"and Time tracking field must be in field configuration and screens of the project (%s)", message)
+	}
+	if m := worklogMinimumRe.FindStringSubmatch(message); m != nil && issue != "" {
+		return fmt.Errorf("JIRA accepts worklogs of at least %s (%s)", m[1], message)
+	}
+	if status := resp.StatusCode; status == http.StatusBadRequest && startedRe.MatchString(message) {
+		return RedateError{Err: fmt.Errorf("JIRA: %s, %s", resp.Status, message)}
+	}
+	if message == "" {
+		// not JSON, e.g. error page of proxy, --verbose shows it
+		return fmt.Errorf("JIRA: %s", resp.Status)
+	}
+	return fmt.Errorf("JIRA: %s, %s", resp.Status, message)
+}
+
+// jiraMessage returns messages of JIRA error response, empty if it had none.
+func jiraMessage(err error) string {
+	var jerr *jira.Error
+	if !errors.As(err, &jerr) {
+		return ""
+	}
+	messages := append([]string{}, jerr.ErrorMessages...)
+	fields := make([]string, 0, len(jerr.Errors))
+	for field := range jerr.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field, jerr.Errors[field]))
+	}
+	return strings.Join(messages, "; ")
+}
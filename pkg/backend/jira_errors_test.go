@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_jiraError(t *testing.T) {
+	var status int
+	var header, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header != "" {
+			w.Header().Set("X-Authentication-Denied-Reason", header)
+		}
+		w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	conf := config.Config{JiraURL: srv.URL, JiraLogin: "me", JiraPassword: "secret"}
+	conf.SetOrigin("Retries", "config.toml")
+	b, err := newJiraBackend(conf)
+	require.NoError(t, err)
+	add := func(code int, denied, response string) error {
+		status, header, body = code, denied, response
+		_, err := b.AddWorklog(Worklog{Issue: "PRJ-1", Started: time.Now(), Spent: 30 * time.Second})
+		return err
+	}
+
+	require.ErrorContains(t, add(http.StatusUnauthorized, "", ``), `JIRA rejected credentials (401 Unauthorized)`)
+	require.EqualError(t, add(http.StatusForbidden, "CAPTCHA_CHALLENGE; login-url=https://jira/login.jsp", `{}`),
+		"JIRA requires CAPTCHA after failed logins, log in through browser once to clear it")
+	require.EqualError(t, add(http.StatusNotFound, "", `{"errorMessages": ["Issue Does Not Exist"], "errors": {}}`),
+		"issue PRJ-1 is not found, or you are not permitted to see it")
+	require.ErrorContains(t, add(http.StatusBadRequest, "", `{"errorMessages": [], "errors": {"timeLogged": "Time tracking is disabled."}}`),
+		"time tracking is off in JIRA: admin has to enable it")
+	require.EqualError(t, add(http.StatusBadRequest, "", `{"errorMessages": [], "errors": {"timeLogged": "The time logged must be at least 1 minute."}}`),
+		"JIRA accepts worklogs of at least 1 minute (timeLogged: The time logged must be at least 1 minute.)")
+	err = add(http.StatusBadRequest, "", `{"errorMessages": ["Comment is too long"], "errors": {"started": "Invalid date"}}`)
+	require.EqualError(t, err, "JIRA: 400 Bad Request, Comment is too long; started: Invalid date")
+	require.ErrorAs(t, err, &RedateError{})
+	require.EqualError(t, b.DeleteWorklog("PRJ-1", "7"), "JIRA: 400 Bad Request, Comment is too long; started: Invalid date", "body is read for DELETE too")
+}
@@ -0,0 +1,159 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/andygrunwald/go-jira"
+)
+
+// Sprint is sprint of a board, worklogs started in [Start, End) count to it.
+type Sprint struct {
+	ID    int
+	Name  string
+	State string
+	Start time.Time
+	// when sprint was completed, now for active one
+	End time.Time
+}
+
+// SprintReporter is implemented by backends that know sprints.
+type SprintReporter interface {
+	// Sprint returns sprint of given id or, without one, active sprint of
+	// the board, board of DefaultProject without board.
+	Sprint(board, id int) (Sprint, error)
+	// SprintWorklogs returns issues of sprint and worklogs of current user
+	// or, with all, of everyone logged to them within the sprint.
+	SprintWorklogs(s Sprint, all bool) ([]Issue, []Worklog, error)
+}
+
+func (b *jiraBackend) Sprint(board, id int) (Sprint, error) {
+	var found jira.Sprint
+	if id > 0 {
+		resp, err := b.get(fmt.Sprintf("rest/agile/1.0/sprint/%d", id), &found)
+		if err != nil {
+			return Sprint{}, fmt.Errorf("get sprint %d: %w", id, jiraError(resp, err, ""))
+		}
+	} else {
+		if board == 0 {
+			var err error
+			if board, err = b.projectBoard(); err != nil {
+				return Sprint{}, err
+			}
+		}
+		active, resp, err := b.client.Board.GetAllSprintsWithOptions(board, &jira.GetAllSprintsOptions{State: "active"})
+		if err != nil {
+			return Sprint{}, fmt.Errorf("get sprints of board %d: %w", board, jiraError(resp, err, ""))
+		}
+		if len(active.Values) == 0 {
+			return Sprint{}, fmt.Errorf("board %d has no active sprint, pass --sprint <id> for a closed one", board)
+		}
+		// parallel sprints are rare, the one started first is taken
+		found = active.Values[0]
+	}
+	if found.StartDate == nil {
+		return Sprint{}, fmt.Errorf("sprint %q is not started yet", found.Name)
+	}
+
+	s := Sprint{ID: found.ID, Name: found.Name, State: found.State, Start: *found.StartDate, End: time.Now()}
+	if found.CompleteDate != nil {
+		s.End = *found.CompleteDate
+	}
+	return s, nil
+}
+
+// projectBoard returns scrum board of DefaultProject, sprints live on boards.
+func (b *jiraBackend) projectBoard() (int, error) {
+	if b.conf.DefaultProject == "" {
+		return 0, config.Error{Field: "SprintBoard", Err: fmt.Errorf("cannot tell board of the sprint, pass board id, set SprintBoard or DefaultProject in config")}
+	}
+	boards, resp, err := b.client.Board.GetAllBoards(&jira.BoardListOptions{BoardType: "scrum", ProjectKeyOrID: b.conf.DefaultProject})
+	if err != nil {
+		return 0, fmt.Errorf("find board of %s: %w", b.conf.DefaultProject, jiraError(resp, err, ""))
+	}
+	if len(boards.Values) == 0 {
+		return 0, fmt.Errorf("project %s has no scrum board, pass board id", b.conf.DefaultProject)
+	}
+	if len(boards.Values) > 1 {
+		debugf("project %s has %d scrum boards, using %q (%d)", b.conf.DefaultProject, len(boards.Values), boards.Values[0].Name, boards.Values[0].ID)
+	}
+	return boards.Values[0].ID, nil
+}
+
+// SprintWorklogs reads every page of issues of sprint and of their
+// worklogs, up to WorklogLimit records. Worklogs read until then are
+// returned with TruncatedError.
+func (b *jiraBackend) SprintWorklogs(s Sprint, all bool) ([]Issue, []Worklog, error) {
+	var issues []Issue
+	for {
+		var page struct {
+			Total  int          `json:"total"`
+			Issues []jira.Issue `json:"issues"`
+		}
+		resp, err := b.get(fmt.Sprintf("rest/agile/1.0/sprint/%d/issue?fields=summary,status,issuetype&startAt=%d&maxResults=100", s.ID, len(issues)), &page)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get issues of sprint %q: %w", s.Name, jiraError(resp, err, ""))
+		}
+		for _, issue := range page.Issues {
+			issues = append(issues, fromJiraIssue(issue))
+		}
+		if len(page.Issues) == 0 || len(issues) >= page.Total {
+			break
+		}
+	}
+
+	var me jiraSelf
+	if !all {
+		var err error
+		if me, err = b.self(); err != nil {
+			return nil, nil, err
+		}
+	}
+	limit := b.conf.WorklogLimit()
+	var worklogs []Worklog
+	read := 0
+	for _, issue := range issues {
+		for startAt := 0; ; {
+			if read >= limit {
+				return issues, worklogs, TruncatedError{Limit: limit}
+			}
+			records, err := b.worklogPage(issue.Key, startAt)
+			if err != nil {
+				return nil, nil, err
+			}
+			for i := range records.Worklogs {
+				rec := &records.Worklogs[i]
+				if rec.Started == nil || !all && !isAuthor(rec.Author, me) {
+					continue
+				}
+				if started := time.Time(*rec.Started); started.Before(s.Start) || !started.Before(s.End) {
+					continue
+				}
+				worklogs = append(worklogs, fromJiraWorklog(issue.Key, rec, b.conf.JiraLogin))
+			}
+			read += len(records.Worklogs)
+			startAt += len(records.Worklogs)
+			if len(records.Worklogs) == 0 || startAt >= records.Total {
+				break
+			}
+		}
+	}
+	return issues, worklogs, nil
+}
+
+// get decodes response of JIRA to GET request into v, for endpoints go-jira
+// has no method for.
+func (b *jiraBackend) get(path string, v interface{}) (*jira.Response, error) {
+	req, err := b.client.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req, v)
+	if err != nil && resp != nil {
+		// go-jira leaves body of the error response unread here
+		err = jira.NewJiraError(resp, err)
+	}
+	return resp, err
+}
@@ -0,0 +1,120 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/andygrunwald/go-jira"
+)
+
+// TeamReporter is implemented by backends that list worklogs of other users.
+type TeamReporter interface {
+	// TeamWorklogs returns worklogs of users started in [from, to) on issues
+	// found by jql, Author being the user as given. Notes tell what the
+	// current user could not see.
+	TeamWorklogs(jql string, users []string, from, to time.Time) ([]Worklog, []string, error)
+}
+
+// TeamWorklogs reads every page of issues of jql with worklogs in range and
+// of their worklogs, up to WorklogLimit records. Worklogs read until then
+// are returned with TruncatedError.
+func (b *jiraBackend) TeamWorklogs(jql string, users []string, from, to time.Time) ([]Worklog, []string, error) {
+	query := fmt.Sprintf(`worklogDate >= "%s" AND worklogDate < "%s"`, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if jql = orderByRe.ReplaceAllString(jql, ""); jql != "" {
+		query = fmt.Sprintf("(%s) AND %s", jql, query)
+	}
+	var keys, notes []string
+	for {
+		params := url.Values{
+			"jql":           {query},
+			"fields":        {"summary"},
+			"startAt":       {fmt.Sprint(len(keys))},
+			"maxResults":    {"100"},
+			"validateQuery": {"warn"},
+		}
+		var page struct {
+			Total  int `json:"total"`
+			Issues []struct {
+				Key string `json:"key"`
+			} `json:"issues"`
+			// e.g. issue of the query that does not exist or is not visible
+			WarningMessages []string `json:"warningMessages"`
+		}
+		resp, err := b.get("rest/api/2/search?"+params.Encode(), &page)
+		if err != nil {
+			return nil, nil, fmt.Errorf("search issues of the team: %w", jiraError(resp, err, ""))
+		}
+		if len(keys) == 0 {
+			notes = append(notes, page.WarningMessages...)
+		}
+		for _, issue := range page.Issues {
+			keys = append(keys, issue.Key)
+		}
+		if len(page.Issues) == 0 || len(keys) >= page.Total {
+			break
+		}
+	}
+
+	limit := b.conf.WorklogLimit()
+	var worklogs []Worklog
+	read := 0
+	for _, key := range keys {
+		for startAt := 0; ; {
+			if read >= limit {
+				return worklogs, notes, TruncatedError{Limit: limit}
+			}
+			records, err := b.worklogPage(key, startAt)
+			var notFound IssueNotFoundError
+			if errors.As(err, &notFound) {
+				// found by search, yet its worklogs are not visible
+				notes = append(notes, fmt.Sprintf("Worklogs of %s are not visible to you", key))
+				break
+			} else if err != nil {
+				return nil, nil, err
+			}
+			for i := range records.Worklogs {
+				rec := &records.Worklogs[i]
+				user := teamUser(rec.Author, users)
+				if rec.Started == nil || user == "" {
+					continue
+				}
+				if started := time.Time(*rec.Started); started.Before(from) || !started.Before(to) {
+					continue
+				}
+				wl := fromJiraWorklog(key, rec, b.conf.JiraLogin)
+				wl.Author = user
+				worklogs = append(worklogs, wl)
+			}
+			read += len(records.Worklogs)
+			startAt += len(records.Worklogs)
+			if len(records.Worklogs) == 0 || startAt >= records.Total {
+				break
+			}
+		}
+	}
+	return worklogs, notes, nil
+}
+
+// teamUser returns the one of users author is, by username, email, key,
+// account id or display name, empty if none.
+func teamUser(author *jira.User, users []string) string {
+	if author == nil {
+		return ""
+	}
+	for _, user := range users {
+		for _, id := range []string{author.Name, author.EmailAddress, author.Key, author.AccountID, author.DisplayName} {
+			if id != "" && strings.EqualFold(id, user) {
+				return user
+			}
+		}
+	}
+	return ""
+}
+
+// TeamWorklogs asks JIRA, Tempo worklogs are JIRA worklogs too.
+func (b *tempoBackend) TeamWorklogs(jql string, users []string, from, to time.Time) ([]Worklog, []string, error) {
+	return b.jira.TeamWorklogs(jql, users, from, to)
+}
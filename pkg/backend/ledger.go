@@ -0,0 +1,160 @@
+package backend
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/config"
+)
+
+const (
+	ledgerFile     = "ledger.jsonl"
+	ledgerLockFile = "ledger.lock"
+)
+
+// LedgerEntry is a local record of a worklog created by tlog.
+// Ledger lets us answer "how much did I log today" without asking JIRA.
+type LedgerEntry struct {
+	Issue     string    `json:"issue"`
+	WorklogID string    `json:"worklog_id,omitempty"`
+	Started   time.Time `json:"started"`
+	Seconds   int       `json:"seconds"`
+	Comment   string    `json:"comment,omitempty"`
+	LoggedAt  time.Time `json:"logged_at"`
+	// where imported worklog came from, e.g. "harvest:123"
+	ImportIDs []string `json:"import_ids,omitempty"`
+}
+
+// AppendLedger records worklog tlog created in the local ledger of the context.
+func AppendLedger(conf config.Config, e LedgerEntry) error {
+	path, err := state.ContextPath(conf.Context(), ledgerFile)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockLedger(conf)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open ledger: %w", err)
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// ReadLedger returns entries of the local ledger of the context, oldest first.
+func ReadLedger(conf config.Config) ([]LedgerEntry, error) {
+	path, err := state.ContextPath(conf.Context(), ledgerFile)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open ledger: %w", err)
+	}
+	defer f.Close()
+
+	var entries []LedgerEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e LedgerEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("corrupted ledger line %q: %w", scanner.Text(), err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// lockLedger holds ledger lock until returned func is called, so that
+// entries appended meanwhile are not lost by a rewrite.
+func lockLedger(conf config.Config) (func(), error) {
+	path, err := state.ContextPath(conf.Context(), ledgerLockFile)
+	if err != nil {
+		return nil, err
+	}
+	return state.Lock(path)
+}
+
+// updateLedger passes ledger entries to fn and stores what it returns, other
+// tlog processes wait meanwhile.
+func updateLedger(conf config.Config, fn func([]LedgerEntry) []LedgerEntry) error {
+	unlock, err := lockLedger(conf)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := ReadLedger(conf)
+	if err != nil {
+		return err
+	}
+	entries = fn(entries)
+
+	var data []byte
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	path, err := state.ContextPath(conf.Context(), ledgerFile)
+	if err != nil {
+		return err
+	}
+	return state.WriteFileAtomic(path, data, 0600)
+}
+
+// UpdateLedgerWorklog changes time, start and comment of the entry of wl, if
+// it was logged by tlog, to what they are in backend now.
+func UpdateLedgerWorklog(conf config.Config, wl Worklog) error {
+	if wl.ID == "" {
+		return nil
+	}
+	return updateLedger(conf, func(entries []LedgerEntry) []LedgerEntry {
+		for i, e := range entries {
+			if e.Issue == wl.Issue && e.WorklogID == wl.ID {
+				entries[i].Started, entries[i].Seconds, entries[i].Comment = wl.Started, int(wl.Spent.Seconds()), wl.Comment
+			}
+		}
+		return entries
+	})
+}
+
+// RemoveLedgerWorklog removes entry of deleted worklog of issue, if any.
+func RemoveLedgerWorklog(conf config.Config, issue, id string) error {
+	if id == "" {
+		return nil
+	}
+	return updateLedger(conf, func(entries []LedgerEntry) []LedgerEntry {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.Issue != issue || e.WorklogID != id {
+				kept = append(kept, e)
+			}
+		}
+		return kept
+	})
+}
@@ -0,0 +1,256 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/What-If-I/tlog/pkg/parse"
+	"github.com/pterm/pterm"
+)
+
+// MockFile keeps worklogs of mock backend in the state dir.
+const MockFile = "mock_backend.json"
+
+// MockBackend keeps worklogs in a local file instead of a tracker, so tlog can be
+// tried without JIRA. With empty path data lives in memory only, which tests use.
+type MockBackend struct {
+	path   string
+	Author string
+	// summaries of MockFixtures, issues of the file win
+	fixtures map[string]string
+
+	mu   sync.Mutex
+	data mockData
+}
+
+// mockData is the content of mock backend file. Issues can be added to it by hand,
+// worklogs can be logged to any issue key.
+type mockData struct {
+	// summaries by issue key
+	Issues   map[string]string `json:"issues,omitempty"`
+	Worklogs []mockWorklog     `json:"worklogs,omitempty"`
+	LastID   int               `json:"last_id"`
+}
+
+type mockWorklog struct {
+	ID      string    `json:"id"`
+	Issue   string    `json:"issue"`
+	Author  string    `json:"author"`
+	Started time.Time `json:"started"`
+	Seconds int       `json:"seconds"`
+	Comment string    `json:"comment,omitempty"`
+}
+
+// mockNotice makes sure nobody takes worklogs of mock backend for real ones.
+var mockNotice sync.Once
+
+// NoticeMock warns once per run that backend is mock one keeping worklogs in path.
+func NoticeMock(path string) {
+	mockNotice.Do(func() {
+		// stderr, so that JSON output stays parseable
+		pterm.Warning.WithWriter(os.Stderr).Printfln("MOCK BACKEND: nothing is logged to JIRA, worklogs are kept in %s", path)
+	})
+}
+
+func newMockBackend(conf config.Config) (*MockBackend, error) {
+	path, err := state.ContextPath(conf.Context(), MockFile)
+	if err != nil {
+		return nil, err
+	}
+	author := conf.JiraLogin
+	if author == "" {
+		author = "mock"
+	}
+	fixtures, err := loadMockFixtures(conf.MockFixtures)
+	if err != nil {
+		return nil, err
+	}
+	NoticeMock(path)
+	return &MockBackend{path: path, Author: author, fixtures: fixtures}, nil
+}
+
+// loadMockFixtures returns issue summaries of fixtures file, none if path is empty.
+func loadMockFixtures(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	path, err := state.ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read MockFixtures: %w", err)
+	}
+	var fixtures mockData
+	if err := json.Unmarshal(raw, &fixtures); err != nil {
+		return nil, fmt.Errorf("MockFixtures %s is broken: %w", path, err)
+	}
+	return fixtures.Issues, nil
+}
+
+// issues returns summaries of issues of d and of fixtures.
+func (b *MockBackend) issues(d *mockData) map[string]string {
+	if len(b.fixtures) == 0 {
+		return d.Issues
+	}
+	issues := make(map[string]string, len(b.fixtures)+len(d.Issues))
+	for key, summary := range b.fixtures {
+		issues[key] = summary
+	}
+	for key, summary := range d.Issues {
+		issues[key] = summary
+	}
+	return issues
+}
+
+// update runs f on the current data and saves it if f succeeds.
+func (b *MockBackend) update(f func(d *mockData) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.path != "" {
+		b.data = mockData{}
+		raw, err := os.ReadFile(b.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("read mock backend: %w", err)
+		}
+		if err == nil {
+			if err := json.Unmarshal(raw, &b.data); err != nil {
+				return fmt.Errorf("%s is broken: %w", b.path, err)
+			}
+		}
+	}
+	if err := f(&b.data); err != nil {
+		return err
+	}
+	if b.path == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(b.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return state.WriteFileAtomic(b.path, raw, 0600)
+}
+
+func (b *MockBackend) AddWorklog(wl Worklog) (Worklog, error) {
+	if !parse.IsIssueKey(wl.Issue) {
+		return Worklog{}, fmt.Errorf("issue %s does not exist", wl.Issue)
+	}
+	err := b.update(func(d *mockData) error {
+		d.LastID++
+		wl.ID = strconv.Itoa(d.LastID)
+		wl.Author = b.Author
+		d.Worklogs = append(d.Worklogs, toMockWorklog(wl))
+		return nil
+	})
+	return wl, err
+}
+
+func (b *MockBackend) UpdateWorklog(wl Worklog) (Worklog, error) {
+	err := b.update(func(d *mockData) error {
+		i, err := d.find(wl.Issue, wl.ID)
+		if err != nil {
+			return err
+		}
+		wl.Author = d.Worklogs[i].Author
+		d.Worklogs[i] = toMockWorklog(wl)
+		return nil
+	})
+	return wl, err
+}
+
+func (b *MockBackend) DeleteWorklog(issue, id string) error {
+	return b.update(func(d *mockData) error {
+		i, err := d.find(issue, id)
+		if err != nil {
+			return err
+		}
+		d.Worklogs = append(d.Worklogs[:i], d.Worklogs[i+1:]...)
+		return nil
+	})
+}
+
+func (b *MockBackend) ListWorklogs(from, to time.Time) ([]Worklog, error) {
+	var worklogs []Worklog
+	err := b.update(func(d *mockData) error {
+		for _, wl := range d.Worklogs {
+			if wl.Author == b.Author && !wl.Started.Before(from) && wl.Started.Before(to) {
+				worklogs = append(worklogs, wl.worklog())
+			}
+		}
+		return nil
+	})
+	sort.SliceStable(worklogs, func(i, j int) bool { return worklogs[i].Started.Before(worklogs[j].Started) })
+	return worklogs, err
+}
+
+func (b *MockBackend) GetIssue(key string) (Issue, error) {
+	var issue Issue
+	err := b.update(func(d *mockData) error {
+		summary, ok := b.issues(d)[key]
+		if !ok {
+			return fmt.Errorf("issue %s does not exist", key)
+		}
+		issue = Issue{Key: key, Summary: summary}
+		return nil
+	})
+	return issue, err
+}
+
+// SearchIssues returns issues with query in key or summary, ignoring case.
+func (b *MockBackend) SearchIssues(query string) ([]Issue, error) {
+	var issues []Issue
+	query = strings.ToLower(query)
+	err := b.update(func(d *mockData) error {
+		all := b.issues(d)
+		for _, key := range sortedKeys(all) {
+			if strings.Contains(strings.ToLower(key+" "+all[key]), query) {
+				issues = append(issues, Issue{Key: key, Summary: all[key]})
+			}
+		}
+		return nil
+	})
+	return issues, err
+}
+
+func (d *mockData) find(issue, id string) (int, error) {
+	for i, wl := range d.Worklogs {
+		if wl.Issue == issue && wl.ID == id {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("worklog %s of %s does not exist", id, issue)
+}
+
+func toMockWorklog(wl Worklog) mockWorklog {
+	return mockWorklog{
+		ID:      wl.ID,
+		Issue:   wl.Issue,
+		Author:  wl.Author,
+		Started: wl.Started,
+		Seconds: int(wl.Spent.Seconds()),
+		Comment: wl.Comment,
+	}
+}
+
+func (wl mockWorklog) worklog() Worklog {
+	return Worklog{
+		ID:      wl.ID,
+		Issue:   wl.Issue,
+		Author:  wl.Author,
+		Started: wl.Started,
+		Spent:   time.Duration(wl.Seconds) * time.Second,
+		Comment: wl.Comment,
+	}
+}
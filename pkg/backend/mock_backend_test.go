@@ -0,0 +1,118 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+// isolateUserDirs points home, config and cache dirs of every OS to a temp
+// dir, so that the test neither sees nor touches real tlog state. It returns
+// the config dir.
+func isolateUserDirs(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	// macOS keeps both dirs in home, Windows reads the other variables
+	for key, dir := range map[string]string{
+		"HOME":            root,
+		"USERPROFILE":     root,
+		"XDG_CONFIG_HOME": filepath.Join(root, "config"),
+		"APPDATA":         filepath.Join(root, "config"),
+		"XDG_CACHE_HOME":  filepath.Join(root, "cache"),
+		"LOCALAPPDATA":    filepath.Join(root, "cache"),
+	} {
+		t.Setenv(key, dir)
+	}
+	dir, err := os.UserConfigDir()
+	require.NoError(t, err)
+	return dir
+}
+
+func Test_mockBackend(t *testing.T) {
+	b := &MockBackend{Author: "me", data: mockData{Issues: map[string]string{"INT-1": "Standup", "INT-2": "Review"}}}
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	first, err := b.AddWorklog(Worklog{Issue: "INT-1", Started: day.Add(9 * time.Hour), Spent: time.Hour, Comment: "daily"})
+	require.NoError(t, err)
+	require.Equal(t, "me", first.Author)
+	second, err := b.AddWorklog(Worklog{Issue: "INT-2", Started: day.Add(24 * time.Hour), Spent: 30 * time.Minute})
+	require.NoError(t, err)
+	require.NotEqual(t, first.ID, second.ID)
+	_, err = b.AddWorklog(Worklog{Issue: "standup", Started: day, Spent: time.Hour})
+	require.Error(t, err)
+
+	worklogs, err := b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, []Worklog{first}, worklogs)
+
+	first.Spent = 2 * time.Hour
+	_, err = b.UpdateWorklog(first)
+	require.NoError(t, err)
+	worklogs, err = b.ListWorklogs(day, day.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.Len(t, worklogs, 2)
+	require.Equal(t, 2*time.Hour, worklogs[0].Spent)
+
+	require.NoError(t, b.DeleteWorklog("INT-1", first.ID))
+	require.Error(t, b.DeleteWorklog("INT-1", first.ID))
+	worklogs, err = b.ListWorklogs(day, day.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.Equal(t, []Worklog{second}, worklogs)
+
+	issue, err := b.GetIssue("INT-2")
+	require.NoError(t, err)
+	require.Equal(t, "Review", issue.Summary)
+	_, err = b.GetIssue("INT-3")
+	require.Error(t, err)
+	issues, err := b.SearchIssues("stand")
+	require.NoError(t, err)
+	require.Equal(t, []Issue{{Key: "INT-1", Summary: "Standup"}}, issues)
+}
+
+func Test_newMockBackend(t *testing.T) {
+	isolateUserDirs(t)
+	conf := config.Config{Backend: config.Mock}
+
+	backend, err := New(conf)
+	require.NoError(t, err)
+	_, err = backend.AddWorklog(Worklog{Issue: "INT-1", Started: time.Now(), Spent: time.Hour})
+	require.NoError(t, err)
+
+	// another process sees the worklog
+	backend, err = New(conf)
+	require.NoError(t, err)
+	worklogs, err := backend.ListWorklogs(time.Now().Add(-time.Hour), time.Now())
+	require.NoError(t, err)
+	require.Len(t, worklogs, 1)
+
+	_, err = New(config.Config{Backend: "trello"})
+	require.ErrorContains(t, err, "unknown Backend")
+}
+
+func Test_mockBackend_fixtures(t *testing.T) {
+	isolateUserDirs(t)
+	fixtures := filepath.Join(t.TempDir(), "fixtures.json")
+	require.NoError(t, os.WriteFile(fixtures, []byte(`{"issues": {"DEMO-1": "Talk slides", "DEMO-2": "Rehearsal"}}`), 0600))
+
+	b, err := newMockBackend(config.Config{Backend: "mock", MockFixtures: fixtures})
+	require.NoError(t, err)
+	issue, err := b.GetIssue("DEMO-1")
+	require.NoError(t, err)
+	require.Equal(t, "Talk slides", issue.Summary)
+	issues, err := b.SearchIssues("rehears")
+	require.NoError(t, err)
+	require.Equal(t, []Issue{{Key: "DEMO-2", Summary: "Rehearsal"}}, issues)
+
+	_, err = b.AddWorklog(Worklog{Issue: "DEMO-1", Started: time.Now(), Spent: time.Hour})
+	require.NoError(t, err)
+	raw, err := os.ReadFile(b.path)
+	require.NoError(t, err)
+	require.NotContains(t, string(raw), "Talk slides", "fixtures are not copied to state")
+
+	_, err = newMockBackend(config.Config{Backend: "mock", MockFixtures: filepath.Join(t.TempDir(), "missing.json")})
+	require.Error(t, err)
+}
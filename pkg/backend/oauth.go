@@ -0,0 +1,264 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/pterm/pterm"
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	oauthTokenFile = "oauth.json"
+	// must be registered as callback URL of the OAuth app in Atlassian developer console
+	OAuthRedirectURL = "http://localhost:8976/callback"
+	OAuthScopes      = "read:jira-work write:jira-work read:jira-user offline_access"
+)
+
+// Atlassian endpoints, variables so tests can point them to a fake server.
+var (
+	OAuthAuthorizeURL = "https://auth.atlassian.com/authorize"
+	oauthTokenURL     = "https://auth.atlassian.com/oauth/token"
+	atlassianAPIURL   = "https://api.atlassian.com"
+)
+
+// OAuthToken is what is kept in keyring between runs.
+type OAuthToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+	// CloudID identifies the site, OAuth requests go through api.atlassian.com/ex/jira/<id>
+	CloudID string `json:"cloud_id"`
+}
+
+// Expired reports whether access token expires within a minute of now.
+func (t OAuthToken) Expired(now time.Time) bool {
+	return now.Add(time.Minute).After(t.Expiry)
+}
+
+// oauthBaseURL is where JIRA REST API of the site is served for OAuth clients.
+func oauthBaseURL(cloudID string) string {
+	return atlassianAPIURL + "/ex/jira/" + cloudID + "/"
+}
+
+func oauthKeyringUser(conf config.Config) string {
+	return "oauth@" + strings.TrimSuffix(conf.JiraURL, "/")
+}
+
+// loadOAuthToken reads token from keyring, falling back to state file.
+func loadOAuthToken(conf config.Config) (*OAuthToken, error) {
+	data, err := keyring.Get(config.KeyringService, oauthKeyringUser(conf))
+	if err != nil {
+		path, pathErr := state.ContextPath(conf.Context(), oauthTokenFile)
+		if pathErr != nil {
+			return nil, pathErr
+		}
+		raw, readErr := os.ReadFile(path)
+		if errors.Is(readErr, os.ErrNotExist) {
+			return nil, errors.New("not logged in, run `tlog auth login`")
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("read OAuth token: %w", readErr)
+		}
+		data = string(raw)
+	}
+
+	var t OAuthToken
+	if err := json.Unmarshal([]byte(data), &t); err != nil {
+		return nil, fmt.Errorf("stored OAuth token is broken, run `tlog auth login`: %w", err)
+	}
+	return &t, nil
+}
+
+// SaveOAuthToken stores token in keyring, or in a file readable only by user if there is no keyring.
+func SaveOAuthToken(conf config.Config, t *OAuthToken) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	err = keyring.Set(config.KeyringService, oauthKeyringUser(conf), string(data))
+	if err == nil {
+		return nil
+	}
+	pterm.Warning.WithWriter(os.Stderr).Printfln("No keyring available (%s), OAuth token is stored in a file", err)
+
+	path, err := state.ContextPath(conf.Context(), oauthTokenFile)
+	if err != nil {
+		return err
+	}
+	return state.WriteFileAtomic(path, data, 0600)
+}
+
+// DeleteOAuthToken removes stored token from both keyring and state file.
+func DeleteOAuthToken(conf config.Config) (bool, error) {
+	deleted := false
+	err := keyring.Delete(config.KeyringService, oauthKeyringUser(conf))
+	if err == nil {
+		deleted = true
+	}
+
+	path, err := state.ContextPath(conf.Context(), oauthTokenFile)
+	if err != nil {
+		return deleted, err
+	}
+	err = os.Remove(path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return deleted, nil
+	}
+	return deleted, err
+}
+
+// oauthTransport adds access token to requests and refreshes it once it expires.
+type oauthTransport struct {
+	conf  config.Config
+	next  http.RoundTripper
+	mu    sync.Mutex
+	token *OAuthToken
+}
+
+func (t *oauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	access, err := t.accessToken()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+access)
+	return t.next.RoundTrip(req)
+}
+
+func (t *oauthTransport) accessToken() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.token.Expired(time.Now()) {
+		return t.token.AccessToken, nil
+	}
+	refreshed, err := RequestToken(t.conf, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {t.token.RefreshToken},
+	})
+	if err != nil {
+		return "", fmt.Errorf("refresh OAuth token, try `tlog auth login`: %w", err)
+	}
+	refreshed.CloudID = t.token.CloudID
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = t.token.RefreshToken
+	}
+	if err := SaveOAuthToken(t.conf, refreshed); err != nil {
+		return "", fmt.Errorf("save refreshed OAuth token: %w", err)
+	}
+	t.token = refreshed
+	return t.token.AccessToken, nil
+}
+
+// RequestToken performs token request of given grant type.
+func RequestToken(conf config.Config, params url.Values) (*OAuthToken, error) {
+	body := map[string]string{
+		"client_id":     conf.OAuthClientID,
+		"client_secret": conf.OAuthClientSecret,
+	}
+	for k := range params {
+		body[k] = params.Get(k)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	timeout, _, _, err := conf.RetryPolicy()
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport, Timeout: timeout}
+	req, err := http.NewRequestWithContext(InterruptCtx, http.MethodPost, oauthTokenURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode token response (%s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return nil, fmt.Errorf("token request failed (%s): %s %s", resp.Status, result.Error, result.ErrorDescription)
+	}
+	return &OAuthToken{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(result.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// ResolveCloudID finds ID of the site with given URL among sites token grants access to.
+func ResolveCloudID(conf config.Config, accessToken string) (string, error) {
+	transport, err := BaseTransport(conf)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(InterruptCtx, http.MethodGet, atlassianAPIURL+"/oauth/token/accessible-resources", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	timeout, _, _, err := conf.RetryPolicy()
+	if err != nil {
+		return "", err
+	}
+	resp, err := (&http.Client{Transport: transport, Timeout: timeout}).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("list accessible sites: %s", resp.Status)
+	}
+
+	var sites []struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&sites); err != nil {
+		return "", fmt.Errorf("decode accessible sites: %w", err)
+	}
+
+	want := strings.TrimSuffix(strings.ToLower(conf.JiraURL), "/")
+	var available []string
+	for _, s := range sites {
+		if strings.TrimSuffix(strings.ToLower(s.URL), "/") == want {
+			return s.ID, nil
+		}
+		available = append(available, s.URL)
+	}
+	return "", fmt.Errorf("access to %s was not granted, available sites: %s", conf.JiraURL, strings.Join(available, ", "))
+}
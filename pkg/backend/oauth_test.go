@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func Test_oauthTransport(t *testing.T) {
+	keyring.MockInit()
+	isolateUserDirs(t)
+
+	var refreshes int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Equal(t, "refresh_token", body["grant_type"])
+			require.Equal(t, "old-refresh", body["refresh_token"])
+			refreshes++
+			fmt.Fprint(w, `{"access_token": "new-access", "refresh_token": "new-refresh", "expires_in": 3600}`)
+		default:
+			require.Equal(t, "Bearer new-access", r.Header.Get("Authorization"))
+		}
+	}))
+	defer srv.Close()
+	oldTokenURL := oauthTokenURL
+	oauthTokenURL = srv.URL + "/oauth/token"
+	t.Cleanup(func() { oauthTokenURL = oldTokenURL })
+
+	conf := config.Config{JiraURL: "https://company.atlassian.net", AuthType: config.AuthOAuth}
+	expired := &OAuthToken{AccessToken: "old-access", RefreshToken: "old-refresh", Expiry: time.Now().Add(-time.Hour), CloudID: "cloud"}
+	client := &http.Client{Transport: &oauthTransport{conf: conf, next: http.DefaultTransport, token: expired}}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL + "/rest/api/2/myself")
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	require.Equal(t, 1, refreshes, "token is refreshed only once")
+
+	stored, err := loadOAuthToken(conf)
+	require.NoError(t, err)
+	require.Equal(t, "new-refresh", stored.RefreshToken)
+	require.Equal(t, "cloud", stored.CloudID)
+
+	deleted, err := DeleteOAuthToken(conf)
+	require.NoError(t, err)
+	require.True(t, deleted)
+	_, err = loadOAuthToken(conf)
+	require.ErrorContains(t, err, "tlog auth login")
+}
+
+func Test_resolveCloudID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/oauth/token/accessible-resources", r.URL.Path)
+		fmt.Fprint(w, `[{"id": "a1", "url": "https://other.atlassian.net"}, {"id": "b2", "url": "https://company.atlassian.net"}]`)
+	}))
+	defer srv.Close()
+	oldAPIURL := atlassianAPIURL
+	atlassianAPIURL = srv.URL
+	t.Cleanup(func() { atlassianAPIURL = oldAPIURL })
+
+	id, err := ResolveCloudID(config.Config{JiraURL: "https://Company.atlassian.net/"}, "token")
+	require.NoError(t, err)
+	require.Equal(t, "b2", id)
+
+	_, err = ResolveCloudID(config.Config{JiraURL: "https://missing.atlassian.net"}, "token")
+	require.ErrorContains(t, err, "available sites: https://other.atlassian.net, https://company.atlassian.net")
+}
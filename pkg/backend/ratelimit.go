@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// OnPacing is told about every request held back by rate limit, e.g. to show it in spinner.
+// Calls never overlap.
+var OnPacing func(pause time.Duration)
+
+// rateLimiter is token bucket shared by all requests of the process, so that
+// rate is limited however many workers send them.
+type rateLimiter struct {
+	mu sync.Mutex
+	// tokens per second, bucket holds as many
+	rate   float64
+	tokens float64
+	last   time.Time
+	// rate is not changed by config, for tests
+	pinned bool
+}
+
+// requestLimiter limits requests of every retryTransport.
+var requestLimiter = &rateLimiter{}
+
+// setRate changes rate, the last config loaded wins.
+func (l *rateLimiter) setRate(rate float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.pinned && l.rate != rate {
+		l.rate = rate
+		l.tokens = l.burst()
+		l.last = time.Now()
+	}
+}
+
+func (l *rateLimiter) burst() float64 {
+	return math.Max(1, math.Floor(l.rate))
+}
+
+// reserve takes token and returns how long to wait before it may be used.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 {
+		return 0
+	}
+	l.tokens = math.Min(l.burst(), l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	pause := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	if OnPacing != nil {
+		OnPacing(pause)
+	}
+	return pause
+}
+
+// wait blocks until request may be sent.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	pause := l.reserve(time.Now())
+	if pause <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(pause)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-InterruptCtx.Done():
+		return ErrInterrupted
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// tests send requests to local servers as fast as they can
+	requestLimiter = &rateLimiter{pinned: true}
+	os.Exit(m.Run())
+}
+
+func Test_rateLimiter_reserve(t *testing.T) {
+	l := &rateLimiter{}
+	l.setRate(2)
+	now := l.last
+	require.Zero(t, l.reserve(now))
+	require.Zero(t, l.reserve(now), "bucket holds a second of requests")
+	require.Equal(t, 500*time.Millisecond, l.reserve(now))
+	require.Equal(t, time.Second, l.reserve(now), "waiting requests queue up")
+	require.Equal(t, 500*time.Millisecond, l.reserve(now.Add(time.Second)))
+
+	// idle time does not accumulate more than the bucket holds
+	later := now.Add(time.Hour)
+	require.Zero(t, l.reserve(later))
+	require.Zero(t, l.reserve(later))
+	require.Equal(t, 500*time.Millisecond, l.reserve(later))
+
+	require.Zero(t, (&rateLimiter{}).reserve(now), "no rate, no limit")
+}
+
+func Test_rateLimiter_wait(t *testing.T) {
+	l := &rateLimiter{}
+	l.setRate(20)
+	var paced []time.Duration
+	OnPacing = func(pause time.Duration) { paced = append(paced, pause) }
+	t.Cleanup(func() { OnPacing = nil })
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, l.wait(context.Background()))
+		}()
+	}
+	wg.Wait()
+	// 20 right away, 10 more at 20 per second
+	require.GreaterOrEqual(t, time.Since(start), 450*time.Millisecond)
+	require.Len(t, paced, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, l.wait(ctx), context.Canceled)
+}
@@ -0,0 +1,323 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/pterm/pterm"
+)
+
+// activityAttribute is the Worklog attribute holding Redmine activity id.
+const activityAttribute = "activity_id"
+
+// redmineBackend logs time as Redmine time entries. Issues are numbers and
+// entries have a date, but no time of day.
+type redmineBackend struct {
+	conf   config.Config
+	client *http.Client
+	base   string
+}
+
+type redmineTimeEntry struct {
+	ID    int `json:"id"`
+	Issue *struct {
+		ID int `json:"id"`
+	} `json:"issue"`
+	User *struct {
+		Name string `json:"name"`
+	} `json:"user"`
+	Activity *struct {
+		ID int `json:"id"`
+	} `json:"activity"`
+	Hours    float64 `json:"hours"`
+	Comments string  `json:"comments"`
+	SpentOn  string  `json:"spent_on"`
+}
+
+type redmineActivity struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"is_default"`
+	Active    *bool  `json:"active"`
+}
+
+func newRedmineBackend(conf config.Config) (*redmineBackend, error) {
+	if conf.RedmineURL == "" {
+		return nil, errors.New("RedmineURL is not set in config")
+	}
+	if conf.RedmineAPIKey == "" {
+		return nil, errors.New("RedmineAPIKey is not set in config, find it in Redmine > My account > API access key")
+	}
+	transport, err := BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := NewRetryTransport(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+	return &redmineBackend{conf: conf, client: &http.Client{Transport: retry}, base: strings.TrimSuffix(conf.RedmineURL, "/")}, nil
+}
+
+// do sends request to Redmine REST API and decodes response into out, if given.
+func (b *redmineBackend) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, b.base+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Redmine-API-Key", b.conf.RedmineAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach Redmine: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+		return StatusError(resp.StatusCode, fmt.Errorf("Redmine: %s", strings.Join(append([]string{resp.Status}, failure.Errors...), ", ")))
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// activity returns configured activity of issue: ActivityID of aliases
+// pointing at it, then RedmineActivityID. Zero means none is configured.
+func (b *redmineBackend) activity(issue string) int {
+	for _, name := range sortedKeys(b.conf.TaskAliasDetails) {
+		if d := b.conf.TaskAliasDetails[name]; d.Issue == issue && d.ActivityID != 0 {
+			return d.ActivityID
+		}
+	}
+	return b.conf.RedmineActivityID
+}
+
+func (b *redmineBackend) activities() ([]redmineActivity, error) {
+	var resp struct {
+		Activities []redmineActivity `json:"time_entry_activities"`
+	}
+	if err := b.do(http.MethodGet, "/enumerations/time_entry_activities.json", nil, &resp); err != nil {
+		return nil, fmt.Errorf("get activities: %w", err)
+	}
+	active := resp.Activities[:0]
+	for _, a := range resp.Activities {
+		if a.Active == nil || *a.Active {
+			active = append(active, a)
+		}
+	}
+	return active, nil
+}
+
+// PrepareWorklog picks activity of time entry. Redmine requires one, so it
+// is asked for when neither config nor Redmine provides a default.
+func (b *redmineBackend) PrepareWorklog(wl *Worklog) error {
+	if wl.Attributes[activityAttribute] != "" {
+		return nil
+	}
+	if id := b.activity(wl.Issue); id != 0 {
+		wl.Attributes = map[string]string{activityAttribute: strconv.Itoa(id)}
+		return nil
+	}
+
+	activities, err := b.activities()
+	if err != nil {
+		return err
+	}
+	for _, a := range activities {
+		if a.IsDefault {
+			// Redmine applies it itself
+			return nil
+		}
+	}
+	if len(activities) == 0 {
+		return errors.New("Redmine has no active time entry activities")
+	}
+	if PromptChoose == nil {
+		return errors.New("Redmine requires activity, set RedmineActivityID or ActivityID of alias")
+	}
+
+	names := make([]string, len(activities))
+	for i, a := range activities {
+		names[i] = fmt.Sprintf("%s (%d)", a.Name, a.ID)
+	}
+	idx, err := PromptChoose("Which activity is it?", names)
+	if err != nil {
+		return err
+	}
+	pterm.Info.Printfln("Set RedmineActivityID = %d or ActivityID of alias to skip this question", activities[idx].ID)
+	wl.Attributes = map[string]string{activityAttribute: strconv.Itoa(activities[idx].ID)}
+	return nil
+}
+
+func (b *redmineBackend) toTimeEntry(wl Worklog) (map[string]interface{}, error) {
+	issueID, err := strconv.Atoi(wl.Issue)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a Redmine issue, Redmine issues are numbers", wl.Issue)
+	}
+	entry := map[string]interface{}{
+		"issue_id": issueID,
+		"spent_on": wl.Started.In(b.conf.Location()).Format("2006-01-02"),
+		// Redmine keeps hours with two decimal places
+		"hours":    math.Round(wl.Spent.Hours()*100) / 100,
+		"comments": wl.Comment,
+	}
+	activity := b.activity(wl.Issue)
+	if id, err := strconv.Atoi(wl.Attributes[activityAttribute]); err == nil {
+		activity = id
+	}
+	if activity != 0 {
+		entry["activity_id"] = activity
+	}
+	return map[string]interface{}{"time_entry": entry}, nil
+}
+
+func (b *redmineBackend) fromTimeEntry(e redmineTimeEntry) (Worklog, error) {
+	started, err := time.ParseInLocation("2006-01-02", e.SpentOn, b.conf.Location())
+	if err != nil {
+		return Worklog{}, fmt.Errorf("Redmine returned unexpected date of time entry %d: %w", e.ID, err)
+	}
+	wl := Worklog{
+		ID:      strconv.Itoa(e.ID),
+		Started: started,
+		Spent:   time.Duration(e.Hours * float64(time.Hour)).Round(time.Minute),
+		Comment: e.Comments,
+		URL:     fmt.Sprintf("%s/time_entries/%d", b.base, e.ID),
+	}
+	if e.Issue != nil {
+		wl.Issue = strconv.Itoa(e.Issue.ID)
+	}
+	if e.User != nil {
+		wl.Author = e.User.Name
+	}
+	if e.Activity != nil {
+		wl.Attributes = map[string]string{activityAttribute: strconv.Itoa(e.Activity.ID)}
+	}
+	return wl, nil
+}
+
+func (b *redmineBackend) AddWorklog(wl Worklog) (Worklog, error) {
+	body, err := b.toTimeEntry(wl)
+	if err != nil {
+		return Worklog{}, err
+	}
+	var resp struct {
+		TimeEntry redmineTimeEntry `json:"time_entry"`
+	}
+	if err := b.do(http.MethodPost, "/time_entries.json", body, &resp); err != nil {
+		return Worklog{}, err
+	}
+	return b.fromTimeEntry(resp.TimeEntry)
+}
+
+// UpdateWorklog replaces time entry, Redmine responds with no content, so wl is returned as is.
+func (b *redmineBackend) UpdateWorklog(wl Worklog) (Worklog, error) {
+	body, err := b.toTimeEntry(wl)
+	if err != nil {
+		return Worklog{}, err
+	}
+	if err := b.do(http.MethodPut, "/time_entries/"+url.PathEscape(wl.ID)+".json", body, nil); err != nil {
+		return Worklog{}, err
+	}
+	return wl, nil
+}
+
+func (b *redmineBackend) DeleteWorklog(issue, id string) error {
+	return b.do(http.MethodDelete, "/time_entries/"+url.PathEscape(id)+".json", nil, nil)
+}
+
+func (b *redmineBackend) ListWorklogs(from, to time.Time) ([]Worklog, error) {
+	const pageSize = 100
+	fromDate, toDate := listedDates(b.conf, from, to)
+	var worklogs []Worklog
+	for offset := 0; ; offset += pageSize {
+		query := url.Values{
+			"user_id": {"me"},
+			"from":    {fromDate},
+			"to":      {toDate},
+			"limit":   {strconv.Itoa(pageSize)},
+			"offset":  {strconv.Itoa(offset)},
+		}
+		var page struct {
+			TimeEntries []redmineTimeEntry `json:"time_entries"`
+			TotalCount  int                `json:"total_count"`
+		}
+		if err := b.do(http.MethodGet, "/time_entries.json?"+query.Encode(), nil, &page); err != nil {
+			return nil, fmt.Errorf("list time entries: %w", err)
+		}
+		for _, e := range page.TimeEntries {
+			wl, err := b.fromTimeEntry(e)
+			if err != nil {
+				return nil, err
+			}
+			if !wl.Started.Before(from) && wl.Started.Before(to) {
+				worklogs = append(worklogs, wl)
+			}
+		}
+		if offset+pageSize >= page.TotalCount || len(page.TimeEntries) == 0 {
+			break
+		}
+	}
+	sort.SliceStable(worklogs, func(i, j int) bool { return worklogs[i].Started.Before(worklogs[j].Started) })
+	return worklogs, nil
+}
+
+func (b *redmineBackend) GetIssue(id string) (Issue, error) {
+	var resp struct {
+		Issue struct {
+			Subject string `json:"subject"`
+			Status  struct {
+				Name string `json:"name"`
+			} `json:"status"`
+			Tracker struct {
+				Name string `json:"name"`
+			} `json:"tracker"`
+		} `json:"issue"`
+	}
+	if err := b.do(http.MethodGet, "/issues/"+url.PathEscape(id)+".json", nil, &resp); err != nil {
+		return Issue{}, err
+	}
+	return Issue{Key: id, Summary: resp.Issue.Subject, Status: resp.Issue.Status.Name, Type: resp.Issue.Tracker.Name}, nil
+}
+
+// SearchIssues finds open and closed issues with query in subject.
+func (b *redmineBackend) SearchIssues(query string) ([]Issue, error) {
+	var resp struct {
+		Issues []struct {
+			ID      int    `json:"id"`
+			Subject string `json:"subject"`
+		} `json:"issues"`
+	}
+	params := url.Values{"subject": {"~" + query}, "status_id": {"*"}, "limit": {"100"}}
+	if err := b.do(http.MethodGet, "/issues.json?"+params.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	issues := make([]Issue, 0, len(resp.Issues))
+	for _, issue := range resp.Issues {
+		issues = append(issues, Issue{Key: strconv.Itoa(issue.ID), Summary: issue.Subject})
+	}
+	return issues, nil
+}
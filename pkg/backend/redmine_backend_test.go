@@ -0,0 +1,76 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_redmineBackend(t *testing.T) {
+	var created map[string]map[string]interface{}
+	defaultActivity := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "key", r.Header.Get("X-Redmine-API-Key"))
+		switch r.URL.Path {
+		case "/enumerations/time_entry_activities.json":
+			json.NewEncoder(w).Encode(map[string]interface{}{"time_entry_activities": []map[string]interface{}{
+				{"id": 8, "name": "Design"},
+				{"id": 9, "name": "Development", "is_default": defaultActivity},
+			}})
+		case "/time_entries.json":
+			if r.Method == http.MethodPost {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"time_entry": {"id": 5, "issue": {"id": 123}, "user": {"name": "Jane Doe"}, "activity": {"id": 9},
+					"hours": 1.5, "comments": "review", "spent_on": "2024-03-04"}}`))
+				return
+			}
+			require.Equal(t, "me", r.URL.Query().Get("user_id"))
+			w.Write([]byte(`{"time_entries": [
+				{"id": 5, "issue": {"id": 123}, "hours": 1.5, "spent_on": "2024-03-04"},
+				{"id": 6, "issue": {"id": 124}, "hours": 0.25, "spent_on": "2024-03-05"}
+			], "total_count": 2}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	conf := config.Config{
+		RedmineURL: srv.URL, RedmineAPIKey: "key", Timezone: "UTC",
+		TaskAliasDetails: map[string]config.AliasDetail{"review": {Issue: "123", ActivityID: 9}},
+	}
+	b, err := newRedmineBackend(conf)
+	require.NoError(t, err)
+
+	wl := Worklog{Issue: "123", Started: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), Spent: 90 * time.Minute, Comment: "review"}
+	require.NoError(t, b.PrepareWorklog(&wl))
+	require.Equal(t, "9", wl.Attributes[activityAttribute])
+	added, err := b.AddWorklog(wl)
+	require.NoError(t, err)
+	require.Equal(t, "5", added.ID)
+	require.Equal(t, 90*time.Minute, added.Spent)
+	require.Equal(t, map[string]interface{}{
+		"issue_id": float64(123), "spent_on": "2024-03-04", "hours": 1.5, "comments": "review", "activity_id": float64(9),
+	}, created["time_entry"])
+
+	// no configured activity and no default one in Redmine
+	err = b.PrepareWorklog(&Worklog{Issue: "124"})
+	require.ErrorContains(t, err, "requires activity")
+	defaultActivity = true
+	require.NoError(t, b.PrepareWorklog(&Worklog{Issue: "124"}))
+
+	_, err = b.AddWorklog(Worklog{Issue: "PRJ-1"})
+	require.ErrorContains(t, err, "Redmine issues are numbers")
+
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	worklogs, err := b.ListWorklogs(day, day.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	require.Len(t, worklogs, 2)
+	require.Equal(t, "124", worklogs[1].Issue)
+	require.Equal(t, 15*time.Minute, worklogs[1].Spent)
+}
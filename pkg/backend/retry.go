@@ -0,0 +1,276 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+)
+
+// maxRetryAfter is the longest Retry-After tlog waits for, rather than failing.
+const maxRetryAfter = time.Minute
+
+// OnRetry is told about every retry before its pause, e.g. to show it in spinner
+var OnRetry func(attempt, retries int, pause time.Duration)
+
+// retryTransport limits time of every request attempt and retries requests
+// that failed because of network or overloaded server.
+type retryTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+// NewRetryTransport wraps next with request timeout and retries of config.
+func NewRetryTransport(conf config.Config, next http.RoundTripper) (http.RoundTripper, error) {
+	timeout, retries, backoff, err := conf.RetryPolicy()
+	if err != nil {
+		return nil, err
+	}
+	requestLimiter.setRate(conf.RateLimitPerSecond())
+	return &retryTransport{next: next, timeout: timeout, retries: retries, backoff: backoff}, nil
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&InFlight, 1)
+	defer atomic.AddInt32(&InFlight, -1)
+
+	var pause time.Duration
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if err := t.wait(req, attempt, pause); err != nil {
+				return nil, err
+			}
+			if req.Body != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req = req.Clone(req.Context())
+				req.Body = body
+			}
+		}
+
+		if err := requestLimiter.wait(req.Context()); err != nil {
+			return nil, err
+		}
+		resp, err := t.attempt(req)
+		canRetry := attempt < t.retries && (req.Body == nil || req.GetBody != nil)
+		switch {
+		case err != nil:
+			if canRetry && !errors.Is(err, ErrInterrupted) && (idempotent(req) || IsDialError(err)) {
+				pause = t.backoffPause(attempt + 1)
+				debugf("%s %s failed, retrying in %s: %s", req.Method, req.URL.Redacted(), pause, err)
+				continue
+			}
+			return nil, err
+		case canRetry && (idempotent(req) && retryableStatus(resp.StatusCode) || notApplied(resp)):
+			var ok bool
+			if pause, ok = t.pause(attempt+1, resp); !ok {
+				return resp, nil
+			}
+			debugf("%s %s: %s, retrying in %s", req.Method, req.URL.Redacted(), resp.Status, pause)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			continue
+		default:
+			if resp.StatusCode >= 400 && Debugf != nil {
+				logErrorBody(req, resp)
+			}
+			return resp, nil
+		}
+	}
+}
+
+// logErrorBody shows body of error response with --verbose, messages made
+// of it do not always tell everything. Body is left for the caller to read.
+func logErrorBody(req *http.Request, resp *http.Response) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		debugf("%s %s: %s, cannot read body: %s", req.Method, req.URL.Redacted(), resp.Status, err)
+	} else {
+		debugf("%s %s: %s\n%s", req.Method, req.URL.Redacted(), resp.Status, body)
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+}
+
+// pause returns how long to wait before given retry: Retry-After of response
+// if it sets one, otherwise backoff. False means server asks to wait too long.
+func (t *retryTransport) pause(attempt int, resp *http.Response) (time.Duration, bool) {
+	after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !ok {
+		return t.backoffPause(attempt), true
+	}
+	return after, after <= maxRetryAfter
+}
+
+// backoffPause doubles backoff with every attempt, jitter keeps clients
+// failed at the same moment from retrying at the same moment.
+func (t *retryTransport) backoffPause(attempt int) time.Duration {
+	pause := t.backoff << (attempt - 1)
+	if pause <= 0 {
+		return 0
+	}
+	return pause/2 + time.Duration(rand.Int63n(int64(pause)))
+}
+
+// retryAfter parses Retry-After header, either seconds or HTTP date.
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		if at.Before(now) {
+			return 0, true
+		}
+		return at.Sub(now), true
+	}
+	return 0, false
+}
+
+// attempt sends request once, it is aborted after timeout or on Ctrl-C.
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	// timeout before connection is there means request was never sent
+	var connected int32
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { atomic.StoreInt32(&connected, 1) },
+	})
+	go func() {
+		select {
+		case <-InterruptCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		var netErr net.Error
+		switch {
+		case InterruptCtx.Err() != nil:
+			return nil, ErrInterrupted
+		case atomic.LoadInt32(&connected) == 0 && errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return nil, connectError{host: req.URL.Host, timeout: t.timeout, err: err}
+		case atomic.LoadInt32(&connected) == 0 && errors.As(err, &netErr) && netErr.Timeout():
+			timeout := tlsHandshakeTimeout
+			if IsDialError(err) {
+				timeout = dialTimeout
+			}
+			return nil, connectError{host: req.URL.Host, timeout: timeout, err: err}
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return nil, TimeoutError{Timeout: t.timeout}
+		}
+		return nil, err
+	}
+	// body is read after RoundTrip returns, context must live until then
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// wait pauses before retry.
+func (t *retryTransport) wait(req *http.Request, attempt int, pause time.Duration) error {
+	if OnRetry != nil {
+		OnRetry(attempt, t.retries, pause)
+	}
+	timer := time.NewTimer(pause)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-InterruptCtx.Done():
+		return ErrInterrupted
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// TimeoutError is returned when JIRA did not respond in time, request may
+// have been processed anyway.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("JIRA did not respond within %s, raise RequestTimeout in config if it is just slow", e.Timeout)
+}
+
+// connectError is returned when connection to JIRA was not established in
+// time, e.g. because of VPN, so request was never sent.
+type connectError struct {
+	host    string
+	timeout time.Duration
+	err     error
+}
+
+func (e connectError) Error() string {
+	return fmt.Sprintf("could not connect to %s within %s, check your network or VPN", e.host, e.timeout)
+}
+
+func (e connectError) Unwrap() error { return e.err }
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// idempotent tells whether request can be repeated without side effects, e.g. duplicate worklog.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// IsDialError tells whether connection failed before request was sent, so it is safe to retry any request.
+func IsDialError(err error) bool {
+	var connErr connectError
+	var opErr *net.OpError
+	return errors.As(err, &connErr) || errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// notApplied tells whether server refused request without processing it,
+// so that even request creating worklog can be retried. Rate limiter
+// answers with 429, overloaded server with 503, unless something was
+// created after all. Gateway errors do not tell whether JIRA got the request.
+func notApplied(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusServiceUnavailable:
+		return resp.Header.Get("Location") == ""
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package backend
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_retryTransport(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch {
+		case r.URL.Path == "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case r.URL.Path == "/gateway" && calls == 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case calls == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: &retryTransport{next: http.DefaultTransport, timeout: time.Second, retries: 2}}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, calls, "GET is retried")
+
+	calls = 0
+	resp, err = client.Post(srv.URL+"/gateway", "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	require.Equal(t, 1, calls, "POST might have been processed, it is not retried")
+
+	calls = 0
+	resp, err = client.Post(srv.URL, "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, calls, "POST refused with 503 was not processed")
+
+	calls = 0
+	client.Transport = &retryTransport{next: http.DefaultTransport, timeout: 50 * time.Millisecond}
+	_, err = client.Get(srv.URL + "/slow")
+	require.ErrorContains(t, err, "JIRA did not respond within 50ms, raise RequestTimeout")
+	require.False(t, IsDialError(err), "request was sent")
+}
+
+func Test_retryTransport_connectTimeout(t *testing.T) {
+	// accepts connections, but never answers TLS handshake, as if packets were dropped midway
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	next, err := BaseTransport(config.Config{})
+	require.NoError(t, err)
+	client := &http.Client{Transport: &retryTransport{next: next, timeout: 100 * time.Millisecond}}
+	_, err = client.Get("https://" + ln.Addr().String())
+	require.ErrorContains(t, err, "could not connect to "+ln.Addr().String()+" within 100ms")
+	require.True(t, IsDialError(err), "request was never sent, it can be retried or queued")
+}
+
+func Test_retryTransport_rateLimit(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch {
+		case r.URL.Path == "/later":
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case calls == 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+	var retries []string
+	OnRetry = func(attempt, total int, pause time.Duration) {
+		retries = append(retries, fmt.Sprintf("%d/%d in %s", attempt, total, pause))
+	}
+	t.Cleanup(func() { OnRetry = nil })
+	// backoff would make test wait, Retry-After: 0 must win over it
+	client := &http.Client{Transport: &retryTransport{next: http.DefaultTransport, timeout: time.Second, retries: 4, backoff: time.Hour}}
+
+	resp, err := client.Post(srv.URL, "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, []string{"1/4 in 0s"}, retries)
+
+	calls = 0
+	resp, err = client.Get(srv.URL + "/later")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, 1, calls, "too long Retry-After is not waited for")
+}
+
+func Test_retryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	d, ok := retryAfter("8", now)
+	require.True(t, ok)
+	require.Equal(t, 8*time.Second, d)
+
+	d, ok = retryAfter("Mon, 04 Mar 2024 09:00:30 GMT", now)
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, d)
+
+	_, ok = retryAfter("soon", now)
+	require.False(t, ok)
+}
+
+func Test_backoffPause(t *testing.T) {
+	tr := &retryTransport{backoff: time.Second}
+	for i := 0; i < 20; i++ {
+		pause := tr.backoffPause(3)
+		require.GreaterOrEqual(t, pause, 2*time.Second)
+		require.Less(t, pause, 6*time.Second)
+	}
+	require.Zero(t, (&retryTransport{}).backoffPause(1))
+}
@@ -0,0 +1,293 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/What-If-I/tlog/internal/state"
+	"github.com/What-If-I/tlog/pkg/config"
+)
+
+const sessionFile = "session.json"
+
+// SessionCookie is JIRA session cached between runs.
+type SessionCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// zero if JIRA did not tell, session is then used until JIRA rejects it
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+func (c SessionCookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && now.After(c.Expires)
+}
+
+func loadSession(conf config.Config) (*SessionCookie, error) {
+	path, err := state.ContextPath(conf.Context(), sessionFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read session: %w", err)
+	}
+	var c SessionCookie
+	if err := json.Unmarshal(data, &c); err != nil {
+		// broken cache only costs another login
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// SaveSession caches session cookie of JIRA for the next runs.
+func SaveSession(conf config.Config, c *SessionCookie) error {
+	path, err := state.ContextPath(conf.Context(), sessionFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return state.WriteFileAtomic(path, data, 0600)
+}
+
+// DeleteSession removes cached session, reporting whether there was one.
+func DeleteSession(conf config.Config) (bool, error) {
+	path, err := state.ContextPath(conf.Context(), sessionFile)
+	if err != nil {
+		return false, err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// sessionTransport attaches session cookie to requests, logging in when there is
+// no valid session and once more when JIRA says session is gone.
+type sessionTransport struct {
+	conf     config.Config
+	password string
+	next     http.RoundTripper
+
+	mu      sync.Mutex
+	session *SessionCookie
+}
+
+func (t *sessionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	session, err := t.currentSession(false)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.send(req, session)
+	if err != nil || !sessionRejected(resp) || (req.Body != nil && req.GetBody == nil) {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	session, err = t.currentSession(true)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+	return t.send(req, session)
+}
+
+func (t *sessionTransport) send(req *http.Request, session *SessionCookie) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.AddCookie(&http.Cookie{Name: session.Name, Value: session.Value})
+	return t.next.RoundTrip(req)
+}
+
+// currentSession returns cached session, logging in if it is missing, expired or relogin is forced.
+func (t *sessionTransport) currentSession(relogin bool) (*SessionCookie, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.session == nil && !relogin {
+		cached, err := loadSession(t.conf)
+		if err != nil {
+			return nil, err
+		}
+		t.session = cached
+	}
+	if !relogin && t.session != nil && !t.session.expired(time.Now()) {
+		return t.session, nil
+	}
+
+	session, err := t.login()
+	if err != nil {
+		return nil, err
+	}
+	if err := SaveSession(t.conf, session); err != nil {
+		return nil, err
+	}
+	t.session = session
+	return session, nil
+}
+
+func (t *sessionTransport) login() (*SessionCookie, error) {
+	body, err := json.Marshal(map[string]string{"username": t.conf.JiraLogin, "password": t.password})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(t.conf.JiraURL, "/")+"/rest/auth/1/session", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("session login: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("session login failed: %s", resp.Status)
+	}
+
+	var result struct {
+		Session struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"session"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode session: %w", err)
+	}
+	session := &SessionCookie{Name: result.Session.Name, Value: result.Session.Value}
+	for _, c := range resp.Cookies() {
+		if c.Name == session.Name && !c.Expires.IsZero() {
+			session.Expires = c.Expires
+		}
+	}
+	return session, nil
+}
+
+// sessionRejected tells whether JIRA treated request as anonymous because session has ended.
+func sessionRejected(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	return resp.Header.Get("X-Seraph-LoginReason") != "" || strings.EqualFold(resp.Header.Get("X-AUSERNAME"), "anonymous")
+}
+
+// reusedSessionCookie is cookie of session JIRA Server starts for basic auth request.
+const reusedSessionCookie = "JSESSIONID"
+
+// sessionReuseTransport sends cached session cookie instead of credentials,
+// which spares the slow authentication of SSO-fronted instances. Request is
+// sent with credentials when there is no session or JIRA does not accept it
+// any more, and session JIRA answers with is cached for the next runs.
+type sessionReuseTransport struct {
+	conf config.Config
+	// sends request with credentials
+	basic http.RoundTripper
+	// sends request as it is
+	next http.RoundTripper
+
+	mu      sync.Mutex
+	loaded  bool
+	session *SessionCookie
+}
+
+func (t *sessionReuseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// body that cannot be sent twice goes with credentials right away
+	if session := t.cached(); session != nil && (req.Body == nil || req.GetBody != nil) {
+		sent := req.Clone(req.Context())
+		sent.AddCookie(&http.Cookie{Name: session.Name, Value: session.Value})
+		resp, err := t.next.RoundTrip(sent)
+		if err != nil || !sessionGone(resp) {
+			return resp, err
+		}
+		resp.Body.Close()
+		debugf("cached session is not accepted, sending credentials")
+		t.forget(session)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+
+	resp, err := t.basic.RoundTrip(req)
+	if err == nil && resp.StatusCode < 300 {
+		t.remember(resp)
+	}
+	return resp, err
+}
+
+// cached returns session of previous runs, nil if there is none or it has expired.
+func (t *sessionReuseTransport) cached() *SessionCookie {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.loaded {
+		t.loaded = true
+		session, err := loadSession(t.conf)
+		if err != nil {
+			debugf("cannot load cached session: %s", err)
+		}
+		t.session = session
+	}
+	if t.session == nil || t.session.Name != reusedSessionCookie || t.session.expired(time.Now()) {
+		return nil
+	}
+	return t.session
+}
+
+func (t *sessionReuseTransport) forget(session *SessionCookie) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.session != session {
+		// another request has got new one already
+		return
+	}
+	t.session = nil
+	if _, err := DeleteSession(t.conf); err != nil {
+		debugf("cannot remove cached session: %s", err)
+	}
+}
+
+// remember caches session JIRA started for request authenticated with credentials.
+func (t *sessionReuseTransport) remember(resp *http.Response) {
+	for _, c := range resp.Cookies() {
+		if c.Name != reusedSessionCookie || c.Value == "" {
+			continue
+		}
+		session := &SessionCookie{Name: c.Name, Value: c.Value, Expires: c.Expires}
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.session = session
+		if err := SaveSession(t.conf, session); err != nil {
+			debugf("cannot cache session: %s", err)
+		}
+		return
+	}
+}
+
+// sessionGone tells whether JIRA treated request as anonymous, which it does
+// for unknown or expired session instead of refusing it.
+func sessionGone(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusUnauthorized || strings.EqualFold(resp.Header.Get("X-AUSERNAME"), "anonymous")
+}
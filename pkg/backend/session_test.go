@@ -0,0 +1,132 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_sessionTransport(t *testing.T) {
+	configDir := isolateUserDirs(t)
+
+	var logins int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/auth/1/session" {
+			logins++
+			fmt.Fprintf(w, `{"session": {"name": "JSESSIONID", "value": "s%d"}}`, logins)
+			return
+		}
+		c, err := r.Cookie("JSESSIONID")
+		if err != nil || c.Value != fmt.Sprintf("s%d", logins) {
+			w.Header().Set("X-AUSERNAME", "anonymous")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	conf := config.Config{JiraURL: srv.URL, JiraLogin: "user", AuthType: config.AuthSession}
+	newClient := func() *http.Client {
+		return &http.Client{Transport: &sessionTransport{conf: conf, password: "secret", next: http.DefaultTransport}}
+	}
+
+	resp, err := newClient().Post(srv.URL+"/rest/api/2/issue/INT-1/worklog", "application/json", strings.NewReader("worklog"))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "worklog", string(body))
+	require.Equal(t, 1, logins)
+
+	info, err := os.Stat(filepath.Join(configDir, "tlog", sessionFile))
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// cached session is reused by the next run
+	resp, err = newClient().Get(srv.URL + "/rest/api/2/myself")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 1, logins)
+
+	// session ended on server, request is retried with new one
+	logins++
+	resp, err = newClient().Post(srv.URL+"/rest/api/2/issue/INT-1/worklog", "application/json", strings.NewReader("again"))
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "again", string(body))
+	require.Equal(t, 3, logins)
+
+	deleted, err := DeleteSession(conf)
+	require.NoError(t, err)
+	require.True(t, deleted)
+}
+
+func Test_sessionReuseTransport(t *testing.T) {
+	isolateUserDirs(t)
+
+	var basic int
+	session := "s1"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); ok {
+			require.Equal(t, "user", user)
+			require.Equal(t, "secret", password)
+			basic++
+			http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: session})
+		} else if c, err := r.Cookie("JSESSIONID"); err != nil || c.Value != session {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	conf := config.Config{JiraURL: srv.URL, JiraLogin: "user", JiraPassword: "secret"}
+	conf.SetOrigin("Retries", "config.toml")
+	require.True(t, conf.ReusesSession())
+	post := func(body string) string {
+		// every run has its own client
+		client, _, err := jiraHTTPClient(conf)
+		require.NoError(t, err)
+		resp, err := client.Post(srv.URL+"/rest/api/2/issue/INT-1/worklog", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		got, _ := io.ReadAll(resp.Body)
+		return string(got)
+	}
+
+	require.Equal(t, "first", post("first"))
+	require.Equal(t, 1, basic)
+	require.Equal(t, "second", post("second"))
+	require.Equal(t, 1, basic, "session of the previous run is reused")
+
+	// session ended on server, credentials are sent again
+	session = "s2"
+	require.Equal(t, "third", post("third"))
+	require.Equal(t, 2, basic)
+	require.Equal(t, "fourth", post("fourth"))
+	require.Equal(t, 2, basic)
+
+	conf.NoSessionReuse = true
+	require.Equal(t, "fifth", post("fifth"))
+	require.Equal(t, 3, basic)
+
+	require.False(t, config.Config{JiraURL: "https://company.atlassian.net"}.ReusesSession(), "Cloud starts no session")
+	require.False(t, config.Config{JiraURL: srv.URL, AuthType: config.AuthPAT}.ReusesSession())
+}
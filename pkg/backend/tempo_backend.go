@@ -0,0 +1,454 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+)
+
+// DefaultTempoURL is used when TempoURL is not set.
+const DefaultTempoURL = "https://api.tempo.io/4"
+
+// tempoBackend stores worklogs in Tempo Cloud, so they get work attributes
+// Tempo approval needs. Tempo knows issues by numeric id only, JIRA is asked
+// for ids, keys and summaries.
+type tempoBackend struct {
+	conf   config.Config
+	jira   *jiraBackend
+	client *http.Client
+	base   string
+
+	// mu guards the caches, imports create worklogs in parallel
+	mu        sync.Mutex
+	accountID string
+	// issue keys by numeric id
+	keys map[int]string
+}
+
+// tempoWorklog is worklog as Tempo API sends and receives it.
+type tempoWorklog struct {
+	ID               int    `json:"tempoWorklogId,omitempty"`
+	Self             string `json:"self,omitempty"`
+	IssueID          int    `json:"issueId,omitempty"`
+	AuthorAccountID  string `json:"authorAccountId,omitempty"`
+	StartDate        string `json:"startDate"`
+	StartTime        string `json:"startTime"`
+	TimeSpentSeconds int    `json:"timeSpentSeconds"`
+	Description      string `json:"description"`
+	// attributes are sent as list and received wrapped in an object
+	Attributes json.RawMessage `json:"attributes,omitempty"`
+
+	Issue *struct {
+		ID int `json:"id"`
+	} `json:"issue,omitempty"`
+	Author *struct {
+		AccountID string `json:"accountId"`
+	} `json:"author,omitempty"`
+}
+
+type tempoAttribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// tempoAttributeDef is a work attribute configured in Tempo.
+type tempoAttributeDef struct {
+	Key      string            `json:"key"`
+	Name     string            `json:"name"`
+	Required bool              `json:"required"`
+	Values   []string          `json:"values"`
+	Names    map[string]string `json:"names"`
+}
+
+func newTempoBackend(conf config.Config) (*tempoBackend, error) {
+	if conf.TempoToken == "" {
+		return nil, errors.New("TempoToken is not set in config, create token in Tempo > Settings > API integration")
+	}
+	jira, err := newJiraBackend(conf)
+	if err != nil {
+		return nil, err
+	}
+	transport, err := BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := NewRetryTransport(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+	base := conf.TempoURL
+	if base == "" {
+		base = DefaultTempoURL
+	}
+	return &tempoBackend{
+		conf:   conf,
+		jira:   jira,
+		client: &http.Client{Transport: retry},
+		base:   strings.TrimSuffix(base, "/"),
+		keys:   map[int]string{},
+	}, nil
+}
+
+// do sends request to Tempo API and decodes response into out, if given.
+func (b *tempoBackend) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	if !strings.HasPrefix(path, "http") {
+		path = b.base + path
+	}
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.conf.TempoToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach Tempo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+		messages := []string{resp.Status}
+		for _, e := range failure.Errors {
+			messages = append(messages, e.Message)
+		}
+		return StatusError(resp.StatusCode, fmt.Errorf("Tempo: %s", strings.Join(messages, ", ")))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// account returns JIRA account id of the current user, Tempo identifies authors by it.
+func (b *tempoBackend) account() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.accountID != "" {
+		return b.accountID, nil
+	}
+	self, _, err := b.jira.client.User.GetSelf()
+	if err != nil {
+		return "", fmt.Errorf("get current user: %w", err)
+	}
+	b.accountID = self.AccountID
+	return b.accountID, nil
+}
+
+func (b *tempoBackend) issueID(key string) (int, error) {
+	issue, _, err := b.jira.client.Issue.Get(key, nil)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.Atoi(issue.ID)
+	if err != nil {
+		return 0, fmt.Errorf("JIRA returned unexpected id %q of %s", issue.ID, key)
+	}
+	b.mu.Lock()
+	b.keys[id] = issue.Key
+	b.mu.Unlock()
+	return id, nil
+}
+
+func (b *tempoBackend) issueKey(id int) (string, error) {
+	b.mu.Lock()
+	key, ok := b.keys[id]
+	b.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	issue, _, err := b.jira.client.Issue.Get(strconv.Itoa(id), nil)
+	if err != nil {
+		return "", err
+	}
+	b.mu.Lock()
+	b.keys[id] = issue.Key
+	b.mu.Unlock()
+	return issue.Key, nil
+}
+
+// attributes returns work attributes configured for issue: TempoAttributes
+// overridden by Attributes of aliases pointing at it.
+func (b *tempoBackend) attributes(issue string) map[string]string {
+	attrs := config.CloneMap(b.conf.TempoAttributes)
+	if attrs == nil {
+		attrs = map[string]string{}
+	}
+	for _, name := range sortedKeys(b.conf.TaskAliasDetails) {
+		if d := b.conf.TaskAliasDetails[name]; d.Issue == issue {
+			for k, v := range d.Attributes {
+				attrs[k] = v
+			}
+		}
+	}
+	return attrs
+}
+
+// PrepareWorklog fills work attributes of worklog, asking for required ones
+// that are not configured. Attributes given by --attr may be named by key or
+// name. Values are checked here, so that Tempo does not reject worklog
+// with a less helpful message.
+func (b *tempoBackend) PrepareWorklog(wl *Worklog) error {
+	var defs struct {
+		Results []tempoAttributeDef `json:"results"`
+	}
+	if err := b.do(http.MethodGet, "/work-attributes", nil, &defs); err != nil {
+		return fmt.Errorf("get work attributes: %w", err)
+	}
+
+	attrs := b.attributes(wl.Issue)
+	for k, v := range wl.Attributes {
+		def, ok := findAttributeDef(defs.Results, k)
+		if !ok {
+			return fmt.Errorf("unknown work attribute %q, Tempo has %s", k, attributeKeys(defs.Results))
+		}
+		attrs[def.Key] = v
+	}
+	for _, def := range defs.Results {
+		if v := attrs[def.Key]; v != "" {
+			if err := checkAttributeValue(def, v); err != nil {
+				return err
+			}
+			continue
+		}
+		if !def.Required {
+			continue
+		}
+		if PromptChoose == nil || PromptInput == nil {
+			return fmt.Errorf("work attribute %s (%s) is required, set it in TempoAttributes or Attributes of alias", def.Name, def.Key)
+		}
+		value, err := promptAttribute(def)
+		if err != nil {
+			return err
+		}
+		attrs[def.Key] = value
+	}
+	wl.Attributes = attrs
+	return nil
+}
+
+// findAttributeDef finds work attribute by key or, ignoring case, by name.
+func findAttributeDef(defs []tempoAttributeDef, key string) (tempoAttributeDef, bool) {
+	for _, def := range defs {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	for _, def := range defs {
+		if strings.EqualFold(def.Name, key) {
+			return def, true
+		}
+	}
+	return tempoAttributeDef{}, false
+}
+
+func attributeKeys(defs []tempoAttributeDef) string {
+	if len(defs) == 0 {
+		return "none"
+	}
+	keys := make([]string, len(defs))
+	for i, def := range defs {
+		keys[i] = fmt.Sprintf("%s (%s)", def.Key, def.Name)
+	}
+	return strings.Join(keys, ", ")
+}
+
+// checkAttributeValue tells whether value is one of static values of attribute, if it has any.
+func checkAttributeValue(def tempoAttributeDef, value string) error {
+	if len(def.Values) == 0 || containsString(def.Values, value) {
+		return nil
+	}
+	return fmt.Errorf("%q is not a value of work attribute %s (%s), expected one of %s", value, def.Name, def.Key, strings.Join(def.Values, ", "))
+}
+
+func promptAttribute(def tempoAttributeDef) (string, error) {
+	label := fmt.Sprintf("%s is required by Tempo", def.Name)
+	if len(def.Values) > 0 {
+		items := make([]string, len(def.Values))
+		for i, v := range def.Values {
+			items[i] = v
+			if name := def.Names[v]; name != "" && name != v {
+				items[i] = fmt.Sprintf("%s (%s)", name, v)
+			}
+		}
+		idx, err := PromptChoose(label, items)
+		if err != nil {
+			return "", err
+		}
+		return def.Values[idx], nil
+	}
+	return PromptInput(label)
+}
+
+func (b *tempoBackend) toTempo(wl Worklog) (tempoWorklog, error) {
+	account, err := b.account()
+	if err != nil {
+		return tempoWorklog{}, err
+	}
+	issueID, err := b.issueID(wl.Issue)
+	if err != nil {
+		return tempoWorklog{}, err
+	}
+
+	attrs := wl.Attributes
+	if attrs == nil {
+		// worklogs not prepared, e.g. edited ones, get configured values
+		attrs = b.attributes(wl.Issue)
+	}
+	list := make([]tempoAttribute, 0, len(attrs))
+	for _, k := range sortedKeys(attrs) {
+		list = append(list, tempoAttribute{Key: k, Value: attrs[k]})
+	}
+	rawAttrs, err := json.Marshal(list)
+	if err != nil {
+		return tempoWorklog{}, err
+	}
+
+	started := wl.Started.In(b.conf.Location())
+	return tempoWorklog{
+		IssueID:          issueID,
+		AuthorAccountID:  account,
+		StartDate:        started.Format("2006-01-02"),
+		StartTime:        started.Format("15:04:05"),
+		TimeSpentSeconds: int(wl.Spent.Seconds()),
+		Description:      wl.Comment,
+		Attributes:       rawAttrs,
+	}, nil
+}
+
+func (b *tempoBackend) fromTempo(tw tempoWorklog) (Worklog, error) {
+	wl := Worklog{
+		ID:      strconv.Itoa(tw.ID),
+		Spent:   time.Duration(tw.TimeSpentSeconds) * time.Second,
+		Comment: tw.Description,
+		URL:     tw.Self,
+	}
+	if tw.Author != nil {
+		wl.Author = tw.Author.AccountID
+	}
+	started, err := time.ParseInLocation("2006-01-02 15:04:05", tw.StartDate+" "+tw.StartTime, b.conf.Location())
+	if err != nil {
+		return Worklog{}, fmt.Errorf("Tempo returned unexpected start of worklog %d: %w", tw.ID, err)
+	}
+	wl.Started = started
+	if tw.Issue != nil {
+		if wl.Issue, err = b.issueKey(tw.Issue.ID); err != nil {
+			return Worklog{}, err
+		}
+	}
+
+	var attrs struct {
+		Values []tempoAttribute `json:"values"`
+	}
+	if len(tw.Attributes) > 0 && json.Unmarshal(tw.Attributes, &attrs) == nil && len(attrs.Values) > 0 {
+		wl.Attributes = map[string]string{}
+		for _, a := range attrs.Values {
+			wl.Attributes[a.Key] = a.Value
+		}
+	}
+	return wl, nil
+}
+
+func (b *tempoBackend) AddWorklog(wl Worklog) (Worklog, error) {
+	body, err := b.toTempo(wl)
+	if err != nil {
+		return Worklog{}, err
+	}
+	var created tempoWorklog
+	if err := b.do(http.MethodPost, "/worklogs", body, &created); err != nil {
+		return Worklog{}, err
+	}
+	return b.fromTempo(created)
+}
+
+func (b *tempoBackend) UpdateWorklog(wl Worklog) (Worklog, error) {
+	body, err := b.toTempo(wl)
+	if err != nil {
+		return Worklog{}, err
+	}
+	var updated tempoWorklog
+	if err := b.do(http.MethodPut, "/worklogs/"+url.PathEscape(wl.ID), body, &updated); err != nil {
+		return Worklog{}, err
+	}
+	return b.fromTempo(updated)
+}
+
+// DeleteWorklog deletes worklog by Tempo id, issue is not needed.
+func (b *tempoBackend) DeleteWorklog(issue, id string) error {
+	return b.do(http.MethodDelete, "/worklogs/"+url.PathEscape(id), nil, nil)
+}
+
+func (b *tempoBackend) ListWorklogs(from, to time.Time) ([]Worklog, error) {
+	account, err := b.account()
+	if err != nil {
+		return nil, err
+	}
+	fromDate, toDate := listedDates(b.conf, from, to)
+	query := url.Values{
+		"from":  {fromDate},
+		"to":    {toDate},
+		"limit": {"1000"},
+	}
+	next := "/worklogs/user/" + url.PathEscape(account) + "?" + query.Encode()
+
+	var worklogs []Worklog
+	for next != "" {
+		var page struct {
+			Results  []tempoWorklog `json:"results"`
+			Metadata struct {
+				Next string `json:"next"`
+			} `json:"metadata"`
+		}
+		if err := b.do(http.MethodGet, next, nil, &page); err != nil {
+			return nil, fmt.Errorf("list worklogs: %w", err)
+		}
+		for _, tw := range page.Results {
+			wl, err := b.fromTempo(tw)
+			if err != nil {
+				return nil, err
+			}
+			if !wl.Started.Before(from) && wl.Started.Before(to) {
+				worklogs = append(worklogs, wl)
+			}
+		}
+		next = page.Metadata.Next
+	}
+	sort.SliceStable(worklogs, func(i, j int) bool { return worklogs[i].Started.Before(worklogs[j].Started) })
+	return worklogs, nil
+}
+
+func (b *tempoBackend) GetIssue(key string) (Issue, error) {
+	return b.jira.GetIssue(key)
+}
+
+func (b *tempoBackend) SearchIssues(query string) ([]Issue, error) {
+	return b.jira.SearchIssues(query)
+}
+
+func (b *tempoBackend) WorkedIssues(days int) ([]Issue, error) {
+	return b.jira.WorkedIssues(days)
+}
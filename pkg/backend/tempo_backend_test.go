@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newTempoTestServer(t *testing.T, created *map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/myself":
+			w.Write([]byte(`{"accountId": "acc-1"}`))
+		case "/rest/api/2/issue/INT-1", "/rest/api/2/issue/10001":
+			w.Write([]byte(`{"id": "10001", "key": "INT-1"}`))
+		case "/4/work-attributes":
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			w.Write([]byte(`{"results": [
+				{"key": "_Account_", "name": "Account", "required": true},
+				{"key": "_Activity_", "name": "Activity", "required": true, "values": ["dev", "ops"]},
+				{"key": "_Note_", "name": "Note", "required": false}
+			]}`))
+		case "/4/worklogs":
+			data, _ := io.ReadAll(r.Body)
+			require.NoError(t, json.Unmarshal(data, created))
+			w.Write([]byte(`{"tempoWorklogId": 7, "issue": {"id": 10001}, "author": {"accountId": "acc-1"},
+				"startDate": "2024-03-04", "startTime": "09:00:00", "timeSpentSeconds": 3600,
+				"attributes": {"values": [{"key": "_Account_", "value": "ACC"}]}}`))
+		case "/4/worklogs/user/acc-1":
+			require.Equal(t, "2024-03-04", r.URL.Query().Get("from"))
+			w.Write([]byte(`{"results": [
+				{"tempoWorklogId": 7, "issue": {"id": 10001}, "startDate": "2024-03-04", "startTime": "09:00:00", "timeSpentSeconds": 3600},
+				{"tempoWorklogId": 8, "issue": {"id": 10001}, "startDate": "2024-03-05", "startTime": "09:00:00", "timeSpentSeconds": 3600}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func Test_tempoBackend(t *testing.T) {
+	var created map[string]interface{}
+	srv := newTempoTestServer(t, &created)
+	defer srv.Close()
+	conf := config.Config{
+		JiraURL: srv.URL, JiraLogin: "me@example.com", JiraPassword: "secret",
+		TempoToken: "token", TempoURL: srv.URL + "/4", Timezone: "UTC",
+		TempoAttributes:  map[string]string{"_Account_": "ACC", "_Activity_": "ops"},
+		TaskAliasDetails: map[string]config.AliasDetail{"dev": {Issue: "INT-1", Attributes: map[string]string{"_Activity_": "dev"}}},
+	}
+	b, err := newTempoBackend(conf)
+	require.NoError(t, err)
+
+	wl := Worklog{Issue: "INT-1", Started: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), Spent: time.Hour, Comment: "work"}
+	require.NoError(t, b.PrepareWorklog(&wl))
+	require.Equal(t, map[string]string{"_Account_": "ACC", "_Activity_": "dev"}, wl.Attributes)
+
+	added, err := b.AddWorklog(wl)
+	require.NoError(t, err)
+	require.Equal(t, "7", added.ID)
+	require.Equal(t, "INT-1", added.Issue)
+	require.Equal(t, "acc-1", created["authorAccountId"])
+	require.Equal(t, float64(10001), created["issueId"])
+	require.Equal(t, "09:00:00", created["startTime"])
+	require.Len(t, created["attributes"], 2)
+
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	worklogs, err := b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, worklogs, 1)
+	require.Equal(t, "INT-1", worklogs[0].Issue)
+
+	// required attribute without value cannot be asked for without terminal
+	b.conf.TempoAttributes = nil
+	b.conf.TaskAliasDetails = nil
+	err = b.PrepareWorklog(&Worklog{Issue: "INT-1"})
+	require.ErrorContains(t, err, "Account")
+
+	// --attr, by key or name, wins over config
+	wl = Worklog{Issue: "INT-1", Attributes: map[string]string{"account": "CLIENT", "_Activity_": "ops"}}
+	require.NoError(t, b.PrepareWorklog(&wl))
+	require.Equal(t, map[string]string{"_Account_": "CLIENT", "_Activity_": "ops"}, wl.Attributes)
+
+	err = b.PrepareWorklog(&Worklog{Issue: "INT-1", Attributes: map[string]string{"_Billable_": "true"}})
+	require.ErrorContains(t, err, `unknown work attribute "_Billable_"`)
+	err = b.PrepareWorklog(&Worklog{Issue: "INT-1", Attributes: map[string]string{"_Account_": "ACC", "Activity": "sales"}})
+	require.ErrorContains(t, err, `"sales" is not a value of work attribute Activity (_Activity_), expected one of dev, ops`)
+}
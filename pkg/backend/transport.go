@@ -0,0 +1,166 @@
+package backend
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/manifoldco/promptui"
+	"github.com/pterm/pterm"
+)
+
+// Connection to black-holed address, e.g. with VPN half-up, fails this
+// fast, rather than once RequestTimeout is over.
+const (
+	dialTimeout         = 10 * time.Second
+	tlsHandshakeTimeout = 10 * time.Second
+)
+
+// BaseTransport returns transport all JIRA requests go through, with TLS configured as in config.
+func BaseTransport(conf config.Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = tlsHandshakeTimeout
+	proxy, err := proxyFunc(conf)
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy
+	if conf.CACertFile == "" && !conf.InsecureSkipVerify && conf.ClientCertFile == "" && conf.ClientKeyFile == "" {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if conf.CACertFile != "" {
+		pem, err := os.ReadFile(conf.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CACertFile: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("CACertFile " + conf.CACertFile + " contains no PEM certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if conf.InsecureSkipVerify {
+		pterm.Warning.WithWriter(os.Stderr).Println("InsecureSkipVerify is set, TLS certificate of JIRA is not verified")
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if conf.ClientCertFile != "" || conf.ClientKeyFile != "" {
+		cert, err := loadClientCert(conf)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// clientCerts caches loaded client certificates, so passphrase is asked once per run.
+var clientCerts = map[string]tls.Certificate{}
+
+// loadClientCert loads client certificate and its key, decrypting key if needed.
+func loadClientCert(conf config.Config) (tls.Certificate, error) {
+	if conf.ClientCertFile == "" || conf.ClientKeyFile == "" {
+		return tls.Certificate{}, errors.New("both ClientCertFile and ClientKeyFile must be set")
+	}
+	cacheKey := conf.ClientCertFile + "\x00" + conf.ClientKeyFile
+	if cert, ok := clientCerts[cacheKey]; ok {
+		return cert, nil
+	}
+
+	certPEM, err := os.ReadFile(conf.ClientCertFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("read ClientCertFile: %w", err)
+	}
+	keyPEM, err := os.ReadFile(conf.ClientKeyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("read ClientKeyFile: %w", err)
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return tls.Certificate{}, fmt.Errorf("ClientKeyFile %s contains no PEM key", conf.ClientKeyFile)
+	}
+	// legacy PEM encryption is deprecated, but it is what `openssl rsa -aes256` produces
+	if x509.IsEncryptedPEMBlock(block) {
+		passphrase, err := clientKeyPassphrase(conf)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		der, err := x509.DecryptPEMBlock(block, []byte(passphrase))
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("decrypt ClientKeyFile %s: %w", conf.ClientKeyFile, err)
+		}
+		keyPEM = pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der})
+	} else if strings.Contains(block.Type, "ENCRYPTED") {
+		return tls.Certificate{}, fmt.Errorf("ClientKeyFile %s is PKCS#8 encrypted, which is not supported: "+
+			"convert it with `openssl rsa -aes256` or decrypt it", conf.ClientKeyFile)
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("ClientCertFile %s and ClientKeyFile %s: %w", conf.ClientCertFile, conf.ClientKeyFile, err)
+	}
+	clientCerts[cacheKey] = cert
+	return cert, nil
+}
+
+// clientKeyPassphrase returns passphrase of client key from config, command or prompt.
+func clientKeyPassphrase(conf config.Config) (string, error) {
+	switch {
+	case conf.ClientKeyPassphrase != "":
+		return conf.ClientKeyPassphrase, nil
+	case conf.ClientKeyPassphraseCommand != "":
+		return config.RunSecretCommand("ClientKeyPassphraseCommand", conf.ClientKeyPassphraseCommand)
+	}
+
+	prompt := promptui.Prompt{
+		Label:       pterm.LightBlue("Passphrase of " + conf.ClientKeyFile),
+		HideEntered: true,
+		Mask:        '*',
+	}
+	passphrase, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("ClientKeyFile %s is encrypted, set ClientKeyPassphrase or ClientKeyPassphraseCommand", conf.ClientKeyFile)
+	}
+	return passphrase, nil
+}
+
+// proxyFunc picks proxy for requests: ProxyURL from config if set, otherwise HTTP(S)_PROXY and NO_PROXY.
+func proxyFunc(conf config.Config) (func(*http.Request) (*url.URL, error), error) {
+	proxy := http.ProxyFromEnvironment
+	if conf.ProxyURL != "" {
+		u, err := url.Parse(conf.ProxyURL)
+		if err != nil || u.Host == "" {
+			return nil, config.Error{Field: "ProxyURL", Err: fmt.Errorf("invalid ProxyURL %q in config: URL like http://proxy:3128 expected", conf.ProxyURL)}
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := proxy(req)
+		if err != nil {
+			return nil, err
+		}
+		if u == nil {
+			debugf("%s %s", req.Method, req.URL.Redacted())
+		} else {
+			debugf("%s %s via proxy %s", req.Method, req.URL.Redacted(), u.Redacted())
+		}
+		return u, nil
+	}, nil
+}
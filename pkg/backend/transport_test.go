@@ -0,0 +1,113 @@
+package backend
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_baseTransport(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, certPEM, 0600))
+
+	get := func(conf config.Config) error {
+		transport, err := BaseTransport(conf)
+		if err != nil {
+			return err
+		}
+		resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	transport, err := BaseTransport(config.Config{})
+	require.NoError(t, err)
+	require.Equal(t, tlsHandshakeTimeout, transport.TLSHandshakeTimeout)
+
+	require.ErrorContains(t, get(config.Config{}), "certificate")
+	require.NoError(t, get(config.Config{CACertFile: caFile}))
+	require.NoError(t, get(config.Config{InsecureSkipVerify: true}))
+
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	require.ErrorContains(t, get(config.Config{CACertFile: missing}), missing)
+}
+
+func Test_proxyFunc(t *testing.T) {
+	var proxyAuth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyAuth = r.Header.Get("Proxy-Authorization")
+		require.Equal(t, "http://jira.example.com/rest/api/2/myself", r.URL.String(), "proxy gets absolute URL")
+	}))
+	defer proxy.Close()
+	t.Setenv("HTTP_PROXY", "http://env-proxy.invalid:3128")
+
+	transport, err := BaseTransport(config.Config{ProxyURL: strings.Replace(proxy.URL, "http://", "http://user:pass@", 1)})
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: transport}).Get("http://jira.example.com/rest/api/2/myself")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, "Basic dXNlcjpwYXNz", proxyAuth)
+
+	_, err = proxyFunc(config.Config{ProxyURL: "proxy:3128"})
+	require.ErrorContains(t, err, "invalid ProxyURL")
+}
+
+func Test_loadClientCert(t *testing.T) {
+	dir := t.TempDir()
+	writeCert := func(name string, passphrase string) (string, string) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: name}, NotAfter: time.Now().Add(time.Hour)}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		require.NoError(t, err)
+
+		keyBlock := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+		if passphrase != "" {
+			keyBlock, err = x509.EncryptPEMBlock(rand.Reader, keyBlock.Type, keyBlock.Bytes, []byte(passphrase), x509.PEMCipherAES256)
+			require.NoError(t, err)
+		}
+		certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+		require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+		require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(keyBlock), 0600))
+		return certFile, keyFile
+	}
+	t.Cleanup(func() { clientCerts = map[string]tls.Certificate{} })
+
+	certFile, keyFile := writeCert("plain", "")
+	_, err := loadClientCert(config.Config{ClientCertFile: certFile, ClientKeyFile: keyFile})
+	require.NoError(t, err)
+
+	otherCert, _ := writeCert("other", "")
+	_, err = loadClientCert(config.Config{ClientCertFile: otherCert, ClientKeyFile: keyFile})
+	require.ErrorContains(t, err, otherCert)
+	require.ErrorContains(t, err, keyFile)
+
+	certFile, keyFile = writeCert("encrypted", "s3cret")
+	_, err = loadClientCert(config.Config{ClientCertFile: certFile, ClientKeyFile: keyFile, ClientKeyPassphrase: "wrong"})
+	require.ErrorContains(t, err, "decrypt ClientKeyFile")
+	_, err = loadClientCert(config.Config{ClientCertFile: certFile, ClientKeyFile: keyFile, ClientKeyPassphrase: "s3cret"})
+	require.NoError(t, err)
+
+	_, err = loadClientCert(config.Config{ClientCertFile: certFile})
+	require.ErrorContains(t, err, "both ClientCertFile and ClientKeyFile")
+}
@@ -0,0 +1,329 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+)
+
+// WorkTypeAttribute is the Worklog attribute holding YouTrack work type, set by --work-type.
+const WorkTypeAttribute = "type"
+
+const youtrackWorkItemFields = "id,date,duration(minutes),text,author(login),issue(idReadable),type(name)"
+
+// youtrackBackend logs time as YouTrack work items. Issue ids look like JIRA keys.
+type youtrackBackend struct {
+	conf   config.Config
+	client *http.Client
+	base   string
+
+	// mu guards login, imports create worklogs in parallel
+	mu    sync.Mutex
+	login string
+}
+
+type youtrackWorkItem struct {
+	ID       string `json:"id,omitempty"`
+	Date     int64  `json:"date"`
+	Duration struct {
+		Minutes int `json:"minutes"`
+	} `json:"duration"`
+	Text   string `json:"text"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author,omitempty"`
+	Issue *struct {
+		IDReadable string `json:"idReadable"`
+	} `json:"issue,omitempty"`
+	Type *youtrackWorkType `json:"type,omitempty"`
+}
+
+type youtrackWorkType struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// youtrackError is an unsuccessful response of YouTrack.
+type youtrackError struct {
+	status  int
+	message string
+}
+
+func (e *youtrackError) Error() string {
+	return "YouTrack: " + e.message
+}
+
+func newYouTrackBackend(conf config.Config) (*youtrackBackend, error) {
+	if conf.YouTrackURL == "" {
+		return nil, errors.New("YouTrackURL is not set in config")
+	}
+	if conf.YouTrackToken == "" {
+		return nil, errors.New("YouTrackToken is not set in config, create permanent token in YouTrack > Profile > Account security")
+	}
+	transport, err := BaseTransport(conf)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := NewRetryTransport(conf, transport)
+	if err != nil {
+		return nil, err
+	}
+	return &youtrackBackend{conf: conf, client: &http.Client{Transport: retry}, base: strings.TrimSuffix(conf.YouTrackURL, "/")}, nil
+}
+
+// do sends request to YouTrack REST API and decodes response into out, if given.
+func (b *youtrackBackend) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, b.base+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.conf.YouTrackToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach YouTrack: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Description string `json:"error_description"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&failure)
+		message := resp.Status
+		if failure.Description != "" {
+			message += ", " + failure.Description
+		}
+		return StatusError(resp.StatusCode, &youtrackError{status: resp.StatusCode, message: message})
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// YouTrackUser returns full name, or login, of the user the token belongs to.
+// Rejected token is reported as AuthError.
+func YouTrackUser(cfg config.Config) (string, error) {
+	b, err := newYouTrackBackend(cfg)
+	if err != nil {
+		return "", err
+	}
+	var user struct {
+		Login    string `json:"login"`
+		FullName string `json:"fullName"`
+	}
+	err = b.do(http.MethodGet, "/api/users/me?fields=login,fullName", nil, &user)
+	var failure *youtrackError
+	switch {
+	case errors.As(err, &failure) && (failure.status == http.StatusUnauthorized || failure.status == http.StatusForbidden):
+		return "", AuthError{Err: fmt.Errorf("YouTrack rejected token (%s)", failure.message)}
+	case err != nil:
+		return "", err
+	case user.FullName != "":
+		return user.FullName, nil
+	default:
+		return user.Login, nil
+	}
+}
+
+func (b *youtrackBackend) currentUser() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.login != "" {
+		return b.login, nil
+	}
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := b.do(http.MethodGet, "/api/users/me?fields=login", nil, &user); err != nil {
+		return "", fmt.Errorf("get current user: %w", err)
+	}
+	b.login = user.Login
+	return b.login, nil
+}
+
+// workType returns work type of worklog: the one given with --work-type, then
+// WorkType of aliases pointing at the issue, then YouTrackWorkType.
+func (b *youtrackBackend) workType(wl Worklog) string {
+	if t := wl.Attributes[WorkTypeAttribute]; t != "" {
+		return t
+	}
+	for _, name := range sortedKeys(b.conf.TaskAliasDetails) {
+		if d := b.conf.TaskAliasDetails[name]; d.Issue == wl.Issue && d.WorkType != "" {
+			return d.WorkType
+		}
+	}
+	return b.conf.YouTrackWorkType
+}
+
+// workTypeID finds work type by name, YouTrack references it by id.
+func (b *youtrackBackend) workTypeID(name string) (string, error) {
+	var types []youtrackWorkType
+	if err := b.do(http.MethodGet, "/api/admin/timeTrackingSettings/workItemTypes?fields=id,name", nil, &types); err != nil {
+		return "", fmt.Errorf("get work types: %w", err)
+	}
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		if strings.EqualFold(t.Name, name) {
+			return t.ID, nil
+		}
+		names = append(names, t.Name)
+	}
+	return "", fmt.Errorf("unknown work type %q, YouTrack has %s", name, strings.Join(names, ", "))
+}
+
+func (b *youtrackBackend) toWorkItem(wl Worklog) (youtrackWorkItem, error) {
+	item := youtrackWorkItem{Date: wl.Started.UnixMilli(), Text: wl.Comment}
+	item.Duration.Minutes = int(wl.Spent.Minutes())
+	if name := b.workType(wl); name != "" {
+		id, err := b.workTypeID(name)
+		if err != nil {
+			return youtrackWorkItem{}, err
+		}
+		item.Type = &youtrackWorkType{ID: id}
+	}
+	return item, nil
+}
+
+func (b *youtrackBackend) fromWorkItem(issue string, item youtrackWorkItem) Worklog {
+	wl := Worklog{
+		ID:      item.ID,
+		Issue:   issue,
+		Started: time.UnixMilli(item.Date).In(b.conf.Location()),
+		Spent:   time.Duration(item.Duration.Minutes) * time.Minute,
+		Comment: item.Text,
+	}
+	if item.Issue != nil {
+		wl.Issue = item.Issue.IDReadable
+	}
+	if item.Author != nil {
+		wl.Author = item.Author.Login
+	}
+	if item.Type != nil && item.Type.Name != "" {
+		wl.Attributes = map[string]string{WorkTypeAttribute: item.Type.Name}
+	}
+	if wl.Issue != "" {
+		wl.URL = fmt.Sprintf("%s/issue/%s", b.base, wl.Issue)
+	}
+	return wl
+}
+
+func (b *youtrackBackend) workItemsPath(issue, id string) string {
+	path := "/api/issues/" + url.PathEscape(issue) + "/timeTracking/workItems"
+	if id != "" {
+		path += "/" + url.PathEscape(id)
+	}
+	return path + "?fields=" + youtrackWorkItemFields
+}
+
+func (b *youtrackBackend) AddWorklog(wl Worklog) (Worklog, error) {
+	item, err := b.toWorkItem(wl)
+	if err != nil {
+		return Worklog{}, err
+	}
+	var created youtrackWorkItem
+	if err := b.do(http.MethodPost, b.workItemsPath(wl.Issue, ""), item, &created); err != nil {
+		return Worklog{}, err
+	}
+	return b.fromWorkItem(wl.Issue, created), nil
+}
+
+func (b *youtrackBackend) UpdateWorklog(wl Worklog) (Worklog, error) {
+	item, err := b.toWorkItem(wl)
+	if err != nil {
+		return Worklog{}, err
+	}
+	var updated youtrackWorkItem
+	if err := b.do(http.MethodPost, b.workItemsPath(wl.Issue, wl.ID), item, &updated); err != nil {
+		return Worklog{}, err
+	}
+	return b.fromWorkItem(wl.Issue, updated), nil
+}
+
+func (b *youtrackBackend) DeleteWorklog(issue, id string) error {
+	return b.do(http.MethodDelete, b.workItemsPath(issue, id), nil, nil)
+}
+
+func (b *youtrackBackend) ListWorklogs(from, to time.Time) ([]Worklog, error) {
+	login, err := b.currentUser()
+	if err != nil {
+		return nil, err
+	}
+
+	const pageSize = 100
+	fromDate, toDate := listedDates(b.conf, from, to)
+	var worklogs []Worklog
+	for skip := 0; ; skip += pageSize {
+		query := url.Values{
+			"author":    {login},
+			"startDate": {fromDate},
+			"endDate":   {toDate},
+			"fields":    {youtrackWorkItemFields},
+			"$top":      {strconv.Itoa(pageSize)},
+			"$skip":     {strconv.Itoa(skip)},
+		}
+		var items []youtrackWorkItem
+		if err := b.do(http.MethodGet, "/api/workItems?"+query.Encode(), nil, &items); err != nil {
+			return nil, fmt.Errorf("list work items: %w", err)
+		}
+		for _, item := range items {
+			if wl := b.fromWorkItem("", item); !wl.Started.Before(from) && wl.Started.Before(to) {
+				worklogs = append(worklogs, wl)
+			}
+		}
+		if len(items) < pageSize {
+			break
+		}
+	}
+	sort.SliceStable(worklogs, func(i, j int) bool { return worklogs[i].Started.Before(worklogs[j].Started) })
+	return worklogs, nil
+}
+
+func (b *youtrackBackend) GetIssue(id string) (Issue, error) {
+	var issue struct {
+		IDReadable string `json:"idReadable"`
+		Summary    string `json:"summary"`
+	}
+	if err := b.do(http.MethodGet, "/api/issues/"+url.PathEscape(id)+"?fields=idReadable,summary", nil, &issue); err != nil {
+		return Issue{}, err
+	}
+	return Issue{Key: issue.IDReadable, Summary: issue.Summary}, nil
+}
+
+// SearchIssues finds issues with YouTrack query, e.g. "for: me #Unresolved".
+func (b *youtrackBackend) SearchIssues(query string) ([]Issue, error) {
+	var found []struct {
+		IDReadable string `json:"idReadable"`
+		Summary    string `json:"summary"`
+	}
+	params := url.Values{"query": {query}, "fields": {"idReadable,summary"}, "$top": {"100"}}
+	if err := b.do(http.MethodGet, "/api/issues?"+params.Encode(), nil, &found); err != nil {
+		return nil, err
+	}
+	issues := make([]Issue, 0, len(found))
+	for _, issue := range found {
+		issues = append(issues, Issue{Key: issue.IDReadable, Summary: issue.Summary})
+	}
+	return issues, nil
+}
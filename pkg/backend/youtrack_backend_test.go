@@ -0,0 +1,75 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/What-If-I/tlog/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_youtrackBackend(t *testing.T) {
+	var created map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/users/me":
+			w.Write([]byte(`{"login": "me", "fullName": "Jane Doe"}`))
+		case "/api/admin/timeTrackingSettings/workItemTypes":
+			w.Write([]byte(`[{"id": "53-0", "name": "Development"}, {"id": "53-1", "name": "Testing"}]`))
+		case "/api/issues/PRJ-1/timeTracking/workItems":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			w.Write([]byte(`{"id": "8-1", "date": 1709542800000, "duration": {"minutes": 90}, "text": "review",
+				"author": {"login": "me"}, "issue": {"idReadable": "PRJ-1"}, "type": {"name": "Testing"}}`))
+		case "/api/workItems":
+			require.Equal(t, "me", r.URL.Query().Get("author"))
+			w.Write([]byte(`[
+				{"id": "8-1", "date": 1709542800000, "duration": {"minutes": 90}, "author": {"login": "me"}, "issue": {"idReadable": "PRJ-1"}},
+				{"id": "8-2", "date": 1709629200000, "duration": {"minutes": 30}, "author": {"login": "me"}, "issue": {"idReadable": "PRJ-2"}}
+			]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf := config.Config{
+		Backend: config.YouTrack, YouTrackURL: srv.URL, YouTrackToken: "token", Timezone: "UTC",
+		YouTrackWorkType: "Development",
+		TaskAliasDetails: map[string]config.AliasDetail{"qa": {Issue: "PRJ-1", WorkType: "testing"}},
+	}
+	b, err := newYouTrackBackend(conf)
+	require.NoError(t, err)
+
+	started := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	wl, err := b.AddWorklog(Worklog{Issue: "PRJ-1", Started: started, Spent: 90 * time.Minute, Comment: "review"})
+	require.NoError(t, err)
+	require.Equal(t, "8-1", wl.ID)
+	require.Equal(t, started, wl.Started)
+	require.Equal(t, float64(started.UnixMilli()), created["date"])
+	require.Equal(t, map[string]interface{}{"minutes": float64(90)}, created["duration"])
+	require.Equal(t, map[string]interface{}{"id": "53-1"}, created["type"], "alias work type wins")
+
+	_, err = b.AddWorklog(Worklog{Issue: "PRJ-1", Started: started, Spent: time.Hour, Attributes: map[string]string{WorkTypeAttribute: "Design"}})
+	require.ErrorContains(t, err, `unknown work type "Design"`)
+
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	worklogs, err := b.ListWorklogs(day, day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, worklogs, 1)
+	require.Equal(t, "PRJ-1", worklogs[0].Issue)
+
+	name, err := YouTrackUser(conf)
+	require.NoError(t, err)
+	require.Equal(t, "Jane Doe", name)
+	conf.YouTrackToken = "wrong"
+	_, err = YouTrackUser(conf)
+	require.ErrorContains(t, err, "rejected token")
+	require.ErrorAs(t, err, new(AuthError))
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Balance describes flex time account of `tlog balance`.
+type Balance struct {
+	// "2006-01-02", nothing is expected before it
+	EmploymentStart string `toml:"EmploymentStart,omitempty"`
+	// manual corrections, e.g. balance carried over from spreadsheet
+	Adjustments []Adjustment `toml:"Adjustments,omitempty"`
+}
+
+// Adjustment adds Hours to balance at Date, negative ones take them away.
+type Adjustment struct {
+	Date  string  `toml:"Date"` // "2006-01-02"
+	Hours float64 `toml:"Hours"`
+	Note  string  `toml:"Note,omitempty"`
+}
+
+// EmploymentDate returns EmploymentStart, zero time if it is not set.
+func (b Balance) EmploymentDate(loc *time.Location) (time.Time, error) {
+	if b.EmploymentStart == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", b.EmploymentStart, loc)
+	if err != nil {
+		return time.Time{}, Error{Field: "Balance.EmploymentStart", Err: fmt.Errorf("invalid Balance.EmploymentStart %q in config: yyyy-mm-dd expected", b.EmploymentStart)}
+	}
+	return t, nil
+}
+
+// AdjustmentDate returns day of adjustment at position i.
+func (b Balance) AdjustmentDate(i int, loc *time.Location) (time.Time, error) {
+	a := b.Adjustments[i]
+	t, err := time.ParseInLocation("2006-01-02", a.Date, loc)
+	if err != nil {
+		field := fmt.Sprintf("Balance.Adjustments[%d].Date", i)
+		return time.Time{}, Error{Field: field, Err: fmt.Errorf("invalid %s %q in config: yyyy-mm-dd expected", field, a.Date)}
+	}
+	return t, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import "github.com/What-If-I/tlog/pkg/parse"
+
+// Billing tells which time `tlog report billable` bills, e.g.
+//
+//	[Billing.Projects.APP]
+//	Billable = true
+//	Rate = 95
+type Billing struct {
+	// defaults of issues by project key, issues of projects not listed are not billable
+	Projects map[string]BillingProject `toml:"Projects,omitempty"`
+	// shown after amounts, e.g. "EUR"
+	Currency string `toml:"Currency,omitempty"`
+}
+
+// BillingProject is default of issues of one project.
+type BillingProject struct {
+	Billable bool `toml:"Billable"`
+	// amount per billable hour, nothing is multiplied if not set
+	Rate float64 `toml:"Rate,omitzero"`
+}
+
+// Billable reports whether time logged to issue is billable: as aliases of
+// the issue with Billable tell, otherwise as its project does. When aliases
+// disagree, billable one wins.
+func (c Config) Billable(issue string) bool {
+	marked, billable := false, false
+	for _, d := range c.TaskAliasDetails {
+		if d.Issue == issue && d.Billable != nil {
+			marked = true
+			billable = billable || *d.Billable
+		}
+	}
+	if marked {
+		return billable
+	}
+	return c.Billing.Projects[parse.Project(issue)].Billable
+}
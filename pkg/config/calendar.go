@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Calendar describes days off that are not expected to be logged.
+type Calendar struct {
+	// days off, "2006-01-02", "2006.01.02" or "01.02" for every year
+	Holidays []string `toml:"Holidays,omitempty"`
+	// adds public holidays of the region from RegionHolidays, e.g. "DE"
+	Region string `toml:"Region,omitempty"`
+	// alias or issue key vacation is logged to
+	VacationAlias string `toml:"VacationAlias,omitempty"`
+	// alias or issue key sick leave is logged to
+	SickAlias string `toml:"SickAlias,omitempty"`
+}
+
+// RegionHolidays are public holidays falling on the same date every year.
+// Movable ones, like Easter, are not included, add them to Holidays.
+var RegionHolidays = map[string][]string{
+	"DE": {"01.01", "05.01", "10.03", "12.25", "12.26"},
+	"FR": {"01.01", "05.01", "05.08", "07.14", "08.15", "11.01", "11.11", "12.25"},
+	"GB": {"01.01", "12.25", "12.26"},
+	"RU": {"01.01", "01.02", "01.03", "01.04", "01.05", "01.06", "01.07", "01.08", "02.23", "03.08", "05.01", "05.09", "06.12", "11.04"},
+	"US": {"01.01", "06.19", "07.04", "11.11", "12.25"},
+}
+
+// Holiday is a parsed Holidays entry, zero Year means every year.
+type Holiday struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+func (h Holiday) matches(day time.Time) bool {
+	y, m, d := day.Date()
+	return (h.Year == 0 || h.Year == y) && h.Month == m && h.Day == d
+}
+
+// ParseHoliday accepts mm.dd dates of every year, plus ISO dates.
+func ParseHoliday(s string) (Holiday, error) {
+	for _, layout := range []string{"2006-01-02", "2006.01.02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Holiday{Year: t.Year(), Month: t.Month(), Day: t.Day()}, nil
+		}
+	}
+	if t, err := time.Parse("01.02", s); err == nil {
+		return Holiday{Month: t.Month(), Day: t.Day()}, nil
+	}
+	return Holiday{}, fmt.Errorf("%q is not a date, use yyyy-mm-dd, yyyy.mm.dd or mm.dd for every year", s)
+}
+
+// holidays returns parsed holidays of the calendar, invalid entries are skipped
+// here and reported by `tlog config validate`.
+func (c Calendar) holidays() []Holiday {
+	dates := append(append([]string(nil), RegionHolidays[strings.ToUpper(c.Region)]...), c.Holidays...)
+	parsed := make([]Holiday, 0, len(dates))
+	for _, s := range dates {
+		if h, err := ParseHoliday(s); err == nil {
+			parsed = append(parsed, h)
+		}
+	}
+	return parsed
+}
+
+// IsHoliday reports whether day is a holiday.
+func (c Calendar) IsHoliday(day time.Time) bool {
+	for _, h := range c.holidays() {
+		if h.matches(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWorkday reports whether day is expected to be logged, neither weekend nor holiday.
+func (c Calendar) IsWorkday(day time.Time) bool {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.IsHoliday(day)
+}
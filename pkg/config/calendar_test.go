@@ -0,0 +1,28 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar_IsHoliday(t *testing.T) {
+	cal := Calendar{Holidays: []string{"2022-03-14", "2022.03.15", "08.01", "nonsense"}, Region: "de"}
+	tests := []struct {
+		day  time.Time
+		want bool
+	}{
+		{day: time.Date(2022, time.March, 14, 0, 0, 0, 0, time.UTC), want: true},
+		{day: time.Date(2022, time.March, 15, 0, 0, 0, 0, time.UTC), want: true},
+		{day: time.Date(2023, time.March, 14, 0, 0, 0, 0, time.UTC), want: false},
+		{day: time.Date(2023, time.August, 1, 0, 0, 0, 0, time.UTC), want: true},
+		{day: time.Date(2023, time.October, 3, 0, 0, 0, 0, time.UTC), want: true}, // from region
+		{day: time.Date(2023, time.October, 4, 0, 0, 0, 0, time.UTC), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.day.Format("2006-01-02"), func(t *testing.T) {
+			require.Equal(t, tt.want, cal.IsHoliday(tt.day))
+		})
+	}
+}
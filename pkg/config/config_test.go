@@ -0,0 +1,188 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Rounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		wantStep time.Duration
+		wantMode string
+		wantErr  bool
+	}{
+		{name: "defaults", cfg: Config{}, wantStep: time.Minute, wantMode: RoundNearest},
+		{name: "configured", cfg: Config{RoundTo: "15m", TimerRounding: "Up"}, wantStep: 15 * time.Minute, wantMode: RoundUp},
+		{name: "bad increment", cfg: Config{RoundTo: "15"}, wantErr: true},
+		{name: "negative increment", cfg: Config{RoundTo: "-15m"}, wantErr: true},
+		{name: "bad mode", cfg: Config{TimerRounding: "sideways"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, mode, err := tt.cfg.Rounding()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStep, step)
+			require.Equal(t, tt.wantMode, mode)
+		})
+	}
+}
+
+func Test_applyEnv(t *testing.T) {
+	t.Setenv("TLOG_JIRA_URL", "https://env.example.com")
+	t.Setenv("TLOG_WORKDAY_HOURS", "7.5")
+
+	cfg, err := ApplyEnv(Config{
+		JiraURL:   "https://file.example.com",
+		JiraLogin: "user",
+		origins:   map[string]string{"JiraURL": "/file.toml", "JiraLogin": "/file.toml"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "https://env.example.com", cfg.JiraURL)
+	require.Equal(t, "env TLOG_JIRA_URL", cfg.Origin("JiraURL"))
+	require.Equal(t, "user", cfg.JiraLogin)
+	require.Equal(t, "/file.toml", cfg.Origin("JiraLogin"))
+	require.Equal(t, 7.5, cfg.WorkdayHours)
+	require.Equal(t, "env TLOG_WORKDAY_HOURS", cfg.Origin("WorkdayHours"))
+
+	t.Setenv("TLOG_WORKDAY_HOURS", "lots")
+	_, err = ApplyEnv(Config{})
+	require.Error(t, err)
+}
+
+func Test_applyEnv_mock(t *testing.T) {
+	t.Setenv("TLOG_MOCK", "1")
+	cfg, err := ApplyEnv(Config{Backend: "tempo"})
+	require.NoError(t, err)
+	require.Equal(t, "mock", cfg.Backend)
+	require.Equal(t, "env TLOG_MOCK", cfg.Origin("Backend"))
+	require.True(t, cfg.HasCredentials())
+
+	t.Setenv("TLOG_MOCK", "0")
+	cfg, err = ApplyEnv(Config{Backend: "tempo"})
+	require.NoError(t, err)
+	require.Equal(t, "tempo", cfg.Backend)
+
+	t.Setenv("TLOG_MOCK", "sure")
+	_, err = ApplyEnv(Config{})
+	require.ErrorContains(t, err, "TLOG_MOCK")
+}
+
+func TestConfig_Aliases(t *testing.T) {
+	conf := Config{
+		TaskAliases: map[string]string{"review": "INT-24", "standup": "INT-1"},
+		TaskAliasDetails: map[string]AliasDetail{
+			"standup": {Issue: "MEET-1", Comment: "daily standup"},
+			"retro":   {Issue: "MEET-2"},
+		},
+	}
+	require.Equal(t, map[string]string{"review": "INT-24", "standup": "MEET-1", "retro": "MEET-2"}, conf.Aliases())
+	require.Equal(t, "daily standup", conf.AliasComment("standup"))
+	require.Empty(t, conf.AliasComment("retro"))
+	require.Empty(t, conf.AliasComment("review"))
+	require.Equal(t, "INT-1", conf.TaskAliases["standup"], "config is not modified")
+}
+
+func Test_checkValues(t *testing.T) {
+	require.NoError(t, Check(Config{}))
+	require.NoError(t, Check(Config{WorkdayHours: 7.5, origins: map[string]string{"WorkdayHours": "config.toml"}}))
+	require.NoError(t, Check(Config{Timezone: "Europe/Berlin", DefaultStartTime: "09:30"}))
+
+	err := Check(Config{WeeklyTargetHours: -1, origins: map[string]string{"WeeklyTargetHours": "config.toml"}})
+	require.ErrorContains(t, err, "WeeklyTargetHours")
+
+	err = Check(Config{origins: map[string]string{"WorkdayHours": "env TLOG_WORKDAY_HOURS"}})
+	require.ErrorContains(t, err, "WorkdayHours must be positive, got 0 in env TLOG_WORKDAY_HOURS")
+
+	require.ErrorContains(t, Check(Config{Timezone: "Mars/Base"}), "Timezone")
+
+	err = Check(Config{DefaultStartTime: "25:00", origins: map[string]string{"DefaultStartTime": "config.toml"}})
+	require.EqualError(t, err, `DefaultStartTime "25:00" is not a time of day, use HH:MM, e.g. 09:00 in config.toml`)
+}
+
+func Test_writeConfig_perms(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not used on windows")
+	}
+	path := filepath.Join(t.TempDir(), "tlog", "config.toml")
+
+	require.NoError(t, Write(Config{JiraLogin: "user"}, path))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, Save(Config{JiraLogin: "user"}, path))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func Test_writeConfig_roundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{name: "plain", password: "hunter2"},
+		{name: "quotes", password: `pa"ss'word`},
+		{name: "backslashes", password: `C:\path\n\"`},
+		{name: "toml syntax", password: "\"\nJiraURL = \"https://evil.example.com"},
+		{name: "non-ascii", password: "пароль🔑 ünïcødé\t"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			cfg := Config{
+				JiraURL:        "https://jira.example.com",
+				JiraLogin:      "user.name",
+				JiraPassword:   tt.password,
+				DefaultProject: "PROJ",
+				TaskAliases:    map[string]string{"review": "INT-24", "встреча": "INT-18"},
+				WorkdayHours:   7.5,
+			}
+
+			require.NoError(t, Write(cfg, path))
+			got, err := Decode(path)
+			require.NoError(t, err)
+
+			got.origins = nil
+			require.Equal(t, cfg, got)
+		})
+	}
+}
+
+func Test_writeConfig_fresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, Write(Config{JiraURL: "https://jira.example.com", JiraLogin: "user", JiraPassword: "p"}, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `DefaultProject = ""`)
+	require.Contains(t, string(data), "WorkdayHours = 8.0")
+	require.Contains(t, string(data), "[TaskAliases]")
+}
+
+func TestConfig_RetryPolicy(t *testing.T) {
+	timeout, retries, backoff, err := Config{}.RetryPolicy()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{30 * time.Second, 2, time.Second}, []interface{}{timeout, retries, backoff})
+
+	var conf Config
+	conf.SetOrigin("Retries", "config.toml")
+	_, retries, _, err = conf.RetryPolicy()
+	require.NoError(t, err)
+	require.Zero(t, retries, "retries can be disabled")
+
+	_, _, _, err = Config{RequestTimeout: "soon"}.RetryPolicy()
+	require.ErrorContains(t, err, "RequestTimeout")
+}
@@ -0,0 +1,12 @@
+package config
+
+// Error is returned for value of config that is invalid or missing,
+// Field is key of the value, e.g. "RoundTo", Err says what is expected.
+type Error struct {
+	Field string
+	Err   error
+}
+
+func (e Error) Error() string { return e.Err.Error() }
+
+func (e Error) Unwrap() error { return e.Err }
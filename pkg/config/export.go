@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Export holds layouts of CSV timesheets `tlog export` writes, e.g.
+//
+//	[Export.Presets.erp]
+//	Columns = ["day", "project", "hours", "comment"]
+//	Headers = ["Datum", "Projekt", "Stunden", "Taetigkeit"]
+//	Delimiter = ";"
+//	Decimal = ","
+//	DateFormat = "02.01.2006"
+type Export struct {
+	// preset used without --preset, built-in layout if not set
+	Default string                  `toml:"Default,omitempty"`
+	Presets map[string]ExportPreset `toml:"Presets,omitempty"`
+}
+
+// ExportPreset is layout of CSV timesheet.
+type ExportPreset struct {
+	// in order, see exportColumns
+	Columns []string `toml:"Columns"`
+	// names of columns in header row, Columns if not set
+	Headers []string `toml:"Headers,omitempty"`
+	// "," if not set
+	Delimiter string `toml:"Delimiter,omitempty"`
+	// separator of hours fraction, "." if not set
+	Decimal string `toml:"Decimal,omitempty"`
+	// Go layout of days, e.g. "02.01.2006", 2006-01-02 if not set
+	DateFormat string `toml:"DateFormat,omitempty"`
+	// lines end with \r\n, as Windows software often expects
+	CRLF bool `toml:"CRLF,omitempty"`
+	// header row is left out
+	NoHeader bool `toml:"NoHeader,omitempty"`
+}
+
+// exportColumns are columns presets can list, with what they hold.
+var exportColumns = map[string]string{
+	"day":      "day worklog started on, in DateFormat",
+	"start":    "time of day worklog started at, e.g. 09:30",
+	"issue":    "issue key",
+	"project":  "project of issue, e.g. APP of APP-12",
+	"summary":  "issue summary",
+	"hours":    "time as decimal hours, e.g. 1.50",
+	"minutes":  "time in minutes",
+	"duration": "time like 1h30m",
+	"comment":  "worklog comment",
+	"author":   "author of worklog",
+}
+
+// defaultExportPreset is layout without presets.
+var defaultExportPreset = ExportPreset{Columns: []string{"day", "issue", "hours", "comment"}}
+
+// Preset returns export preset of name, Default or built-in one if name is
+// empty, with defaults filled in.
+func (e Export) Preset(name string) (ExportPreset, error) {
+	unknown := "Export.Presets"
+	if name == "" {
+		name, unknown = e.Default, "Export.Default"
+	}
+	p := defaultExportPreset
+	if name != "" {
+		var ok bool
+		if p, ok = e.Presets[name]; !ok {
+			return ExportPreset{}, Error{Field: unknown, Err: fmt.Errorf("unknown export preset %q, add [Export.Presets.%s] to config", name, name)}
+		}
+	}
+	field := "Export.Presets." + name
+	if len(p.Columns) == 0 {
+		return ExportPreset{}, Error{Field: field + ".Columns", Err: fmt.Errorf("%s.Columns is empty, list columns of timesheet", field)}
+	}
+	for _, column := range p.Columns {
+		if _, ok := exportColumns[column]; !ok {
+			return ExportPreset{}, Error{Field: field + ".Columns", Err: fmt.Errorf("unknown column %q in %s.Columns, use %s", column, field, strings.Join(sortedKeys(exportColumns), ", "))}
+		}
+	}
+	if len(p.Headers) == 0 {
+		p.Headers = p.Columns
+	} else if len(p.Headers) != len(p.Columns) {
+		return ExportPreset{}, Error{Field: field + ".Headers", Err: fmt.Errorf("%s has %d headers for %d columns", field, len(p.Headers), len(p.Columns))}
+	}
+	if p.Delimiter == "" {
+		p.Delimiter = ","
+	}
+	if r, size := utf8.DecodeRuneInString(p.Delimiter); size != len(p.Delimiter) || r == '"' || r == '\r' || r == '\n' {
+		return ExportPreset{}, Error{Field: field + ".Delimiter", Err: fmt.Errorf("invalid %s.Delimiter %q, a single character expected, e.g. \";\"", field, p.Delimiter)}
+	}
+	if p.Decimal == "" {
+		p.Decimal = "."
+	}
+	if p.Decimal != "." && p.Decimal != "," {
+		return ExportPreset{}, Error{Field: field + ".Decimal", Err: fmt.Errorf("invalid %s.Decimal %q, \".\" or \",\" expected", field, p.Decimal)}
+	}
+	if p.Decimal == p.Delimiter {
+		return ExportPreset{}, Error{Field: field + ".Decimal", Err: fmt.Errorf("%s.Decimal is the same as Delimiter, hours would be split in two", field)}
+	}
+	if p.DateFormat == "" {
+		p.DateFormat = "2006-01-02"
+	}
+	// layout has to tell the day, e.g. "January" alone does not
+	sample := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	if parsed, err := time.Parse(p.DateFormat, sample.Format(p.DateFormat)); err != nil || !parsed.Equal(sample) {
+		return ExportPreset{}, Error{Field: field + ".DateFormat", Err: fmt.Errorf("invalid %s.DateFormat %q, Go layout with year, month and day expected, e.g. \"02.01.2006\"", field, p.DateFormat)}
+	}
+	return p, nil
+}
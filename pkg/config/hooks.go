@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultHookTimeout = 10 * time.Second
+
+// Hooks are executables run around every worklog submission. The worklog is
+// passed as JSON on stdin and as TLOG_ISSUE, TLOG_SECONDS, TLOG_DAY and
+// TLOG_WORKLOG_ID environment variables.
+type Hooks struct {
+	// run before submission, exiting non-zero cancels it, stderr tells why
+	PreLog string `toml:"PreLog,omitempty"`
+	// run after worklog is created, its failure is only reported
+	PostLog string `toml:"PostLog,omitempty"`
+	// e.g. "30s", hook is killed after it, 10s if not set
+	Timeout string `toml:"Timeout,omitempty"`
+}
+
+// RunTimeout returns how long a hook may run.
+func (h Hooks) RunTimeout() (time.Duration, error) {
+	if h.Timeout == "" {
+		return defaultHookTimeout, nil
+	}
+	d, err := time.ParseDuration(h.Timeout)
+	if err != nil || d <= 0 {
+		return 0, Error{Field: "Hooks.Timeout", Err: fmt.Errorf("invalid Hooks.Timeout %q in config: positive duration like 10s expected", h.Timeout)}
+	}
+	return d, nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// LocalFile is per-repository config that shadows values of the user config.
+const LocalFile = ".tlog.toml"
+
+// FindLocal looks for .tlog.toml in dir and its parents,
+// stopping at the root of git repository or filesystem.
+func FindLocal(dir string) string {
+	for {
+		path := filepath.Join(dir, LocalFile)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Merge returns base with values defined in override applied on top of it.
+// Scalars are replaced, maps are merged key by key. Only keys present in override's
+// file (i.e. having origin) are applied, so zero values can override too.
+func Merge(base, override Config) Config {
+	if len(override.origins) == 0 {
+		return base
+	}
+
+	merged := base.Clone()
+	if merged.origins == nil {
+		merged.origins = map[string]string{}
+	}
+
+	mv := reflect.ValueOf(&merged).Elem()
+	ov := reflect.ValueOf(override)
+	t := mv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if key == "" || !field.IsExported() {
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Map {
+			if key == "profiles" {
+				continue
+			}
+			src := ov.Field(i)
+			if src.Len() > 0 && mv.Field(i).IsNil() {
+				mv.Field(i).Set(reflect.MakeMap(field.Type))
+			}
+			for _, k := range src.MapKeys() {
+				mv.Field(i).SetMapIndex(k, src.MapIndex(k))
+				fullKey := key + "." + k.String()
+				merged.origins[fullKey] = override.origins[fullKey]
+			}
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			// tables are merged key by key, as top-level keys are
+			for j := 0; j < field.Type.NumField(); j++ {
+				sub := strings.Split(field.Type.Field(j).Tag.Get("toml"), ",")[0]
+				fullKey := key + "." + sub
+				if origin, ok := override.origins[fullKey]; ok {
+					mv.Field(i).Field(j).Set(ov.Field(i).Field(j))
+					merged.origins[fullKey] = origin
+				}
+			}
+			continue
+		}
+
+		if origin, ok := override.origins[key]; ok {
+			mv.Field(i).Set(ov.Field(i))
+			merged.origins[key] = origin
+		}
+	}
+	return merged
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findLocalConfig(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	nested := filepath.Join(repo, "cmd", "app")
+	require.NoError(t, os.MkdirAll(nested, 0700))
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0700))
+
+	require.Equal(t, "", FindLocal(nested))
+
+	// config above git root is ignored
+	require.NoError(t, os.WriteFile(filepath.Join(root, LocalFile), nil, 0600))
+	require.Equal(t, "", FindLocal(nested))
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, LocalFile), nil, 0600))
+	require.Equal(t, filepath.Join(repo, LocalFile), FindLocal(nested))
+
+	require.NoError(t, os.WriteFile(filepath.Join(nested, LocalFile), nil, 0600))
+	require.Equal(t, filepath.Join(nested, LocalFile), FindLocal(nested))
+}
+
+func Test_mergeConfig(t *testing.T) {
+	base := Config{
+		JiraURL:        "https://company.jira.ru",
+		DefaultProject: "INT",
+		WorkdayHours:   8,
+		TaskAliases:    map[string]string{"review": "INT-24", "meeting": "INT-18"},
+		origins:        map[string]string{"JiraURL": "home", "DefaultProject": "home", "WorkdayHours": "home", "TaskAliases.review": "home"},
+	}
+	override := Config{
+		DefaultProject: "APP",
+		WorkdayHours:   0,
+		TaskAliases:    map[string]string{"review": "APP-1", "bug": "APP-2"},
+		origins:        map[string]string{"DefaultProject": "repo", "WorkdayHours": "repo", "TaskAliases.review": "repo", "TaskAliases.bug": "repo"},
+	}
+
+	got := Merge(base, override)
+	require.Equal(t, "https://company.jira.ru", got.JiraURL)
+	require.Equal(t, "home", got.Origin("JiraURL"))
+	require.Equal(t, "APP", got.DefaultProject)
+	require.Equal(t, "repo", got.Origin("DefaultProject"))
+	require.Equal(t, float64(0), got.WorkdayHours, "explicitly set value overrides")
+	require.Equal(t, map[string]string{"review": "APP-1", "meeting": "INT-18", "bug": "APP-2"}, got.TaskAliases)
+	require.Equal(t, "repo", got.Origin("TaskAliases.bug"))
+	require.Equal(t, "INT-24", base.TaskAliases["review"], "base is not modified")
+}
+
+func Test_mergeConfig_aliasDetails(t *testing.T) {
+	base := Config{
+		TaskAliasDetails: map[string]AliasDetail{"standup": {Issue: "INT-1", Comment: "standup"}},
+		origins:          map[string]string{"TaskAliasDetails.standup": "home"},
+	}
+	override := Config{
+		TaskAliasDetails: map[string]AliasDetail{"demo": {Issue: "APP-3"}},
+		origins:          map[string]string{"TaskAliasDetails.demo": "repo"},
+	}
+
+	got := Merge(base, override)
+	require.Equal(t, map[string]AliasDetail{
+		"standup": {Issue: "INT-1", Comment: "standup"},
+		"demo":    {Issue: "APP-3"},
+	}, got.TaskAliasDetails)
+	require.Equal(t, "repo", got.Origin("TaskAliasDetails.demo"))
+	require.Len(t, base.TaskAliasDetails, 1, "base is not modified")
+}
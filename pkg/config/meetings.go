@@ -0,0 +1,31 @@
+package config
+
+import "strings"
+
+// Meetings maps calendar events to issues for `tlog import ics`.
+type Meetings struct {
+	// attendee address of the user, events they declined are skipped, JiraLogin if not set
+	Email string `toml:"Email,omitempty"`
+	// alias or issue of events no rule matches
+	Alias string `toml:"Alias,omitempty"`
+	// tried in order, first matching one wins
+	Rules []MeetingRule `toml:"Rules,omitempty"`
+}
+
+// MeetingRule logs events with summary matching regular expression to alias, e.g.
+//
+//	[[Meetings.Rules]]
+//	Match = "(?i)standup"
+//	Alias = "standup"
+type MeetingRule struct {
+	Match string `toml:"Match"`
+	Alias string `toml:"Alias"`
+}
+
+// AttendeeEmail returns attendee address of the user.
+func (m Meetings) AttendeeEmail(conf Config) string {
+	if m.Email == "" && strings.Contains(conf.JiraLogin, "@") {
+		return conf.JiraLogin
+	}
+	return m.Email
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	// PasswordSourceKeyring makes tlog read password from OS keyring instead of config file.
+	PasswordSourceKeyring = "keyring"
+	KeyringService        = "tlog"
+)
+
+// KeyringUser is the keyring account password of given config is stored under.
+// JIRA URL is part of it, so every context gets its own entry.
+func KeyringUser(c Config) string {
+	return c.JiraLogin + "@" + strings.TrimSuffix(c.JiraURL, "/")
+}
+
+// Password returns JIRA password. Password set via environment is used as is,
+// then PasswordCommand, keyring and JiraPassword from config are tried.
+func (c Config) Password() (string, error) {
+	if strings.HasPrefix(c.Origin("JiraPassword"), "env ") {
+		return c.JiraPassword, nil
+	}
+	if c.PasswordCommand != "" {
+		return runPasswordCommand(c.PasswordCommand)
+	}
+	if c.PasswordSource != PasswordSourceKeyring {
+		return c.JiraPassword, nil
+	}
+
+	password, err := keyring.Get(KeyringService, KeyringUser(c))
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", fmt.Errorf("password for %s is not in keyring, run `tlog auth set`", KeyringUser(c))
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot read password from keyring: %w", err)
+	}
+	return password, nil
+}
+
+var (
+	passwordsMu sync.Mutex
+	// output of PasswordCommand by command, every client of the process
+	// reuses it, so password manager asks for unlock once
+	passwords = map[string]string{}
+)
+
+// runPasswordCommand runs PasswordCommand with shell and returns its trimmed
+// output, once per process. Failures are not remembered, the next call runs
+// the command again.
+func runPasswordCommand(command string) (string, error) {
+	passwordsMu.Lock()
+	defer passwordsMu.Unlock()
+	if password, ok := passwords[command]; ok {
+		return password, nil
+	}
+	password, err := RunSecretCommand("PasswordCommand", command)
+	if err != nil {
+		return "", err
+	}
+	passwords[command] = password
+	return password, nil
+}
+
+// RunSecretCommand runs command from config key with shell and returns its trimmed output.
+// Output is a secret, so it is never printed, not even in errors.
+func RunSecretCommand(key, command string) (string, error) {
+	cmd := exec.Command("sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stdin, cmd.Stderr = os.Stdin, &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := fmt.Sprintf("could not obtain Jira credential: %s %q failed: %s", key, command, err)
+		if s := strings.TrimSpace(stderr.String()); s != "" {
+			msg += "\n" + s
+		}
+		return "", errors.New(msg)
+	}
+
+	secret := strings.TrimSpace(string(out))
+	if secret == "" {
+		return "", fmt.Errorf("could not obtain Jira credential: %s %q printed nothing", key, command)
+	}
+	return secret, nil
+}
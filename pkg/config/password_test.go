@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func TestConfig_Password(t *testing.T) {
+	keyring.MockInit()
+	conf := Config{JiraURL: "https://jira.example.com/", JiraLogin: "user", JiraPassword: "from-file"}
+
+	password, err := conf.Password()
+	require.NoError(t, err)
+	require.Equal(t, "from-file", password)
+
+	conf.PasswordSource = PasswordSourceKeyring
+	_, err = conf.Password()
+	require.ErrorContains(t, err, "tlog auth set")
+
+	require.NoError(t, keyring.Set(KeyringService, "user@https://jira.example.com", "from-keyring"))
+	password, err = conf.Password()
+	require.NoError(t, err)
+	require.Equal(t, "from-keyring", password)
+
+	conf.JiraPassword = "from-env"
+	conf.SetOrigin("JiraPassword", "env TLOG_JIRA_PASSWORD")
+	password, err = conf.Password()
+	require.NoError(t, err)
+	require.Equal(t, "from-env", password)
+}
+
+func Test_runPasswordCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	password, err := runPasswordCommand("printf ' token\\n'")
+	require.NoError(t, err)
+	require.Equal(t, "token", password)
+
+	_, err = runPasswordCommand("echo 'entry not found' >&2; exit 1")
+	require.ErrorContains(t, err, "could not obtain Jira credential")
+	require.ErrorContains(t, err, "entry not found")
+
+	_, err = runPasswordCommand("true")
+	require.ErrorContains(t, err, "printed nothing")
+
+	conf := Config{JiraPassword: "from-file", PasswordCommand: "echo from-command"}
+	password, err = conf.Password()
+	require.NoError(t, err)
+	require.Equal(t, "from-command", password)
+
+	count := filepath.Join(t.TempDir(), "count")
+	command := fmt.Sprintf("echo x >> %q; wc -l < %q", count, count)
+	for i := 0; i < 3; i++ {
+		password, err = runPasswordCommand(command)
+		require.NoError(t, err)
+		require.Equal(t, "1", password, "command runs once per process")
+	}
+}
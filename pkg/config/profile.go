@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DefaultContext is the name of implicit profile formed by top-level config keys.
+const DefaultContext = "default"
+
+// Profile is a named set of settings for another JIRA instance, selected with --context.
+// Values set in profile override top-level ones, aliases are merged with profile ones taking precedence.
+type Profile struct {
+	Backend            string                 `toml:"Backend,omitempty"`
+	TempoToken         string                 `toml:"TempoToken,omitempty"`
+	GitLabURL          string                 `toml:"GitLabURL,omitempty"`
+	GitLabToken        string                 `toml:"GitLabToken,omitempty"`
+	YouTrackURL        string                 `toml:"YouTrackURL,omitempty"`
+	YouTrackToken      string                 `toml:"YouTrackToken,omitempty"`
+	RedmineURL         string                 `toml:"RedmineURL,omitempty"`
+	RedmineAPIKey      string                 `toml:"RedmineAPIKey,omitempty"`
+	AzureDevOpsURL     string                 `toml:"AzureDevOpsURL,omitempty"`
+	AzureDevOpsToken   string                 `toml:"AzureDevOpsToken,omitempty"`
+	ClockifyAPIKey     string                 `toml:"ClockifyAPIKey,omitempty"`
+	ClockifyWorkspace  string                 `toml:"ClockifyWorkspace,omitempty"`
+	HarvestAccountID   string                 `toml:"HarvestAccountID,omitempty"`
+	HarvestToken       string                 `toml:"HarvestToken,omitempty"`
+	GoogleCalendarID   string                 `toml:"GoogleCalendarID,omitempty"`
+	WakatimeAPIKey     string                 `toml:"WakatimeAPIKey,omitempty"`
+	GitRepos           []string               `toml:"GitRepos,omitempty"`
+	NotifyWebhook      string                 `toml:"NotifyWebhook,omitempty"`
+	NotifyTemplate     string                 `toml:"NotifyTemplate,omitempty"`
+	JiraURL            string                 `toml:"JiraURL,omitempty"`
+	JiraLogin          string                 `toml:"JiraLogin,omitempty"`
+	JiraPassword       string                 `toml:"JiraPassword,omitempty"`
+	PasswordSource     string                 `toml:"PasswordSource,omitempty"`
+	PasswordCommand    string                 `toml:"PasswordCommand,omitempty"`
+	AuthType           string                 `toml:"AuthType,omitempty"`
+	CACertFile         string                 `toml:"CACertFile,omitempty"`
+	InsecureSkipVerify bool                   `toml:"InsecureSkipVerify,omitempty"`
+	DefaultProject     string                 `toml:"DefaultProject,omitempty"`
+	TaskAliases        map[string]string      `toml:"TaskAliases,omitempty"`
+	TaskAliasDetails   map[string]AliasDetail `toml:"TaskAliasDetails,omitempty"`
+}
+
+// Context returns name of the selected profile, empty for the default one.
+func (c Config) Context() string {
+	return c.context
+}
+
+// SelectContext applies profile with given name to config.
+// Without name, CurrentContext from config is used.
+func SelectContext(cfg Config, name string) (Config, error) {
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	if name == "" || name == DefaultContext {
+		return cfg, nil
+	}
+
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		return Config{}, fmt.Errorf("context %q is not defined in config, see `tlog context list`", name)
+	}
+
+	cfg = cfg.Clone()
+	if cfg.origins == nil {
+		cfg.origins = map[string]string{}
+	}
+	prefix := "profiles." + name + "."
+
+	pv := reflect.ValueOf(profile)
+	cv := reflect.ValueOf(&cfg).Elem()
+	for i := 0; i < pv.NumField(); i++ {
+		field := pv.Type().Field(i)
+		key := strings.Split(field.Tag.Get("toml"), ",")[0]
+		value := pv.Field(i)
+		target := cv.FieldByName(field.Name)
+
+		if field.Type.Kind() == reflect.Map {
+			if value.Len() > 0 && target.IsNil() {
+				target.Set(reflect.MakeMap(field.Type))
+			}
+			for _, k := range value.MapKeys() {
+				target.SetMapIndex(k, value.MapIndex(k))
+				if origin, ok := cfg.origins[prefix+key+"."+k.String()]; ok {
+					cfg.origins[key+"."+k.String()] = origin + " [profiles." + name + "]"
+				}
+			}
+			continue
+		}
+		if value.IsZero() {
+			continue
+		}
+		target.Set(value)
+		if origin, ok := cfg.origins[prefix+key]; ok {
+			cfg.origins[key] = origin + " [profiles." + name + "]"
+		}
+	}
+	if profile.JiraPassword != "" && profile.PasswordSource == "" && profile.PasswordCommand == "" {
+		// password in profile means keyring or command of top-level context is not used
+		cfg.PasswordSource, cfg.PasswordCommand = "", ""
+	}
+
+	cfg.context = name
+	return cfg, nil
+}
+
+// ProfileNames returns sorted names of profiles defined in config.
+func ProfileNames(cfg Config) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
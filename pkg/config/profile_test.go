@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_selectContext(t *testing.T) {
+	cfg := Config{
+		JiraURL:        "https://company.jira.ru",
+		JiraLogin:      "user",
+		DefaultProject: "INT",
+		TaskAliases:    map[string]string{"review": "INT-24", "meeting": "INT-18"},
+		CurrentContext: "client",
+		Profiles: map[string]Profile{
+			"client": {
+				JiraURL:     "https://client.atlassian.net",
+				TaskAliases: map[string]string{"review": "CL-2"},
+			},
+		},
+		origins: map[string]string{"JiraURL": "/c.toml", "profiles.client.JiraURL": "/c.toml"},
+	}
+
+	t.Run("current context", func(t *testing.T) {
+		got, err := SelectContext(cfg, "")
+		require.NoError(t, err)
+		require.Equal(t, "client", got.Context())
+		require.Equal(t, "https://client.atlassian.net", got.JiraURL)
+		require.Equal(t, "/c.toml [profiles.client]", got.Origin("JiraURL"))
+		require.Equal(t, "user", got.JiraLogin, "not overridden by profile")
+		require.Equal(t, "INT", got.DefaultProject)
+		require.Equal(t, map[string]string{"review": "CL-2", "meeting": "INT-18"}, got.TaskAliases)
+		require.Equal(t, "INT-24", cfg.TaskAliases["review"], "original config is not modified")
+	})
+
+	t.Run("default context", func(t *testing.T) {
+		got, err := SelectContext(cfg, DefaultContext)
+		require.NoError(t, err)
+		require.Equal(t, "", got.Context())
+		require.Equal(t, "https://company.jira.ru", got.JiraURL)
+	})
+
+	t.Run("unknown context", func(t *testing.T) {
+		_, err := SelectContext(cfg, "missing")
+		require.Error(t, err)
+	})
+}
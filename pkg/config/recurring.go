@@ -0,0 +1,20 @@
+package config
+
+// RecurringEntry is a worklog logged on the same weekdays over and over by
+// `tlog fill-recurring`, e.g.
+//
+//	[[Recurring]]
+//	Days = ["mon", "tue", "wed", "thu", "fri"]
+//	Time = "15m"
+//	Task = "MEET-1"
+//	Comment = "standup"
+type RecurringEntry struct {
+	// weekdays, e.g. "mon" or "monday"
+	Days []string `toml:"Days"`
+	Time string   `toml:"Time"`
+	// alias or issue key
+	Task    string `toml:"Task"`
+	Comment string `toml:"Comment,omitempty"`
+	// time of day it starts at, e.g. "09:30", DefaultStartTime if not set
+	Start string `toml:"Start,omitempty"`
+}
@@ -0,0 +1,43 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	issueKeyRe = regexp.MustCompile(`^[A-Z][A-Z0-9_]*-[0-9]+$`)
+	// Redmine and Azure DevOps have no other ids, JIRA accepts them too
+	issueIDRe = regexp.MustCompile(`^[0-9]+$`)
+	// e.g. group/project#123
+	gitlabRefRe = regexp.MustCompile(`^[\w.-]+(/[\w.-]+)+#[0-9]+$`)
+)
+
+// IsIssueKey reports whether s is JIRA issue key, e.g. "PRJ-42".
+func IsIssueKey(s string) bool {
+	return issueKeyRe.MatchString(s)
+}
+
+// IsIssueID reports whether s is numeric issue id, e.g. "42".
+func IsIssueID(s string) bool {
+	return issueIDRe.MatchString(s)
+}
+
+// IsGitLabRef reports whether s is GitLab issue reference, e.g. "group/app#42".
+func IsGitLabRef(s string) bool {
+	return gitlabRefRe.MatchString(s)
+}
+
+// IsIssue reports whether s is issue key, issue id or GitLab issue reference.
+func IsIssue(s string) bool {
+	return IsIssueKey(s) || IsIssueID(s) || IsGitLabRef(s)
+}
+
+// Project returns project of issue, e.g. "PRJ" of "PRJ-42" or "group/app"
+// of "group/app#42", issue itself if it has none.
+func Project(issue string) string {
+	if i := strings.LastIndexAny(issue, "-#"); i > 0 {
+		return issue[:i]
+	}
+	return issue
+}
@@ -0,0 +1,26 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsIssue(t *testing.T) {
+	for input, want := range map[string]bool{
+		"PRJ-42": true, "42": true, "group/app#42": true, "group/sub/app#1": true,
+		"prj-42": false, "PRJ": false, "#42": false, "review": false, "": false,
+	} {
+		require.Equal(t, want, IsIssue(input), input)
+	}
+	require.True(t, IsIssueKey("A_B-1"))
+	require.False(t, IsIssueKey("42"))
+	require.True(t, IsIssueID("42"))
+	require.False(t, IsGitLabRef("PRJ-42"))
+}
+
+func TestProject(t *testing.T) {
+	for issue, want := range map[string]string{"PRJ-42": "PRJ", "group/app#42": "group/app", "42": "42", "MY-APP-3": "MY-APP"} {
+		require.Equal(t, want, Project(issue), issue)
+	}
+}
@@ -0,0 +1,212 @@
+// Package parse reads time, day and task of a worklog the way tlog command
+// line accepts them, e.g. "1h30m", "yesterday" or "42".
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoDefaultProject is returned by Parser.Task for issue number when
+// Parser has no DefaultProject to prefix it with.
+var ErrNoDefaultProject = errors.New("if using issue number, set DefaultProject in config")
+
+// Kinds of Error.
+const (
+	KindTime = "time"
+	KindDay  = "day"
+)
+
+// Error is returned for input that is not what it should be.
+type Error struct {
+	Input string
+	// KindTime or KindDay
+	Kind string
+	// why Input is not valid, nil if it just is not of any known form
+	Err error
+}
+
+func (e Error) Error() string {
+	switch e.Kind {
+	case KindTime:
+		return fmt.Sprintf("invalid time %q, duration like 1h30m or 0.5d expected", e.Input)
+	case KindDay:
+		return fmt.Sprintf("invalid day %q, [yy.]mm.dd, day of the week, or day of the month expected", e.Input)
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("invalid %s %q: %s", e.Kind, e.Input, e.Err)
+	}
+	return fmt.Sprintf("invalid %s %q", e.Kind, e.Input)
+}
+
+func (e Error) Unwrap() error { return e.Err }
+
+// Parser parses input relative to its settings, zero Parser is usable.
+type Parser struct {
+	// Now returns current time, time.Now if nil
+	Now func() time.Time
+	// Location days are in, time.Local if nil
+	Location *time.Location
+	// length of a day of work, "1d" is 8 hours if zero
+	Workday time.Duration
+	// project key, e.g. "PRJ", or GitLab project path, e.g. "group/app",
+	// issue numbers are prefixed with
+	DefaultProject string
+	// issues by alias, e.g. "review": "PRJ-24"
+	Aliases map[string]string
+}
+
+func (p Parser) now() time.Time {
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	loc := p.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return now().In(loc)
+}
+
+// Task returns issue key of input: issue of alias, number prefixed with
+// DefaultProject, e.g. "PRJ-42" or "group/app#42", or input as it is.
+func (p Parser) Task(input string) (string, error) {
+	if task, ok := p.Aliases[input]; ok {
+		return task, nil
+	}
+
+	// GitLab project path, e.g. group/project, takes #123 too
+	gitlab := strings.Contains(p.DefaultProject, "/")
+	number := input
+	if gitlab {
+		number = strings.TrimPrefix(input, "#")
+	}
+
+	// if input is number, assume it is issue key
+	if _, err := strconv.Atoi(number); err == nil {
+		if p.DefaultProject == "" {
+			return "", ErrNoDefaultProject
+		}
+		if gitlab {
+			return fmt.Sprintf("%s#%s", p.DefaultProject, number), nil
+		}
+
+		return fmt.Sprintf("%s-%s", p.DefaultProject, input), nil
+	}
+
+	return input, nil
+}
+
+// Day returns start of the day described by input: empty for today,
+// "today", "yesterday", day of the current week, e.g. "monday", day of the
+// current month, e.g. "15", "mm.dd", "yyyy.mm.dd" or "yyyy-mm-dd".
+func (p Parser) Day(input string) (time.Time, error) {
+	now := p.now()
+	loc := now.Location()
+	y, m, d := now.Date()
+	todayStart := time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+	input = strings.ToLower(input)
+	if input == "" || input == "today" {
+		return todayStart, nil
+	}
+
+	if input == "yesterday" {
+		return todayStart.AddDate(0, 0, -1), nil
+	}
+
+	var weekdayWant time.Weekday
+	switch input {
+	case "monday":
+		weekdayWant = time.Monday
+	case "tuesday":
+		weekdayWant = time.Tuesday
+	case "wednesday":
+		weekdayWant = time.Wednesday
+	case "thursday":
+		weekdayWant = time.Thursday
+	case "friday":
+		weekdayWant = time.Friday
+	case "saturday":
+		weekdayWant = time.Saturday
+	case "sunday":
+		weekdayWant = time.Weekday(7) // week ends with sunday
+	default:
+		weekdayWant = -1 // NoneDay
+	}
+
+	if weekdayWant != -1 {
+		return todayStart.AddDate(0, 0, int(weekdayWant-now.Weekday())), nil
+	}
+
+	if d, err := strconv.Atoi(input); err == nil {
+		return time.Date(y, m, d, 0, 0, 0, 0, loc), nil
+	}
+
+	if t, err := time.Parse("01.02", input); err == nil {
+		return time.Date(y, t.Month(), t.Day(), 0, 0, 0, 0, loc), nil
+	}
+
+	for _, layout := range []string{"2006.01.02", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, input, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, Error{Input: input, Kind: KindDay}
+}
+
+// TimeLog parses duration like "1h30m". Days are also accepted as leading
+// part, e.g. "1d" or "0.5d2h", one day being Workday long.
+func (p Parser) TimeLog(input string) (time.Duration, error) {
+	workday := p.Workday
+	if workday == 0 {
+		workday = 8 * time.Hour
+	}
+	days, rest, found := strings.Cut(input, "d")
+	if !found {
+		d, err := time.ParseDuration(input)
+		if err != nil {
+			return 0, Error{Input: input, Kind: KindTime, Err: err}
+		}
+		return d, nil
+	}
+
+	n, err := strconv.ParseFloat(days, 64)
+	if err != nil || n < 0 {
+		return 0, Error{Input: input, Kind: KindTime, Err: err}
+	}
+	duration := time.Duration(n * float64(workday))
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, Error{Input: input, Kind: KindTime, Err: err}
+		}
+		duration += d
+	}
+	return duration, nil
+}
+
+// FormatDuration renders duration the way JIRA does and TimeLog reads,
+// e.g. 1h12m or 45m.
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	d = d.Truncate(time.Minute)
+	h := d / time.Hour
+	m := (d % time.Hour) / time.Minute
+	switch {
+	case h == 0:
+		return fmt.Sprintf("%s%dm", sign, m)
+	case m == 0:
+		return fmt.Sprintf("%s%dh", sign, h)
+	default:
+		return fmt.Sprintf("%s%dh%dm", sign, h, m)
+	}
+}
@@ -0,0 +1,108 @@
+package parse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_Day(t *testing.T) {
+	// far from UTC, so local and UTC dates differ
+	loc := time.FixedZone("UTC+14", 14*60*60)
+	// Wednesday, 2024-03-06 01:30 in loc, still March 5 in UTC
+	now := time.Date(2024, 3, 5, 11, 30, 0, 0, time.UTC)
+	p := Parser{Now: func() time.Time { return now }, Location: loc}
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{input: "", want: time.Date(2024, 3, 6, 0, 0, 0, 0, loc)},
+		{input: "today", want: time.Date(2024, 3, 6, 0, 0, 0, 0, loc)},
+		{input: "Yesterday", want: time.Date(2024, 3, 5, 0, 0, 0, 0, loc)},
+		{input: "monday", want: time.Date(2024, 3, 4, 0, 0, 0, 0, loc)},
+		{input: "friday", want: time.Date(2024, 3, 8, 0, 0, 0, 0, loc)},
+		{input: "sunday", want: time.Date(2024, 3, 10, 0, 0, 0, 0, loc)},
+		{input: "15", want: time.Date(2024, 3, 15, 0, 0, 0, 0, loc)},
+		{input: "02.29", want: time.Date(2024, 2, 29, 0, 0, 0, 0, loc)},
+		{input: "1999.04.20", want: time.Date(1999, 4, 20, 0, 0, 0, 0, loc)},
+		{input: "1999-04-20", want: time.Date(1999, 4, 20, 0, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := p.Day(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := p.Day("someday")
+	var invalid Error
+	require.ErrorAs(t, err, &invalid)
+	require.Equal(t, Error{Input: "someday", Kind: KindDay}, invalid)
+}
+
+func TestParser_TimeLog(t *testing.T) {
+	p := Parser{Workday: 7*time.Hour + 30*time.Minute}
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{input: "1h", want: time.Hour},
+		{input: "45m", want: 45 * time.Minute},
+		{input: "1d", want: 7*time.Hour + 30*time.Minute},
+		{input: "0.5d2h", want: 5*time.Hour + 45*time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := p.TimeLog(tt.input)
+		require.NoError(t, err, tt.input)
+		require.Equal(t, tt.want, got, tt.input)
+	}
+
+	got, err := Parser{}.TimeLog("1d")
+	require.NoError(t, err)
+	require.Equal(t, 8*time.Hour, got, "zero Parser has 8 hour workday")
+
+	for _, input := range []string{"d", "1d2", "-1d", "ahaha"} {
+		_, err := p.TimeLog(input)
+		require.ErrorAs(t, err, &Error{}, input)
+		require.EqualError(t, err, `invalid time "`+input+`", duration like 1h30m or 0.5d expected`)
+	}
+}
+
+func TestParser_Task(t *testing.T) {
+	p := Parser{DefaultProject: "INT", Aliases: map[string]string{"review": "INT-24"}}
+	for input, want := range map[string]string{"review": "INT-24", "42": "INT-42", "OPS-1": "OPS-1", "#42": "#42"} {
+		got, err := p.Task(input)
+		require.NoError(t, err)
+		require.Equal(t, want, got, input)
+	}
+
+	gitlab := Parser{DefaultProject: "group/app"}
+	for input, want := range map[string]string{"42": "group/app#42", "#42": "group/app#42", "group/other#3": "group/other#3"} {
+		got, err := gitlab.Task(input)
+		require.NoError(t, err)
+		require.Equal(t, want, got, input)
+	}
+
+	_, err := Parser{}.Task("42")
+	require.ErrorIs(t, err, ErrNoDefaultProject)
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		input time.Duration
+		want  string
+	}{
+		{input: 0, want: "0m"},
+		{input: 45 * time.Minute, want: "45m"},
+		{input: 2 * time.Hour, want: "2h"},
+		{input: 72*time.Minute + 13*time.Second, want: "1h12m"},
+		{input: -90 * time.Minute, want: "-1h30m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			require.Equal(t, tt.want, FormatDuration(tt.input))
+		})
+	}
+}